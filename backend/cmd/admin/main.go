@@ -0,0 +1,238 @@
+// admin は運用者向けの管理コマンドです
+// サーバーと同じ設定・サービスを使用します
+//
+//	go run ./cmd/admin user set-role <email> <user|admin|agency|operator>
+//	go run ./cmd/admin vision set-operator <id> <email>
+//	go run ./cmd/admin project set-status <id> <draft|active|complete|cancelled>
+//	go run ./cmd/admin support complete <id> <payment_intent_id>
+//	go run ./cmd/admin payments reconcile <from> <to>
+//	go run ./cmd/admin task list
+//	go run ./cmd/admin task run <name>
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/db"
+	"github.com/masvc/oshiome_go/backend/internal/db/migrations"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/realtime"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/scheduler"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+const usage = `使い方: admin [-config FILE] <コマンド>
+
+  user set-role <email> <user|admin|agency|operator>          ユーザーのロールを変更
+  vision set-operator <id> <email>                            ビジョンの運営会社を設定（operator ロール）
+  project set-status <id> <draft|active|complete|cancelled>   プロジェクトの状態を変更
+  support complete <id> <payment_intent_id>                   支援を手動で完了にする
+  payments reconcile <from> <to>                              期間（YYYY-MM-DD、to の日を含まない）のStripeの決済を照合
+  task list                                                   定期タスクの一覧
+  task run <name>                                             定期タスクを1回実行
+`
+
+func main() {
+	configFile := flag.String("config", "", "設定ファイル（.toml / .yaml）。未指定の場合はCONFIG_FILE")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal("設定の読み込みに失敗しました:", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("設定に誤りがあります:\n%v", err)
+	}
+
+	dbInstance, err := db.InitDB(cfg.Database)
+	if err != nil {
+		log.Fatal("データベース接続に失敗しました:", err)
+	}
+	defer db.CloseDB()
+
+	// 変更は監査ログに管理コマンドによる操作として記録する
+	if err := audit.Register(dbInstance); err != nil {
+		log.Fatal(err)
+	}
+	ctx := audit.WithActor(context.Background(), audit.Actor{Source: cliSource()})
+	if err := migrations.EnsureUpToDate(ctx, dbInstance); err != nil {
+		log.Fatal(err)
+	}
+
+	feeRules, err := service.LoadFeeRules(cfg.Payout.FeeRules)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	utils.InitStripe(cfg.Stripe.SecretKey)
+	services := service.New(repository.NewStore(dbInstance), service.Deps{
+		Payments: service.NewStripeGateway(),
+		Connect:  service.NewStripeConnectGateway(),
+		FeeRules: feeRules,
+		Notifier: realtime.NewNotifier(dbInstance),
+		Hasher:   utils.NewPasswordHasher(cfg.Auth.BcryptCost),
+		Guard:    auth.NewLoginGuard(dbInstance, mail.New(cfg.Mail), cfg.Server.FrontendURL),
+	})
+
+	if err := run(ctx, services, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run はサブコマンドを実行します
+func run(ctx context.Context, services *service.Services, args []string) error {
+	command := strings.Join(args[:2], " ")
+	params := args[2:]
+
+	switch command {
+	case "user set-role":
+		if len(params) != 2 {
+			return usageError(command)
+		}
+		user, err := services.Users.SetRole(ctx, params[0], models.UserRole(params[1]))
+		if err != nil {
+			return describe(err)
+		}
+		fmt.Printf("ロールを変更しました: user_id=%d email=%s role=%s\n", user.ID, user.Email, user.Role)
+
+	case "vision set-operator":
+		if len(params) != 2 {
+			return usageError(command)
+		}
+		id, err := parseID(params[0])
+		if err != nil {
+			return err
+		}
+		vision, err := services.Invoices.SetVisionOperator(ctx, id, params[1])
+		if err != nil {
+			return describe(err)
+		}
+		fmt.Printf("ビジョンの運営会社を設定しました: vision_id=%d operator_id=%d\n", vision.ID, *vision.OperatorID)
+
+	case "project set-status":
+		if len(params) != 2 {
+			return usageError(command)
+		}
+		id, err := parseID(params[0])
+		if err != nil {
+			return err
+		}
+		if err := services.Projects.SetStatus(ctx, id, models.ProjectStatus(params[1])); err != nil {
+			return describe(err)
+		}
+		fmt.Printf("プロジェクトの状態を変更しました: project_id=%d status=%s\n", id, params[1])
+
+	case "support complete":
+		if len(params) != 2 {
+			return usageError(command)
+		}
+		id, err := parseID(params[0])
+		if err != nil {
+			return err
+		}
+		if err := services.Supports.CompletePayment(ctx, id, params[1]); err != nil {
+			return err
+		}
+		fmt.Printf("支援を完了にしました: support_id=%d\n", id)
+
+	case "payments reconcile":
+		if len(params) != 2 {
+			return usageError(command)
+		}
+		from, err := parseDate(params[0])
+		if err != nil {
+			return err
+		}
+		to, err := parseDate(params[1])
+		if err != nil {
+			return err
+		}
+		report, err := services.Reconciliation.Reconcile(ctx, from, to)
+		if err != nil {
+			return describe(err)
+		}
+		fmt.Printf("照合しました: 決済=%d 残高取引=%d 修正=%d 要確認=%d\n",
+			report.Payments, report.BalanceTransactions, len(report.Repairs), len(report.Discrepancies))
+		for _, item := range report.Repairs {
+			fmt.Printf("  修正   %-18s %s support_id=%d %s\n", item.Kind, item.PaymentIntentID, item.SupportID, item.Detail)
+		}
+		for _, item := range report.Discrepancies {
+			fmt.Printf("  要確認 %-18s %s support_id=%d %s\n", item.Kind, item.PaymentIntentID, item.SupportID, item.Detail)
+		}
+
+	case "task list":
+		for _, name := range scheduler.NewDefault(services).Names() {
+			fmt.Println(name)
+		}
+
+	case "task run":
+		if len(params) != 1 {
+			return usageError(command)
+		}
+		if err := scheduler.NewDefault(services).RunOnce(ctx, params[0]); err != nil {
+			return err
+		}
+		fmt.Printf("タスクを実行しました: %s\n", params[0])
+
+	default:
+		return fmt.Errorf("不明なコマンドです: %s\n\n%s", command, usage)
+	}
+	return nil
+}
+
+// cliSource は監査ログに記録する操作の経路（実行したOSのユーザー名を含める）
+func cliSource() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+func usageError(command string) error {
+	return fmt.Errorf("引数が正しくありません: %s\n\n%s", command, usage)
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("無効なIDです: %s", s)
+	}
+	return uint(id), nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("無効な日付です（YYYY-MM-DD）: %s", s)
+	}
+	return t, nil
+}
+
+// describe はAPIErrorの詳細をメッセージに含めます
+func describe(err error) error {
+	if apiErr, ok := err.(*utils.APIError); ok && apiErr.Detail != "" {
+		return fmt.Errorf("%s: %s", apiErr.Message, apiErr.Detail)
+	}
+	return err
+}
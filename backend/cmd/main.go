@@ -1,131 +1,156 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/masvc/oshiome_go/backend/internal/config"
 	"github.com/masvc/oshiome_go/backend/internal/db"
 	"github.com/masvc/oshiome_go/backend/internal/db/migrations"
-	"github.com/masvc/oshiome_go/backend/internal/handlers"
-	"github.com/masvc/oshiome_go/backend/internal/middleware"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/seed"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
 )
 
 func main() {
-	// .envファイルの読み込み
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found")
-	}
-
 	// コマンドライン引数の解析
-	migrate := flag.Bool("migrate", false, "データベースのマイグレーションを実行")
+	configFile := flag.String("config", "", "設定ファイル（.toml / .yaml）。未指定の場合はCONFIG_FILE")
+	migrate := flag.String("migrate", "", "データベースのマイグレーションを実行（up / down / status / redo）")
+	seedProfile := flag.String("seed", "", "シードデータを投入（"+strings.Join(seed.Profiles(), " / ")+"）")
+	randomSeed := flag.Int64("random-seed", 1, "シードデータの乱数シード（同じ値なら同じデータを生成）")
+	reset := flag.Bool("reset", false, "シードデータの投入前に既存のデータを削除（本番環境では不可）")
 	flag.Parse()
 
+	// 設定の読み込み（必須の秘密情報が欠けている場合は起動しない）
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal("設定の読み込みに失敗しました:", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("設定に誤りがあります:\n%v", err)
+	}
+
+	// 構造化ログ（log パッケージの出力も同じ形式・同じ伏せ字の規則で出力する）
+	logger, err := logging.New(os.Stderr, cfg.Log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+	if cfg.Env != config.EnvDevelopment {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	logger.Info("設定を読み込みました", "env", cfg.Env, "config", cfg.Redacted())
+
 	// マイグレーションフラグが指定された場合
-	if *migrate {
-		if err := migrations.RunMigrations(); err != nil {
+	if *migrate != "" {
+		if err := migrations.RunMigrations(cfg.Database, *migrate); err != nil {
 			log.Fatal("マイグレーションに失敗しました:", err)
 		}
 		return
 	}
 
 	// データベース接続の初期化
-	dbInstance, err := db.InitDB()
+	dbInstance, err := db.InitDB(cfg.Database)
 	if err != nil {
 		log.Fatal("データベース接続に失敗しました:", err)
 	}
 
-	// Stripeの初期化
-	utils.InitStripe()
-
-	r := gin.Default()
-
-	// CORSの設定
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{
-		"http://localhost:5173",
-		"https://oshiome.onrender.com",
-	}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{
-		"Origin",
-		"Content-Type",
-		"Authorization",
-		"Stripe-Signature",
-		"Accept",
-		"X-Requested-With",
-	}
-	config.AllowCredentials = true
-	config.ExposeHeaders = []string{"Content-Length"}
-	config.MaxAge = 86400 // プリフライトリクエストのキャッシュ時間（24時間）
-	r.Use(cors.New(config))
-
-	// ミドルウェアの設定
-	r.Use(middleware.ErrorHandler())
-
-	// ハンドラーのインスタンス化
-	userHandler := handlers.NewUserHandler()
-	projectHandler := handlers.NewProjectHandler()
-	supportHandler := handlers.NewSupportHandler()
-	healthHandler := handlers.NewHealthHandler()
-	h := handlers.NewHandler(dbInstance)
-
-	// パブリックルート
-	public := r.Group("/api")
-	{
-		// ヘルスチェック
-		public.GET("/health", healthHandler.HealthCheck)
-
-		// ユーザー関連
-		public.POST("/register", userHandler.CreateUser)
-		public.POST("/login", userHandler.Login)
-
-		// プロジェクト一覧と詳細は認証不要
-		public.GET("/projects", projectHandler.ListProjects)
-		public.GET("/projects/:id", projectHandler.GetProject)
-		public.GET("/projects/:id/supports", supportHandler.GetProjectSupports)
-
-		// Webhook（Stripe-Signatureヘッダーを許可）
-		public.POST("/webhook", h.HandleStripeWebhook)
-
-		// 支払い検証（セッションIDから支援情報を取得）
-		public.GET("/payments/verify", supportHandler.VerifyPaymentBySession)
-	}
-
-	// 認証が必要なルート
-	protected := r.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
-	{
-		// ユーザー関連
-		protected.GET("/auth/me", userHandler.GetCurrentUser)
-		protected.GET("/users/:id", userHandler.GetUser)
-		protected.PUT("/users/:id", userHandler.UpdateUser)
-
-		// プロジェクト関連（作成・更新・削除は認証必要）
-		protected.POST("/projects", projectHandler.CreateProject)
-		// マイプロジェクトと支援プロジェクト（:idパラメータを使用するルートより先に定義）
-		protected.GET("/projects/my", projectHandler.ListMyProjects)
-		protected.GET("/projects/supported", projectHandler.ListSupportedProjects)
-		// IDパラメータを使用するルート
-		protected.PUT("/projects/:id", projectHandler.UpdateProject)
-		protected.DELETE("/projects/:id", projectHandler.DeleteProject)
-
-		// サポート関連
-		protected.POST("/projects/:id/supports", supportHandler.CreateSupport)
-		protected.GET("/supports/:id", supportHandler.GetSupportStatus)
-	}
-
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8000"
-	}
-
-	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
+	// 未適用のマイグレーションがある場合は起動しない
+	if err := migrations.EnsureUpToDate(context.Background(), dbInstance); err != nil {
+		log.Fatal(err)
+	}
+
+	// シードフラグが指定された場合
+	if *seedProfile != "" {
+		opts := seed.Options{
+			Profile:    *seedProfile,
+			RandomSeed: *randomSeed,
+			Reset:      *reset,
+			Env:        cfg.Env,
+			Hasher:     utils.NewPasswordHasher(cfg.Auth.BcryptCost),
+		}
+		if err := seed.Run(context.Background(), dbInstance, opts); err != nil {
+			log.Fatal("シードデータの投入に失敗しました:", err)
+		}
+		return
+	}
+
+	// トレーシング（TRACING_EXPORTER が未設定の場合は無効）
+	tracer, err := tracing.New(cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stripeの初期化（API呼び出しごとのスパンを作成する）
+	utils.InitStripe(cfg.Stripe.SecretKey)
+	utils.SetStripeTransport(&tracing.Transport{Tracer: tracer, Peer: "stripe"})
+
+	srv, err := server.New(server.Deps{Config: cfg, DB: dbInstance, Logger: logger, Tracer: tracer})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// SIGINT / SIGTERM（デプロイ時の停止）で停止処理を始める
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", ":"+cfg.Server.Port)
+	if err != nil {
 		log.Fatal("サーバーの起動に失敗しました:", err)
 	}
+	srv.Start(ctx)
+
+	// メトリクスは内部向けのリスナーで公開（METRICS_ADDR）
+	var metricsServer *http.Server
+	if cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", srv.Metrics)
+		metricsServer = &http.Server{Addr: cfg.Metrics.Addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			logger.Info("メトリクスを公開します", "addr", cfg.Metrics.Addr)
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("メトリクスのリスナーが停止しました", "error", err)
+			}
+		}()
+	}
+
+	logger.Info("サーバーを起動しました", "port", cfg.Server.Port)
+	serveErr := srv.Serve(ctx, ln)
+	stop()
+	if serveErr != nil {
+		logger.Error("サーバーが停止しました", "error", serveErr)
+	}
+
+	// ジョブ・定期タスクの終了を待ち、送信待ちのスパンを書き出してから接続を閉じる
+	timeout, _ := cfg.Server.ShutdownTimeoutDuration()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Stop(shutdownCtx); err != nil {
+		logger.Error("バックグラウンド処理の停止に失敗しました", "error", err)
+	}
+	if metricsServer != nil {
+		metricsServer.Shutdown(shutdownCtx)
+	}
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("スパンのエクスポートに失敗しました", "error", err)
+	}
+	db.CloseDB()
+	logger.Info("サーバーを停止しました")
+
+	if serveErr != nil {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,128 @@
+// Package audit は監査ログ（audit_events）を記録します
+//
+// 監査ログは追記のみで、更新・削除・TRUNCATEはデータベースのトリガーで拒否します
+// （保持期間による削除と退会時の消去は専用ロールが所有する関数 prune_audit_events / erase_audit_event_changes でのみ可能）
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 監査ログのアクション
+const (
+	ActionUserLocked   = "user.locked"
+	ActionUserUnlocked = "user.unlocked"
+
+	ActionTwoFactorEnabled     = "user.2fa_enabled"
+	ActionTwoFactorDisabled    = "user.2fa_disabled"
+	ActionRecoveryCodeUsed     = "user.recovery_code_used"
+	ActionRecoveryCodesRenewed = "user.recovery_codes_renewed"
+	ActionIdentityLinked       = "user.identity_linked"
+
+	ActionDataExported   = "user.data_exported"
+	ActionAccountDeleted = "user.account_deleted"
+
+	// 以下はGORMのコールバック（GormPlugin）が変更の差分とともに記録します
+	ActionUserProfileUpdated  = "user.profile_updated"
+	ActionUserRoleChanged     = "user.role_changed"
+	ActionUserPasswordChanged = "user.password_changed"
+	ActionUserDeleted         = "user.deleted"
+
+	ActionProjectUpdated       = "project.updated"
+	ActionProjectStatusChanged = "project.status_changed"
+	ActionProjectDeleted       = "project.deleted"
+
+	ActionSupportUpdated       = "support.updated"
+	ActionSupportStatusChanged = "support.status_changed"
+	ActionSupportDeleted       = "support.deleted"
+
+	ActionSettlementUpdated       = "settlement.updated"
+	ActionSettlementStatusChanged = "settlement.status_changed"
+	ActionSettlementDeleted       = "settlement.deleted"
+
+	ActionInvoiceUpdated       = "invoice.updated"
+	ActionInvoiceStatusChanged = "invoice.status_changed"
+	ActionInvoiceDeleted       = "invoice.deleted"
+
+	ActionExpenseUpdated       = "expense.updated"
+	ActionExpenseStatusChanged = "expense.status_changed"
+	ActionExpenseDeleted       = "expense.deleted"
+
+	ActionReceiptUpdated = "receipt.updated"
+	ActionReceiptVoided  = "receipt.voided"
+	ActionReceiptDeleted = "receipt.deleted"
+)
+
+// Entry は監査ログに記録する内容
+type Entry struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	IP         string
+	UserAgent  string
+	RequestID  string
+	// Changes は列ごとの変更前後の値
+	Changes  map[string]Change
+	Metadata map[string]interface{}
+}
+
+// Change は列の変更前後の値
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Record は監査ログを記録します
+func Record(tx *gorm.DB, entry Entry) error {
+	metadata, err := marshalObject(entry.Metadata)
+	if err != nil {
+		return err
+	}
+	changes, err := marshalObject(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditEvent{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		RequestID:  entry.RequestID,
+		Changes:    changes,
+		Metadata:   metadata,
+	}).Error
+}
+
+// RecordOrLog は監査ログを記録し、失敗した場合はログに出力します
+// 監査ログの失敗で本来の処理を止めたくない箇所で使用します
+func RecordOrLog(tx *gorm.DB, entry Entry) {
+	if err := Record(tx, entry); err != nil {
+		logging.FromContext(tx.Statement.Context).Error("監査ログの記録に失敗しました", "action", entry.Action, "error", err)
+	}
+}
+
+// EraseChanges は対象の監査ログから変更前後の値を消去します（退会時の個人情報の消去）
+// 操作の記録（誰がいつ何をしたか）は残します
+func EraseChanges(tx *gorm.DB, targetType string, targetID uint) error {
+	return tx.Exec("SELECT erase_audit_event_changes(?, ?)", targetType, targetID).Error
+}
+
+// marshalObject はJSONオブジェクトに変換します（空の場合は {}）
+func marshalObject[T any](m map[string]T) (models.JSONB, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return models.JSONB(b), nil
+}
@@ -0,0 +1,71 @@
+package audit
+
+import "context"
+
+// Actor は操作の主体とリクエストの情報
+// HTTPリクエストではミドルウェアが、管理CLIや定期タスクでは呼び出し側がcontextに設定します
+type Actor struct {
+	// UserID は操作したユーザー（システムによる操作の場合はnil）
+	UserID    *uint
+	IP        string
+	UserAgent string
+	RequestID string
+	// Source は操作の経路（api / cli / scheduler など。監査ログのmetadataに記録します）
+	Source string
+}
+
+type actorKey struct{}
+type skipKey struct{}
+
+// WithActor は操作の主体をcontextに設定します
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithActorID はcontextの操作の主体にユーザーIDを設定します
+func WithActorID(ctx context.Context, userID uint) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.UserID = &userID
+	return WithActor(ctx, actor)
+}
+
+// ActorFromContext はcontextの操作の主体を返します（未設定の場合はゼロ値）
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// SkipTracking はcontextでの更新・削除を GormPlugin の記録対象から外します
+// 個人情報を消去する退会処理など、専用の監査ログを記録する処理で使用します
+func SkipTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func trackingSkipped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// NewEntry はcontextの操作の主体を設定した監査ログのエントリを作成します
+func NewEntry(ctx context.Context, action, targetType string, targetID uint) Entry {
+	actor := ActorFromContext(ctx)
+	entry := Entry{
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+	}
+	if actor.Source != "" {
+		entry.Metadata = map[string]interface{}{"source": actor.Source}
+	}
+	return entry
+}
@@ -0,0 +1,347 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// redacted は値を伏せる列の変更前後に記録する値
+const redacted = "[REDACTED]"
+
+// trackedTable は GormPlugin が変更を記録するテーブル
+type trackedTable struct {
+	targetType string
+	// ignore は差分に含めない列（更新のたびに変わる列や、専用の監査ログがある列）
+	ignore map[string]bool
+	// secret は変更されたことのみ記録し、値を伏せる列
+	secret map[string]bool
+	// updateAction は差分から更新のアクションを決めます
+	updateAction func(changes map[string]Change) string
+	deleteAction string
+}
+
+var trackedTables = map[string]trackedTable{
+	"projects": {
+		targetType: "project",
+		ignore:     set("created_at", "updated_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionProjectStatusChanged
+			}
+			return ActionProjectUpdated
+		},
+		deleteAction: ActionProjectDeleted,
+	},
+	"supports": {
+		targetType: "support",
+		ignore:     set("created_at", "updated_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionSupportStatusChanged
+			}
+			return ActionSupportUpdated
+		},
+		deleteAction: ActionSupportDeleted,
+	},
+	"settlements": {
+		targetType: "settlement",
+		ignore:     set("created_at", "updated_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionSettlementStatusChanged
+			}
+			return ActionSettlementUpdated
+		},
+		deleteAction: ActionSettlementDeleted,
+	},
+	"invoices": {
+		targetType: "invoice",
+		ignore:     set("created_at", "updated_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionInvoiceStatusChanged
+			}
+			return ActionInvoiceUpdated
+		},
+		deleteAction: ActionInvoiceDeleted,
+	},
+	"expenses": {
+		targetType: "expense",
+		ignore:     set("created_at", "updated_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionExpenseStatusChanged
+			}
+			return ActionExpenseUpdated
+		},
+		deleteAction: ActionExpenseDeleted,
+	},
+	"receipts": {
+		targetType: "receipt",
+		ignore:     set("created_at"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["status"]; ok {
+				return ActionReceiptVoided
+			}
+			return ActionReceiptUpdated
+		},
+		deleteAction: ActionReceiptDeleted,
+	},
+	"users": {
+		targetType: "user",
+		// ログイン失敗の追跡とロック、二要素認証、退会はそれぞれ専用のアクションで記録する
+		ignore: set("created_at", "updated_at", "failed_login_count", "last_failed_login_at",
+			"locked_until", "unlock_token_hash", "totp_secret", "totp_enabled", "totp_last_used_step",
+			"deletion_requested_at"),
+		secret: set("password"),
+		updateAction: func(changes map[string]Change) string {
+			if _, ok := changes["role"]; ok {
+				return ActionUserRoleChanged
+			}
+			if _, ok := changes["password"]; ok {
+				return ActionUserPasswordChanged
+			}
+			return ActionUserProfileUpdated
+		},
+		deleteAction: ActionUserDeleted,
+	},
+}
+
+func set(columns ...string) map[string]bool {
+	m := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		m[c] = true
+	}
+	return m
+}
+
+// GormPlugin は projects・supports・settlements・invoices・expenses・receipts・users の更新と削除を、変更前後の差分とともに監査ログに記録するGORMのプラグイン
+//
+// 監査ログは変更と同じトランザクションで記録し、記録に失敗した場合は変更もロールバックします
+// 操作の主体はクエリのcontext（db.WithContext で渡したもの）の Actor です
+type GormPlugin struct{}
+
+const gormBeforeKey = "audit:before"
+
+// Name はプラグインの名前を返します
+func (GormPlugin) Name() string { return "audit" }
+
+// Initialize は更新・削除の前後にコールバックを登録します
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", p.before); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", p.before); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+// Register は GormPlugin を登録します（登録済みの場合は何もしません）
+func Register(db *gorm.DB) error {
+	if err := db.Use(GormPlugin{}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+		return fmt.Errorf("監査ログのコールバックの登録に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// tracked は記録対象のテーブルであれば、その設定を返します
+func tracked(tx *gorm.DB) (trackedTable, bool) {
+	if tx.Error != nil || tx.Statement.Schema == nil || trackingSkipped(tx.Statement.Context) {
+		return trackedTable{}, false
+	}
+	table, ok := trackedTables[tx.Statement.Schema.Table]
+	return table, ok
+}
+
+// before は変更対象の行を変更前の値とともに読み込みます（行ロックを取得し、並行する変更と混ざらないようにする）
+func (GormPlugin) before(tx *gorm.DB) {
+	if _, ok := tracked(tx); !ok {
+		return
+	}
+	stmt := tx.Statement
+	query := tx.Session(&gorm.Session{NewDB: true}).Table(stmt.Schema.Table).
+		Clauses(clause.Locking{Strength: "UPDATE"})
+
+	conditions := false
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			query = query.Clauses(clause.Where{Exprs: where.Exprs})
+			conditions = true
+		}
+	}
+	// db.Model(&project).Updates(...) のように主キーで対象を指定した場合
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if value, zero := field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+				query = query.Where(clause.Eq{Column: clause.Column{Name: field.DBName}, Value: value})
+				conditions = true
+			}
+		}
+	}
+	// 条件のない更新・削除はGORMが拒否する
+	if !conditions {
+		return
+	}
+	// 論理削除済みの行は更新・削除の対象にならない
+	if field := stmt.Schema.LookUpField("DeletedAt"); field != nil && !stmt.Unscoped {
+		query = query.Where(clause.Eq{Column: clause.Column{Name: field.DBName}, Value: nil})
+	}
+
+	var rows []map[string]interface{}
+	if err := query.Find(&rows).Error; err != nil {
+		tx.AddError(fmt.Errorf("audit: 変更前の値の読み込みに失敗しました: %w", err))
+		return
+	}
+	tx.InstanceSet(gormBeforeKey, rows)
+}
+
+func (GormPlugin) afterUpdate(tx *gorm.DB) {
+	table, before, ok := beforeRows(tx)
+	if !ok {
+		return
+	}
+	pk := primaryKey(tx)
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk])
+	}
+	var after []map[string]interface{}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Table(tx.Statement.Schema.Table).
+		Where(clause.IN{Column: clause.Column{Name: pk}, Values: ids}).
+		Find(&after).Error; err != nil {
+		tx.AddError(fmt.Errorf("audit: 変更後の値の読み込みに失敗しました: %w", err))
+		return
+	}
+	afterByID := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		afterByID[fmt.Sprint(row[pk])] = row
+	}
+
+	for _, old := range before {
+		changes := diff(table, old, afterByID[fmt.Sprint(old[pk])])
+		if len(changes) == 0 {
+			continue
+		}
+		record(tx, table, table.updateAction(changes), old[pk], changes)
+	}
+}
+
+func (GormPlugin) afterDelete(tx *gorm.DB) {
+	table, before, ok := beforeRows(tx)
+	if !ok || tx.RowsAffected == 0 {
+		return
+	}
+	pk := primaryKey(tx)
+	// 削除した行の値を変更前の値として残す
+	for _, old := range before {
+		record(tx, table, table.deleteAction, old[pk], diff(table, old, nil))
+	}
+}
+
+// beforeRows は before で読み込んだ行を返します
+func beforeRows(tx *gorm.DB) (trackedTable, []map[string]interface{}, bool) {
+	table, ok := tracked(tx)
+	if !ok {
+		return table, nil, false
+	}
+	v, ok := tx.InstanceGet(gormBeforeKey)
+	if !ok {
+		return table, nil, false
+	}
+	rows := v.([]map[string]interface{})
+	return table, rows, len(rows) > 0
+}
+
+func primaryKey(tx *gorm.DB) string {
+	if field := tx.Statement.Schema.PrioritizedPrimaryField; field != nil {
+		return field.DBName
+	}
+	return "id"
+}
+
+// record は変更を記録します。記録に失敗した場合は変更をロールバックさせます
+func record(tx *gorm.DB, table trackedTable, action string, id interface{}, changes map[string]Change) {
+	entry := NewEntry(tx.Statement.Context, action, table.targetType, toUint(id))
+	entry.Changes = changes
+	if err := Record(tx.Session(&gorm.Session{NewDB: true}), entry); err != nil {
+		tx.AddError(fmt.Errorf("audit: 監査ログの記録に失敗しました: %w", err))
+	}
+}
+
+// diff は変更された列の変更前後の値を返します（after が nil の場合は削除として全列を記録）
+func diff(table trackedTable, before, after map[string]interface{}) map[string]Change {
+	changes := make(map[string]Change)
+	columns := make([]string, 0, len(before))
+	for column := range before {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		if table.ignore[column] {
+			continue
+		}
+		from := normalize(before[column])
+		var to interface{}
+		if after != nil {
+			to = normalize(after[column])
+			if equal(from, to) {
+				continue
+			}
+		}
+		if table.secret[column] {
+			from, to = redacted, redacted
+			if after == nil {
+				to = nil
+			}
+		}
+		changes[column] = Change{From: from, To: to}
+	}
+	return changes
+}
+
+// normalize はデータベースから読み込んだ値をJSONに変換できる形にします
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC()
+	}
+	return v
+}
+
+func equal(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toUint(v interface{}) uint {
+	switch v := v.(type) {
+	case int64:
+		return uint(v)
+	case int32:
+		return uint(v)
+	case int:
+		return uint(v)
+	case uint:
+		return v
+	case uint64:
+		return uint(v)
+	}
+	return 0
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/jobs"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ログイン試行の制限値
+const (
+	// アカウントごと: この回数連続で失敗すると遅延を課す
+	accountDelayThreshold = 3
+	// アカウントごと: この回数連続で失敗するとロックする
+	accountLockThreshold = 5
+	// アカウントロックの期間
+	accountLockDuration = 15 * time.Minute
+
+	// IPごとの失敗回数を数える期間
+	ipWindow = 15 * time.Minute
+	// IPごと: 期間内にこの回数失敗すると遅延を課す
+	ipDelayThreshold = 10
+	// IPごと: 期間内にこの回数失敗するとブロックする
+	ipBlockThreshold = 50
+
+	// 段階的な遅延の上限
+	maxDelay = time.Minute
+)
+
+// JobUnlockMail はロック解除メールを送信するジョブの種類
+const JobUnlockMail = "auth.unlock_mail"
+
+// LoginGuard はログインの総当たり攻撃を防ぐための試行回数の追跡を行います
+type LoginGuard struct {
+	db          *gorm.DB
+	mailer      mail.Mailer
+	frontendURL string
+	now         func() time.Time
+}
+
+// NewLoginGuard は新しいLoginGuardインスタンスを作成します
+// frontendURLはロック解除メールのリンクに使用します
+func NewLoginGuard(db *gorm.DB, mailer mail.Mailer, frontendURL string) *LoginGuard {
+	return &LoginGuard{db: db, mailer: mailer, frontendURL: frontendURL, now: time.Now}
+}
+
+// SetClock は現在時刻の取得に使用する関数を差し替えます（テスト用）
+func (g *LoginGuard) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// Register はジョブの処理をWorkerに登録します
+func (g *LoginGuard) Register(w *jobs.Worker) {
+	w.Register(JobUnlockMail, g.handleUnlockMail)
+}
+
+type unlockMailPayload struct {
+	UserID uint `json:"user_id"`
+}
+
+// Throttled はログイン試行が制限されている状態と再試行までの待機時間
+type Throttled struct {
+	Err        *utils.APIError
+	RetryAfter time.Duration
+}
+
+// Error はerrorインターフェースを実装
+func (t *Throttled) Error() string {
+	return t.Err.Error()
+}
+
+// progressiveDelay は失敗回数に応じて指数的に増える待機時間を返します
+func progressiveDelay(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	shift := failures - threshold
+	if shift > 6 {
+		return maxDelay
+	}
+	delay := time.Second << uint(shift)
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// CheckIP はIPアドレスからのログイン試行が許可されているかを確認します
+func (g *LoginGuard) CheckIP(ctx context.Context, ip string) *Throttled {
+	now := g.now()
+
+	var stats struct {
+		Failures int
+		Last     *time.Time
+	}
+	if err := g.db.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Select("COUNT(*) AS failures, MAX(created_at) AS last").
+		Where("ip = ? AND success = ? AND created_at > ?", ip, false, now.Add(-ipWindow)).
+		Scan(&stats).Error; err != nil {
+		// 集計に失敗してもログイン自体は継続させる
+		logging.FromContext(ctx).Error("ログイン試行の集計に失敗しました", "error", err)
+		return nil
+	}
+
+	if stats.Failures >= ipBlockThreshold {
+		return &Throttled{Err: utils.ErrTooManyAttempts, RetryAfter: ipWindow}
+	}
+
+	if stats.Last != nil {
+		if wait := stats.Last.Add(progressiveDelay(stats.Failures, ipDelayThreshold)).Sub(now); wait > 0 {
+			return &Throttled{Err: utils.ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// CheckAccount はアカウントへのログイン試行が許可されているかを確認します
+func (g *LoginGuard) CheckAccount(user *models.User) *Throttled {
+	now := g.now()
+
+	if user.IsLocked(now) {
+		return &Throttled{Err: utils.ErrAccountLocked, RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
+	if user.LastFailedLoginAt != nil {
+		if wait := user.LastFailedLoginAt.Add(progressiveDelay(user.FailedLoginCount, accountDelayThreshold)).Sub(now); wait > 0 {
+			return &Throttled{Err: utils.ErrTooManyAttempts, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// RecordFailure はログイン失敗を記録し、閾値を超えた場合はアカウントをロックします
+// userはメールアドレスに該当するユーザーが存在しない場合nil
+// ロックした場合はロック解除メールの送信をジョブとして登録します
+func (g *LoginGuard) RecordFailure(ctx context.Context, user *models.User, email, ip, userAgent string) {
+	now := g.now()
+	db := g.db.WithContext(ctx)
+
+	if err := db.Create(&models.LoginAttempt{Email: email, IP: ip, Success: false, CreatedAt: now}).Error; err != nil {
+		logging.FromContext(ctx).Error("ログイン試行の記録に失敗しました", "error", err)
+	}
+
+	if user == nil {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 並行したログイン失敗で回数を取りこぼさないよう、行をロックしてから数える
+		var current models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_login_count", "locked_until").
+			First(&current, user.ID).Error; err != nil {
+			return err
+		}
+
+		// ロック期間が明けた後の失敗は1回目から数え直す
+		failures := current.FailedLoginCount + 1
+		if current.LockedUntil != nil && !current.IsLocked(now) {
+			failures = 1
+		}
+
+		updates := map[string]interface{}{
+			"failed_login_count":   failures,
+			"last_failed_login_at": now,
+		}
+
+		// ロック中の失敗は回数のみ数え、ロック期間は延長しない
+		locked := failures >= accountLockThreshold && !current.IsLocked(now)
+		if locked {
+			updates["locked_until"] = now.Add(accountLockDuration)
+		}
+
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		// ロックがコミットされた場合のみメールを送信する
+		if _, err := jobs.Enqueue(tx, JobUnlockMail, unlockMailPayload{UserID: user.ID}); err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.Entry{
+			Action:     audit.ActionUserLocked,
+			TargetType: "user",
+			TargetID:   user.ID,
+			IP:         ip,
+			UserAgent:  userAgent,
+			Metadata: map[string]interface{}{
+				"failed_login_count": failures,
+				"locked_until":       now.Add(accountLockDuration),
+			},
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("ログイン失敗の記録に失敗しました", "user_id", user.ID, "error", err)
+	}
+}
+
+// RecordSuccess はログイン成功を記録し、失敗回数をリセットします
+func (g *LoginGuard) RecordSuccess(ctx context.Context, user *models.User, ip string) {
+	db := g.db.WithContext(ctx)
+	if err := db.Create(&models.LoginAttempt{Email: user.Email, IP: ip, Success: true, CreatedAt: g.now()}).Error; err != nil {
+		logging.FromContext(ctx).Error("ログイン試行の記録に失敗しました", "error", err)
+	}
+
+	if user.FailedLoginCount == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := db.Model(user).Updates(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+		"unlock_token_hash":    "",
+	}).Error; err != nil {
+		logging.FromContext(ctx).Error("ログイン失敗回数のリセットに失敗しました", "user_id", user.ID, "error", err)
+	}
+}
+
+// Unlock はメールで送信したトークンを使ってアカウントのロックを解除します
+func (g *LoginGuard) Unlock(token, ip, userAgent string) error {
+	if token == "" {
+		return utils.ErrInvalidInput.WithDetail(utils.ErrMsgInvalidUnlockToken)
+	}
+
+	var user models.User
+	if err := g.db.Where("unlock_token_hash = ? AND locked_until > ?", hashToken(token), g.now()).
+		First(&user).Error; err != nil {
+		return utils.ErrInvalidInput.WithDetail(utils.ErrMsgInvalidUnlockToken)
+	}
+
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"failed_login_count":   0,
+			"last_failed_login_at": nil,
+			"locked_until":         nil,
+			"unlock_token_hash":    "",
+		}).Error; err != nil {
+			return utils.ErrInternalServer.WithDetail("アカウントのロック解除に失敗しました")
+		}
+		if err := audit.Record(tx, audit.Entry{
+			ActorID:    &user.ID,
+			Action:     audit.ActionUserUnlocked,
+			TargetType: "user",
+			TargetID:   user.ID,
+			IP:         ip,
+			UserAgent:  userAgent,
+		}); err != nil {
+			return utils.ErrInternalServer.WithDetail("監査ログの記録に失敗しました")
+		}
+		return nil
+	})
+}
+
+// PruneAttempts は retention より前のログイン試行の記録を削除し、件数を返します（0以下の場合は削除しない）
+func (g *LoginGuard) PruneAttempts(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+	result := g.db.WithContext(ctx).Where("created_at < ?", g.now().Add(-retention)).Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+// handleUnlockMail はロック解除用のリンクをユーザーの表示言語でメール送信します
+// トークンはジョブに保存せず送信時に発行するため、再試行のたびに前回のトークンは無効になります
+func (g *LoginGuard) handleUnlockMail(ctx context.Context, raw []byte) error {
+	var payload unlockMailPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	// 送信前にロックが解除された（期間の経過・ログインの成功）場合は送らない
+	var user models.User
+	err := g.db.WithContext(ctx).Where("id = ? AND locked_until > ?", payload.UserID, g.now()).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newUnlockToken()
+	if err != nil {
+		return err
+	}
+	if err := g.db.WithContext(ctx).Model(&user).Update("unlock_token_hash", hashToken(token)).Error; err != nil {
+		return err
+	}
+
+	locale := i18n.UserLocale(user.Locale)
+	return g.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: i18n.T(locale, "mail.unlock.subject"),
+		Body:    i18n.T(locale, "mail.unlock.body", int(accountLockDuration.Minutes()), g.frontendURL, token),
+	})
+}
+
+// newUnlockToken はランダムなロック解除トークンを生成します
+func newUnlockToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken はトークンをDBに保存するためにハッシュ化します
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,275 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEmailNotVerified はプロバイダーから確認済みのメールアドレスが得られない場合のエラー
+var ErrEmailNotVerified = errors.New("oidc: email is not verified")
+
+// Config はOAuth2/OIDCプロバイダーの設定
+// Issuerが設定されている場合はディスカバリーで各エンドポイントを取得し、IDトークンを検証します
+// Issuerがない場合（OAuth2のみのプロバイダー）はUserInfoURLからユーザー情報を取得します
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	JWKSURL     string
+
+	// ParseUserInfo はUserInfoエンドポイントのレスポンスを解析します（未設定時はOIDC標準のクレーム）
+	ParseUserInfo func([]byte) (*Identity, error)
+}
+
+// Identity はプロバイダーで認証されたユーザーの情報
+type Identity struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// Provider はOAuth2/OIDCの認可コードフロー（PKCE）を扱います
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu         sync.Mutex
+	discovered bool
+	keys       *keySet
+}
+
+// NewProvider は新しいProviderインスタンスを作成します
+func NewProvider(cfg Config) *Provider {
+	if cfg.ParseUserInfo == nil {
+		cfg.ParseUserInfo = parseStandardUserInfo
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// Name はプロバイダー名を返します
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// discoveryDocument は /.well-known/openid-configuration のレスポンス
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover はOIDCディスカバリーで未設定のエンドポイントを補完します
+// 失敗した場合は次回のリクエストで再試行します
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered || p.cfg.Issuer == "" {
+		return nil
+	}
+
+	var doc discoveryDocument
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.client, wellKnown, &doc); err != nil {
+		return fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return fmt.Errorf("oidc: issuer mismatch: %q != %q", doc.Issuer, p.cfg.Issuer)
+	}
+
+	if p.cfg.AuthURL == "" {
+		p.cfg.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.cfg.TokenURL == "" {
+		p.cfg.TokenURL = doc.TokenEndpoint
+	}
+	if p.cfg.UserInfoURL == "" {
+		p.cfg.UserInfoURL = doc.UserInfoEndpoint
+	}
+	if p.cfg.JWKSURL == "" {
+		p.cfg.JWKSURL = doc.JWKSURI
+	}
+	p.keys = newKeySet(p.client, p.cfg.JWKSURL)
+	p.discovered = true
+	return nil
+}
+
+// AuthCodeURL は認可エンドポイントへのリダイレクトURLを返します
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	v.Set("state", state)
+	v.Set("code_challenge", CodeChallenge(verifier))
+	v.Set("code_challenge_method", "S256")
+	if p.cfg.Issuer != "" {
+		v.Set("nonce", nonce)
+	}
+
+	sep := "?"
+	if strings.Contains(p.cfg.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.cfg.AuthURL + sep + v.Encode(), nil
+}
+
+// tokenResponse はトークンエンドポイントのレスポンス
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	ErrorDesc   string `json:"error_description"`
+}
+
+// Exchange は認可コードをトークンに交換し、認証されたユーザーの情報を返します
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("oidc: token request failed: status=%d error=%s %s", resp.StatusCode, token.Error, token.ErrorDesc)
+	}
+
+	// OIDCプロバイダーはIDトークンを検証して利用する
+	if p.cfg.Issuer != "" {
+		if token.IDToken == "" {
+			return nil, errors.New("oidc: id_token is missing")
+		}
+		return p.verifyIDToken(ctx, token.IDToken, nonce)
+	}
+
+	return p.userInfo(ctx, token.AccessToken)
+}
+
+// userInfo はアクセストークンでUserInfoエンドポイントからユーザー情報を取得します
+func (p *Provider) userInfo(ctx context.Context, accessToken string) (*Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: userinfo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: userinfo request failed: status=%d", resp.StatusCode)
+	}
+
+	identity, err := p.cfg.ParseUserInfo(body)
+	if err != nil {
+		return nil, err
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("oidc: subject is missing")
+	}
+	return identity, nil
+}
+
+// getJSON はGETリクエストのJSONレスポンスをデコードします
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// parseStandardUserInfo はOIDC標準クレームのUserInfoレスポンスを解析します
+func parseStandardUserInfo(body []byte) (*Identity, error) {
+	var identity Identity
+	if err := json.Unmarshal(body, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// RandomString はstate・nonce・PKCEのcode_verifierに使うランダムな文字列を生成します
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge はPKCEのcode_verifierからS256のcode_challengeを計算します
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+)
+
+// presets は主要プロバイダーの既定設定（環境変数で上書き可能）
+var presets = map[string]Config{
+	"google": {
+		Issuer: "https://accounts.google.com",
+		Scopes: []string{"openid", "email", "profile"},
+	},
+	// XはOIDCに対応していないため、OAuth2のユーザー情報APIを利用する
+	// メールアドレスは取得できないため、ログイン済みユーザーへの連携またはIDでの再ログインのみ可能
+	"x": {
+		AuthURL:       "https://twitter.com/i/oauth2/authorize",
+		TokenURL:      "https://api.twitter.com/2/oauth2/token",
+		UserInfoURL:   "https://api.twitter.com/2/users/me?user.fields=profile_image_url",
+		Scopes:        []string{"users.read", "tweet.read"},
+		ParseUserInfo: parseXUserInfo,
+	},
+}
+
+// Registry は有効なプロバイダーの一覧
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry は設定からRegistryを作成します
+func NewRegistry(configs ...Config) *Registry {
+	r := &Registry{providers: make(map[string]*Provider)}
+	for _, cfg := range configs {
+		r.providers[cfg.Name] = NewProvider(cfg)
+	}
+	return r
+}
+
+// NewRegistryFromConfig は設定からRegistryを作成します
+// 主要プロバイダーは名前だけで既定値が入り、設定した項目で上書きされます
+// リダイレクトURLの既定値は {backendURL}/api/v1/auth/oauth/{name}/callback です
+func NewRegistryFromConfig(providers []config.OAuthProvider, backendURL string) (*Registry, error) {
+	var configs []Config
+	for _, p := range providers {
+		name := strings.ToLower(p.Name)
+
+		cfg := presets[name]
+		cfg.Name = name
+		cfg.ClientID = p.ClientID
+		cfg.ClientSecret = p.ClientSecret
+		if cfg.ClientID == "" {
+			return nil, fmt.Errorf("OAUTH_%s_CLIENT_ID is not set", strings.ToUpper(name))
+		}
+
+		overrides := map[*string]string{
+			&cfg.Issuer:      p.Issuer,
+			&cfg.AuthURL:     p.AuthURL,
+			&cfg.TokenURL:    p.TokenURL,
+			&cfg.UserInfoURL: p.UserInfoURL,
+			&cfg.RedirectURL: p.RedirectURL,
+		}
+		for field, v := range overrides {
+			if v != "" {
+				*field = v
+			}
+		}
+		if len(p.Scopes) > 0 {
+			cfg.Scopes = p.Scopes
+		}
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = backendURL + "/api/v1/auth/oauth/" + name + "/callback"
+		}
+
+		if cfg.Issuer == "" && (cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "") {
+			return nil, fmt.Errorf("oauth provider %q requires ISSUER or AUTH_URL/TOKEN_URL/USERINFO_URL", name)
+		}
+
+		configs = append(configs, cfg)
+	}
+
+	return NewRegistry(configs...), nil
+}
+
+// Get は名前に対応するプロバイダーを返します
+func (r *Registry) Get(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names は有効なプロバイダー名の一覧を返します
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseXUserInfo はXのユーザー情報APIのレスポンスを解析します
+func parseXUserInfo(body []byte) (*Identity, error) {
+	var resp struct {
+		Data struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			ProfileImageURL string `json:"profile_image_url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Subject: resp.Data.ID,
+		Name:    resp.Data.Name,
+		Picture: resp.Data.ProfileImageURL,
+	}, nil
+}
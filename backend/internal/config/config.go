@@ -0,0 +1,400 @@
+// Package config はアプリケーションの設定を読み込み、型付きの構造体として提供します
+//
+// 設定は以下の順に読み込み、後のものほど優先されます
+//  1. 既定値
+//  2. 設定ファイル（CONFIG_FILE または -config で指定した TOML / YAML）
+//  3. .env ファイル
+//  4. 環境変数
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 実行環境
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// 本番環境で求めるJWT署名鍵の最小長（バイト）
+const minJWTSecretLength = 32
+
+// Config はアプリケーション全体の設定
+type Config struct {
+	Env string `toml:"env" yaml:"env" env:"ENV"`
+
+	Server    Server    `toml:"server" yaml:"server"`
+	Database  Database  `toml:"database" yaml:"database"`
+	Auth      Auth      `toml:"auth" yaml:"auth"`
+	Stripe    Stripe    `toml:"stripe" yaml:"stripe"`
+	Mail      Mail      `toml:"mail" yaml:"mail"`
+	RateLimit RateLimit `toml:"rate_limit" yaml:"rate_limit"`
+	OAuth     OAuth     `toml:"oauth" yaml:"oauth"`
+	Log       Log       `toml:"log" yaml:"log"`
+	Metrics   Metrics   `toml:"metrics" yaml:"metrics"`
+	Tracing   Tracing   `toml:"tracing" yaml:"tracing"`
+	Audit     Audit     `toml:"audit" yaml:"audit"`
+	Payout    Payout    `toml:"payout" yaml:"payout"`
+	Receipt   Receipt   `toml:"receipt" yaml:"receipt"`
+}
+
+// Server はHTTPサーバーの設定
+type Server struct {
+	Port        string   `toml:"port" yaml:"port" env:"SERVER_PORT"`
+	CORSOrigins []string `toml:"cors_origins" yaml:"cors_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// メール内のリンクや決済後のリダイレクト先に使用するフロントエンドのURL
+	FrontendURL string `toml:"frontend_url" yaml:"frontend_url" env:"FRONTEND_URL"`
+	// OAuthのリダイレクトURLに使用するバックエンドのURL
+	BackendURL string `toml:"backend_url" yaml:"backend_url" env:"BACKEND_URL"`
+	// バージョンのない /api/... を廃止する日（YYYY-MM-DD、Sunsetヘッダーに使用）
+	LegacyAPISunset string `toml:"legacy_api_sunset" yaml:"legacy_api_sunset" env:"LEGACY_API_SUNSET"`
+	// 停止時に処理中のリクエスト（Webhookなど）の完了を待つ時間（例: 30s）
+	ShutdownTimeout string `toml:"shutdown_timeout" yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// X-Forwarded-For を信頼するプロキシのIPアドレス・CIDR（空の場合はどのプロキシも信頼せず、接続元のアドレスを使用）
+	TrustedProxies []string `toml:"trusted_proxies" yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// ShutdownTimeoutDuration は停止時に処理中のリクエストの完了を待つ時間を返します
+func (s Server) ShutdownTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(s.ShutdownTimeout)
+}
+
+// LegacyAPISunsetTime はバージョンのない /api/... を廃止する日時（UTC）を返します
+func (s Server) LegacyAPISunsetTime() (time.Time, error) {
+	return time.Parse(time.DateOnly, s.LegacyAPISunset)
+}
+
+// Database はPostgreSQLの接続設定
+type Database struct {
+	Host     string `toml:"host" yaml:"host" env:"DB_HOST"`
+	Port     string `toml:"port" yaml:"port" env:"DB_PORT"`
+	User     string `toml:"user" yaml:"user" env:"DB_USER"`
+	Password string `toml:"password" yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `toml:"name" yaml:"name" env:"DB_NAME"`
+	// 未設定の場合、開発環境では disable、それ以外では require
+	SSLMode string `toml:"sslmode" yaml:"sslmode" env:"DB_SSLMODE"`
+}
+
+// DSN はPostgreSQLの接続文字列を返します
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Tokyo",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+// Auth は認証の設定
+type Auth struct {
+	JWTSecret  string `toml:"jwt_secret" yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	BcryptCost int    `toml:"bcrypt_cost" yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+	// ログイン試行の記録の保持日数（0の場合は削除しない）
+	LoginAttemptRetentionDays int `toml:"login_attempt_retention_days" yaml:"login_attempt_retention_days" env:"LOGIN_ATTEMPT_RETENTION_DAYS"`
+}
+
+// LoginAttemptRetention はログイン試行の記録の保持期間を返します
+func (a Auth) LoginAttemptRetention() time.Duration {
+	return time.Duration(a.LoginAttemptRetentionDays) * 24 * time.Hour
+}
+
+// Stripe は決済の設定
+type Stripe struct {
+	SecretKey     string `toml:"secret_key" yaml:"secret_key" env:"STRIPE_SECRET_KEY" secret:"true"`
+	WebhookSecret string `toml:"webhook_secret" yaml:"webhook_secret" env:"STRIPE_WEBHOOK_SECRET" secret:"true"`
+	// Connectアカウントのイベント（送金先の状態・入金の結果）を受け取るWebhookの署名シークレット
+	ConnectWebhookSecret string `toml:"connect_webhook_secret" yaml:"connect_webhook_secret" env:"STRIPE_CONNECT_WEBHOOK_SECRET" secret:"true"`
+}
+
+// Mail はメール送信の設定（SMTPHostが未設定の場合はログ出力のみ。production では必須）
+type Mail struct {
+	SMTPHost     string `toml:"smtp_host" yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `toml:"smtp_port" yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `toml:"smtp_username" yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `toml:"smtp_password" yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `toml:"from" yaml:"from" env:"MAIL_FROM"`
+}
+
+// RateLimit はレート制限の設定
+type RateLimit struct {
+	// memory または postgres（複数レプリカでは postgres）
+	Store string `toml:"store" yaml:"store" env:"RATE_LIMIT_STORE"`
+	// 空の場合は既定のポリシー（書式は middleware.ParseRateLimitPolicies を参照）
+	Policies string `toml:"policies" yaml:"policies" env:"RATE_LIMIT_POLICIES"`
+}
+
+// Log はログ出力の設定
+type Log struct {
+	// debug / info / warn / error
+	Level string `toml:"level" yaml:"level" env:"LOG_LEVEL"`
+	// json または text（text は開発時の確認用）
+	Format string `toml:"format" yaml:"format" env:"LOG_FORMAT"`
+}
+
+// Metrics は /metrics（Prometheus形式）の公開設定（どちらも未設定の場合は公開しない）
+type Metrics struct {
+	// 内部向けのリスナーのアドレス（例: 127.0.0.1:9090）。このリスナーではトークンなしで公開します
+	Addr string `toml:"addr" yaml:"addr" env:"METRICS_ADDR"`
+	// APIと同じポートで公開する場合のBearerトークン
+	Token string `toml:"token" yaml:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+// Tracing は分散トレーシングの設定（Exporter が未設定の場合は無効）
+type Tracing struct {
+	// otlp（OTLP/HTTP）または stdout（ローカルでの確認用）
+	Exporter string `toml:"exporter" yaml:"exporter" env:"TRACING_EXPORTER"`
+	// OTLPコレクターのURL（/v1/traces へ送信します）
+	Endpoint string `toml:"endpoint" yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// OTLPコレクターへ送るヘッダー（key=value のカンマ区切り。認証ヘッダーなど）
+	Headers     []string `toml:"headers" yaml:"headers" env:"OTEL_EXPORTER_OTLP_HEADERS" secret:"true"`
+	ServiceName string   `toml:"service_name" yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	// 親のないトレースを記録する割合（0〜1）
+	SampleRatio float64 `toml:"sample_ratio" yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Audit は監査ログの設定
+type Audit struct {
+	// 監査ログの保持日数（0の場合は削除しない）
+	RetentionDays int `toml:"retention_days" yaml:"retention_days" env:"AUDIT_RETENTION_DAYS"`
+}
+
+// Retention は監査ログの保持期間を返します
+func (a Audit) Retention() time.Duration {
+	return time.Duration(a.RetentionDays) * 24 * time.Hour
+}
+
+// Payout は主催者への送金の設定
+type Payout struct {
+	// 空の場合は既定のルール（書式は service.ParseFeeRules を参照）
+	FeeRules string `toml:"fee_rules" yaml:"fee_rules" env:"PAYOUT_FEE_RULES"`
+}
+
+// Receipt は領収書の発行者の設定
+type Receipt struct {
+	IssuerName    string `toml:"issuer_name" yaml:"issuer_name" env:"RECEIPT_ISSUER_NAME"`
+	IssuerAddress string `toml:"issuer_address" yaml:"issuer_address" env:"RECEIPT_ISSUER_ADDRESS"`
+	// 適格請求書発行事業者の登録番号（T + 13桁。空の場合は領収書に記載しない）
+	RegistrationNumber string `toml:"registration_number" yaml:"registration_number" env:"RECEIPT_REGISTRATION_NUMBER"`
+}
+
+// registrationNumberPattern は適格請求書発行事業者の登録番号の書式
+var registrationNumberPattern = regexp.MustCompile(`^T\d{13}$`)
+
+// OAuth はソーシャルログインの設定
+type OAuth struct {
+	Providers []OAuthProvider `toml:"providers" yaml:"providers"`
+}
+
+// OAuthProvider はソーシャルログインのプロバイダーごとの設定
+// 環境変数では OAUTH_PROVIDERS=google,x と OAUTH_<NAME>_* で指定します
+type OAuthProvider struct {
+	Name         string   `toml:"name" yaml:"name"`
+	ClientID     string   `toml:"client_id" yaml:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `toml:"client_secret" yaml:"client_secret" env:"CLIENT_SECRET" secret:"true"`
+	Issuer       string   `toml:"issuer" yaml:"issuer" env:"ISSUER"`
+	AuthURL      string   `toml:"auth_url" yaml:"auth_url" env:"AUTH_URL"`
+	TokenURL     string   `toml:"token_url" yaml:"token_url" env:"TOKEN_URL"`
+	UserInfoURL  string   `toml:"userinfo_url" yaml:"userinfo_url" env:"USERINFO_URL"`
+	Scopes       []string `toml:"scopes" yaml:"scopes" env:"SCOPES"`
+	RedirectURL  string   `toml:"redirect_url" yaml:"redirect_url" env:"REDIRECT_URL"`
+}
+
+// Default は既定値の設定を返します
+func Default() *Config {
+	return &Config{
+		Env: EnvProduction,
+		Server: Server{
+			Port:        "8000",
+			CORSOrigins: []string{"http://localhost:5173", "https://oshiome.onrender.com"},
+			FrontendURL: "http://localhost:5173",
+			BackendURL:  "http://localhost:8000",
+			// フロントエンドを /api/v1 へ移行するまでの猶予
+			LegacyAPISunset: "2027-04-01",
+			ShutdownTimeout: "30s",
+		},
+		Database: Database{
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "oshiome",
+		},
+		Auth: Auth{BcryptCost: 12, LoginAttemptRetentionDays: 90},
+		Mail: Mail{
+			SMTPPort: "587",
+			From:     "no-reply@oshiome.com",
+		},
+		RateLimit: RateLimit{Store: "memory"},
+		Log:       Log{Level: "info", Format: "json"},
+		Tracing: Tracing{
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "oshiome-backend",
+			SampleRatio: 1,
+		},
+		Audit:   Audit{RetentionDays: 365},
+		Receipt: Receipt{IssuerName: "Oshiome"},
+	}
+}
+
+// IsProduction は本番環境かを返します
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validate は設定値を検証し、問題をまとめて返します
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvDevelopment, EnvTest, EnvStaging, EnvProduction:
+	default:
+		add("ENV must be one of development, test, staging, production (got %q)", c.Env)
+	}
+
+	if c.Auth.JWTSecret == "" {
+		add("JWT_SECRET is required")
+	} else if c.IsProduction() && len(c.Auth.JWTSecret) < minJWTSecretLength {
+		add("JWT_SECRET must be at least %d bytes in production", minJWTSecretLength)
+	}
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		add("BCRYPT_COST must be between 4 and 31 (got %d)", c.Auth.BcryptCost)
+	}
+
+	if c.IsProduction() {
+		if c.Stripe.SecretKey == "" {
+			add("STRIPE_SECRET_KEY is required in production")
+		}
+		if c.Stripe.WebhookSecret == "" {
+			add("STRIPE_WEBHOOK_SECRET is required in production")
+		}
+		if c.Stripe.ConnectWebhookSecret == "" {
+			add("STRIPE_CONNECT_WEBHOOK_SECRET is required in production")
+		}
+		if c.Database.SSLMode == "disable" {
+			add("DB_SSLMODE=disable is not allowed in production")
+		}
+		// ログ出力のみのMailerはパスワード再設定などのトークンを含む本文をログに残すため使わせない
+		if c.Mail.SMTPHost == "" {
+			add("SMTP_HOST is required in production")
+		}
+	}
+
+	if c.Server.Port == "" {
+		add("SERVER_PORT is required")
+	}
+	if len(c.Server.CORSOrigins) == 0 {
+		add("CORS_ALLOWED_ORIGINS is required")
+	}
+	if _, err := c.Server.LegacyAPISunsetTime(); err != nil {
+		add("LEGACY_API_SUNSET must be a date in YYYY-MM-DD format (got %q)", c.Server.LegacyAPISunset)
+	}
+
+	if d, err := c.Server.ShutdownTimeoutDuration(); err != nil || d <= 0 {
+		add("SHUTDOWN_TIMEOUT must be a positive duration such as 30s (got %q)", c.Server.ShutdownTimeout)
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				add("TRUSTED_PROXIES must be IP addresses or CIDRs (got %q)", proxy)
+			}
+		}
+	}
+
+	switch c.RateLimit.Store {
+	case "memory", "postgres":
+	default:
+		add("RATE_LIMIT_STORE must be memory or postgres (got %q)", c.RateLimit.Store)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL must be one of debug, info, warn, error (got %q)", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("LOG_FORMAT must be json or text (got %q)", c.Log.Format)
+	}
+
+	if c.Metrics.Addr != "" && c.Metrics.Addr == ":"+c.Server.Port {
+		add("METRICS_ADDR must differ from the API port (got %q)", c.Metrics.Addr)
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "otlp", "stdout":
+	default:
+		add("TRACING_EXPORTER must be otlp or stdout (got %q)", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO must be between 0 and 1 (got %v)", c.Tracing.SampleRatio)
+	}
+
+	if c.Auth.LoginAttemptRetentionDays < 0 {
+		add("LOGIN_ATTEMPT_RETENTION_DAYS must be 0 (keep forever) or a positive number of days (got %d)", c.Auth.LoginAttemptRetentionDays)
+	}
+	if c.Audit.RetentionDays < 0 {
+		add("AUDIT_RETENTION_DAYS must be 0 (keep forever) or a positive number of days (got %d)", c.Audit.RetentionDays)
+	}
+
+	if c.Receipt.IssuerName == "" {
+		add("RECEIPT_ISSUER_NAME is required")
+	}
+	if n := c.Receipt.RegistrationNumber; n != "" && !registrationNumberPattern.MatchString(n) {
+		add("RECEIPT_REGISTRATION_NUMBER must be T followed by 13 digits (got %q)", n)
+	}
+
+	for _, p := range c.OAuth.Providers {
+		if p.ClientID == "" {
+			add("OAUTH_%s_CLIENT_ID is required", strings.ToUpper(p.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyDerived は他の設定値から決まる値を補完します
+func (c *Config) applyDerived() {
+	if c.Database.SSLMode == "" {
+		c.Database.SSLMode = "require"
+		if c.Env == EnvDevelopment || c.Env == EnvTest {
+			c.Database.SSLMode = "disable"
+		}
+	}
+	c.Server.FrontendURL = strings.TrimSuffix(c.Server.FrontendURL, "/")
+	c.Server.BackendURL = strings.TrimSuffix(c.Server.BackendURL, "/")
+}
+
+// Load は設定を読み込みます
+// pathが空の場合は環境変数CONFIG_FILEの設定ファイルを使用します（どちらも空なら設定ファイルなし）
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadDotEnv(".env"); err != nil {
+		return nil, err
+	}
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.applyDerived()
+	return cfg, nil
+}
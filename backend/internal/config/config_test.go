@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig は検証を通る開発環境の設定を返します
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Env = EnvDevelopment
+	cfg.Auth.JWTSecret = "dev-secret"
+	cfg.applyDerived()
+	return cfg
+}
+
+// productionConfig は検証を通る本番環境の設定を返します
+func productionConfig() *Config {
+	cfg := Default()
+	cfg.Auth.JWTSecret = strings.Repeat("s", minJWTSecretLength)
+	cfg.Stripe.SecretKey = "sk_live_test"
+	cfg.Stripe.WebhookSecret = "whsec_test"
+	cfg.Stripe.ConnectWebhookSecret = "whsec_test_connect"
+	cfg.Mail.SMTPHost = "smtp.example.com"
+	cfg.applyDerived()
+	return cfg
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Errorf("開発環境の設定: %v", err)
+	}
+	if err := productionConfig().Validate(); err != nil {
+		t.Errorf("本番環境の設定: %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   func() *Config
+		modify func(cfg *Config)
+		err    string
+	}{
+		{"不明な環境", validConfig, func(c *Config) { c.Env = "prod" }, "ENV must be one of"},
+		{"JWTの秘密鍵なし", validConfig, func(c *Config) { c.Auth.JWTSecret = "" }, "JWT_SECRET is required"},
+		{"本番環境の短い秘密鍵", productionConfig, func(c *Config) { c.Auth.JWTSecret = "short" }, "JWT_SECRET must be at least 32 bytes"},
+		{"bcryptのコストが小さい", validConfig, func(c *Config) { c.Auth.BcryptCost = 3 }, "BCRYPT_COST must be between 4 and 31"},
+		{"bcryptのコストが大きい", validConfig, func(c *Config) { c.Auth.BcryptCost = 32 }, "BCRYPT_COST must be between 4 and 31"},
+		{"本番環境のStripeの鍵なし", productionConfig, func(c *Config) { c.Stripe.SecretKey = "" }, "STRIPE_SECRET_KEY is required"},
+		{"本番環境のWebhookの署名なし", productionConfig, func(c *Config) { c.Stripe.WebhookSecret = "" }, "STRIPE_WEBHOOK_SECRET is required"},
+		{"本番環境のConnectの署名なし", productionConfig, func(c *Config) { c.Stripe.ConnectWebhookSecret = "" }, "STRIPE_CONNECT_WEBHOOK_SECRET is required"},
+		{"本番環境のSSLなし", productionConfig, func(c *Config) { c.Database.SSLMode = "disable" }, "DB_SSLMODE=disable is not allowed"},
+		{"本番環境のSMTPなし", productionConfig, func(c *Config) { c.Mail.SMTPHost = "" }, "SMTP_HOST is required"},
+		{"ポートなし", validConfig, func(c *Config) { c.Server.Port = "" }, "SERVER_PORT is required"},
+		{"CORSのオリジンなし", validConfig, func(c *Config) { c.Server.CORSOrigins = nil }, "CORS_ALLOWED_ORIGINS is required"},
+		{"旧APIの廃止日の形式", validConfig, func(c *Config) { c.Server.LegacyAPISunset = "2027/04/01" }, "LEGACY_API_SUNSET must be a date"},
+		{"シャットダウンの猶予の形式", validConfig, func(c *Config) { c.Server.ShutdownTimeout = "30" }, "SHUTDOWN_TIMEOUT must be a positive duration"},
+		{"シャットダウンの猶予が0", validConfig, func(c *Config) { c.Server.ShutdownTimeout = "0s" }, "SHUTDOWN_TIMEOUT must be a positive duration"},
+		{"信頼するプロキシの形式", validConfig, func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy"} }, `TRUSTED_PROXIES must be IP addresses or CIDRs (got "proxy")`},
+		{"レート制限の保存先", validConfig, func(c *Config) { c.RateLimit.Store = "redis" }, "RATE_LIMIT_STORE must be memory or postgres"},
+		{"ログのレベル", validConfig, func(c *Config) { c.Log.Level = "verbose" }, "LOG_LEVEL must be one of"},
+		{"ログの形式", validConfig, func(c *Config) { c.Log.Format = "xml" }, "LOG_FORMAT must be json or text"},
+		{"メトリクスとAPIが同じポート", validConfig, func(c *Config) { c.Metrics.Addr = ":8000" }, "METRICS_ADDR must differ from the API port"},
+		{"トレースの出力先", validConfig, func(c *Config) { c.Tracing.Exporter = "jaeger" }, "TRACING_EXPORTER must be otlp or stdout"},
+		{"トレースの割合", validConfig, func(c *Config) { c.Tracing.SampleRatio = 1.5 }, "TRACING_SAMPLE_RATIO must be between 0 and 1"},
+		{"ログイン試行の保持期間", validConfig, func(c *Config) { c.Auth.LoginAttemptRetentionDays = -1 }, "LOGIN_ATTEMPT_RETENTION_DAYS must be 0"},
+		{"監査ログの保持期間", validConfig, func(c *Config) { c.Audit.RetentionDays = -1 }, "AUDIT_RETENTION_DAYS must be 0"},
+		{"領収書の発行者なし", validConfig, func(c *Config) { c.Receipt.IssuerName = "" }, "RECEIPT_ISSUER_NAME is required"},
+		{"登録番号の形式", validConfig, func(c *Config) { c.Receipt.RegistrationNumber = "1234567890123" }, "RECEIPT_REGISTRATION_NUMBER must be T followed by 13 digits"},
+		{"OAuthのクライアントIDなし", validConfig, func(c *Config) { c.OAuth.Providers = []OAuthProvider{{Name: "google"}} }, "OAUTH_GOOGLE_CLIENT_ID is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.base()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Auth.JWTSecret = ""
+	cfg.Log.Format = "xml"
+	cfg.Receipt.RegistrationNumber = "T123"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("不正な設定を受け付けました")
+	}
+	for _, want := range []string{"JWT_SECRET", "LOG_FORMAT", "RECEIPT_REGISTRATION_NUMBER"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s の問題が含まれていません: %v", want, err)
+		}
+	}
+	if n := len(strings.Split(err.Error(), "\n")); n != 3 {
+		t.Errorf("問題の数: got %d, want 3: %v", n, err)
+	}
+}
+
+func TestValidateAllowsShortSecretOutsideProduction(t *testing.T) {
+	cfg := validConfig()
+	cfg.Auth.JWTSecret = "short"
+	cfg.Stripe.SecretKey = ""
+	cfg.Mail.SMTPHost = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("開発環境の設定: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Redacted は秘密情報を伏せた設定の一覧を返します（起動時のログ出力用）
+func (c *Config) Redacted() string {
+	var b strings.Builder
+	dump(&b, reflect.ValueOf(c).Elem(), "")
+	for _, p := range c.OAuth.Providers {
+		dump(&b, reflect.ValueOf(p), "OAUTH_"+strings.ToUpper(p.Name)+"_")
+	}
+	return b.String()
+}
+
+// dump はenvタグのあるフィールドを「環境変数名=値」の形式で書き出します
+func dump(b *strings.Builder, v reflect.Value, prefix string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			dump(b, value, prefix)
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		var s string
+		switch {
+		case value.IsZero():
+			s = "(未設定)"
+		case field.Tag.Get("secret") == "true":
+			s = "********"
+		case value.Kind() == reflect.Slice:
+			s = strings.Join(value.Interface().([]string), ",")
+		default:
+			s = fmt.Sprint(value.Interface())
+		}
+		fmt.Fprintf(b, "  %s%s=%s\n", prefix, key, s)
+	}
+}
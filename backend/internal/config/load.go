@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile は拡張子に応じてTOMLまたはYAMLの設定ファイルを読み込みます
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: failed to read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file type %s (use .toml, .yaml or .yml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadDotEnv は.envファイルを環境変数に読み込みます（既に設定された環境変数は上書きしません）
+func loadDotEnv(path string) error {
+	err := godotenv.Load(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("config: failed to load %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv はenvタグに対応する環境変数で設定を上書きします
+func loadEnv(cfg *Config) error {
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), ""); err != nil {
+		return err
+	}
+	return loadOAuthEnv(&cfg.OAuth)
+}
+
+// loadOAuthEnv は OAUTH_PROVIDERS と OAUTH_<NAME>_* からプロバイダーの設定を読み込みます
+// 設定ファイルに同名のプロバイダーがある場合は環境変数の値で上書きします
+func loadOAuthEnv(oauth *OAuth) error {
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		idx := -1
+		for i := range oauth.Providers {
+			if oauth.Providers[i].Name == name {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			oauth.Providers = append(oauth.Providers, OAuthProvider{Name: name})
+			idx = len(oauth.Providers) - 1
+		}
+
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		if err := applyEnv(reflect.ValueOf(&oauth.Providers[idx]).Elem(), prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEnv は構造体のフィールドを再帰的にたどり、環境変数の値を設定します
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(prefix + key)
+		if !ok {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("config: invalid %s: %w", prefix+key, err)
+		}
+	}
+	return nil
+}
+
+// setValue は文字列をフィールドの型に変換して設定します
+func setValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		// カンマまたは空白区切り
+		items := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' })
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
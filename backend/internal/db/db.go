@@ -1,72 +1,42 @@
 package db
 
 import (
-	"fmt"
 	"log"
-	"os"
+	"log/slog"
+	"time"
 
-	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var db *gorm.DB
 
-// InitDB データベース接続を初期化し、必要なマイグレーションを実行します
-func InitDB() (*gorm.DB, error) {
-	// 環境変数から接続情報を取得
-	dbUser := os.Getenv("DB_USER")
-	if dbUser == "" {
-		dbUser = "postgres"
-	}
-	dbPass := os.Getenv("DB_PASSWORD")
-	if dbPass == "" {
-		dbPass = "postgres"
-	}
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "localhost"
-	}
-	dbPort := os.Getenv("DB_PORT")
-	if dbPort == "" {
-		dbPort = "5432"
-	}
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "oshiome"
-	}
-
-	// DSN（Data Source Name）の構築
-	// 本番環境（Render）ではSSL/TLS接続を有効に
-	sslMode := "require"
-	if os.Getenv("ENV") == "development" {
-		sslMode = "disable"
-	}
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Tokyo",
-		dbHost, dbUser, dbPass, dbName, dbPort, sslMode)
-
+// InitDB データベース接続を初期化します
+// スキーマの変更は migrations パッケージ（-migrate up）で行います
+func InitDB(cfg config.Database) (*gorm.DB, error) {
 	// データベース接続
 	var err error
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{Logger: newLogger()})
 	if err != nil {
 		log.Printf("データベース接続エラー: %v", err)
 		return nil, err
 	}
 
-	// マイグレーションの実行
-	if err := runMigrations(db); err != nil {
-		log.Printf("マイグレーションに失敗しました:%v", err)
-		return nil, err
-	}
-
 	log.Println("データベースの初期化が完了しました")
 	return db, nil
 }
 
-// GetDB 初期化済みのデータベース接続を返します
-func GetDB() *gorm.DB {
-	return db
+// newLogger はGORMのログを slog の既定のロガーへ出力するロガーを作成します
+// SQLの値（メールアドレスなど）はログに出さず、プレースホルダーのまま出力します
+func newLogger() logger.Interface {
+	return logger.New(slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn), logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		IgnoreRecordNotFoundError: true,
+		ParameterizedQueries:      true,
+		LogLevel:                  logger.Warn,
+	})
 }
 
 // CloseDB データベース接続を閉じます
@@ -82,11 +52,3 @@ func CloseDB() {
 		}
 	}
 }
-
-// runMigrations 必要なマイグレーションを実行します
-func runMigrations(db *gorm.DB) error {
-	if err := db.AutoMigrate(&models.User{}, &models.Project{}); err != nil {
-		return fmt.Errorf("マイグレーションエラー: %v", err)
-	}
-	return nil
-}
@@ -1,32 +1,146 @@
 package migrations
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
 
+	"github.com/masvc/oshiome_go/backend/internal/config"
 	"github.com/masvc/oshiome_go/backend/internal/db"
-	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
 )
 
-// RunMigrations データベースのマイグレーションを実行します
-func RunMigrations() error {
+// ErrPendingMigrations は未適用のマイグレーションがある場合のエラー
+var ErrPendingMigrations = errors.New("未適用のマイグレーションがあります。-migrate up を実行してください")
+
+// RunMigrations マイグレーションのサブコマンド（up / down / status / redo）を実行します
+func RunMigrations(cfg config.Database, command string) error {
 	// データベース接続
-	database, err := db.InitDB()
+	database, err := db.InitDB(cfg)
 	if err != nil {
 		return err
 	}
 	defer db.CloseDB()
 
-	// マイグレーションの実行
-	if err := database.AutoMigrate(&models.User{}); err != nil {
+	migrator, err := newMigrator(database)
+	if err != nil {
 		return err
 	}
-	if err := database.AutoMigrate(&models.Project{}); err != nil {
-		return err
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Printf("適用しました: %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("適用するマイグレーションはありません")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			log.Println("取り消すマイグレーションはありません")
+			return nil
+		}
+		log.Printf("取り消しました: %04d_%s", m.Version, m.Name)
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			log.Println("再適用するマイグレーションはありません")
+			return nil
+		}
+		log.Printf("再適用しました: %04d_%s", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q (up, down, status, redo)", command)
 	}
-	if err := database.AutoMigrate(&models.Support{}); err != nil {
+
+	return nil
+}
+
+// EnsureUpToDate はすべてのマイグレーションが適用済みかを確認します
+// 未適用のものがある場合はErrPendingMigrationsを返し、サーバーを起動させません
+func EnsureUpToDate(ctx context.Context, database *gorm.DB) error {
+	migrator, err := newMigrator(database)
+	if err != nil {
 		return err
 	}
 
-	log.Println("マイグレーションが正常に完了しました")
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w（最初の未適用: %04d_%s）", ErrPendingMigrations, pending[0].Version, pending[0].Name)
+	}
 	return nil
 }
+
+// SchemaVersion はスキーマのバージョン（readinessの確認用）
+type SchemaVersion struct {
+	// Current は適用済みのうち最新のバージョン（未適用の場合は0）
+	Current int
+	// Latest はこのビルドに含まれる最新のバージョン
+	Latest int
+	// Pending は未適用のマイグレーションの数
+	Pending int
+}
+
+// CheckVersion は適用済みのバージョンとこのビルドの最新のバージョンを返します
+func CheckVersion(ctx context.Context, database *gorm.DB) (SchemaVersion, error) {
+	migrator, err := newMigrator(database)
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+
+	var v SchemaVersion
+	for _, s := range statuses {
+		v.Latest = s.Version
+		if s.AppliedAt == nil {
+			v.Pending++
+		} else {
+			v.Current = s.Version
+		}
+	}
+	return v, nil
+}
+
+// newMigrator はGORMの接続からMigratorを作成します
+func newMigrator(database *gorm.DB) (*Migrator, error) {
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+	return New(sqlDB)
+}
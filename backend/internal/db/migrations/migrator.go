@@ -0,0 +1,297 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// 複数レプリカが同時にマイグレーションしないためのアドバイザリロックのキー
+const advisoryLockKey = 7_428_130_501
+
+// Migration は番号付きのSQLマイグレーション
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status はマイグレーションの適用状況
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator はembedしたSQLファイルでスキーマを管理します
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New は新しいMigratorインスタンスを作成します
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load は {番号}_{名前}.up.sql / .down.sql の組を番号順に読み込みます
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", name)
+		}
+		versionStr, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", name)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join("sql", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		} else if m.Name != title {
+			return nil, fmt.Errorf("duplicate migration version: %d", version)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up は未適用のマイグレーションを順に適用し、適用したマイグレーションを返します
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, mig.Up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())",
+				mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down は最後に適用したマイグレーションを1つ取り消し、取り消したマイグレーションを返します
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		reverted, err = m.down(ctx, conn)
+		return err
+	})
+	return reverted, err
+}
+
+// Redo は最後に適用したマイグレーションを取り消して再適用します
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		mig, err := m.down(ctx, conn)
+		if err != nil || mig == nil {
+			return err
+		}
+		if err := apply(ctx, conn, mig.Up,
+			"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())",
+			mig.Version, mig.Name); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		redone = mig
+		return nil
+	})
+	return redone, err
+}
+
+// down はロックを取得済みの接続で最後のマイグレーションを取り消します
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn) (*Migration, error) {
+	var version int
+	err := conn.QueryRowContext(ctx, "SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range m.migrations {
+		mig := m.migrations[i]
+		if mig.Version != version {
+			continue
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no down file", mig.Version, mig.Name)
+		}
+		if err := apply(ctx, conn, mig.Down, "DELETE FROM schema_migrations WHERE version = $1", mig.Version); err != nil {
+			return nil, fmt.Errorf("rollback %04d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+		return &mig, nil
+	}
+	return nil, fmt.Errorf("applied migration %04d is not found in this build", version)
+}
+
+// Status は全マイグレーションの適用状況を返します
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if appliedAt, ok := done[mig.Version]; ok {
+			s.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Pending は未適用のマイグレーションを返します
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// withLock はアドバイザリロックを取得した接続で処理を実行します
+// 他のレプリカがマイグレーション中の場合は完了まで待機します
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureTable はschema_migrationsテーブルを作成します
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	return err
+}
+
+// appliedVersions は適用済みのバージョンと適用日時を返します
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	done := make(map[int]time.Time)
+
+	// 一度もマイグレーションしていないデータベース
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return done, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
+
+// apply はSQLとschema_migrationsの更新を1つのトランザクションで実行します
+func apply(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
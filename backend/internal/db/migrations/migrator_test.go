@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadOrdersMigrationsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0010_add_index.up.sql":      {Data: []byte("CREATE INDEX")},
+		"sql/0002_create_users.up.sql":   {Data: []byte("CREATE TABLE users")},
+		"sql/0002_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"sql/0001_init.up.sql":           {Data: []byte("SELECT 1")},
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", Up: "SELECT 1"},
+		{Version: 2, Name: "create_users", Up: "CREATE TABLE users", Down: "DROP TABLE users"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("マイグレーションの数: got %d, want %d", len(migrations), len(want))
+	}
+	for i, m := range migrations {
+		if m != want[i] {
+			t.Errorf("%d番目のマイグレーション: got %+v, want %+v", i, m, want[i])
+		}
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		err   string
+	}{
+		{
+			name:  "方向のないファイル名",
+			files: fstest.MapFS{"sql/0001_init.sql": {}},
+			err:   "invalid migration file name",
+		},
+		{
+			name:  "不明な方向",
+			files: fstest.MapFS{"sql/0001_init.sideways.sql": {}},
+			err:   "invalid migration file name",
+		},
+		{
+			name:  "数値でないバージョン",
+			files: fstest.MapFS{"sql/first_init.up.sql": {Data: []byte("SELECT 1")}},
+			err:   "invalid migration version",
+		},
+		{
+			name: "名前の異なる同じバージョン",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql":  {Data: []byte("SELECT 1")},
+				"sql/0001_other.up.sql": {Data: []byte("SELECT 2")},
+			},
+			err: "duplicate migration version: 1",
+		},
+		{
+			name:  "upのないマイグレーション",
+			files: fstest.MapFS{"sql/0003_orphan.down.sql": {Data: []byte("SELECT 1")}},
+			err:   "migration 0003_orphan has no up file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsAreSequential(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("マイグレーションがありません")
+	}
+	// 番号は1から欠番なく続き、すべて取り消し用のファイルを持つ
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("%04d_%s: 番号が連続していません（want %04d）", m.Version, m.Name, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("%04d_%s: downのファイルがありません", m.Version, m.Name)
+		}
+	}
+}
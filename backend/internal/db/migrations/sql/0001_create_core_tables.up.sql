@@ -0,0 +1,45 @@
+-- ユーザー・プロジェクト・支援
+-- AutoMigrateで作成済みのデータベースにも適用できるようにIF NOT EXISTSを付ける
+CREATE TABLE IF NOT EXISTS users (
+    id                bigserial PRIMARY KEY,
+    email             varchar(255) NOT NULL UNIQUE,
+    password          varchar(255) NOT NULL,
+    name              varchar(255) NOT NULL,
+    bio               text,
+    profile_image_url varchar(255),
+    created_at        timestamptz,
+    updated_at        timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id              bigserial PRIMARY KEY,
+    title           varchar(255) NOT NULL,
+    description     text,
+    target_amount   bigint NOT NULL,
+    current_amount  bigint DEFAULT 0,
+    deadline        timestamptz NOT NULL,
+    user_id         bigint NOT NULL,
+    status          varchar(20) DEFAULT 'draft',
+    thumbnail_url   varchar(255),
+    office_approved boolean DEFAULT true,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz,
+    CONSTRAINT fk_projects_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects (deleted_at);
+
+CREATE TABLE IF NOT EXISTS supports (
+    id                  bigserial PRIMARY KEY,
+    user_id             bigint,
+    project_id          bigint,
+    amount              bigint,
+    message             text,
+    status              text,
+    payment_intent_id   varchar(255),
+    checkout_session_id varchar(255),
+    created_at          timestamptz,
+    updated_at          timestamptz,
+    CONSTRAINT fk_supports_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_projects_supports FOREIGN KEY (project_id) REFERENCES projects (id)
+);
@@ -0,0 +1,32 @@
+-- ログイン失敗の追跡・アカウントロック・監査ログ
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS failed_login_count   bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_failed_login_at timestamptz,
+    ADD COLUMN IF NOT EXISTS locked_until         timestamptz,
+    ADD COLUMN IF NOT EXISTS unlock_token_hash    varchar(64);
+CREATE INDEX IF NOT EXISTS idx_users_unlock_token_hash ON users (unlock_token_hash);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id         bigserial PRIMARY KEY,
+    email      varchar(255),
+    ip         varchar(64),
+    success    boolean,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (email);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_created_at ON login_attempts (ip, created_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          bigserial PRIMARY KEY,
+    actor_id    bigint,
+    action      varchar(100) NOT NULL,
+    target_type varchar(50),
+    target_id   bigint,
+    ip          varchar(64),
+    user_agent  text,
+    metadata    jsonb,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at);
@@ -0,0 +1,7 @@
+-- レート制限のトークンバケット（RATE_LIMIT_STORE=postgres の場合に使用）
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        varchar(255) PRIMARY KEY,
+    tokens     decimal NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
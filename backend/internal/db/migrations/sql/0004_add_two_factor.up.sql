@@ -0,0 +1,15 @@
+-- ロールと二要素認証（TOTP）
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role                varchar(20) NOT NULL DEFAULT 'user',
+    ADD COLUMN IF NOT EXISTS totp_secret         varchar(64),
+    ADD COLUMN IF NOT EXISTS totp_enabled        boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS totp_last_used_step bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
@@ -0,0 +1,25 @@
+-- ソーシャルログインの連携と認可リクエストのstate
+CREATE TABLE IF NOT EXISTS user_identities (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    provider   varchar(50) NOT NULL,
+    subject    varchar(255) NOT NULL,
+    email      varchar(255),
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities (provider, subject);
+
+CREATE TABLE IF NOT EXISTS oauth_states (
+    id            bigserial PRIMARY KEY,
+    state         varchar(64) NOT NULL,
+    provider      varchar(50) NOT NULL,
+    nonce         varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    link_user_id  bigint,
+    expires_at    timestamptz NOT NULL,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_states_state ON oauth_states (state);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires_at ON oauth_states (expires_at);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS deletion_requested_at;
+
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS jobs;
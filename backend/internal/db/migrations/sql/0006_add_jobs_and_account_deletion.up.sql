@@ -0,0 +1,33 @@
+-- バックグラウンドジョブ・個人データのエクスポート・退会
+CREATE TABLE IF NOT EXISTS jobs (
+    id           bigserial PRIMARY KEY,
+    type         varchar(100) NOT NULL,
+    payload      jsonb NOT NULL,
+    status       varchar(20) NOT NULL DEFAULT 'pending',
+    attempts     bigint NOT NULL DEFAULT 0,
+    max_attempts bigint NOT NULL DEFAULT 5,
+    run_at       timestamptz NOT NULL,
+    locked_at    timestamptz,
+    last_error   text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs (status, run_at);
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    status     varchar(20) NOT NULL DEFAULT 'pending',
+    archive    bytea,
+    size       bigint,
+    ready_at   timestamptz,
+    expires_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deletion_requested_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at            timestamptz;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS project_tags;
+DROP TABLE IF EXISTS tags;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS vision_id;
+DROP TABLE IF EXISTS visions;
@@ -0,0 +1,30 @@
+-- 街頭ビジョンとプロジェクトのタグ
+CREATE TABLE visions (
+    id          bigserial PRIMARY KEY,
+    name        varchar(255) NOT NULL,
+    location    varchar(255),
+    size        varchar(255),
+    period      varchar(255),
+    description text,
+    image_url   varchar(255),
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+
+ALTER TABLE projects
+    ADD COLUMN vision_id bigint,
+    ADD CONSTRAINT fk_projects_vision FOREIGN KEY (vision_id) REFERENCES visions (id);
+CREATE INDEX idx_projects_vision_id ON projects (vision_id);
+
+CREATE TABLE tags (
+    id         bigserial PRIMARY KEY,
+    name       varchar(50) NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX idx_tags_name ON tags (name);
+
+CREATE TABLE project_tags (
+    project_id bigint NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    tag_id     bigint NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (project_id, tag_id)
+);
@@ -0,0 +1,2 @@
+-- ユーザーの表示言語（空の場合はAccept-Languageに従う）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale varchar(8) NOT NULL DEFAULT '';
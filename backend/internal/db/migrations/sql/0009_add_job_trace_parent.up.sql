@@ -0,0 +1,2 @@
+-- ジョブを登録したリクエストのトレース（W3C traceparent）。ジョブの実行をそのトレースの子として記録する
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS trace_parent varchar(55) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+DROP INDEX IF EXISTS idx_audit_events_target;
+ALTER TABLE audit_events
+    DROP COLUMN IF EXISTS changes,
+    DROP COLUMN IF EXISTS request_id;
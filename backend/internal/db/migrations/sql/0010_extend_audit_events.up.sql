@@ -0,0 +1,24 @@
+-- 監査ログの拡張（リクエストID・変更前後の差分・対象ごとの検索・追記のみの強制）
+ALTER TABLE audit_events
+    ADD COLUMN IF NOT EXISTS request_id varchar(128) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS changes    jsonb NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_type, target_id, created_at);
+
+-- 監査ログは追記のみ。更新・削除は保持期間による削除と退会時の消去（oshiome.audit_maintenance を
+-- トランザクション内で on にした場合）に限る
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('oshiome.audit_maintenance', true) IS DISTINCT FROM 'on' THEN
+        RAISE EXCEPTION 'audit_events is append-only (% is not allowed)', TG_OP;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS settlements;
+DROP TABLE IF EXISTS payout_accounts;
+
+ALTER TABLE supports DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE projects DROP COLUMN IF EXISTS type;
@@ -0,0 +1,45 @@
+-- 主催者への送金（企画の種別・返金額・送金先・精算）
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS type varchar(30) NOT NULL DEFAULT 'other';
+ALTER TABLE supports
+    ADD COLUMN IF NOT EXISTS refunded_amount bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS payout_accounts (
+    id                bigserial PRIMARY KEY,
+    user_id           bigint NOT NULL,
+    stripe_account_id varchar(255) NOT NULL,
+    details_submitted boolean NOT NULL DEFAULT false,
+    payouts_enabled   boolean NOT NULL DEFAULT false,
+    created_at        timestamptz,
+    updated_at        timestamptz,
+    CONSTRAINT fk_payout_accounts_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_accounts_user_id ON payout_accounts (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payout_accounts_stripe_account_id ON payout_accounts (stripe_account_id);
+
+CREATE TABLE IF NOT EXISTS settlements (
+    id                    bigserial PRIMARY KEY,
+    project_id            bigint NOT NULL,
+    user_id               bigint NOT NULL,
+    project_type          varchar(30) NOT NULL,
+    gross_amount          bigint NOT NULL,
+    refunded_amount       bigint NOT NULL,
+    fee_amount            bigint NOT NULL,
+    net_amount            bigint NOT NULL,
+    support_count         bigint NOT NULL,
+    fee_rate_bps          bigint NOT NULL,
+    fee_fixed_per_support bigint NOT NULL,
+    status                varchar(20) NOT NULL DEFAULT 'pending',
+    stripe_transfer_id    varchar(255),
+    stripe_payout_id      varchar(255),
+    failure_reason        text,
+    paid_at               timestamptz,
+    created_at            timestamptz,
+    updated_at            timestamptz,
+    CONSTRAINT fk_settlements_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_settlements_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settlements_project_id ON settlements (project_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_user_id ON settlements (user_id);
+CREATE INDEX IF NOT EXISTS idx_settlements_status ON settlements (status);
+CREATE INDEX IF NOT EXISTS idx_settlements_stripe_payout_id ON settlements (stripe_payout_id);
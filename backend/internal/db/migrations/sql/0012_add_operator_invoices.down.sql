@@ -0,0 +1,14 @@
+DELETE FROM settlements WHERE recipient <> 'organizer';
+DROP INDEX IF EXISTS idx_settlements_project_recipient;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settlements_project_id ON settlements (project_id);
+ALTER TABLE settlements
+    DROP COLUMN IF EXISTS requested_amount,
+    DROP COLUMN IF EXISTS invoice_id,
+    DROP COLUMN IF EXISTS recipient;
+
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS invoices;
+
+DROP INDEX IF EXISTS idx_visions_operator_id;
+ALTER TABLE visions DROP COLUMN IF EXISTS operator_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS settlement_mode;
@@ -0,0 +1,57 @@
+-- ビジョンの運営会社への直接支払い（精算方法・運営会社・請求書・経費の立替）
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS settlement_mode varchar(20) NOT NULL DEFAULT 'organizer';
+ALTER TABLE visions
+    ADD COLUMN IF NOT EXISTS operator_id bigint REFERENCES users (id);
+CREATE INDEX IF NOT EXISTS idx_visions_operator_id ON visions (operator_id);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id               bigserial PRIMARY KEY,
+    project_id       bigint NOT NULL,
+    vision_id        bigint NOT NULL,
+    operator_id      bigint NOT NULL,
+    number           varchar(100) NOT NULL,
+    amount           bigint NOT NULL,
+    description      text,
+    status           varchar(20) NOT NULL DEFAULT 'submitted',
+    rejection_reason text,
+    reviewed_by      bigint,
+    reviewed_at      timestamptz,
+    paid_at          timestamptz,
+    created_at       timestamptz,
+    updated_at       timestamptz,
+    CONSTRAINT fk_invoices_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_invoices_vision FOREIGN KEY (vision_id) REFERENCES visions (id),
+    CONSTRAINT fk_invoices_operator FOREIGN KEY (operator_id) REFERENCES users (id),
+    CONSTRAINT fk_invoices_reviewed_by FOREIGN KEY (reviewed_by) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_project_id ON invoices (project_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_operator_id ON invoices (operator_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_status ON invoices (status);
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id               bigserial PRIMARY KEY,
+    project_id       bigint NOT NULL,
+    user_id          bigint NOT NULL,
+    amount           bigint NOT NULL,
+    description      text NOT NULL,
+    status           varchar(20) NOT NULL DEFAULT 'submitted',
+    rejection_reason text,
+    reviewed_by      bigint,
+    reviewed_at      timestamptz,
+    created_at       timestamptz,
+    updated_at       timestamptz,
+    CONSTRAINT fk_expenses_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_expenses_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_expenses_reviewed_by FOREIGN KEY (reviewed_by) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_expenses_project_id ON expenses (project_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_status ON expenses (status);
+
+-- 精算はプロジェクトごと・受取人ごとに作成する
+ALTER TABLE settlements
+    ADD COLUMN IF NOT EXISTS recipient varchar(20) NOT NULL DEFAULT 'organizer',
+    ADD COLUMN IF NOT EXISTS invoice_id bigint REFERENCES invoices (id),
+    ADD COLUMN IF NOT EXISTS requested_amount bigint NOT NULL DEFAULT 0;
+DROP INDEX IF EXISTS idx_settlements_project_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settlements_project_recipient ON settlements (project_id, recipient);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS journal_lines;
+DROP TABLE IF EXISTS journal_entries;
+DROP FUNCTION IF EXISTS journal_lines_balanced();
+DROP FUNCTION IF EXISTS journal_append_only();
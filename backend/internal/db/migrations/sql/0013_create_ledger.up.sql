@@ -0,0 +1,147 @@
+-- 複式簿記の帳簿（仕訳と仕訳明細）
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id            bigserial PRIMARY KEY,
+    reference     varchar(255) NOT NULL,
+    kind          varchar(30) NOT NULL,
+    project_id    bigint,
+    support_id    bigint,
+    settlement_id bigint,
+    created_at    timestamptz,
+    CONSTRAINT fk_journal_entries_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_journal_entries_support FOREIGN KEY (support_id) REFERENCES supports (id),
+    CONSTRAINT fk_journal_entries_settlement FOREIGN KEY (settlement_id) REFERENCES settlements (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_reference ON journal_entries (reference);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_project_id ON journal_entries (project_id);
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    id         bigserial PRIMARY KEY,
+    entry_id   bigint NOT NULL,
+    account    varchar(30) NOT NULL,
+    project_id bigint,
+    debit      bigint NOT NULL DEFAULT 0,
+    credit     bigint NOT NULL DEFAULT 0,
+    CONSTRAINT fk_journal_lines_entry FOREIGN KEY (entry_id) REFERENCES journal_entries (id),
+    -- 借方・貸方のどちらか一方にのみ金額を持つ
+    CONSTRAINT chk_journal_lines_amount CHECK (debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0))
+);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_entry_id ON journal_lines (entry_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_project_id ON journal_lines (project_id);
+
+-- 帳簿は追記のみ
+CREATE OR REPLACE FUNCTION journal_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only (% is not allowed)', TG_TABLE_NAME, TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS journal_entries_append_only ON journal_entries;
+CREATE TRIGGER journal_entries_append_only
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION journal_append_only();
+DROP TRIGGER IF EXISTS journal_lines_append_only ON journal_lines;
+CREATE TRIGGER journal_lines_append_only
+    BEFORE UPDATE OR DELETE ON journal_lines
+    FOR EACH ROW EXECUTE FUNCTION journal_append_only();
+
+-- 仕訳の借方と貸方の合計はコミット時に一致していなければならない
+CREATE OR REPLACE FUNCTION journal_lines_balanced() RETURNS trigger AS $$
+DECLARE
+    difference bigint;
+BEGIN
+    SELECT SUM(debit) - SUM(credit) INTO difference FROM journal_lines WHERE entry_id = NEW.entry_id;
+    IF difference <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced (debit - credit = %)', NEW.entry_id, difference;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS journal_lines_balanced ON journal_lines;
+CREATE CONSTRAINT TRIGGER journal_lines_balanced
+    AFTER INSERT ON journal_lines
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION journal_lines_balanced();
+
+-- 既存の支援・返金・精算の仕訳（Stripeの決済手数料は記録されていないため含めない）
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, support_id, created_at)
+    SELECT 'support:' || id || ':completed', 'support_completed', project_id, id, created_at
+    FROM supports
+    WHERE status IN ('completed', 'refunded') AND amount > 0
+    RETURNING id, project_id, support_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN supports s ON s.id = e.support_id
+CROSS JOIN LATERAL (VALUES
+    ('platform_cash', s.amount, 0::bigint),
+    ('project_escrow', 0::bigint, s.amount)
+) AS l (account, debit, credit);
+
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, support_id, created_at)
+    SELECT 'support:' || id || ':refund:' || LEAST(refunded_amount, amount), 'refund', project_id, id, updated_at
+    FROM supports
+    WHERE status IN ('completed', 'refunded') AND refunded_amount > 0
+    RETURNING id, project_id, support_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN supports s ON s.id = e.support_id
+CROSS JOIN LATERAL (VALUES
+    ('refunds', LEAST(s.refunded_amount, s.amount), 0::bigint),
+    ('platform_cash', 0::bigint, LEAST(s.refunded_amount, s.amount))
+) AS l (account, debit, credit);
+
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, settlement_id, created_at)
+    SELECT 'settlement:' || id || ':net:' || net_amount, 'settlement', project_id, id, created_at
+    FROM settlements
+    WHERE net_amount > 0
+    RETURNING id, project_id, settlement_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN settlements st ON st.id = e.settlement_id
+CROSS JOIN LATERAL (VALUES
+    ('project_escrow', st.net_amount, 0::bigint),
+    (CASE st.recipient WHEN 'operator' THEN 'operator_payable' ELSE 'organizer_payable' END, 0::bigint, st.net_amount)
+) AS l (account, debit, credit);
+
+-- 手数料はプロジェクトごとに1回（受取人ごとの精算は同じ手数料を持つ）
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, settlement_id, created_at)
+    SELECT DISTINCT ON (project_id) 'project:' || project_id || ':fee:' || fee_amount, 'settlement', project_id, id, created_at
+    FROM settlements
+    WHERE fee_amount > 0
+    ORDER BY project_id, id
+    RETURNING id, project_id, settlement_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN settlements st ON st.id = e.settlement_id
+CROSS JOIN LATERAL (VALUES
+    ('project_escrow', st.fee_amount, 0::bigint),
+    ('platform_revenue', 0::bigint, st.fee_amount)
+) AS l (account, debit, credit);
+
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, settlement_id, created_at)
+    SELECT 'settlement:' || id || ':transfer', 'transfer', project_id, id, updated_at
+    FROM settlements
+    WHERE net_amount > 0 AND COALESCE(stripe_transfer_id, '') <> ''
+    RETURNING id, project_id, settlement_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN settlements st ON st.id = e.settlement_id
+CROSS JOIN LATERAL (VALUES
+    (CASE st.recipient WHEN 'operator' THEN 'operator_payable' ELSE 'organizer_payable' END, st.net_amount, 0::bigint),
+    ('platform_cash', 0::bigint, st.net_amount)
+) AS l (account, debit, credit);
@@ -0,0 +1,13 @@
+-- Stripeの決済・残高取引と支援の照合の結果
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id                   bigserial PRIMARY KEY,
+    window_from          timestamptz NOT NULL,
+    window_to            timestamptz NOT NULL,
+    payments             integer NOT NULL DEFAULT 0,
+    balance_transactions integer NOT NULL DEFAULT 0,
+    repairs              jsonb NOT NULL DEFAULT '[]',
+    discrepancies        jsonb NOT NULL DEFAULT '[]',
+    created_at           timestamptz
+);
+-- 複数のレプリカが同じ期間を照合しても結果は1件だけ保存する
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_reports_window ON reconciliation_reports (window_from, window_to);
@@ -0,0 +1,31 @@
+-- 支援の領収書（適格請求書）と無効化・再発行の履歴
+CREATE TABLE IF NOT EXISTS receipts (
+    id                  bigserial PRIMARY KEY,
+    number              bigint NOT NULL,
+    support_id          bigint NOT NULL,
+    project_id          bigint NOT NULL,
+    user_id             bigint NOT NULL,
+    addressee           varchar(100) NOT NULL DEFAULT '',
+    project_title       varchar(255) NOT NULL,
+    amount              bigint NOT NULL,
+    tax_rate            integer NOT NULL,
+    tax_amount          bigint NOT NULL,
+    paid_at             timestamptz NOT NULL,
+    issuer_name         varchar(255) NOT NULL,
+    issuer_address      varchar(255) NOT NULL DEFAULT '',
+    registration_number varchar(14) NOT NULL DEFAULT '',
+    status              varchar(20) NOT NULL DEFAULT 'issued',
+    reissue_of_id       bigint,
+    void_reason         text,
+    voided_at           timestamptz,
+    created_at          timestamptz,
+    CONSTRAINT fk_receipts_support FOREIGN KEY (support_id) REFERENCES supports (id),
+    CONSTRAINT fk_receipts_project FOREIGN KEY (project_id) REFERENCES projects (id),
+    CONSTRAINT fk_receipts_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_receipts_reissue_of FOREIGN KEY (reissue_of_id) REFERENCES receipts (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_receipts_number ON receipts (number);
+CREATE INDEX IF NOT EXISTS idx_receipts_support_id ON receipts (support_id);
+CREATE INDEX IF NOT EXISTS idx_receipts_project_id ON receipts (project_id);
+-- 支援ごとに有効な領収書は1枚だけ
+CREATE UNIQUE INDEX IF NOT EXISTS idx_receipts_support_issued ON receipts (support_id) WHERE status = 'issued';
@@ -0,0 +1,4 @@
+-- 認可リクエストを開始したブラウザのCookieのハッシュ（ログインCSRF・連携の乗っ取りを防ぐ）
+-- 既存のstateはブラウザに紐づいていないため破棄する
+DELETE FROM oauth_states;
+ALTER TABLE oauth_states ADD COLUMN IF NOT EXISTS browser_hash varchar(64) NOT NULL DEFAULT '';
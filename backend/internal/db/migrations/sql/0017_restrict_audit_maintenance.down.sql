@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+DROP FUNCTION IF EXISTS erase_audit_event_changes(text, bigint);
+DROP FUNCTION IF EXISTS prune_audit_events(timestamptz);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('oshiome.audit_maintenance', true) IS DISTINCT FROM 'on' THEN
+        RAISE EXCEPTION 'audit_events is append-only (% is not allowed)', TG_OP;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,52 @@
+-- 監査ログの保守（保持期間による削除・退会時の消去）を専用の関数 prune_audit_events / erase_audit_event_changes に限定する
+-- セッションで設定できる oshiome.audit_maintenance による回避は廃止し、TRUNCATE も拒否する
+-- スーパーユーザーやロールの作成は不要で、テーブルの所有者（アプリケーションのユーザー）で実行できる
+
+-- 更新・削除は保守用の関数から実行された場合のみ許可する（呼び出し元は PG_CONTEXT で確認する）
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+DECLARE
+    stack text;
+BEGIN
+    GET DIAGNOSTICS stack = PG_CONTEXT;
+    IF TG_OP = 'TRUNCATE'
+        OR stack !~ '\nPL/pgSQL function ([[:alnum:]_"]+\.)?(prune_audit_events|erase_audit_event_changes)\(' THEN
+        RAISE EXCEPTION 'audit_events is append-only (% is not allowed)', TG_OP;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+-- 保持期間を過ぎた監査ログを削除し、件数を返す
+CREATE OR REPLACE FUNCTION prune_audit_events(cutoff timestamptz) RETURNS bigint AS $$
+DECLARE
+    deleted bigint;
+BEGIN
+    DELETE FROM audit_events WHERE created_at < cutoff;
+    GET DIAGNOSTICS deleted = ROW_COUNT;
+    RETURN deleted;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 対象の監査ログから変更前後の値を消去し、件数を返す（退会時の個人情報の消去）
+CREATE OR REPLACE FUNCTION erase_audit_event_changes(p_target_type text, p_target_id bigint) RETURNS bigint AS $$
+DECLARE
+    erased bigint;
+BEGIN
+    UPDATE audit_events SET changes = '{}'::jsonb
+    WHERE target_type = p_target_type AND target_id = p_target_id AND changes <> '{}'::jsonb;
+    GET DIAGNOSTICS erased = ROW_COUNT;
+    RETURN erased;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 所有者（このマイグレーションを実行したユーザー）以外は保守用の関数を実行できない
+REVOKE EXECUTE ON FUNCTION prune_audit_events(timestamptz) FROM PUBLIC;
+REVOKE EXECUTE ON FUNCTION erase_audit_event_changes(text, bigint) FROM PUBLIC;
@@ -0,0 +1,3 @@
+-- 帳簿は追記のみのため、振替の仕訳は取り消さない
+-- 振替後の refunds / disputes の残高は0のため、以前のバージョンの預り金の計算（project_escrow - refunds - disputes）とも一致する
+SELECT 1;
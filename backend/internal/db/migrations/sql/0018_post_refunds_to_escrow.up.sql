@@ -0,0 +1,25 @@
+-- 返金とチャージバックは預り金（project_escrow）から直接差し引くようになったため、
+-- それ以前に refunds / disputes へ計上した残高をプロジェクトごとに預り金へ振り替える
+-- 帳簿は追記のみのため、既存の仕訳は変更せずに振替の仕訳を追加する
+-- 参照には振り替えた最後の明細のIDを含める（ダウングレード後に再度適用しても参照が重複しない）
+WITH balances AS (
+    SELECT project_id, account, SUM(debit) - SUM(credit) AS balance, MAX(id) AS last_line_id
+    FROM journal_lines
+    WHERE account IN ('refunds', 'disputes') AND project_id IS NOT NULL
+    GROUP BY project_id, account
+    HAVING SUM(debit) <> SUM(credit)
+), entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, created_at)
+    SELECT 'project:' || project_id || ':' || account || ':cleared:' || last_line_id,
+           CASE account WHEN 'refunds' THEN 'refund' ELSE 'dispute_withdrawn' END, project_id, now()
+    FROM balances
+    RETURNING id, project_id, reference
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN balances b ON e.reference = 'project:' || b.project_id || ':' || b.account || ':cleared:' || b.last_line_id
+CROSS JOIN LATERAL (VALUES
+    ('project_escrow', GREATEST(b.balance, 0), GREATEST(-b.balance, 0)),
+    (b.account, GREATEST(-b.balance, 0), GREATEST(b.balance, 0))
+) AS l (account, debit, credit);
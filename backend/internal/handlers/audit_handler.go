@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// AuditHandler は監査ログの参照（管理者向け）を担当するハンドラー
+type AuditHandler struct {
+	audit *service.AuditService
+}
+
+// NewAuditHandler はAuditHandlerの新しいインスタンスを作成します
+func NewAuditHandler(audit *service.AuditService) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+// AuditEventQuery は監査ログの絞り込み条件（日時はRFC 3339）
+type AuditEventQuery struct {
+	ActorID    uint      `form:"actor_id"`
+	Action     string    `form:"action" binding:"max=100"`
+	TargetType string    `form:"target_type" binding:"omitempty,oneof=user project support settlement invoice expense"`
+	TargetID   uint      `form:"target_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	// BeforeID は前のページの next_before_id
+	BeforeID uint `form:"before_id"`
+	Limit    int  `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// ListAuditEvents 監査ログを新しい順に取得（管理者のみ）
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var q AuditEventQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	page, err := h.audit.List(c.Request.Context(), userID.(uint), repository.AuditEventFilter{
+		ActorID:    q.ActorID,
+		Action:     q.Action,
+		TargetType: q.TargetType,
+		TargetID:   q.TargetID,
+		From:       q.From,
+		To:         q.To,
+		BeforeID:   q.BeforeID,
+		Limit:      q.Limit,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, page)
+}
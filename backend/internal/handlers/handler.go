@@ -1,17 +1,17 @@
 package handlers
 
 import (
-	"gorm.io/gorm"
-)
+	"strconv"
 
-// Handler はすべてのハンドラーで共有される依存関係を保持します
-type Handler struct {
-	DB *gorm.DB
-}
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
 
-// NewHandler は新しいHandlerインスタンスを作成します
-func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{
-		DB: db,
+// paramID はパスパラメータのIDを数値に変換します
+func paramID(c *gin.Context, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		return 0, utils.ErrInvalidInput.WithDetail("無効なIDです")
 	}
+	return uint(id), nil
 }
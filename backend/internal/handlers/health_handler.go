@@ -1,17 +1,34 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// readinessTimeout は依存先ごとの確認の制限時間
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck は準備完了の判定に使う依存先の確認
+// Check はレスポンスに含める情報（バージョンなど）を返し、利用できない場合はエラーを返します
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) (map[string]interface{}, error)
+}
+
 // HealthHandler はヘルスチェックを担当するハンドラー
-type HealthHandler struct{}
+type HealthHandler struct {
+	checks   []ReadinessCheck
+	draining func() bool
+}
 
 // NewHealthHandler はHealthHandlerの新しいインスタンスを作成します
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+// draining が true を返す間（停止処理中）は準備完了としません
+func NewHealthHandler(draining func() bool, checks ...ReadinessCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, draining: draining}
 }
 
 // HealthCheck はシステムのヘルスステータスを返します
@@ -21,3 +38,55 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 		"service": "oshiome-backend",
 	})
 }
+
+// Liveness はプロセスが応答できるかを返します（/healthz）
+// 依存先の障害で再起動させないため、データベースなどは確認しません
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness はリクエストを受け付けられるかを依存先ごとに返します（/readyz）
+// いずれかの依存先が利用できない場合や停止処理中は503を返します
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	results := make(map[string]gin.H, len(h.checks))
+	ready := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check ReadinessCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := check.Check(ctx)
+			result := gin.H{"status": "ok", "latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+			for k, v := range detail {
+				result[k] = v
+			}
+			if err != nil {
+				result["status"] = "error"
+				result["error"] = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			if err != nil {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	status, code := "ready", http.StatusOK
+	switch {
+	case h.draining != nil && h.draining():
+		status, code = "shutting_down", http.StatusServiceUnavailable
+	case !ready:
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{"status": status, "checks": results})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// InvoiceHandler はビジョンの運営会社への直接支払い（請求書・経費の立替）を担当するハンドラー
+type InvoiceHandler struct {
+	invoices *service.InvoiceService
+}
+
+// NewInvoiceHandler はInvoiceHandlerの新しいインスタンスを作成します
+func NewInvoiceHandler(invoices *service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoices: invoices}
+}
+
+type InvoiceInput struct {
+	ProjectID uint `json:"project_id" binding:"required"`
+	// Number は運営会社の請求書番号
+	Number      string `json:"number" binding:"required,max=100"`
+	Amount      int64  `json:"amount" binding:"required,min=1"`
+	Description string `json:"description" binding:"max=2000"`
+}
+
+type ExpenseInput struct {
+	Amount      int64  `json:"amount" binding:"required,min=1"`
+	Description string `json:"description" binding:"required,max=1000"`
+}
+
+type ReviewInput struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	// Reason は差し戻し・却下の理由（reject の場合は必須）
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// ReviewQuery は請求書・経費の一覧の絞り込み条件
+type ReviewQuery struct {
+	Status models.ReviewStatus `form:"status" binding:"omitempty,oneof=submitted approved rejected paid"`
+}
+
+func (in ReviewInput) toService() service.Review {
+	return service.Review{Approve: in.Decision == "approve", Reason: in.Reason}
+}
+
+// SubmitInvoice 掲出料の請求書を提出（ビジョンの運営会社のみ、差し戻し後は再提出）
+func (h *InvoiceHandler) SubmitInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var input InvoiceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	invoice, err := h.invoices.SubmitInvoice(c.Request.Context(), userID.(uint), service.InvoiceInput{
+		ProjectID:   input.ProjectID,
+		Number:      input.Number,
+		Amount:      input.Amount,
+		Description: input.Description,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, invoice)
+}
+
+// ListMyInvoices 自分（運営会社）が提出した請求書を取得
+func (h *InvoiceHandler) ListMyInvoices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	invoices, err := h.invoices.ListForOperator(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, invoices)
+}
+
+// SubmitExpense 立て替えた経費の精算を申請（主催者のみ）
+func (h *InvoiceHandler) SubmitExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	projectID, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input ExpenseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	expense, err := h.invoices.SubmitExpense(c.Request.Context(), userID.(uint), projectID, service.ExpenseInput{
+		Amount:      input.Amount,
+		Description: input.Description,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusCreated, expense)
+}
+
+// ListProjectExpenses プロジェクトの経費の申請を取得（主催者本人または管理者）
+func (h *InvoiceHandler) ListProjectExpenses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	projectID, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	expenses, err := h.invoices.ListProjectExpenses(c.Request.Context(), userID.(uint), projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, expenses)
+}
+
+// ListInvoices 請求書を取得（管理者のみ）
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var q ReviewQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	invoices, err := h.invoices.ListInvoices(c.Request.Context(), userID.(uint), q.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, invoices)
+}
+
+// ReviewInvoice 請求書を承認または差し戻し（管理者のみ）
+func (h *InvoiceHandler) ReviewInvoice(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input ReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	invoice, err := h.invoices.ReviewInvoice(c.Request.Context(), userID.(uint), id, input.toService())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, invoice)
+}
+
+// ListExpenses 経費の申請を取得（管理者のみ）
+func (h *InvoiceHandler) ListExpenses(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var q ReviewQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	expenses, err := h.invoices.ListExpenses(c.Request.Context(), userID.(uint), q.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, expenses)
+}
+
+// ReviewExpense 経費の申請を承認または却下（管理者のみ）
+func (h *InvoiceHandler) ReviewExpense(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input ReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	expense, err := h.invoices.ReviewExpense(c.Request.Context(), userID.(uint), id, input.toService())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, expense)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// LedgerHandler は帳簿（試算表・プロジェクト別の残高）とStripeとの照合の結果の参照を担当するハンドラー
+type LedgerHandler struct {
+	ledger         *service.LedgerService
+	reconciliation *service.ReconciliationService
+}
+
+// NewLedgerHandler はLedgerHandlerの新しいインスタンスを作成します
+func NewLedgerHandler(ledger *service.LedgerService, reconciliation *service.ReconciliationService) *LedgerHandler {
+	return &LedgerHandler{ledger: ledger, reconciliation: reconciliation}
+}
+
+// GetTrialBalance 勘定科目ごとの試算表を取得（管理者のみ）
+func (h *LedgerHandler) GetTrialBalance(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	tb, err := h.ledger.TrialBalance(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, tb)
+}
+
+// GetProjectLedger プロジェクト別の残高と仕訳を取得（管理者のみ）
+func (h *LedgerHandler) GetProjectLedger(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	projectID, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ledger, err := h.ledger.ProjectLedger(c.Request.Context(), userID.(uint), projectID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, ledger)
+}
+
+// ListReconciliationReports Stripeとの照合の結果を新しい順に取得（管理者のみ）
+func (h *LedgerHandler) ListReconciliationReports(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	reports, err := h.reconciliation.ListReports(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, reports)
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth/oidc"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// 認可リクエストのstateの有効期限
+const oauthStateTTL = 10 * time.Minute
+
+// oauthBrowserCookie は認可リクエストを開始したブラウザを確認するCookie
+// コールバックはこのCookieを持つブラウザからのみ受け付け、他人のコールバックURLを踏ませるログインCSRFや、
+// 他人が発行した連携用の認可URLによる外部アカウントの乗っ取りを防ぐ
+const oauthBrowserCookie = "oshiome_oauth"
+
+// OAuthHandler は外部プロバイダー（Google、X等）によるソーシャルログインを担当するハンドラー
+type OAuthHandler struct {
+	db          *gorm.DB
+	registry    *oidc.Registry
+	tokens      *utils.TokenManager
+	hasher      *utils.PasswordHasher
+	frontendURL string
+	// secureCookie はCookieにSecure属性を付けるか（バックエンドがHTTPSの場合）
+	secureCookie bool
+}
+
+// NewOAuthHandler はOAuthHandlerの新しいインスタンスを作成します
+func NewOAuthHandler(db *gorm.DB, registry *oidc.Registry, tokens *utils.TokenManager, hasher *utils.PasswordHasher, frontendURL, backendURL string) *OAuthHandler {
+	return &OAuthHandler{
+		db:           db,
+		registry:     registry,
+		tokens:       tokens,
+		hasher:       hasher,
+		frontendURL:  frontendURL,
+		secureCookie: strings.HasPrefix(backendURL, "https://"),
+	}
+}
+
+// oauthLoginError はコールバックでフロントエンドに返すエラー
+type oauthLoginError struct {
+	code string
+	err  error
+}
+
+func (e *oauthLoginError) Error() string {
+	return fmt.Sprintf("%s: %v", e.code, e.err)
+}
+
+// ListProviders 利用可能なプロバイダーの一覧を取得
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, utils.Response{
+		Status: "success",
+		Data:   gin.H{"providers": h.registry.Names()},
+	})
+}
+
+// Start 認可コードフローを開始し、プロバイダーの認可画面へリダイレクト
+func (h *OAuthHandler) Start(c *gin.Context) {
+	authURL, err := h.authorizationURL(c, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link ログイン済みユーザーに外部アカウントを連携するための認可URLを発行
+// ブラウザの遷移ではAuthorizationヘッダーを送れないため、URLを返してフロントエンドから遷移させる
+// 認可URLはこのリクエストでCookieを受け取ったブラウザでのみ完了できる（credentials: 'include' で呼び出す）
+func (h *OAuthHandler) Link(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	uid := userID.(uint)
+
+	authURL, err := h.authorizationURL(c, &uid)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status: "success",
+		Data:   gin.H{"authorization_url": authURL},
+	})
+}
+
+// authorizationURL state・nonce・PKCEのcode_verifierを保存して認可URLを組み立て、ブラウザにCookieを設定する
+func (h *OAuthHandler) authorizationURL(c *gin.Context, linkUserID *uint) (string, error) {
+	provider, ok := h.registry.Get(c.Param("provider"))
+	if !ok {
+		return "", utils.ErrNotFound.WithDetail("指定されたログイン方法は利用できません")
+	}
+
+	state, err1 := oidc.RandomString()
+	nonce, err2 := oidc.RandomString()
+	verifier, err3 := oidc.RandomString()
+	browser, err4 := oidc.RandomString()
+	if err := errors.Join(err1, err2, err3, err4); err != nil {
+		return "", utils.ErrInternalServer.WithDetail("認可リクエストの生成に失敗しました")
+	}
+
+	// 期限切れのstateを掃除
+	h.db.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+
+	if err := h.db.Create(&models.OAuthState{
+		State:        state,
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		BrowserHash:  hashBrowserCookie(browser),
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}).Error; err != nil {
+		return "", utils.ErrInternalServer.WithDetail("認可リクエストの保存に失敗しました")
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("認可URLの作成に失敗しました", "provider", provider.Name(), "error", err)
+		return "", utils.ErrInternalServer.WithDetail("ログインプロバイダーに接続できませんでした")
+	}
+	h.setBrowserCookie(c, browser, int(oauthStateTTL.Seconds()))
+	return authURL, nil
+}
+
+// setBrowserCookie は認可リクエストを開始したブラウザのCookieを設定します（maxAgeが負の場合は削除）
+// プロバイダーからのリダイレクト（トップレベルの遷移）で送信されるよう SameSite=Lax とする
+func (h *OAuthHandler) setBrowserCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oauthBrowserCookie,
+		Value:    value,
+		Path:     "/api",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// hashBrowserCookie はCookieの値のハッシュ（stateに保存する値）を返します
+func hashBrowserCookie(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// Callback プロバイダーからのリダイレクトを受け取り、ログインまたは連携を完了してフロントエンドへ戻す
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	result, err := h.completeLogin(c)
+	if err != nil {
+		code := "oauth_failed"
+		var loginErr *oauthLoginError
+		if errors.As(err, &loginErr) {
+			code = loginErr.code
+		}
+		logging.FromContext(c.Request.Context()).Warn("ソーシャルログインに失敗しました", "provider", c.Param("provider"), "error", err)
+		h.redirectToFrontend(c, url.Values{"error": {code}})
+		return
+	}
+	h.redirectToFrontend(c, result)
+}
+
+// completeLogin stateを検証して認可コードを交換し、ユーザーを特定してトークンを発行する
+func (h *OAuthHandler) completeLogin(c *gin.Context) (url.Values, error) {
+	if e := c.Query("error"); e != "" {
+		return nil, &oauthLoginError{code: "access_denied", err: errors.New(e)}
+	}
+
+	provider, ok := h.registry.Get(c.Param("provider"))
+	if !ok {
+		return nil, &oauthLoginError{code: "unknown_provider", err: errors.New(c.Param("provider"))}
+	}
+
+	// stateは1回限り有効（削除できた場合のみ正当なコールバックとみなす）
+	var state models.OAuthState
+	if err := h.db.Where("state = ? AND provider = ?", c.Query("state"), provider.Name()).
+		First(&state).Error; err != nil {
+		return nil, &oauthLoginError{code: "invalid_state", err: err}
+	}
+	if res := h.db.Delete(&state); res.Error != nil || res.RowsAffected == 0 {
+		return nil, &oauthLoginError{code: "invalid_state", err: errors.New("state already used")}
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return nil, &oauthLoginError{code: "invalid_state", err: errors.New("state expired")}
+	}
+	// 認可リクエストを開始したブラウザからのコールバックのみ受け付ける
+	browser, err := c.Cookie(oauthBrowserCookie)
+	h.setBrowserCookie(c, "", -1)
+	if err != nil || state.BrowserHash == "" ||
+		subtle.ConstantTimeCompare([]byte(hashBrowserCookie(browser)), []byte(state.BrowserHash)) != 1 {
+		return nil, &oauthLoginError{code: "invalid_state", err: errors.New("state was issued to another browser")}
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return nil, &oauthLoginError{code: "exchange_failed", err: err}
+	}
+
+	user, err := h.resolveUser(c, provider.Name(), identity, state.LinkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.LinkUserID != nil {
+		return url.Values{"linked": {provider.Name()}}, nil
+	}
+
+	if user.IsLocked(time.Now()) {
+		return nil, &oauthLoginError{code: "account_locked", err: errors.New("account locked")}
+	}
+
+	// ソーシャルログインでも二要素認証は省略しない
+	if user.TOTPEnabled || user.RequiresTwoFactor() {
+		preAuthToken, err := h.tokens.GeneratePreAuth(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return url.Values{
+			"pre_auth_token":      {preAuthToken},
+			"enrollment_required": {fmt.Sprint(!user.TOTPEnabled)},
+		}, nil
+	}
+
+	token, err := h.tokens.Generate(user.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	return url.Values{"token": {token}}, nil
+}
+
+// resolveUser 外部アカウントに対応するユーザーを取得し、必要に応じて連携・作成する
+//  1. 既に連携済みならそのユーザー
+//  2. ログイン済みユーザーからの連携リクエストならそのユーザーに連携
+//  3. 確認済みメールアドレスが一致するユーザーがいなければ新規ユーザーを作成して連携
+//
+// メールアドレスが一致する既存のユーザーには自動で連携しない（account_exists）
+// 外部アカウントを先に取得した第三者による乗っ取りを防ぐため、既存のユーザーはログインしてから連携する
+func (h *OAuthHandler) resolveUser(c *gin.Context, providerName string, identity *oidc.Identity, linkUserID *uint) (*models.User, error) {
+	var user models.User
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", providerName, identity.Subject).First(&existing).Error
+		switch {
+		case err == nil:
+			if linkUserID != nil && *linkUserID != existing.UserID {
+				return &oauthLoginError{code: "already_linked", err: errors.New("identity is linked to another user")}
+			}
+			return tx.First(&user, existing.UserID).Error
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		email := strings.ToLower(strings.TrimSpace(identity.Email))
+		switch {
+		case linkUserID != nil:
+			if err := tx.First(&user, *linkUserID).Error; err != nil {
+				return err
+			}
+		case email == "" || !identity.EmailVerified:
+			return &oauthLoginError{code: "email_not_verified", err: oidc.ErrEmailNotVerified}
+		default:
+			err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+			switch {
+			case err == nil:
+				return &oauthLoginError{code: "account_exists", err: errors.New("email is registered to an unlinked user")}
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+			if err := h.createUser(tx, &user, identity, email); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  identity.Subject,
+			Email:    email,
+		}).Error; err != nil {
+			return err
+		}
+
+		return audit.Record(tx, audit.Entry{
+			ActorID:    &user.ID,
+			Action:     audit.ActionIdentityLinked,
+			TargetType: "user",
+			TargetID:   user.ID,
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Metadata:   map[string]interface{}{"provider": providerName},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// createUser 外部アカウントの情報から新規ユーザーを作成する
+// パスワードはランダムな値とし、パスワードでのログインはできない
+func (h *OAuthHandler) createUser(tx *gorm.DB, user *models.User, identity *oidc.Identity, email string) error {
+	random, err := oidc.RandomString()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := h.hasher.Hash(random)
+	if err != nil {
+		return err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+	profileImageURL := identity.Picture
+	if profileImageURL == "" {
+		profileImageURL = fmt.Sprintf("https://api.dicebear.com/7.x/adventurer/svg?seed=%s", email)
+	}
+
+	*user = models.User{
+		Name:            name,
+		Email:           email,
+		Password:        hashedPassword,
+		Bio:             "よろしくお願いします！",
+		ProfileImageURL: profileImageURL,
+	}
+	return tx.Create(user).Error
+}
+
+// redirectToFrontend 結果をURLフラグメントに載せてフロントエンドへリダイレクトする
+// フラグメントはサーバーに送信されないため、トークンがアクセスログに残らない
+func (h *OAuthHandler) redirectToFrontend(c *gin.Context, values url.Values) {
+	c.Redirect(http.StatusFound, h.frontendURL+"/oauth/callback#"+values.Encode())
+}
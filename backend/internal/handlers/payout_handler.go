@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// PayoutHandler は主催者への送金（送金先の登録・精算・明細）を担当するハンドラー
+type PayoutHandler struct {
+	payouts     *service.PayoutService
+	frontendURL string
+}
+
+// NewPayoutHandler はPayoutHandlerの新しいインスタンスを作成します
+func NewPayoutHandler(payouts *service.PayoutService, frontendURL string) *PayoutHandler {
+	return &PayoutHandler{payouts: payouts, frontendURL: frontendURL}
+}
+
+// SettlementQuery は精算一覧の絞り込み条件
+type SettlementQuery struct {
+	Status models.SettlementStatus `form:"status" binding:"omitempty,oneof=pending in_transit paid failed"`
+}
+
+// StartOnboarding 送金先（Stripe Connect）の登録画面のURLを発行
+func (h *PayoutHandler) StartOnboarding(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	onboarding, err := h.payouts.StartOnboarding(c.Request.Context(), userID.(uint), service.OnboardingLinks{
+		RefreshURL: h.frontendURL + "/settings/payouts?onboarding=refresh",
+		ReturnURL:  h.frontendURL + "/settings/payouts?onboarding=return",
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, onboarding)
+}
+
+// GetAccount 送金先の登録状況を取得
+func (h *PayoutHandler) GetAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	account, err := h.payouts.Account(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, account)
+}
+
+// ListSettlements 自分が主催したプロジェクトの精算と送金の状態を取得
+func (h *PayoutHandler) ListSettlements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	settlements, err := h.payouts.ListForOwner(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, settlements)
+}
+
+// DownloadStatement 精算の明細をCSVでダウンロード（主催者本人または管理者）
+func (h *PayoutHandler) DownloadStatement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	statement, err := h.payouts.Statement(c.Request.Context(), userID.(uint), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := statement.WriteCSV(&buf); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("明細の作成に失敗しました"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, statement.Filename()))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ListAllSettlements すべての精算と送金の状態を取得（管理者のみ）
+func (h *PayoutHandler) ListAllSettlements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var q SettlementQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	settlements, err := h.payouts.List(c.Request.Context(), userID.(uint), q.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, settlements)
+}
+
+// RetrySettlement 入金に失敗した精算を再送（管理者のみ）
+func (h *PayoutHandler) RetrySettlement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	settlement, err := h.payouts.Retry(c.Request.Context(), userID.(uint), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, settlement)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/privacy"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// PrivacyHandler は個人データのエクスポートと退会を担当するハンドラー
+type PrivacyHandler struct {
+	db      *gorm.DB
+	service *privacy.Service
+}
+
+// NewPrivacyHandler はPrivacyHandlerの新しいインスタンスを作成します
+func NewPrivacyHandler(db *gorm.DB, service *privacy.Service) *PrivacyHandler {
+	return &PrivacyHandler{db: db, service: service}
+}
+
+// ExportData 個人データのエクスポートを受け付け、状態を返す
+// 作成はバックグラウンドで行い、完了したらメールで通知する
+func (h *PrivacyHandler) ExportData(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	export, err := h.service.RequestExport(c.Request.Context(), userID.(uint))
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("データのエクスポートの受付に失敗しました", "error", err)
+		c.Error(utils.ErrInternalServer.WithDetail("エクスポートの受付に失敗しました"))
+		return
+	}
+
+	data := gin.H{
+		"id":         export.ID,
+		"status":     export.Status,
+		"created_at": export.CreatedAt,
+	}
+	status := http.StatusAccepted
+	if export.IsDownloadable(time.Now()) {
+		status = http.StatusOK
+		data["download_url"] = "/api/v1/users/me/export/download"
+		data["size"] = export.Size
+		data["expires_at"] = export.ExpiresAt
+	}
+
+	c.JSON(status, utils.Response{Status: "success", Data: data})
+}
+
+// DownloadExport 作成済みのアーカイブをダウンロード
+func (h *PrivacyHandler) DownloadExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	var export models.DataExport
+	if err := h.db.Where("user_id = ? AND status = ?", userID, models.DataExportStatusReady).
+		Order("created_at DESC").First(&export).Error; err != nil || !export.IsDownloadable(time.Now()) {
+		c.Error(utils.ErrNotFound.WithDetail("ダウンロードできるデータがありません"))
+		return
+	}
+
+	filename := fmt.Sprintf("oshiome-export-%s.zip", export.ReadyAt.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", export.Archive)
+}
+
+// DeleteAccount 退会を受け付ける
+// 公開中のプロジェクトがある場合は退会できない
+func (h *PrivacyHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
+	if err := h.service.RequestDeletion(c.Request.Context(), userID.(uint), c.ClientIP()); err != nil {
+		if errors.Is(err, privacy.ErrActiveProjects) {
+			c.Error(utils.ErrConflict.WithDetail("公開中のプロジェクトがあるため退会できません。プロジェクトの終了後に再度お試しください"))
+			return
+		}
+		logging.FromContext(c.Request.Context()).Error("退会の受付に失敗しました", "error", err)
+		c.Error(utils.ErrInternalServer.WithDetail("退会の受付に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, utils.Response{
+		Status: "success",
+		Data:   gin.H{"message": "退会を受け付けました。手続きが完了したらメールでお知らせします"},
+	})
+}
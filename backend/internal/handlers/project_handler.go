@@ -2,62 +2,56 @@ package handlers
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
-	"github.com/masvc/oshiome_go/backend/internal/db"
 	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/realtime"
+	"github.com/masvc/oshiome_go/backend/internal/service"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
-	"gorm.io/gorm"
 )
 
 type ProjectHandler struct {
-	db *gorm.DB
+	projects *service.ProjectService
+	hub      *realtime.Hub
 }
 
-func NewProjectHandler() *ProjectHandler {
-	return &ProjectHandler{db: db.GetDB()}
+func NewProjectHandler(projects *service.ProjectService, hub *realtime.Hub) *ProjectHandler {
+	return &ProjectHandler{projects: projects, hub: hub}
 }
 
+// SSE接続を維持するためのハートビート間隔
+const streamHeartbeatInterval = 30 * time.Second
+
 type ProjectInput struct {
 	Title        string               `json:"title" binding:"required"`
 	Description  string               `json:"description" binding:"required"`
 	TargetAmount int64                `json:"target_amount" binding:"required,min=1000"`
 	Deadline     time.Time            `json:"deadline" binding:"required,gt=now"`
+	Type         models.ProjectType   `json:"type" binding:"omitempty,oneof=digital_signage station_ad other"`
 	Status       models.ProjectStatus `json:"status"`
+	// VisionID は掲出するビジョン（省略した場合は変更しない）
+	VisionID       *uint                 `json:"vision_id"`
+	SettlementMode models.SettlementMode `json:"settlement_mode" binding:"omitempty,oneof=organizer operator"`
 }
 
-// withTx トランザクションを使用して処理を実行
-func (h *ProjectHandler) withTx(c *gin.Context, fn func(*gorm.DB) error) {
-	tx := h.db.Begin()
-	if err := fn(tx); err != nil {
-		tx.Rollback()
-		c.Error(err)
-		return
-	}
-	tx.Commit()
-}
-
-// getProject プロジェクトの取得と権限チェック
-func (h *ProjectHandler) getProject(c *gin.Context, checkOwner bool) (*models.Project, error) {
-	var project models.Project
-	if err := h.db.
-		Preload("User").
-		Preload("Supports").
-		Preload("Supports.User").
-		First(&project, c.Param("id")).Error; err != nil {
-		return nil, utils.ErrNotFound.WithDetail(utils.ErrMsgProjectNotFound)
-	}
-
-	if checkOwner {
-		if userID, exists := c.Get("user_id"); !exists || project.UserID != userID.(uint) {
-			return nil, utils.ErrUnauthorized.WithDetail(utils.ErrMsgUnauthorizedAccess)
-		}
+// toService サービスの入力に変換
+func (in ProjectInput) toService() service.ProjectInput {
+	return service.ProjectInput{
+		Title:          in.Title,
+		Description:    in.Description,
+		TargetAmount:   in.TargetAmount,
+		Deadline:       in.Deadline,
+		Type:           in.Type,
+		VisionID:       in.VisionID,
+		SettlementMode: in.SettlementMode,
+		Status:         in.Status,
 	}
-	return &project, nil
 }
 
 // respond レスポンスを返す
@@ -67,28 +61,24 @@ func respond(c *gin.Context, status int, data interface{}) {
 
 // ListProjects プロジェクト一覧を取得
 func (h *ProjectHandler) ListProjects(c *gin.Context) {
-	var projects []models.Project
-	query := h.db.Preload("User")
-
-	status := c.Query("status")
-	if status != "" {
-		query = query.Where("status = ?", status)
-	} else {
-		// デフォルトでは実施中のプロジェクトのみを表示
-		query = query.Where("status = ?", models.ProjectStatusActive)
-	}
-
-	if err := query.Find(&projects).Error; err != nil {
-		c.Error(utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail))
+	// 未指定の場合は実施中のプロジェクトのみを表示
+	projects, err := h.projects.List(c.Request.Context(), models.ProjectStatus(c.Query("status")))
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
 	respond(c, http.StatusOK, projects)
 }
 
 // GetProject プロジェクト詳細を取得
 func (h *ProjectHandler) GetProject(c *gin.Context) {
-	project, err := h.getProject(c, false)
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	project, err := h.projects.GetDetail(c.Request.Context(), id)
 	if err != nil {
 		c.Error(err)
 		return
@@ -96,11 +86,65 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 	respond(c, http.StatusOK, project)
 }
 
+// StreamProject 支援状況をServer-Sent Eventsで配信
+func (h *ProjectHandler) StreamProject(c *gin.Context) {
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	project, err := h.projects.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// 初回スナップショットより先に購読し、取りこぼしを防ぐ
+	events, unsubscribe := h.hub.Subscribe(project.ID)
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // リバースプロキシでのバッファリングを無効化
+
+	// 接続直後に現在の支援状況を送信（AfterFindで集計済み）
+	c.Render(-1, sse.Event{
+		Event: "progress",
+		Data: realtime.ProjectEvent{
+			ProjectID:       project.ID,
+			CurrentAmount:   project.CurrentAmount,
+			SupportersCount: project.SupportersCount,
+		},
+	})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.Render(-1, sse.Event{Event: "progress", Data: event})
+			if event.Cheer != nil {
+				c.Render(-1, sse.Event{Event: "cheer", Data: event.Cheer})
+			}
+		case <-heartbeat.C:
+			c.Render(-1, sse.Event{Event: "ping", Data: time.Now().Unix()})
+		}
+		return true
+	})
+}
+
 // CreateProject プロジェクトを作成
 func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	var input ProjectInput
 	if err := c.ShouldBind(&input); err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail(err.Error()))
+		c.Error(invalidInput(c, err))
 		return
 	}
 
@@ -134,81 +178,63 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		thumbnailURL = "/" + filepath
 	}
 
-	project := &models.Project{
-		Title:        input.Title,
-		Description:  input.Description,
-		TargetAmount: input.TargetAmount,
-		Deadline:     input.Deadline,
-		UserID:       userID.(uint),
-		Status:       models.ProjectStatusDraft,
-		ThumbnailURL: thumbnailURL,
-	}
-
-	h.withTx(c, func(tx *gorm.DB) error {
-		if err := tx.Create(project).Error; err != nil {
-			return utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectCreateFail)
-		}
-		return nil
-	})
-
-	if !c.IsAborted() {
-		respond(c, http.StatusCreated, project)
+	in := input.toService()
+	in.ThumbnailURL = thumbnailURL
+	project, err := h.projects.Create(c.Request.Context(), userID.(uint), in)
+	if err != nil {
+		c.Error(err)
+		return
 	}
+	respond(c, http.StatusCreated, project)
 }
 
 // UpdateProject プロジェクトを更新
 func (h *ProjectHandler) UpdateProject(c *gin.Context) {
-	project, err := h.getProject(c, true)
+	id, err := paramID(c, "id")
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+
 	var input ProjectInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail(err.Error()))
+		c.Error(invalidInput(c, err))
 		return
 	}
 
-	h.withTx(c, func(tx *gorm.DB) error {
-		updates := models.Project{
-			Title:        input.Title,
-			Description:  input.Description,
-			TargetAmount: input.TargetAmount,
-			Deadline:     input.Deadline,
-		}
-		if input.Status != "" {
-			updates.Status = input.Status
-		}
-		if err := tx.Model(project).Updates(updates).Error; err != nil {
-			return utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectUpdateFail)
-		}
-		return nil
-	})
-
-	if !c.IsAborted() {
-		respond(c, http.StatusOK, project)
+	project, err := h.projects.Update(c.Request.Context(), id, userID.(uint), input.toService())
+	if err != nil {
+		c.Error(err)
+		return
 	}
+	respond(c, http.StatusOK, project)
 }
 
 // DeleteProject プロジェクトを削除
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
-	project, err := h.getProject(c, true)
+	id, err := paramID(c, "id")
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	h.withTx(c, func(tx *gorm.DB) error {
-		if err := tx.Delete(project).Error; err != nil {
-			return utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectDeleteFail)
-		}
-		return nil
-	})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
 
-	if !c.IsAborted() {
-		respond(c, http.StatusOK, gin.H{"message": "プロジェクトを削除しました"})
+	if err := h.projects.Delete(c.Request.Context(), id, userID.(uint)); err != nil {
+		c.Error(err)
+		return
 	}
+	respond(c, http.StatusOK, gin.H{"message": "プロジェクトを削除しました"})
 }
 
 // ListMyProjects ユーザーの主催プロジェクト一覧を取得
@@ -219,12 +245,11 @@ func (h *ProjectHandler) ListMyProjects(c *gin.Context) {
 		return
 	}
 
-	var projects []models.Project
-	if err := h.db.Preload("User").Where("user_id = ?", userID).Find(&projects).Error; err != nil {
-		c.Error(utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail))
+	projects, err := h.projects.ListByOwner(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
 	respond(c, http.StatusOK, projects)
 }
 
@@ -236,14 +261,10 @@ func (h *ProjectHandler) ListSupportedProjects(c *gin.Context) {
 		return
 	}
 
-	var projects []models.Project
-	if err := h.db.Preload("User").
-		Joins("JOIN supports ON supports.project_id = projects.id").
-		Where("supports.user_id = ?", userID).
-		Find(&projects).Error; err != nil {
-		c.Error(utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail))
+	projects, err := h.projects.ListSupportedBy(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
 		return
 	}
-
 	respond(c, http.StatusOK, projects)
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// ReceiptHandler は支援の領収書（PDF）の発行・再発行を担当するハンドラー
+type ReceiptHandler struct {
+	receipts *service.ReceiptService
+}
+
+// NewReceiptHandler はReceiptHandlerの新しいインスタンスを作成します
+func NewReceiptHandler(receipts *service.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{receipts: receipts}
+}
+
+type ReissueReceiptInput struct {
+	Addressee string `json:"addressee" binding:"max=100"`
+	// Reason は発行済みの領収書を無効にする理由（再発行の場合は必須）
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// DownloadReceipt 支援の領収書をPDFでダウンロード（支援者本人・主催者・管理者。未発行の場合は発行）
+func (h *ReceiptHandler) DownloadReceipt(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	receipt, err := h.receipts.Receipt(c.Request.Context(), userID.(uint), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := service.WriteReceiptPDF(&buf, receipt); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("領収書の作成に失敗しました"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, service.ReceiptFilename(receipt)))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// ReissueReceipt 宛名を指定して領収書を発行（発行済みの場合は無効にして再発行。支援者本人のみ）
+func (h *ReceiptHandler) ReissueReceipt(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var input ReissueReceiptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	receipt, err := h.receipts.Reissue(c.Request.Context(), userID.(uint), id, service.ReissueReceiptInput{
+		Addressee: input.Addressee,
+		Reason:    input.Reason,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, receipt)
+}
+
+// ListReceipts 支援の領収書の発行・無効化の履歴を取得
+func (h *ReceiptHandler) ListReceipts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	receipts, err := h.receipts.History(c.Request.Context(), userID.(uint), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	respond(c, http.StatusOK, receipts)
+}
+
+// DownloadProjectReceipts プロジェクトの支援の領収書をZIPでまとめてダウンロード（主催者本人または管理者）
+func (h *ReceiptHandler) DownloadProjectReceipts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	receipts, err := h.receipts.ProjectReceipts(c.Request.Context(), userID.(uint), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := service.WriteReceiptArchive(&buf, receipts); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("領収書の作成に失敗しました"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="oshiome-receipts-%d.zip"`, id))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
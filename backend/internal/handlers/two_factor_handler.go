@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// TwoFactorHandler は二要素認証（TOTP）の登録とログインを担当するハンドラー
+type TwoFactorHandler struct {
+	db     *gorm.DB
+	guard  *auth.LoginGuard
+	tokens *utils.TokenManager
+	now    func() time.Time
+}
+
+// NewTwoFactorHandler はTwoFactorHandlerの新しいインスタンスを作成します
+func NewTwoFactorHandler(db *gorm.DB, guard *auth.LoginGuard, tokens *utils.TokenManager) *TwoFactorHandler {
+	return &TwoFactorHandler{db: db, guard: guard, tokens: tokens, now: time.Now}
+}
+
+// SetClock は現在時刻の取得に使用する関数を差し替えます（テスト用）
+func (h *TwoFactorHandler) SetClock(now func() time.Time) {
+	h.now = now
+}
+
+type TwoFactorLoginInput struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// currentUser 認証済みユーザーを取得
+func (h *TwoFactorHandler) currentUser(c *gin.Context) (*models.User, error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return nil, utils.ErrUnauthorized
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		return nil, utils.ErrNotFound.WithDetail(utils.ErrMsgUserNotFound)
+	}
+	return &user, nil
+}
+
+// auditEntry 二要素認証の操作に関する監査ログのエントリを作成
+func auditEntry(c *gin.Context, user *models.User, action string) audit.Entry {
+	return audit.Entry{
+		ActorID:    &user.ID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   user.ID,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+}
+
+// VerifyLogin パスワード認証後の二段階目として認証コードまたはリカバリーコードを検証
+func (h *TwoFactorHandler) VerifyLogin(c *gin.Context) {
+	var input TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+	if input.Code == "" && input.RecoveryCode == "" {
+		c.Error(utils.ErrInvalidInput.WithDetail("認証コードまたはリカバリーコードを入力してください"))
+		return
+	}
+
+	userID, err := h.tokens.ValidatePreAuth(input.PreAuthToken)
+	if err != nil {
+		c.Error(utils.ErrUnauthorized.WithDetail("ログインの有効期限が切れました。もう一度ログインしてください"))
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.Error(utils.ErrUnauthorized)
+		return
+	}
+	if !user.TOTPEnabled {
+		c.Error(utils.ErrInvalidInput.WithDetail("二要素認証が登録されていません"))
+		return
+	}
+
+	if throttled := h.guard.CheckAccount(&user); throttled != nil {
+		abortThrottled(c, throttled)
+		return
+	}
+
+	ip := c.ClientIP()
+	var verified bool
+	if input.Code != "" {
+		verified = h.verifyTOTP(&user, input.Code)
+	} else {
+		verified = h.consumeRecoveryCode(c, &user, input.RecoveryCode)
+	}
+	if !verified {
+		h.guard.RecordFailure(c.Request.Context(), &user, user.Email, ip, c.Request.UserAgent())
+		c.Error(utils.ErrInvalidTwoFactorCode)
+		return
+	}
+
+	h.guard.RecordSuccess(c.Request.Context(), &user, ip)
+
+	token, err := h.tokens.Generate(user.ID, true)
+	if err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("トークンの生成に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status: "success",
+		Data: gin.H{
+			"user":  user,
+			"token": token,
+		},
+	})
+}
+
+// Enroll 二要素認証の登録を開始し、認証アプリ用のシークレットを発行
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user, err := h.currentUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.TOTPEnabled {
+		c.Error(utils.ErrInvalidInput.WithDetail("二要素認証は既に有効です"))
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("シークレットの生成に失敗しました"))
+		return
+	}
+
+	// 確認コードの検証が完了するまでは有効化しない
+	if err := h.db.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         secret,
+		"totp_last_used_step": 0,
+	}).Error; err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("二要素認証の登録に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status: "success",
+		Data: gin.H{
+			"secret":           secret,
+			"provisioning_uri": auth.TOTPProvisioningURI(secret, user.Email),
+		},
+	})
+}
+
+// ConfirmEnrollment 認証アプリのコードを確認して二要素認証を有効化し、リカバリーコードを発行
+func (h *TwoFactorHandler) ConfirmEnrollment(c *gin.Context) {
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	user, err := h.currentUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.TOTPEnabled {
+		c.Error(utils.ErrInvalidInput.WithDetail("二要素認証は既に有効です"))
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.Error(utils.ErrInvalidInput.WithDetail("先に二要素認証の登録を開始してください"))
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, input.Code, h.now(), user.TOTPLastUsedStep)
+	if !ok {
+		c.Error(utils.ErrInvalidTwoFactorCode)
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("リカバリーコードの生成に失敗しました"))
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":        true,
+			"totp_last_used_step": step,
+		}).Error; err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(tx, user.ID, codes); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, user, audit.ActionTwoFactorEnabled))
+	}); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("二要素認証の有効化に失敗しました"))
+		return
+	}
+
+	// 登録必須ユーザーは二要素認証待ちのトークンで登録するため、ここで通常のトークンを発行する
+	token, err := h.tokens.Generate(user.ID, true)
+	if err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("トークンの生成に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status:  "success",
+		Message: "二要素認証を有効にしました。リカバリーコードは安全な場所に保管してください",
+		Data: gin.H{
+			"recovery_codes": codes,
+			"token":          token,
+		},
+	})
+}
+
+// Disable 二要素認証を無効化
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	user, err := h.currentUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if user.RequiresTwoFactor() {
+		c.Error(utils.ErrForbidden.WithDetail("管理者・事務所スタッフは二要素認証を無効にできません"))
+		return
+	}
+	if !user.TOTPEnabled {
+		c.Error(utils.ErrInvalidInput.WithDetail("二要素認証は有効になっていません"))
+		return
+	}
+	if !h.checkCode(c, user, input.Code) {
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":        false,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, user, audit.ActionTwoFactorDisabled))
+	}); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("二要素認証の無効化に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status:  "success",
+		Message: "二要素認証を無効にしました",
+	})
+}
+
+// RegenerateRecoveryCodes リカバリーコードを再発行（以前のコードは無効になる）
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	user, err := h.currentUser(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !user.TOTPEnabled {
+		c.Error(utils.ErrInvalidInput.WithDetail("二要素認証は有効になっていません"))
+		return
+	}
+	if !h.checkCode(c, user, input.Code) {
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("リカバリーコードの生成に失敗しました"))
+		return
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := replaceRecoveryCodes(tx, user.ID, codes); err != nil {
+			return err
+		}
+		return audit.Record(tx, auditEntry(c, user, audit.ActionRecoveryCodesRenewed))
+	}); err != nil {
+		c.Error(utils.ErrInternalServer.WithDetail("リカバリーコードの再発行に失敗しました"))
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status: "success",
+		Data:   gin.H{"recovery_codes": codes},
+	})
+}
+
+// checkCode ログイン済みユーザーの操作の確認として認証コードを検証
+// 誤ったコードはログインと同じくログイン試行の失敗として記録し、総当たりを制限する
+func (h *TwoFactorHandler) checkCode(c *gin.Context, user *models.User, code string) bool {
+	if throttled := h.guard.CheckAccount(user); throttled != nil {
+		abortThrottled(c, throttled)
+		return false
+	}
+	if !h.verifyTOTP(user, code) {
+		h.guard.RecordFailure(c.Request.Context(), user, user.Email, c.ClientIP(), c.Request.UserAgent())
+		c.Error(utils.ErrInvalidTwoFactorCode)
+		return false
+	}
+	return true
+}
+
+// verifyTOTP 認証コードを検証し、同じコードの再利用を防ぐため使用済みステップを記録
+func (h *TwoFactorHandler) verifyTOTP(user *models.User, code string) bool {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, h.now(), user.TOTPLastUsedStep)
+	if !ok {
+		return false
+	}
+
+	// 同時に同じコードで認証された場合に備え、ステップが進む場合のみ更新する
+	result := h.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+		Update("totp_last_used_step", step)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// consumeRecoveryCode 未使用のリカバリーコードを使用済みにする
+func (h *TwoFactorHandler) consumeRecoveryCode(c *gin.Context, user *models.User, code string) bool {
+	var consumed bool
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, auth.HashRecoveryCode(code)).
+			Update("used_at", h.now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		consumed = true
+		return audit.Record(tx, auditEntry(c, user, audit.ActionRecoveryCodeUsed))
+	})
+	return err == nil && consumed
+}
+
+// replaceRecoveryCodes ユーザーのリカバリーコードを入れ替える
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	records := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: auth.HashRecoveryCode(code)}
+	}
+	return tx.Create(&records).Error
+}
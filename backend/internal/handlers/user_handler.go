@@ -1,24 +1,26 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/masvc/oshiome_go/backend/internal/db"
-	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/service"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
-	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	db *gorm.DB
+	users  *service.UserService
+	guard  *auth.LoginGuard
+	tokens *utils.TokenManager
 }
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{db: db.GetDB()}
+func NewUserHandler(users *service.UserService, guard *auth.LoginGuard, tokens *utils.TokenManager) *UserHandler {
+	return &UserHandler{users: users, guard: guard, tokens: tokens}
 }
 
 type CreateUserInput struct {
@@ -32,52 +34,44 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type UnlockInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
 type UpdateUserInput struct {
 	Name            string `json:"name"`
 	Bio             string `json:"bio"`
 	ProfileImageURL string `json:"profile_image_url"`
+	Locale          string `json:"locale" binding:"omitempty,oneof=ja en"`
 }
 
 // CreateUser 新規ユーザー登録
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var input CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail(err.Error()))
+		c.Error(invalidInput(c, err))
 		return
 	}
 
-	// パスワードのハッシュ化
-	hashedPassword, err := utils.HashPassword(input.Password)
+	user, err := h.users.Register(c.Request.Context(), service.RegisterInput{
+		Name:     input.Name,
+		Email:    input.Email,
+		Password: input.Password,
+		Locale:   i18n.FromContext(c.Request.Context()),
+	})
 	if err != nil {
-		c.Error(utils.ErrInternalServer.WithDetail("パスワードのハッシュ化に失敗しました"))
-		return
-	}
-
-	user := models.User{
-		Name:            input.Name,
-		Email:           input.Email,
-		Password:        hashedPassword,
-		Bio:             "よろしくお願いします！",
-		ProfileImageURL: fmt.Sprintf("https://api.dicebear.com/7.x/adventurer/svg?seed=%s", input.Email),
-	}
-
-	if err := h.db.Create(&user).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate") {
-			c.Error(utils.ErrDuplicateEmail)
-			return
-		}
-		c.Error(utils.ErrInternalServer.WithDetail("ユーザーの作成に失敗しました"))
+		c.Error(err)
 		return
 	}
 
 	// JWTトークンの生成
-	token, err := utils.GenerateToken(user.ID)
+	token, err := h.tokens.Generate(user.ID, false)
 	if err != nil {
 		c.Error(utils.ErrInternalServer.WithDetail("トークンの生成に失敗しました"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, Response{
+	c.JSON(http.StatusCreated, utils.Response{
 		Status: "success",
 		Data: gin.H{
 			"user":  user,
@@ -90,30 +84,57 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 func (h *UserHandler) Login(c *gin.Context) {
 	var input LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail(err.Error()))
+		c.Error(invalidInput(c, err))
 		return
 	}
 
-	var user models.User
-	if err := h.db.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.Error(utils.ErrInvalidCredentials)
+	ip := c.ClientIP()
+	user, err := h.users.Authenticate(c.Request.Context(), service.Credentials{
+		Email:     input.Email,
+		Password:  input.Password,
+		IP:        ip,
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		var throttled *auth.Throttled
+		if errors.As(err, &throttled) {
+			abortThrottled(c, throttled)
+			return
+		}
+		c.Error(err)
 		return
 	}
 
-	// パスワードの検証
-	if !utils.CheckPasswordHash(input.Password, user.Password) {
-		c.Error(utils.ErrInvalidCredentials)
+	// 二要素認証が有効または必須のユーザーは、認証コードの入力（または登録）に進む
+	// 失敗回数のリセットは二要素認証の完了時に行う
+	if user.TOTPEnabled || user.RequiresTwoFactor() {
+		preAuthToken, err := h.tokens.GeneratePreAuth(user.ID)
+		if err != nil {
+			c.Error(utils.ErrInternalServer.WithDetail("トークンの生成に失敗しました"))
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.Response{
+			Status: "success",
+			Data: gin.H{
+				"mfa_required":        true,
+				"enrollment_required": !user.TOTPEnabled,
+				"pre_auth_token":      preAuthToken,
+			},
+		})
 		return
 	}
 
+	h.guard.RecordSuccess(c.Request.Context(), user, ip)
+
 	// JWTトークンの生成
-	token, err := utils.GenerateToken(user.ID)
+	token, err := h.tokens.Generate(user.ID, false)
 	if err != nil {
 		c.Error(utils.ErrInternalServer.WithDetail("トークンの生成に失敗しました"))
 		return
 	}
 
-	c.JSON(http.StatusOK, Response{
+	c.JSON(http.StatusOK, utils.Response{
 		Status: "success",
 		Data: gin.H{
 			"user":  user,
@@ -122,17 +143,46 @@ func (h *UserHandler) Login(c *gin.Context) {
 	})
 }
 
+// UnlockAccount メールのリンクからアカウントのロックを解除
+func (h *UserHandler) UnlockAccount(c *gin.Context) {
+	var input UnlockInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(invalidInput(c, err))
+		return
+	}
+
+	if err := h.guard.Unlock(input.Token, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status:  "success",
+		Message: "アカウントのロックを解除しました",
+	})
+}
+
+// abortThrottled ログイン試行の制限をRetry-Afterヘッダー付きで返す
+func abortThrottled(c *gin.Context, throttled *auth.Throttled) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.Error(throttled.Err)
+}
+
 // GetUser ユーザー情報取得
 func (h *UserHandler) GetUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := h.db.First(&user, id).Error; err != nil {
-		c.Error(utils.ErrNotFound.WithDetail("指定されたユーザーが見つかりません"))
+	user, err := h.users.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, Response{
+	c.JSON(http.StatusOK, utils.Response{
 		Status: "success",
 		Data:   user,
 	})
@@ -146,13 +196,13 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.Error(utils.ErrNotFound.WithDetail("ユーザーが見つかりません"))
+	user, err := h.users.Get(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, Response{
+	c.JSON(http.StatusOK, utils.Response{
 		Status: "success",
 		Data:   user,
 	})
@@ -160,8 +210,11 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 
 // UpdateUser ユーザー情報を更新
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	// パラメータからユーザーIDを取得
-	userID := c.Param("id")
+	id, err := paramID(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
 	// 認証済みユーザーのIDを取得
 	authUserID, exists := c.Get("user_id")
@@ -170,44 +223,24 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// 文字列のユーザーIDをuintに変換
-	uid, err := strconv.ParseUint(userID, 10, 32)
-	if err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail("無効なユーザーIDです"))
-		return
-	}
-
-	// 自分以外のユーザー情報は更新できない
-	if uint(uid) != authUserID.(uint) {
-		c.Error(utils.ErrUnauthorized.WithDetail("他のユーザーの情報は更新できません"))
-		return
-	}
-
 	var input UpdateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.Error(utils.ErrInvalidInput.WithDetail(err.Error()))
+		c.Error(invalidInput(c, err))
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.Error(utils.ErrNotFound.WithDetail("ユーザーが見つかりません"))
-		return
-	}
-
-	// 更新するフィールドを設定
-	updates := models.User{
+	user, err := h.users.UpdateProfile(c.Request.Context(), authUserID.(uint), id, service.ProfileInput{
 		Name:            input.Name,
 		Bio:             input.Bio,
 		ProfileImageURL: input.ProfileImageURL,
-	}
-
-	if err := h.db.Model(&user).Updates(updates).Error; err != nil {
-		c.Error(utils.ErrInternalServer.WithDetail("ユーザー情報の更新に失敗しました"))
+		Locale:          input.Locale,
+	})
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, Response{
+	c.JSON(http.StatusOK, utils.Response{
 		Status: "success",
 		Data:   user,
 	})
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+func init() {
+	// 検証エラーの項目名にJSONのキー（フォームの場合はformタグ）を使う
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	}
+}
+
+// invalidInput はリクエストのバインドで発生したエラーを項目ごとの検証エラーに変換します
+// メッセージはリクエストのロケールで返します
+func invalidInput(c *gin.Context, err error) *utils.APIError {
+	locale := i18n.FromContext(c.Request.Context())
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]utils.FieldError, 0, len(validationErrs))
+		details := make([]string, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := utils.FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: validationMessage(locale, fe),
+			}
+			fields = append(fields, field)
+			details = append(details, field.Field+": "+field.Message)
+		}
+		return utils.ErrInvalidInput.WithDetail(strings.Join(details, i18n.T(locale, "list.separator"))).WithFields(fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		field := utils.FieldError{Field: typeErr.Field, Rule: "type", Message: i18n.T(locale, "値の型が正しくありません")}
+		return utils.ErrInvalidInput.WithDetail(field.Field + ": " + field.Message).WithFields([]utils.FieldError{field})
+	}
+
+	return utils.ErrInvalidInput.WithDetail("リクエストの形式が正しくありません")
+}
+
+// validationMessage は検証ルールに応じたメッセージを返します
+func validationMessage(locale string, fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return i18n.T(locale, "必須項目です")
+	case "email":
+		return i18n.T(locale, "メールアドレスの形式が正しくありません")
+	case "url":
+		return i18n.T(locale, "URLの形式が正しくありません")
+	case "min":
+		if isString {
+			return i18n.T(locale, "%s文字以上で入力してください", fe.Param())
+		}
+		return i18n.T(locale, "%s以上の値を指定してください", fe.Param())
+	case "max":
+		if isString {
+			return i18n.T(locale, "%s文字以内で入力してください", fe.Param())
+		}
+		return i18n.T(locale, "%s以下の値を指定してください", fe.Param())
+	case "len":
+		if isString {
+			return i18n.T(locale, "%s文字で入力してください", fe.Param())
+		}
+		return i18n.T(locale, "%s件で指定してください", fe.Param())
+	case "gt", "gte":
+		// 日時の場合は現在時刻と比較される
+		if fe.Type() == reflect.TypeOf(time.Time{}) {
+			return i18n.T(locale, "現在より後の日時を指定してください")
+		}
+		if fe.Tag() == "gte" {
+			return i18n.T(locale, "%s以上の値を指定してください", fe.Param())
+		}
+		return i18n.T(locale, "%sより大きい値を指定してください", fe.Param())
+	case "oneof":
+		return i18n.T(locale, "%s のいずれかを指定してください", strings.Join(strings.Fields(fe.Param()), ", "))
+	case "numeric", "number":
+		return i18n.T(locale, "数字で入力してください")
+	default:
+		return i18n.T(locale, "値が正しくありません")
+	}
+}
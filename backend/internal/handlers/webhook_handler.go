@@ -1,267 +1,272 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/masvc/oshiome_go/backend/internal/db"
-	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/service"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
 	"github.com/stripe/stripe-go/v72"
-	"github.com/stripe/stripe-go/v72/checkout/session"
 )
 
-// HandleStripeWebhook はStripeからのWebhookを処理します
-func (h *Handler) HandleStripeWebhook(c *gin.Context) {
-	log.Printf("Webhook received: starting to process")
+// WebhookHandler はStripeからのWebhookを受け取り、支援と主催者への送金の状態を更新します
+type WebhookHandler struct {
+	supports      *service.SupportService
+	payouts       *service.PayoutService
+	webhookSecret string
+	// connectWebhookSecret はConnectアカウントのイベントを受け取るエンドポイントの署名シークレット
+	connectWebhookSecret string
+}
+
+// NewWebhookHandler は新しいWebhookHandlerインスタンスを作成します
+func NewWebhookHandler(supports *service.SupportService, payouts *service.PayoutService, stripeCfg config.Stripe) *WebhookHandler {
+	return &WebhookHandler{
+		supports:             supports,
+		payouts:              payouts,
+		webhookSecret:        stripeCfg.WebhookSecret,
+		connectWebhookSecret: stripeCfg.ConnectWebhookSecret,
+	}
+}
+
+// receive はリクエストボディを読み取り、署名を検証したイベントと、ログ・監査ログの情報を設定したcontextを返します
+// 失敗した場合はエラーを設定し、ok に false を返します
+func (h *WebhookHandler) receive(c *gin.Context, secret string) (event stripe.Event, ctx context.Context, ok bool) {
+	logger := logging.FromContext(c.Request.Context())
 
 	// リクエストボディを読み取り
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot read request body"})
-		return
+		logger.Warn("Webhookのリクエストボディを読み取れません", "error", err)
+		metrics.WebhookEvents.Inc("unknown", metrics.WebhookInvalidPayload)
+		c.Error(utils.ErrInvalidInput.WithDetail("リクエストボディを読み取れません"))
+		return event, nil, false
 	}
 
-	log.Printf("Request body read successfully, length: %d bytes", len(body))
-	log.Printf("Stripe-Signature header: %s", c.GetHeader("Stripe-Signature"))
-
-	// Webhookの署名を検証
-	event, err := utils.ValidateWebhookSignature(body, c.GetHeader("Stripe-Signature"))
+	// Webhookの署名を検証（署名ヘッダーの値はログに出力しない）
+	event, err = utils.ValidateWebhookSignature(body, c.GetHeader("Stripe-Signature"), secret)
 	if err != nil {
-		log.Printf("Error verifying webhook signature: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
-		return
+		logger.Warn("Webhookの署名の検証に失敗しました", "error", err, "body_bytes", len(body))
+		metrics.WebhookEvents.Inc("unknown", metrics.WebhookInvalidSignature)
+		c.Error(utils.ErrInvalidInput.WithDetail("Webhookの署名が無効です"))
+		return event, nil, false
+	}
+
+	logger = logger.With("event_id", event.ID, "event_type", event.Type)
+	if event.Account != "" {
+		logger = logger.With("stripe_account", event.Account)
+	}
+	ctx = logging.NewContext(c.Request.Context(), logger)
+	// 支援・精算の状態の変更は監査ログにStripeからの通知として記録する
+	actor := audit.ActorFromContext(ctx)
+	actor.Source = "stripe_webhook"
+	ctx = audit.WithActor(ctx, actor)
+	logger.Info("Webhookを受信しました")
+	return event, ctx, true
+}
+
+// decode はイベントの内容を v に読み込みます。失敗した場合はエラーを設定し、false を返します
+func decode(ctx context.Context, c *gin.Context, event stripe.Event, v interface{}) bool {
+	if err := json.Unmarshal(event.Data.Raw, v); err != nil {
+		logging.FromContext(ctx).Warn("イベントの内容を解析できません", "error", err)
+		metrics.WebhookEvents.Inc(string(event.Type), metrics.WebhookInvalidPayload)
+		c.Error(utils.ErrInvalidInput.WithDetail("イベントの内容を解析できません"))
+		return false
 	}
+	return true
+}
 
-	log.Printf("Webhook signature validated successfully, event type: %s", event.Type)
+// HandleStripeWebhook はStripeからのWebhookを処理します
+func (h *WebhookHandler) HandleStripeWebhook(c *gin.Context) {
+	event, ctx, ok := h.receive(c, h.webhookSecret)
+	if !ok {
+		return
+	}
+	logger := logging.FromContext(ctx)
 
 	// イベントタイプに応じて処理
+	result := metrics.WebhookProcessed
 	switch event.Type {
 	case "checkout.session.completed":
-		log.Printf("Processing checkout.session.completed event")
 		var checkoutSession stripe.CheckoutSession
-		err := json.Unmarshal(event.Data.Raw, &checkoutSession)
-		if err != nil {
-			log.Printf("Error parsing webhook JSON: %v\n", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		if !decode(ctx, c, event, &checkoutSession) {
 			return
 		}
-		handleCheckoutSessionCompleted(checkoutSession)
+		result = h.handleCheckoutSessionCompleted(ctx, checkoutSession)
 
 	case "payment_intent.succeeded":
-		log.Printf("Processing payment_intent.succeeded event")
 		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			log.Printf("Error parsing webhook JSON: %v\n", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		if !decode(ctx, c, event, &paymentIntent) {
 			return
 		}
-		handlePaymentIntentSucceeded(paymentIntent)
+		if err := h.supports.CompletePaymentIntent(ctx, paymentIntent.ID); err != nil {
+			logger.Error("支援の完了に失敗しました", "payment_intent", paymentIntent.ID, "error", err)
+			result = metrics.WebhookError
+		}
 
 	case "payment_intent.payment_failed":
-		log.Printf("Processing payment_intent.payment_failed event")
 		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			log.Printf("Error parsing webhook JSON: %v\n", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		if !decode(ctx, c, event, &paymentIntent) {
+			return
+		}
+		if err := h.supports.FailPaymentIntent(ctx, paymentIntent.ID); err != nil {
+			logger.Error("支援の失敗の記録に失敗しました", "payment_intent", paymentIntent.ID, "error", err)
+			result = metrics.WebhookError
+		}
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		if !decode(ctx, c, event, &charge) {
+			return
+		}
+		if charge.PaymentIntent == nil {
+			logger.Debug("PaymentIntentのない返金のため処理しません", "charge", charge.ID)
+			result = metrics.WebhookIgnored
+			break
+		}
+		if err := h.supports.RefundPaymentIntent(ctx, charge.PaymentIntent.ID, charge.AmountRefunded); err != nil {
+			logger.Error("支援の返金の記録に失敗しました", "payment_intent", charge.PaymentIntent.ID, "error", err)
+			result = metrics.WebhookError
+		}
+
+	case "charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		var dispute stripe.Dispute
+		if !decode(ctx, c, event, &dispute) {
 			return
 		}
-		handlePaymentIntentFailed(paymentIntent)
+		if dispute.PaymentIntent == nil {
+			logger.Debug("PaymentIntentのないチャージバックのため処理しません", "dispute", dispute.ID)
+			result = metrics.WebhookIgnored
+			break
+		}
+		withdrawn := event.Type == "charge.dispute.funds_withdrawn"
+		d := service.Dispute{
+			ID:              dispute.ID,
+			PaymentIntentID: dispute.PaymentIntent.ID,
+			Amount:          dispute.Amount,
+			Fee:             disputeFee(dispute, withdrawn),
+		}
+		var err error
+		if withdrawn {
+			err = h.supports.WithdrawDispute(ctx, d)
+		} else {
+			err = h.supports.ReinstateDispute(ctx, d)
+		}
+		if err != nil {
+			logger.Error("チャージバックの記録に失敗しました", "dispute", dispute.ID, "error", err)
+			result = metrics.WebhookError
+		}
 
 	default:
-		log.Printf("Unhandled event type: %s\n", event.Type)
+		logger.Debug("処理対象外のイベントです")
+		result = metrics.WebhookIgnored
 	}
 
+	metrics.WebhookEvents.Inc(string(event.Type), result)
 	c.JSON(http.StatusOK, gin.H{"received": true})
-	log.Printf("Webhook processed successfully")
 }
 
-// CheckoutSession完了時の処理
-func handleCheckoutSessionCompleted(checkoutSession stripe.CheckoutSession) {
-	log.Printf("CheckoutSession completed: %s", checkoutSession.ID)
-
-	// セッションが支払いモードかを確認
-	if checkoutSession.Mode != "payment" {
-		log.Printf("Skipping non-payment mode session: %s", checkoutSession.Mode)
-		return
-	}
-
-	// 関連するサポート情報を取得
-	// メタデータからsupport_idを取得
-	supportIDStr, ok := checkoutSession.Metadata["support_id"]
-	if !ok {
-		log.Printf("No support_id in metadata")
-		return
-	}
-
-	supportID, err := strconv.ParseUint(supportIDStr, 10, 64)
-	if err != nil {
-		log.Printf("Invalid support_id: %s", supportIDStr)
-		return
-	}
-
-	var support models.Support
-	if err := db.GetDB().First(&support, supportID).Error; err != nil {
-		log.Printf("Support not found: %d", supportID)
-		return
-	}
-
-	// PaymentIntent情報を取得
-	if checkoutSession.PaymentIntent == nil {
-		log.Printf("No PaymentIntent found in session: %s", checkoutSession.ID)
-		return
-	}
-
-	// 支援ステータスを完了に更新
-	tx := db.GetDB().Begin()
-	if tx.Error != nil {
-		log.Printf("Error starting transaction: %v", tx.Error)
-		return
-	}
-
-	// トランザクションのロールバックを保証
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+// disputeFee はチャージバックの引き落とし（withdrawn）または戻りの残高取引の手数料の合計を返します
+// 引き落としは額が負、戻りは額が正の残高取引です
+func disputeFee(dispute stripe.Dispute, withdrawn bool) int64 {
+	var fee int64
+	for _, bt := range dispute.BalanceTransactions {
+		if bt != nil && (bt.Amount < 0) == withdrawn {
+			fee += bt.Fee
 		}
-	}()
-
-	// 支援情報を更新
-	if err := tx.Model(&support).Updates(map[string]interface{}{
-		"status":            models.SupportStatusCompleted,
-		"payment_intent_id": checkoutSession.PaymentIntent.ID,
-	}).Error; err != nil {
-		tx.Rollback()
-		log.Printf("Error updating support: %v", err)
-		return
-	}
-
-	// プロジェクトの現在の支援額更新は不要（AfterFindで動的に計算するため）
-
-	// トランザクションのコミット
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("Error committing transaction: %v", err)
-		return
 	}
-
-	log.Printf("Support completed: %d, Amount: %d", support.ID, support.Amount)
+	return fee
 }
 
-// 支払い成功時の処理
-func handlePaymentIntentSucceeded(paymentIntent stripe.PaymentIntent) {
-	log.Printf("PaymentIntent succeeded: %s", paymentIntent.ID)
-
-	// 関連するチェックアウトセッションがあれば取得
-	params := &stripe.CheckoutSessionListParams{
-		PaymentIntent: stripe.String(paymentIntent.ID),
+// HandleConnectWebhook はConnectアカウントのイベント（送金先の状態・入金の結果）を処理します
+func (h *WebhookHandler) HandleConnectWebhook(c *gin.Context) {
+	event, ctx, ok := h.receive(c, h.connectWebhookSecret)
+	if !ok {
+		return
 	}
+	logger := logging.FromContext(ctx)
 
-	iter := session.List(params)
-	for iter.Next() {
-		s := iter.Current().(*stripe.CheckoutSession)
-		supportIDStr, ok := s.Metadata["support_id"]
-		if !ok {
-			log.Printf("No support_id in metadata for session: %s", s.ID)
-			continue
-		}
-
-		supportID, err := strconv.ParseUint(supportIDStr, 10, 64)
-		if err != nil {
-			log.Printf("Invalid support_id: %s", supportIDStr)
-			continue
+	result := metrics.WebhookProcessed
+	switch event.Type {
+	case "account.updated":
+		var account stripe.Account
+		if !decode(ctx, c, event, &account) {
+			return
 		}
-
-		log.Printf("Found support_id %d for PaymentIntent %s", supportID, paymentIntent.ID)
-
-		var support models.Support
-		if err := db.GetDB().First(&support, supportID).Error; err != nil {
-			log.Printf("Support not found: %d", supportID)
-			continue
+		if err := h.payouts.UpdateAccount(ctx, service.ConnectAccount{
+			ID:               account.ID,
+			DetailsSubmitted: account.DetailsSubmitted,
+			PayoutsEnabled:   account.PayoutsEnabled,
+		}); err != nil {
+			logger.Error("送金先の状態の更新に失敗しました", "error", err)
+			result = metrics.WebhookError
 		}
 
-		// 支援ステータスを完了に更新
-		tx := db.GetDB().Begin()
-		if tx.Error != nil {
-			log.Printf("Error starting transaction: %v", tx.Error)
-			continue
+	case "payout.paid", "payout.failed":
+		var payout stripe.Payout
+		if !decode(ctx, c, event, &payout) {
+			return
 		}
-
-		// トランザクションのロールバックを保証
-		defer func() {
-			if r := recover(); r != nil {
-				tx.Rollback()
-			}
-		}()
-
-		// 支援情報を更新
-		if err := tx.Model(&support).Updates(map[string]interface{}{
-			"status":            models.SupportStatusCompleted,
-			"payment_intent_id": paymentIntent.ID,
-		}).Error; err != nil {
-			tx.Rollback()
-			log.Printf("Error updating support: %v", err)
-			continue
+		var err error
+		if event.Type == "payout.paid" {
+			err = h.payouts.MarkPayoutPaid(ctx, payout.ID)
+		} else {
+			err = h.payouts.MarkPayoutFailed(ctx, payout.ID, string(payout.FailureCode), payout.FailureMessage)
 		}
-
-		// プロジェクトの現在の支援額更新は不要（AfterFindで動的に計算するため）
-
-		// トランザクションのコミット
-		if err := tx.Commit().Error; err != nil {
-			log.Printf("Error committing transaction: %v", err)
-			continue
+		if err != nil {
+			logger.Error("精算の入金の状態の更新に失敗しました", "payout", payout.ID, "error", err)
+			result = metrics.WebhookError
 		}
 
-		log.Printf("Support completed: %d, Amount: %d", support.ID, support.Amount)
-		return // 最初の有効なサポートのみ処理
+	default:
+		logger.Debug("処理対象外のイベントです")
+		result = metrics.WebhookIgnored
 	}
 
-	if err := iter.Err(); err != nil {
-		log.Printf("Error iterating sessions: %v", err)
-	}
+	metrics.WebhookEvents.Inc(string(event.Type), result)
+	c.JSON(http.StatusOK, gin.H{"received": true})
 }
 
-// 支払い失敗時の処理
-func handlePaymentIntentFailed(paymentIntent stripe.PaymentIntent) {
-	log.Printf("PaymentIntent failed: %s", paymentIntent.ID)
+// CheckoutSession完了時の処理（処理結果を metrics.WebhookProcessed などで返す）
+func (h *WebhookHandler) handleCheckoutSessionCompleted(ctx context.Context, checkoutSession stripe.CheckoutSession) string {
+	logger := logging.FromContext(ctx).With("checkout_session", checkoutSession.ID)
 
-	// 関連するチェックアウトセッションがあれば取得
-	params := &stripe.CheckoutSessionListParams{
-		PaymentIntent: stripe.String(paymentIntent.ID),
+	// セッションが支払いモードかを確認
+	if checkoutSession.Mode != "payment" {
+		logger.Debug("支払いモード以外のセッションのため処理しません", "mode", checkoutSession.Mode)
+		return metrics.WebhookIgnored
 	}
 
-	iter := session.List(params)
-	for iter.Next() {
-		s := iter.Current().(*stripe.CheckoutSession)
-		supportIDStr, ok := s.Metadata["support_id"]
-		if !ok {
-			continue
-		}
+	// メタデータからsupport_idを取得
+	supportIDStr, ok := checkoutSession.Metadata["support_id"]
+	if !ok {
+		logger.Warn("メタデータにsupport_idがありません")
+		return metrics.WebhookIgnored
+	}
 
-		supportID, err := strconv.ParseUint(supportIDStr, 10, 64)
-		if err != nil {
-			continue
-		}
+	supportID, err := strconv.ParseUint(supportIDStr, 10, 64)
+	if err != nil {
+		logger.Warn("support_idが不正です", "support_id", supportIDStr)
+		return metrics.WebhookInvalidPayload
+	}
 
-		// 支援情報を失敗状態に更新
-		if err := db.GetDB().Model(&models.Support{}).Where("id = ?", supportID).
-			Updates(map[string]interface{}{
-				"status":            models.SupportStatusFailed,
-				"payment_intent_id": paymentIntent.ID,
-			}).Error; err != nil {
-			log.Printf("Error updating support %d: %v", supportID, err)
-		} else {
-			log.Printf("Support %d marked as failed", supportID)
-		}
+	// PaymentIntent情報を取得
+	if checkoutSession.PaymentIntent == nil {
+		logger.Warn("セッションにPaymentIntentがありません", "support_id", supportID)
+		return metrics.WebhookInvalidPayload
 	}
 
-	if err := iter.Err(); err != nil {
-		log.Printf("Error iterating sessions: %v", err)
+	// プロジェクトの現在の支援額更新は不要（AfterFindで動的に計算するため）
+	if err := h.supports.CompletePayment(ctx, uint(supportID), checkoutSession.PaymentIntent.ID); err != nil {
+		logger.Error("支援の完了に失敗しました", "support_id", supportID, "error", err)
+		return metrics.WebhookError
 	}
+	return metrics.WebhookProcessed
 }
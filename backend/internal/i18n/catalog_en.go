@@ -0,0 +1,193 @@
+package i18n
+
+// en は英語のメッセージ（キーは日本語の文言または識別子）
+var en = map[string]string{
+	"list.separator":  "; ",
+	"format.datetime": "Jan 2, 2006 15:04 MST",
+
+	// APIError のメッセージ
+	"入力が無効です":                           "The request is invalid",
+	"認証が必要です":                           "Authentication is required",
+	"この操作を行う権限がありません":                   "You do not have permission to perform this action",
+	"リソースが見つかりません":                      "The resource was not found",
+	"サーバーエラーが発生しました":                    "An internal server error occurred",
+	"このメールアドレスは既に使用されています":              "This email address is already in use",
+	"現在の状態ではこの操作を行えません":                 "This action cannot be performed in the current state",
+	"メールアドレスまたはパスワードが正しくありません":          "The email address or password is incorrect",
+	"認証コードが正しくありません":                    "The verification code is incorrect",
+	"ログイン失敗が続いたため、アカウントを一時的にロックしています":   "Your account has been temporarily locked after repeated failed login attempts",
+	"指定されたAPIバージョンには対応していません":           "The requested API version is not supported",
+	"リクエストが多すぎます。しばらく待ってから再度お試しください":    "Too many requests. Please wait a moment and try again",
+	"ログイン試行回数が多すぎます。しばらく待ってから再度お試しください": "Too many login attempts. Please wait a moment and try again",
+
+	// エラーの詳細
+	"プロジェクトが見つかりません":                          "Project not found",
+	"プロジェクトの作成に失敗しました":                        "Failed to create the project",
+	"プロジェクトの更新に失敗しました":                        "Failed to update the project",
+	"プロジェクトの削除に失敗しました":                        "Failed to delete the project",
+	"プロジェクト一覧の取得に失敗しました":                      "Failed to load projects",
+	"このアクションを実行する権限がありません":                    "You are not allowed to perform this action",
+	"ユーザーの作成に失敗しました":                          "Failed to create the user",
+	"ユーザーが見つかりません":                            "User not found",
+	"ロック解除リンクが無効か、有効期限が切れています":                "The unlock link is invalid or has expired",
+	"APIが見つかりません":                             "API not found",
+	"API-Versionヘッダーで指定したバージョンはこのパスでは利用できません": "The version in the API-Version header is not available on this path",
+	"Webhookの署名が無効です":                         "Invalid webhook signature",
+	"この情報にアクセスする権限がありません":                     "You are not allowed to access this information",
+	"アカウントのロック解除に失敗しました":                      "Failed to unlock the account",
+	"アクティブなプロジェクトのみ支援可能です":                    "Only active projects can be supported",
+	"アップロードディレクトリの作成に失敗しました":                  "Failed to create the upload directory",
+	"イベントの内容を解析できません":                         "Failed to parse the event",
+	"エクスポートの受付に失敗しました":                        "Failed to accept the export request",
+	"シークレットの生成に失敗しました":                        "Failed to generate a secret",
+	"セッションIDが必要です":                            "A session ID is required",
+	"セッションIDに対応する支援情報が見つかりません":                "No support was found for the session ID",
+	"ダウンロードできるデータがありません":                      "There is no data available for download",
+	"トークンの生成に失敗しました":                          "Failed to generate a token",
+	"パスワードのハッシュ化に失敗しました":                      "Failed to hash the password",
+	"ファイルのアップロードに失敗しました":                      "Failed to upload the file",
+	"ユーザー情報の更新に失敗しました":                        "Failed to update the user",
+	"リカバリーコードの再発行に失敗しました":                     "Failed to regenerate recovery codes",
+	"リカバリーコードの生成に失敗しました":                      "Failed to generate recovery codes",
+	"リクエストの形式が正しくありません":                       "The request body is malformed",
+	"リクエストボディを読み取れません":                        "Failed to read the request body",
+	"ログインの有効期限が切れました。もう一度ログインしてください":          "Your login has expired. Please log in again",
+	"ログインプロバイダーに接続できませんでした":                   "Could not connect to the login provider",
+	"不正な認証ヘッダーです":                             "Malformed Authorization header",
+	"予期せぬエラーが発生しました":                          "An unexpected error occurred",
+	"二要素認証が登録されていません":                         "Two-factor authentication is not set up",
+	"二要素認証の有効化に失敗しました":                        "Failed to enable two-factor authentication",
+	"二要素認証の無効化に失敗しました":                        "Failed to disable two-factor authentication",
+	"二要素認証の登録に失敗しました":                         "Failed to set up two-factor authentication",
+	"二要素認証は既に有効です":                            "Two-factor authentication is already enabled",
+	"二要素認証は有効になっていません":                        "Two-factor authentication is not enabled",
+	"他のユーザーの情報は更新できません":                       "You cannot update another user's information",
+	"先に二要素認証の登録を開始してください":                     "Start two-factor authentication setup first",
+	"公開中のプロジェクトがあるため退会できません。プロジェクトの終了後に再度お試しください": "You cannot delete your account while you have active projects. Please try again after they end",
+	"二要素認証を完了してください。再度ログインしてください":                 "Complete two-factor authentication. Please log in again",
+	"指定されたログイン方法は利用できません":                         "The specified login method is not available",
+	"支援の作成に失敗しました":                                "Failed to create the support",
+	"支援情報が見つかりません":                                "Support not found",
+	"支援情報の取得に失敗しました":                              "Failed to load supports",
+	"支援情報の更新に失敗しました":                              "Failed to update the support",
+	"無効なIDです":        "Invalid ID",
+	"無効なステータスです":     "Invalid status",
+	"無効なトークンです":      "Invalid token",
+	"無効なロールです":       "Invalid role",
+	"監査ログの記録に失敗しました": "Failed to record the audit log",
+	"管理者・事務所スタッフは二要素認証を無効にできません":           "Administrators and agency staff cannot disable two-factor authentication",
+	"認可リクエストの保存に失敗しました":                    "Failed to save the authorization request",
+	"認可リクエストの生成に失敗しました":                    "Failed to create the authorization request",
+	"認証コードまたはリカバリーコードを入力してください":            "Enter a verification code or a recovery code",
+	"認証ヘッダーが見つかりません":                       "Authorization header not found",
+	"認証情報が見つかりません":                         "Authentication information not found",
+	"対応していない言語です":                          "Unsupported language",
+	"退会の受付に失敗しました":                         "Failed to accept the account deletion request",
+	"送金先が登録されていません":                        "No payout account has been registered",
+	"送金先の登録に失敗しました":                        "Failed to register the payout account",
+	"送金先の登録画面の発行に失敗しました":                   "Failed to create the payout account setup link",
+	"送金先の更新に失敗しました":                        "Failed to update the payout account",
+	"主催者の送金先の登録が完了していません":                  "The organizer has not finished setting up their payout account",
+	"精算が見つかりません":                           "Settlement not found",
+	"精算の取得に失敗しました":                         "Failed to load settlements",
+	"精算の再送に失敗しました":                         "Failed to retry the settlement",
+	"精算の再送は管理者のみ行えます":                      "Only administrators can retry settlements",
+	"すべての精算は管理者のみ参照できます":                   "Only administrators can view all settlements",
+	"再送できるのは入金に失敗した精算のみです":                 "Only settlements whose payout failed can be retried",
+	"明細の作成に失敗しました":                         "Failed to create the statement",
+	"ビジョンが見つかりません":                         "Vision not found",
+	"ビジョンの更新に失敗しました":                       "Failed to update the vision",
+	"公開後はビジョンと精算方法を変更できません":                "The vision and settlement mode cannot be changed after the project is published",
+	"運営会社への直接支払いには運営会社が登録されたビジョンを選択してください": "Select a vision with a registered operator to pay the operator directly",
+	"運営会社に設定できるのは operator ロールのユーザーのみです":   "Only users with the operator role can be set as a vision operator",
+	"請求書を提出できるのはビジョンの運営会社のみです":             "Only vision operators can submit invoices",
+	"このプロジェクトのビジョンの運営会社ではありません":            "You are not the operator of this project's vision",
+	"運営会社への直接支払いのプロジェクトではありません":            "This project does not pay the vision operator directly",
+	"中止されたプロジェクトです":                        "The project has been cancelled",
+	"このプロジェクトの請求書は提出済みです":                  "An invoice has already been submitted for this project",
+	"承認済みの請求書は変更できません":                     "An approved invoice cannot be changed",
+	"請求書が見つかりません":                          "Invoice not found",
+	"請求書の登録に失敗しました":                        "Failed to submit the invoice",
+	"請求書の取得に失敗しました":                        "Failed to load invoices",
+	"請求書の更新に失敗しました":                        "Failed to update the invoice",
+	"請求書の審査は管理者のみ行えます":                     "Only administrators can review invoices",
+	"経費が見つかりません":                           "Expense not found",
+	"経費の登録に失敗しました":                         "Failed to submit the expense",
+	"経費の取得に失敗しました":                         "Failed to load expenses",
+	"経費の更新に失敗しました":                         "Failed to update the expense",
+	"経費の審査は管理者のみ行えます":                      "Only administrators can review expenses",
+	"差し戻し・却下の理由を入力してください":                  "Enter a reason for the rejection",
+	"審査できるのは審査待ちの請求書・経費のみです":               "Only invoices and expenses awaiting review can be reviewed",
+	"精算済みのプロジェクトの経費は変更できません":               "Expenses cannot be changed after the project has been settled",
+	"帳簿は管理者のみ参照できます":                       "Only administrators can view the ledger",
+	"帳簿の取得に失敗しました":                         "Failed to load the ledger",
+	"監査ログは管理者のみ参照できます":                     "Only administrators can view audit events",
+	"監査ログの取得に失敗しました":                       "Failed to load audit events",
+	"from は to より前の日時を指定してください":            "from must be earlier than to",
+	"決済セッションの作成に失敗しました:":                   "Failed to create the checkout session:",
+	"照合の結果は管理者のみ参照できます":                    "Only administrators can view reconciliation reports",
+	"照合の結果の取得に失敗しました":                      "Failed to load reconciliation reports",
+	"照合の期間が正しくありません":                       "The reconciliation period is invalid",
+	"決済が完了した支援のみ領収書を発行できます":                "Receipts can only be issued for completed payments",
+	"領収書の取得に失敗しました":                        "Failed to load receipts",
+	"領収書の発行に失敗しました":                        "Failed to issue the receipt",
+	"領収書の作成に失敗しました":                        "Failed to generate the receipt",
+	"再発行の理由を入力してください":                      "Enter a reason for reissuing the receipt",
+	"宛名は100文字以内で入力してください":                  "The addressee must be at most 100 characters",
+
+	// 入力値の検証
+	"必須項目です": "is required",
+	"メールアドレスの形式が正しくありません": "must be a valid email address",
+	"URLの形式が正しくありません":     "must be a valid URL",
+	"%s文字以上で入力してください":     "must be at least %s characters",
+	"%s以上の値を指定してください":     "must be %s or greater",
+	"%s文字以内で入力してください":     "must be at most %s characters",
+	"%s以下の値を指定してください":     "must be %s or less",
+	"%s文字で入力してください":       "must be exactly %s characters",
+	"%s件で指定してください":        "must contain exactly %s items",
+	"現在より後の日時を指定してください":   "must be in the future",
+	"%sより大きい値を指定してください":   "must be greater than %s",
+	"%s のいずれかを指定してください":   "must be one of %s",
+	"数字で入力してください":         "must be a number",
+	"値が正しくありません":          "is invalid",
+	"値の型が正しくありません":        "has the wrong type",
+
+	// メール
+	"mail.unlock.subject": "[Oshiome] Your account has been temporarily locked",
+	"mail.unlock.body": `We temporarily locked your account after repeated failed login attempts.
+
+If it was you, you can unlock your account with the link below (valid for %d minutes).
+%s/unlock?token=%s
+
+If you did not try to log in, someone else may be trying to access your account.
+Please change your password after unlocking it.
+`,
+
+	"mail.export_ready.subject": "[Oshiome] Your personal data is ready to download",
+	"mail.export_ready.body": `Hello %s,
+
+The export of your personal data is complete.
+Please log in and download it from the page below (available until %s).
+%s/settings/privacy
+
+If you did not request this, please change your password and contact support.
+`,
+
+	"mail.deletion_blocked.subject": "[Oshiome] We could not delete your account",
+	"mail.deletion_blocked.body": `Hello %s,
+
+We could not delete your account because you have active projects.
+Please request account deletion again after your projects have ended.
+%s/settings/privacy
+`,
+
+	"mail.deletion_completed.subject": "[Oshiome] Your account has been deleted",
+	"mail.deletion_completed.body": `Hello %s,
+
+Thank you for using Oshiome.
+Your account has been deleted and your personal information has been removed.
+
+Records related to payments (amount and date of supports) are retained for a period required by law.
+This is a send-only address, so we cannot respond to replies.
+`,
+}
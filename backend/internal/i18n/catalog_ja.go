@@ -0,0 +1,46 @@
+package i18n
+
+// ja は日本語のメッセージ
+// エラーメッセージは文言そのものがキーのため、ここには識別子をキーとするものだけを定義します
+var ja = map[string]string{
+	"list.separator":  "、",
+	"format.datetime": "2006/01/02 15:04",
+
+	"mail.unlock.subject": "【推しおめ】アカウントを一時的にロックしました",
+	"mail.unlock.body": `ログインの失敗が続いたため、お客様のアカウントを一時的にロックしました。
+
+ご本人による操作の場合は、以下のリンクからロックを解除できます（%d分間有効）。
+%s/unlock?token=%s
+
+お心当たりがない場合は、第三者による不正ログインの可能性があります。
+ロック解除後にパスワードを変更してください。
+`,
+
+	"mail.export_ready.subject": "【推しおめ】個人データのダウンロード準備ができました",
+	"mail.export_ready.body": `%s 様
+
+ご依頼いただいた個人データのエクスポートが完了しました。
+ログインのうえ、以下のページからダウンロードしてください（%s まで有効）。
+%s/settings/privacy
+
+お心当たりがない場合は、パスワードを変更のうえサポートまでご連絡ください。
+`,
+
+	"mail.deletion_blocked.subject": "【推しおめ】退会手続きを完了できませんでした",
+	"mail.deletion_blocked.body": `%s 様
+
+公開中のプロジェクトがあるため、退会手続きを完了できませんでした。
+プロジェクトの終了後に、あらためて退会の手続きをお願いいたします。
+%s/settings/privacy
+`,
+
+	"mail.deletion_completed.subject": "【推しおめ】退会手続きが完了しました",
+	"mail.deletion_completed.body": `%s 様
+
+推しおめをご利用いただきありがとうございました。
+退会手続きが完了し、お客様の個人情報を削除しました。
+
+なお、決済に関する記録（支援の金額・日時）は法令に基づき一定期間保存します。
+このメールは送信専用のため、ご返信いただいてもお答えできません。
+`,
+}
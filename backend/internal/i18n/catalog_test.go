@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// detailSources はエラーの詳細（WithDetail）の文言を使用するパッケージ
+var detailSources = []string{"../service", "../handlers", "../middleware"}
+
+// detailFuncs はエラーの詳細の文言を最後の引数に受け取る関数
+var detailFuncs = map[string]bool{"WithDetail": true, "requireAdmin": true}
+
+// TestDetailsHaveEnglishMessages はエラーの詳細の文言がすべて英語のカタログにあることを確認します
+// 文字列の連結（"…: " + err.Error()）は先頭の文言を "…:" のキーで確認します
+func TestDetailsHaveEnglishMessages(t *testing.T) {
+	fset := token.NewFileSet()
+	checked := 0
+	for _, dir := range detailSources {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range files {
+			if strings.HasSuffix(path, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) == 0 || !detailFuncs[funcName(call.Fun)] {
+					return true
+				}
+				key, ok := detailKey(call.Args[len(call.Args)-1])
+				if !ok {
+					return true
+				}
+				checked++
+				if _, ok := en[key]; !ok {
+					t.Errorf("%s: 英語のカタログにありません: %q", fset.Position(call.Pos()), key)
+				}
+				return true
+			})
+		}
+	}
+	if checked == 0 {
+		t.Fatal("WithDetail の文言が見つかりません")
+	}
+}
+
+func funcName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
+
+// detailKey は WithDetail の引数からカタログのキーを返します（文字列リテラル以外は対象外）
+func detailKey(arg ast.Expr) (string, bool) {
+	prefix := false
+	for {
+		bin, ok := arg.(*ast.BinaryExpr)
+		if !ok || bin.Op != token.ADD {
+			break
+		}
+		arg, prefix = bin.X, true
+	}
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	key, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	if prefix {
+		key = strings.TrimRight(key, " ")
+	}
+	return key, true
+}
+
+func TestTranslatesDetailPrefix(t *testing.T) {
+	key := "決済セッションの作成に失敗しました: card_declined"
+	if got, want := T(English, key), "Failed to create the checkout session: card_declined"; got != want {
+		t.Errorf("英語: got %q, want %q", got, want)
+	}
+	if got := T(Japanese, key); got != key {
+		t.Errorf("日本語: got %q, want %q", got, key)
+	}
+}
@@ -0,0 +1,109 @@
+// Package i18n はAPIのメッセージとメールの多言語化（日本語・英語）を行います
+//
+// エラーメッセージは日本語の文言そのものをキーとして英語のカタログを引きます
+// カタログにない文言やロケールは日本語にフォールバックします
+// メールのように長い文面は "mail.unlock.body" のような識別子をキーにします
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// 対応するロケール
+const (
+	Japanese = "ja"
+	English  = "en"
+
+	// Default はロケールが決まらない場合に使用するロケール
+	Default = Japanese
+)
+
+// catalogs はロケールごとのメッセージ
+var catalogs = map[string]map[string]string{
+	Japanese: ja,
+	English:  en,
+}
+
+// matcher はAccept-Languageとの照合に使用します（先頭が既定のロケール）
+var matcher = language.NewMatcher([]language.Tag{language.Japanese, language.English})
+
+// Supported は対応するロケールの一覧を返します
+func Supported() []string {
+	return []string{Japanese, English}
+}
+
+// IsSupported はロケールに対応しているかを返します
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Match はAccept-Languageヘッダーから最適なロケールを返します
+// 対応するロケールがない場合は Default を返します
+func Match(acceptLanguage string) string {
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+	if IsSupported(base.String()) {
+		return base.String()
+	}
+	return Default
+}
+
+// T はロケールのメッセージを返します。argsを指定した場合は書式として扱います
+// ロケールにキーがない場合は日本語、日本語にもない場合はキーそのものを使います
+// "文言: 詳細" の形式のキーは、"文言:" がカタログにあれば文言だけを翻訳します
+func T(locale, key string, args ...interface{}) string {
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		msg, ok = translatePrefix(locale, key)
+	}
+	if !ok {
+		if msg, ok = ja[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// translatePrefix は "文言: 詳細" の形式のキーの文言を翻訳します
+func translatePrefix(locale, key string) (string, bool) {
+	i := strings.Index(key, ": ")
+	if i < 0 {
+		return "", false
+	}
+	prefix, ok := catalogs[locale][key[:i+1]]
+	if !ok {
+		return "", false
+	}
+	return prefix + key[i+1:], true
+}
+
+type contextKey struct{}
+
+// WithLocale はロケールを設定したcontextを返します
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext はcontextのロケールを返します（未設定の場合は Default）
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return Default
+}
+
+// UserLocale はユーザーの設定したロケールを返します（未設定または非対応の場合は Default）
+func UserLocale(preference string) string {
+	if IsSupported(preference) {
+		return preference
+	}
+	return Default
+}
@@ -0,0 +1,194 @@
+// Package jobs はPostgreSQLをキューとして使うバックグラウンドジョブの実行基盤です
+// 複数レプリカで同時に動かしても、FOR UPDATE SKIP LOCKEDにより1つのジョブは1回だけ取り出されます
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ジョブが無いときのポーリング間隔
+	pollInterval = 2 * time.Second
+	// 実行中のままこの時間を過ぎたジョブは、ワーカーが落ちたとみなして再実行する
+	staleLockTimeout = 15 * time.Minute
+)
+
+// HandlerFunc はジョブの処理（エラーを返すと再試行されます）
+type HandlerFunc func(ctx context.Context, payload []byte) error
+
+// Enqueue はジョブを登録します
+// 業務データの更新と同じトランザクションで登録すると、コミットされた場合のみ実行されます
+// tx のcontextにスパンがある場合は、ジョブの実行をそのトレースの子として記録します
+func Enqueue(tx *gorm.DB, jobType string, payload interface{}) (*models.Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{Type: jobType, Payload: string(b)}
+	if ctx := tx.Statement.Context; ctx != nil {
+		job.TraceParent = tracing.Traceparent(ctx)
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Worker は登録されたジョブを取り出して実行します
+type Worker struct {
+	db       *gorm.DB
+	handlers map[string]HandlerFunc
+	tracer   *tracing.Tracer
+}
+
+// NewWorker は新しいWorkerインスタンスを作成します
+func NewWorker(db *gorm.DB) *Worker {
+	return &Worker{db: db, handlers: make(map[string]HandlerFunc)}
+}
+
+// SetTracer はジョブの実行ごとにスパンを作成するTracerを設定します（nil の場合は作成しない）
+func (w *Worker) SetTracer(tracer *tracing.Tracer) {
+	w.tracer = tracer
+}
+
+// Register はジョブの種類ごとの処理を登録します
+func (w *Worker) Register(jobType string, handler HandlerFunc) {
+	w.handlers[jobType] = handler
+}
+
+// Run はctxがキャンセルされるまでジョブを実行し続けます
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		ran, err := w.RunNext(ctx)
+		if err != nil {
+			slog.Error("ジョブの取得に失敗しました", "error", err)
+		}
+		if ran {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// RunNext は実行可能なジョブを1件取り出して実行します
+// 実行するジョブが無かった場合はfalseを返します
+func (w *Worker) RunNext(ctx context.Context) (bool, error) {
+	job, err := w.claim()
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// 登録したリクエストのトレースを親にする
+	ctx = tracing.ContextWithTraceparent(ctx, job.TraceParent)
+	ctx, span := w.tracer.Start(ctx, "job "+job.Type,
+		tracing.WithSpanKind(tracing.SpanKindConsumer),
+		tracing.WithAttributes(
+			tracing.Int64("job.id", int64(job.ID)),
+			tracing.String("job.type", job.Type),
+			tracing.Int("job.attempt", job.Attempts),
+		),
+	)
+	defer span.End()
+	ctx = logging.With(ctx, append([]any{"job_id", job.ID, "job_type", job.Type}, tracing.LogAttrs(ctx)...)...)
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		err := fmt.Errorf("unknown job type: %s", job.Type)
+		span.RecordError(err)
+		w.finish(ctx, job, err)
+		return true, nil
+	}
+
+	runErr := runHandler(ctx, handler, job)
+	span.RecordError(runErr)
+	w.finish(ctx, job, runErr)
+	return true, nil
+}
+
+// claim は実行可能なジョブを1件ロックして実行中にします
+func (w *Worker) claim() (*models.Job, error) {
+	var job models.Job
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)",
+				models.JobStatusPending, now, models.JobStatusRunning, now.Add(-staleLockTimeout)).
+			Order("run_at").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":    models.JobStatusRunning,
+			"locked_at": now,
+			"attempts":  gorm.Expr("attempts + 1"),
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Attempts++
+	return &job, nil
+}
+
+// runHandler はパニックをエラーに変換してジョブを実行します
+func runHandler(ctx context.Context, handler HandlerFunc, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, []byte(job.Payload))
+}
+
+// finish はジョブの実行結果を保存します
+// 失敗した場合は試行回数の上限まで、間隔を空けて再実行します
+func (w *Worker) finish(ctx context.Context, job *models.Job, runErr error) {
+	logger := logging.FromContext(ctx)
+	updates := map[string]interface{}{"locked_at": nil}
+	switch {
+	case runErr == nil:
+		updates["status"] = models.JobStatusSucceeded
+		updates["last_error"] = ""
+	case job.Attempts >= job.MaxAttempts:
+		logger.Error("ジョブが失敗しました", "error", runErr)
+		updates["status"] = models.JobStatusFailed
+		updates["last_error"] = runErr.Error()
+	default:
+		logger.Warn("ジョブを再試行します", "attempts", job.Attempts, "error", runErr)
+		updates["status"] = models.JobStatusPending
+		updates["last_error"] = runErr.Error()
+		updates["run_at"] = time.Now().Add(retryDelay(job.Attempts))
+	}
+
+	// 実行を取り消された場合も状態は保存する
+	if err := w.db.WithContext(context.WithoutCancel(ctx)).Model(job).Updates(updates).Error; err != nil {
+		logger.Error("ジョブの状態の更新に失敗しました", "error", err)
+	}
+}
+
+// retryDelay は再試行までの待ち時間（1分、4分、9分…）
+func retryDelay(attempts int) time.Duration {
+	return time.Duration(attempts*attempts) * time.Minute
+}
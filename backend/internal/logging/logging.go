@@ -0,0 +1,65 @@
+// Package logging は構造化ログ（log/slog）の設定と、リクエストごとのロガーを提供します
+//
+// 出力はすべて Redact を通し、秘密情報・メールアドレス・決済IDを伏せます
+// ハンドラーやサービスでは FromContext で取得したロガーを使用してください（リクエストID・ユーザーIDが付きます）
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+)
+
+// New は設定に従ってロガーを作成します
+func New(w io.Writer, cfg config.Log) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	switch cfg.Format {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("ログの形式が不正です: %q", cfg.Format)
+	}
+}
+
+// ParseLevel はログレベル（debug / info / warn / error）を解析します
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return 0, fmt.Errorf("ログレベルが不正です: %q", s)
+	}
+	return level, nil
+}
+
+type contextKey struct{}
+
+// NewContext はロガーを保持したcontextを返します
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext はcontextのロガーを返します（未設定の場合は slog.Default）
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// With はcontextのロガーに項目を追加したcontextを返します
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
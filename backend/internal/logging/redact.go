@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted は伏せた値の代わりに出力する文字列
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys は値を丸ごと伏せる項目名（部分一致、小文字）
+var sensitiveKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"signature",
+	"api_key",
+}
+
+// redactRule は文字列中の機密情報を置き換える規則
+type redactRule struct {
+	pattern *regexp.Regexp
+	replace func(match []string) string
+}
+
+var redactRules = []redactRule{
+	// StripeのAPIキーとWebhookシークレット
+	{
+		pattern: regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_|whsec_)[A-Za-z0-9]+`),
+		replace: func(m []string) string { return m[1] + Redacted },
+	},
+	// Stripe-Signatureヘッダー（t=...,v1=...）
+	{
+		pattern: regexp.MustCompile(`\bt=\d+,v\d=[0-9a-f]+(?:,v\d=[0-9a-f]+)*`),
+		replace: func(m []string) string { return Redacted },
+	},
+	// JWT（アクセストークン・二要素認証待ちのトークン）
+	{
+		pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		replace: func(m []string) string { return Redacted },
+	},
+	// メールアドレス（ドメインのみ残す）
+	{
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,})`),
+		replace: func(m []string) string { return "***@" + m[1] },
+	},
+	// 決済ID（接頭辞と末尾4文字のみ残す）
+	{
+		pattern: regexp.MustCompile(`\b(cs_(?:live|test)_|pi_|ch_|cus_|pm_|py_|re_|txn_|acct_|po_|tr_|in_)([A-Za-z0-9]{5,})`),
+		replace: func(m []string) string { return m[1] + "****" + m[2][len(m[2])-4:] },
+	},
+}
+
+// Redact は文字列中の秘密情報・メールアドレス・決済IDを伏せます
+func Redact(s string) string {
+	for _, rule := range redactRules {
+		s = rule.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			return rule.replace(rule.pattern.FindStringSubmatch(match))
+		})
+	}
+	return s
+}
+
+// isSensitiveKey は値を丸ごと伏せる項目名かを返します
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr は slog.HandlerOptions.ReplaceAttr として、出力する項目（メッセージを含む）を伏せます
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+		return a
+	}
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(v.Error()))
+		case interface{ String() string }:
+			return slog.String(a.Key, Redact(v.String()))
+		}
+	}
+	return a
+}
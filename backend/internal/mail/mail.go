@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+)
+
+// Message は送信するメールの内容
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer はメール送信を抽象化したインターフェース
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New は設定からMailerを作成します
+// SMTPHostが未設定の場合はログ出力のみを行うMailerを返します
+func New(cfg config.Mail) Mailer {
+	if cfg.SMTPHost == "" {
+		log.Printf("Warning: SMTP_HOST is not set, emails will only be logged")
+		return &LogMailer{}
+	}
+
+	return &SMTPMailer{
+		Addr:     fmt.Sprintf("%s:%s", cfg.SMTPHost, cfg.SMTPPort),
+		Host:     cfg.SMTPHost,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.From,
+	}
+}
+
+// SMTPMailer はSMTPサーバー経由でメールを送信します
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send はメールを送信します
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, []byte(b.String())); err != nil {
+		return fmt.Errorf("メールの送信に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// LogMailer はメールを送信せずログに出力します（開発環境用）
+type LogMailer struct{}
+
+// Send はメールの内容をログに出力します
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
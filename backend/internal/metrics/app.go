@@ -0,0 +1,74 @@
+package metrics
+
+// アプリケーション全体で共有するメトリクス（サーバーごとの Registry に登録して公開します）
+var (
+	// HTTPRequests はルートテンプレート・メソッド・ステータスごとのリクエスト数
+	HTTPRequests = NewCounterVec("oshiome_http_requests_total",
+		"HTTPリクエスト数（ルートテンプレート別）", "method", "route", "status")
+	// HTTPRequestDuration はリクエストの処理時間
+	HTTPRequestDuration = NewHistogramVec("oshiome_http_request_duration_seconds",
+		"HTTPリクエストの処理時間（秒）", nil, "method", "route")
+	// HTTPRequestsInFlight は処理中のリクエスト数
+	HTTPRequestsInFlight = NewGaugeVec("oshiome_http_requests_in_flight",
+		"処理中のHTTPリクエスト数")
+
+	// DBQueryDuration はGORMのクエリの所要時間（GormPlugin で記録）
+	DBQueryDuration = NewHistogramVec("oshiome_db_query_duration_seconds",
+		"データベースのクエリの所要時間（秒）", nil, "operation", "table")
+
+	// SupportsCreated は作成された支援（決済待ち）の数
+	SupportsCreated = NewCounterVec("oshiome_supports_created_total", "作成された支援の数")
+	// SupportsCompleted は決済が完了した支援の数
+	SupportsCompleted = NewCounterVec("oshiome_supports_completed_total", "決済が完了した支援の数")
+	// SupportsFailed は決済に失敗した支援の数
+	SupportsFailed = NewCounterVec("oshiome_supports_failed_total", "決済に失敗した支援の数")
+	// CollectedYen は決済が完了した支援の合計金額（円）
+	CollectedYen = NewCounterVec("oshiome_supports_collected_yen_total", "決済が完了した支援の合計金額（円）")
+
+	// WebhookEvents はStripeのWebhookのイベント種別・処理結果ごとの件数
+	WebhookEvents = NewCounterVec("oshiome_webhook_events_total",
+		"StripeのWebhookの受信数（イベント種別・処理結果別）", "type", "result")
+
+	// ReconciliationItems はStripeとの照合で見つかった不一致の種類・処理結果ごとの件数
+	ReconciliationItems = NewCounterVec("oshiome_reconciliation_items_total",
+		"Stripeとの照合で見つかった不一致の数（種類・処理結果別）", "kind", "result")
+)
+
+// Webhookの処理結果（WebhookEvents の result ラベル）
+const (
+	WebhookProcessed        = "processed"
+	WebhookIgnored          = "ignored"
+	WebhookInvalidSignature = "invalid_signature"
+	WebhookInvalidPayload   = "invalid_payload"
+	WebhookError            = "error"
+)
+
+// 照合の不一致の処理結果（ReconciliationItems の result ラベル）
+const (
+	ReconciliationRepaired   = "repaired"
+	ReconciliationUnresolved = "unresolved"
+)
+
+func init() {
+	// ラベルのないカウンターは起動直後から0として公開する
+	for _, c := range []*CounterVec{SupportsCreated, SupportsCompleted, SupportsFailed, CollectedYen} {
+		c.add(0, nil)
+	}
+	HTTPRequestsInFlight.Set(0)
+}
+
+// AppCollectors はアプリケーション全体で共有するメトリクスを返します
+func AppCollectors() []Collector {
+	return []Collector{
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		DBQueryDuration,
+		SupportsCreated,
+		SupportsCompleted,
+		SupportsFailed,
+		CollectedYen,
+		WebhookEvents,
+		ReconciliationItems,
+	}
+}
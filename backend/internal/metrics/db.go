@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GormPlugin はクエリの所要時間を DBQueryDuration に記録するGORMのプラグイン
+type GormPlugin struct{}
+
+const gormStartKey = "metrics:start"
+
+// Name はプラグインの名前を返します
+func (GormPlugin) Name() string { return "metrics" }
+
+// Initialize は各処理の前後にコールバックを登録します
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, p := range processors {
+		operation := p.operation
+		if err := p.before("metrics:before_"+operation, func(tx *gorm.DB) {
+			tx.InstanceSet(gormStartKey, time.Now())
+		}); err != nil {
+			return err
+		}
+		if err := p.after("metrics:after_"+operation, func(tx *gorm.DB) {
+			start, ok := tx.InstanceGet(gormStartKey)
+			if !ok {
+				return
+			}
+			table := tx.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+			DBQueryDuration.Observe(time.Since(start.(time.Time)).Seconds(), operation, table)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DBStatsCollectors はコネクションプールの状態を公開するメトリクスを返します
+func DBStatsCollectors(db *sql.DB) []Collector {
+	stats := func(f func(s sql.DBStats) []Sample) func(*http.Request) []Sample {
+		return func(*http.Request) []Sample { return f(db.Stats()) }
+	}
+	return []Collector{
+		NewGaugeFunc("oshiome_db_connections", "コネクションプールの接続数（使用中・待機中）", []string{"state"},
+			stats(func(s sql.DBStats) []Sample {
+				return []Sample{
+					{LabelValues: []string{"in_use"}, Value: float64(s.InUse)},
+					{LabelValues: []string{"idle"}, Value: float64(s.Idle)},
+				}
+			})),
+		NewGaugeFunc("oshiome_db_max_open_connections", "コネクションプールの最大接続数（0は無制限）", nil,
+			stats(func(s sql.DBStats) []Sample { return []Sample{{Value: float64(s.MaxOpenConnections)}} })),
+		NewCounterFunc("oshiome_db_wait_count_total", "接続の空きを待った回数", nil,
+			stats(func(s sql.DBStats) []Sample { return []Sample{{Value: float64(s.WaitCount)}} })),
+		NewCounterFunc("oshiome_db_wait_duration_seconds_total", "接続の空きを待った合計時間（秒）", nil,
+			stats(func(s sql.DBStats) []Sample { return []Sample{{Value: s.WaitDuration.Seconds()}} })),
+	}
+}
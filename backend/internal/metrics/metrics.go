@@ -0,0 +1,338 @@
+// Package metrics はPrometheusのテキスト形式（version 0.0.4）でメトリクスを公開します
+//
+// カウンター・ゲージ・ヒストグラムはラベルごとに値を持ち、Registry に登録した順に出力します
+// スクレイプ時に値を求めるメトリクス（DBの接続数など）は GaugeFunc を使用します
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector はメトリクスをテキスト形式で書き出します
+type Collector interface {
+	Name() string
+	Write(w *bufio.Writer, r *http.Request)
+}
+
+// Registry は公開するメトリクスの一覧
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+	names      map[string]bool
+}
+
+// NewRegistry は空のRegistryを作成します
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// MustRegister はメトリクスを登録します（同じ名前のメトリクスがある場合はパニック）
+func (r *Registry) MustRegister(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range cs {
+		if r.names[c.Name()] {
+			panic("metrics: duplicate metric " + c.Name())
+		}
+		r.names[c.Name()] = true
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+// ServeHTTP は登録したメトリクスをテキスト形式で返します
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.Write(bw, req)
+	}
+	bw.Flush()
+}
+
+// desc はメトリクスの名前・説明・ラベル名
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) Name() string { return d.name }
+
+func (d *desc) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, typ)
+}
+
+// key はラベルの値をマップのキーにします
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// writeSample は1件の値を書き出します（extraは le などの追加のラベル）
+func writeSample(w *bufio.Writer, name string, labels, values []string, extra []string, value float64) {
+	w.WriteString(name)
+	if len(labels)+len(extra) > 0 {
+		w.WriteByte('{')
+		sep := ""
+		for i, l := range labels {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, l, escapeLabel(values[i]))
+			sep = ","
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, extra[i], escapeLabel(extra[i+1]))
+			sep = ","
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// series はラベルの値ごとの値
+type series struct {
+	values []string
+	value  float64
+}
+
+// vec はラベルの値ごとの値を保持します（カウンターとゲージで共有）
+type vec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*series)}
+}
+
+func (v *vec) add(delta float64, values []string) {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	s.value += delta
+}
+
+func (v *vec) set(value float64, values []string) {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.series[key] = &series{values: append([]string(nil), values...), value: value}
+}
+
+// get はラベルの値に対応する値を返します（テスト・確認用）
+func (v *vec) get(values []string) float64 {
+	key := v.key(values)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *vec) write(w *bufio.Writer, typ string) {
+	v.writeHeader(w, typ)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		writeSample(w, v.name, v.labels, s.values, nil, s.value)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec は増加のみするカウンター
+type CounterVec struct{ vec }
+
+// NewCounterVec はカウンターを作成します（名前は _total で終わる形にしてください）
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, labels)}
+}
+
+// Inc はカウンターを1増やします
+func (c *CounterVec) Inc(labelValues ...string) { c.add(1, labelValues) }
+
+// Add はカウンターを増やします（負の値は無視します）
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta > 0 {
+		c.add(delta, labelValues)
+	}
+}
+
+// Value は現在の値を返します
+func (c *CounterVec) Value(labelValues ...string) float64 { return c.get(labelValues) }
+
+func (c *CounterVec) Write(w *bufio.Writer, _ *http.Request) { c.write(w, "counter") }
+
+// GaugeVec は増減するゲージ
+type GaugeVec struct{ vec }
+
+// NewGaugeVec はゲージを作成します
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, labels)}
+}
+
+// Add はゲージを増減します
+func (g *GaugeVec) Add(delta float64, labelValues ...string) { g.add(delta, labelValues) }
+
+// Set はゲージの値を設定します
+func (g *GaugeVec) Set(value float64, labelValues ...string) { g.set(value, labelValues) }
+
+// Value は現在の値を返します
+func (g *GaugeVec) Value(labelValues ...string) float64 { return g.get(labelValues) }
+
+func (g *GaugeVec) Write(w *bufio.Writer, _ *http.Request) { g.write(w, "gauge") }
+
+// Sample はスクレイプ時に求めた値（LabelValues はラベル名と同じ順）
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// GaugeFunc はスクレイプのたびに値を求めるゲージ（またはカウンター）
+type GaugeFunc struct {
+	desc
+	typ     string
+	collect func(r *http.Request) []Sample
+}
+
+// NewGaugeFunc はスクレイプ時に collect で値を求めるゲージを作成します
+func NewGaugeFunc(name, help string, labels []string, collect func(r *http.Request) []Sample) *GaugeFunc {
+	return &GaugeFunc{desc: desc{name: name, help: help, labels: labels}, typ: "gauge", collect: collect}
+}
+
+// NewCounterFunc はスクレイプ時に collect で値を求めるカウンターを作成します（累計値を外部が持つ場合）
+func NewCounterFunc(name, help string, labels []string, collect func(r *http.Request) []Sample) *GaugeFunc {
+	return &GaugeFunc{desc: desc{name: name, help: help, labels: labels}, typ: "counter", collect: collect}
+}
+
+func (g *GaugeFunc) Write(w *bufio.Writer, r *http.Request) {
+	g.writeHeader(w, g.typ)
+	for _, s := range g.collect(r) {
+		writeSample(w, g.name, g.labels, s.LabelValues, nil, s.Value)
+	}
+}
+
+// DefaultBuckets は所要時間（秒）のヒストグラムの既定の区切り
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec は値の分布を区切りごとの累積件数で表すヒストグラム
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // 区切りごとの件数（累積ではない）
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec はヒストグラムを作成します（buckets が nil の場合は DefaultBuckets）
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe は値を記録します
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count は記録した件数を返します
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) Write(w *bufio.Writer, _ *http.Request) {
+	h.writeHeader(w, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.values, []string{"le", formatValue(le)}, float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.values, []string{"le", "+Inf"}, float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.values, nil, s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.values, nil, float64(s.count))
+	}
+}
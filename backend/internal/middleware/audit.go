@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+)
+
+// AuditActor はIPアドレス・User-Agent・リクエストIDを監査ログの操作の主体としてリクエストのcontextに設定します
+// ユーザーIDは認証ミドルウェアが追加します。RequestLogger の後に設定してください
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("request_id"),
+			Source:    "api",
+		}))
+		c.Next()
+	}
+}
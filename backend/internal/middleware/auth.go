@@ -1,40 +1,106 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
 )
 
 // AuthMiddleware 認証ミドルウェア
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tokens *utils.TokenManager, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.Error(utils.ErrUnauthorized.WithDetail("認証ヘッダーが見つかりません"))
-			c.Abort()
+		claims, user, ok := bearerClaims(c, tokens, db)
+		if !ok {
 			return
 		}
 
-		// Bearer トークンの取得
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.Error(utils.ErrUnauthorized.WithDetail("不正な認証ヘッダーです"))
+		// 二要素認証待ちのトークンでは通常のAPIにアクセスできない
+		if claims.Type != utils.TokenTypeAccess {
+			c.Error(utils.ErrUnauthorized.WithDetail("無効なトークンです"))
 			c.Abort()
 			return
 		}
 
-		// トークンの検証
-		userID, err := utils.ValidateToken(parts[1])
-		if err != nil {
-			c.Error(utils.ErrUnauthorized.WithDetail("無効なトークンです"))
+		// 二要素認証が必須のユーザーは、登録時や管理者への変更前に発行されたトークンなど
+		// 二要素認証を経ていないトークンでは通常のAPIにアクセスできない
+		if user.RequiresTwoFactor() && !claims.MFA {
+			c.Error(utils.ErrUnauthorized.WithDetail("二要素認証を完了してください。再度ログインしてください"))
 			c.Abort()
 			return
 		}
 
 		// ユーザーIDをコンテキストに設定
-		c.Set("user_id", userID)
+		setUserID(c, claims.UserID)
+		c.Set("mfa", claims.MFA)
+		c.Next()
+	}
+}
+
+// PreAuthMiddleware 二要素認証の登録のため、二要素認証待ちのトークンも受け付ける認証ミドルウェア
+func PreAuthMiddleware(tokens *utils.TokenManager, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _, ok := bearerClaims(c, tokens, db)
+		if !ok {
+			return
+		}
+
+		setUserID(c, claims.UserID)
+		c.Set("mfa", claims.MFA)
+		c.Set("pre_auth", claims.Type == utils.TokenTypePreAuth)
 		c.Next()
 	}
 }
+
+// setUserID は認証したユーザーIDをginのコンテキスト、リクエストのロガーと監査ログの操作の主体に設定します
+func setUserID(c *gin.Context, userID uint) {
+	c.Set("user_id", userID)
+	ctx := logging.With(c.Request.Context(), "user_id", userID)
+	c.Request = c.Request.WithContext(audit.WithActorID(ctx, userID))
+}
+
+// bearerClaims Authorizationヘッダーのトークンを検証し、失敗時はリクエストを中断します
+// 退会済みのユーザーのトークンは有効期限内でも受け付けません
+// 二要素認証の要否を判定するため、トークンのユーザーのロールも返します
+func bearerClaims(c *gin.Context, tokens *utils.TokenManager, db *gorm.DB) (*utils.Claims, *models.User, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.Error(utils.ErrUnauthorized.WithDetail("認証ヘッダーが見つかりません"))
+		c.Abort()
+		return nil, nil, false
+	}
+
+	// Bearer トークンの取得
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.Error(utils.ErrUnauthorized.WithDetail("不正な認証ヘッダーです"))
+		c.Abort()
+		return nil, nil, false
+	}
+
+	// トークンの検証
+	claims, err := tokens.Parse(parts[1])
+	if err != nil {
+		c.Error(utils.ErrUnauthorized.WithDetail("無効なトークンです"))
+		c.Abort()
+		return nil, nil, false
+	}
+
+	// deleted_at が設定されたユーザーは論理削除のスコープにより見つからない
+	var user models.User
+	if err := db.WithContext(c.Request.Context()).Select("id", "role").First(&user, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(utils.ErrUnauthorized.WithDetail("無効なトークンです"))
+		} else {
+			c.Error(utils.ErrInternalServer)
+		}
+		c.Abort()
+		return nil, nil, false
+	}
+	return claims, &user, true
+}
@@ -1,28 +1,27 @@
 package middleware
 
 import (
-	"log"
-	"net/http"
+	"errors"
+	"fmt"
 	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
 	"github.com/masvc/oshiome_go/backend/internal/utils"
 )
 
-// ErrorHandler はエラーとパニックを適切なレスポンス形式に変換するミドルウェア
+// ErrorHandler はエラーとパニックを統一されたエラーレスポンス（utils.APIError）に変換するミドルウェア
+// ステータスコードはAPIErrorが持つ値を使用します
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// パニックハンドリング
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v\nStack trace:\n%s", err, debug.Stack())
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"status":  "error",
-					"code":    "INTERNAL_SERVER_ERROR",
-					"message": "予期せぬエラーが発生しました",
-				})
-				c.Abort()
-				return
+				logging.FromContext(c.Request.Context()).Error("パニックから復帰しました",
+					"panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+				apiErr := utils.ErrInternalServer.WithDetail("予期せぬエラーが発生しました")
+				c.AbortWithStatusJSON(apiErr.StatusCode(), localize(apiErr, i18n.FromContext(c.Request.Context())))
 			}
 		}()
 
@@ -36,29 +35,29 @@ func ErrorHandler() gin.HandlerFunc {
 		// 最後のエラーを取得
 		err := c.Errors.Last()
 
-		// APIErrorへの型変換を試みる
+		// APIErrorへの型変換を試みる（ラップされたエラーも含む）
 		var apiErr *utils.APIError
-		if e, ok := err.Err.(*utils.APIError); ok {
-			apiErr = e
-		} else {
-			// 未知のエラーの場合は内部サーバーエラーとして処理
-			apiErr = utils.ErrInternalServer.WithDetail(err.Error())
+		if !errors.As(err.Err, &apiErr) {
+			// 未知のエラーの場合は内部サーバーエラーとして処理（詳細はログにのみ出力）
+			logging.FromContext(c.Request.Context()).Error("想定外のエラーが発生しました",
+				"method", c.Request.Method, "path", c.Request.URL.Path, "error", err.Err)
+			apiErr = utils.ErrInternalServer
 		}
 
-		// HTTPステータスコードの決定
-		status := http.StatusInternalServerError
-		switch apiErr.Code {
-		case "INVALID_INPUT":
-			status = http.StatusBadRequest
-		case "UNAUTHORIZED":
-			status = http.StatusUnauthorized
-		case "NOT_FOUND":
-			status = http.StatusNotFound
-		case "DUPLICATE_EMAIL":
-			status = http.StatusConflict
+		// ハンドラーが既にレスポンスを書き込んでいる場合は上書きしない
+		if c.Writer.Written() {
+			return
 		}
+		c.AbortWithStatusJSON(apiErr.StatusCode(), localize(apiErr, i18n.FromContext(c.Request.Context())))
+	}
+}
 
-		c.JSON(status, apiErr)
-		c.Abort()
+// localize はエラーのメッセージをロケールに合わせて翻訳したコピーを返します
+func localize(apiErr *utils.APIError, locale string) *utils.APIError {
+	localized := *apiErr
+	localized.Message = i18n.T(locale, apiErr.Message)
+	if apiErr.Detail != "" {
+		localized.Detail = i18n.T(locale, apiErr.Detail)
 	}
+	return &localized
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Locale はAccept-Languageからレスポンスのロケールを決め、リクエストのcontextに設定します
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLocale(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// UserLocale は認証済みユーザーが表示言語を設定している場合、そのロケールを優先します
+// AuthMiddleware の後に使用してください
+func UserLocale(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		var locale string
+		if err := db.WithContext(c.Request.Context()).Model(&models.User{}).
+			Where("id = ?", userID).Pluck("locale", &locale).Error; err == nil && i18n.IsSupported(locale) {
+			setLocale(c, locale)
+		}
+		c.Next()
+	}
+}
+
+func setLocale(c *gin.Context, locale string) {
+	c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+	c.Header("Content-Language", locale)
+}
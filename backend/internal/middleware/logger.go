@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
+)
+
+// RequestIDHeader はリクエストIDのヘッダー
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID は受け付けるリクエストIDの形式（ログに出力するため文字種と長さを制限する）
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// RequestLogger はリクエストIDを付けたロガーをリクエストのcontextに設定し、
+// 処理の完了時にルート・ステータス・所要時間・ユーザーIDをログに出力するミドルウェア
+//
+// リクエストIDはX-Request-IDヘッダーの値（ない場合や形式が不正な場合は生成した値）を使用し、レスポンスにも付けます
+// Tracing の後に設定すると、ログにトレースID・スパンIDも付きます
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		reqLogger := logger.With("request_id", requestID).With(tracing.LogAttrs(c.Request.Context())...)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.ClientIP()),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		reqLogger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// newRequestID はランダムなリクエストIDを生成します
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
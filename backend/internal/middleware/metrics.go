@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// Metrics はリクエスト数・処理時間・処理中のリクエスト数を記録するミドルウェア
+// ラベルにはルートテンプレート（/api/v1/projects/:id など）を使用し、存在しないルートは unmatched にまとめます
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Add(1)
+		defer metrics.HTTPRequestsInFlight.Add(-1)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
+
+// MetricsToken は /metrics をBearerトークンで保護するミドルウェア
+func MetricsToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+			c.Error(utils.ErrUnauthorized.WithDetail("無効なトークンです"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
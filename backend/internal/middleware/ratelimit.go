@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// RateLimitScope はレート制限のキーの単位
+type RateLimitScope string
+
+const (
+	RateLimitScopeIP   RateLimitScope = "ip"
+	RateLimitScopeUser RateLimitScope = "user"
+)
+
+// RateLimitPolicy はレート制限のポリシー
+// Period内にLimit回までのリクエストを許可するトークンバケットとして扱います
+type RateLimitPolicy struct {
+	Name   string
+	Scope  RateLimitScope
+	Method string // 空の場合は全メソッド
+	Path   string // バージョンを除いたginのルートテンプレート（例: /api/projects/:id）。空の場合は全ルート
+	Limit  int
+	Period time.Duration
+}
+
+// rate は1秒あたりのトークン補充量を返します
+func (p RateLimitPolicy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// matches はリクエストがポリシーの対象かを判定します
+func (p RateLimitPolicy) matches(method, path string) bool {
+	if p.Method != "" && p.Method != method {
+		return false
+	}
+	return p.Path == "" || p.Path == path
+}
+
+// RateLimitResult はトークンバケットからの取得結果
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining float64 // 取得後に残っているトークン数
+}
+
+// RateLimitStore はトークンバケットの状態を保持するストア
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// DefaultRateLimitPolicies はRATE_LIMIT_POLICIES未設定時のポリシー
+var DefaultRateLimitPolicies = []RateLimitPolicy{
+	{Name: "global-ip", Scope: RateLimitScopeIP, Limit: 300, Period: time.Minute},
+	{Name: "global-user", Scope: RateLimitScopeUser, Limit: 600, Period: time.Minute},
+	{Name: "login", Scope: RateLimitScopeIP, Method: "POST", Path: "/api/login", Limit: 10, Period: time.Minute},
+	{Name: "login-2fa", Scope: RateLimitScopeIP, Method: "POST", Path: "/api/login/2fa", Limit: 10, Period: time.Minute},
+	{Name: "register", Scope: RateLimitScopeIP, Method: "POST", Path: "/api/register", Limit: 5, Period: time.Minute},
+	{Name: "create-support", Scope: RateLimitScopeUser, Method: "POST", Path: "/api/projects/:id/supports", Limit: 20, Period: time.Minute},
+}
+
+// rateLimitExemptPaths はレート制限の対象外とするルート
+// StripeのWebhookは再送制御をStripe側が行うため制限しない
+var rateLimitExemptPaths = map[string]bool{
+	"/api/webhook":         true,
+	"/api/webhook/connect": true,
+}
+
+// ParseRateLimitPolicies は「名前:スコープ:[メソッド パス]:回数/期間」をセミコロン区切りで並べた文字列を解析します
+// 例: "global-ip:ip::300/1m;login:ip:POST /api/login:10/1m"
+func ParseRateLimitPolicies(s string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("レート制限ポリシーの形式が不正です: %q", entry)
+		}
+
+		policy := RateLimitPolicy{
+			Name:  parts[0],
+			Scope: RateLimitScope(parts[1]),
+		}
+		if policy.Scope != RateLimitScopeIP && policy.Scope != RateLimitScopeUser {
+			return nil, fmt.Errorf("レート制限ポリシーのスコープが不正です: %q", entry)
+		}
+
+		if route := strings.Fields(parts[2]); len(route) == 2 {
+			policy.Method, policy.Path = strings.ToUpper(route[0]), route[1]
+		} else if len(route) == 1 {
+			policy.Path = route[0]
+		}
+
+		limit, period, ok := strings.Cut(parts[3], "/")
+		if !ok {
+			return nil, fmt.Errorf("レート制限ポリシーの回数/期間が不正です: %q", entry)
+		}
+		var err error
+		if policy.Limit, err = strconv.Atoi(limit); err != nil || policy.Limit <= 0 {
+			return nil, fmt.Errorf("レート制限ポリシーの回数が不正です: %q", entry)
+		}
+		if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+			return nil, fmt.Errorf("レート制限ポリシーの期間が不正です: %q", entry)
+		}
+
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// RateLimit はトークンバケット方式のレート制限ミドルウェア
+func RateLimit(store RateLimitStore, policies []RateLimitPolicy, tokens *utils.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// ポリシーはAPIのバージョンによらず適用する（/api/v1/login も /api/login として扱う）
+		path := UnversionedRoute(c.FullPath())
+		if rateLimitExemptPaths[path] {
+			c.Next()
+			return
+		}
+
+		var (
+			limiting  *RateLimitPolicy
+			remaining = math.Inf(1)
+			denied    bool
+		)
+
+		for i := range policies {
+			policy := policies[i]
+			if !policy.matches(c.Request.Method, path) {
+				continue
+			}
+
+			subject := rateLimitSubject(c, policy.Scope, tokens)
+			if subject == "" {
+				continue
+			}
+
+			result, err := store.Take(c.Request.Context(), policy.Name+":"+subject, policy)
+			if err != nil {
+				// ストア障害時はリクエストを通す（フェイルオープン）
+				logging.FromContext(c.Request.Context()).Error("レート制限ストアエラー", "policy", policy.Name, "error", err)
+				continue
+			}
+
+			// 拒否したポリシーを優先し、同じ状態なら残りが最も少ないポリシーをヘッダーで返す
+			switch {
+			case !result.Allowed && !denied:
+				limiting, remaining, denied = &policy, result.Remaining, true
+			case result.Allowed != denied && result.Remaining < remaining:
+				limiting, remaining = &policy, result.Remaining
+			}
+		}
+
+		if limiting == nil {
+			c.Next()
+			return
+		}
+
+		rate := limiting.rate()
+		reset := math.Ceil((float64(limiting.Limit) - remaining) / rate)
+		c.Header("RateLimit-Limit", strconv.Itoa(limiting.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(remaining)))))
+		c.Header("RateLimit-Reset", strconv.Itoa(int(math.Max(0, reset))))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limiting.Limit, int(limiting.Period.Seconds())))
+
+		if denied {
+			retryAfter := math.Ceil((1 - remaining) / rate)
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(1, retryAfter))))
+			c.Error(utils.ErrRateLimited)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitSubject はスコープに応じたバケットのキーを返します
+// ユーザースコープで未認証の場合は空文字を返し、IPスコープのポリシーに任せます
+func rateLimitSubject(c *gin.Context, scope RateLimitScope, tokens *utils.TokenManager) string {
+	switch scope {
+	case RateLimitScopeIP:
+		return "ip:" + c.ClientIP()
+	case RateLimitScopeUser:
+		if userID, ok := c.Get("user_id"); ok {
+			return fmt.Sprintf("user:%d", userID.(uint))
+		}
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return ""
+		}
+		userID, err := tokens.Validate(parts[1])
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 使われなくなったバケットを削除するまでの期間
+const rateLimitBucketTTL = time.Hour
+
+// MemoryRateLimitStore はプロセス内でバケットを保持するストア（単一インスタンス・開発用）
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewMemoryRateLimitStore は新しいMemoryRateLimitStoreインスタンスを作成します
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take はバケットからトークンを1つ取得します
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	capacity := float64(policy.Limit)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: capacity, updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	tokens := math.Min(capacity, b.tokens+now.Sub(b.updatedAt).Seconds()*policy.rate())
+	if tokens < 1 {
+		return RateLimitResult{Allowed: false, Remaining: tokens}, nil
+	}
+
+	b.tokens, b.updatedAt = tokens-1, now
+	return RateLimitResult{Allowed: true, Remaining: b.tokens}, nil
+}
+
+// prune は一定時間使われていないバケットを削除します
+func (s *MemoryRateLimitStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < rateLimitBucketTTL {
+		return
+	}
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) > rateLimitBucketTTL {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastPrune = now
+}
+
+// PostgresRateLimitStore はPostgreSQLでバケットを共有するストア（複数レプリカ用）
+type PostgresRateLimitStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresRateLimitStore は新しいPostgresRateLimitStoreインスタンスを作成します
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+// takeSQL はトークンの補充と取得を1文で原子的に行います
+// トークンが足りない場合は行を更新しないため、updated_at = now() かどうかで許可を判定できます
+const takeSQL = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+VALUES (@key, @capacity - 1, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = LEAST(@capacity, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate) - 1,
+	updated_at = now()
+WHERE LEAST(@capacity, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * @rate) >= 1
+RETURNING tokens`
+
+// refillSQL は拒否時の残りトークン数を取得します
+const refillSQL = `
+SELECT LEAST(@capacity, tokens + EXTRACT(EPOCH FROM now() - updated_at) * @rate)
+FROM rate_limit_buckets WHERE key = @key`
+
+// Take はバケットからトークンを1つ取得します
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	args := map[string]interface{}{
+		"key":      key,
+		"capacity": float64(policy.Limit),
+		"rate":     policy.rate(),
+	}
+
+	var tokens []float64
+	if err := s.db.WithContext(ctx).Raw(takeSQL, args).Scan(&tokens).Error; err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(tokens) == 1 {
+		return RateLimitResult{Allowed: true, Remaining: tokens[0]}, nil
+	}
+
+	// 更新されなかった（トークン不足）場合
+	var remaining float64
+	if err := s.db.WithContext(ctx).Raw(refillSQL, args).Scan(&remaining).Error; err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{Allowed: false, Remaining: remaining}, nil
+}
+
+// Prune は一定時間使われていないバケットを削除します
+func (s *PostgresRateLimitStore) Prune(ctx context.Context) error {
+	return s.db.WithContext(ctx).
+		Where("updated_at < ?", time.Now().Add(-rateLimitBucketTTL)).
+		Delete(&models.RateLimitBucket{}).Error
+}
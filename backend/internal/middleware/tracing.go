@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
+)
+
+// Tracing はリクエストごとにサーバーのスパンを作成するミドルウェア（tracer が nil の場合は何もしません）
+// traceparentヘッダーがある場合は呼び出し元のトレースを引き継ぎます
+// スパン名はルートテンプレート（GET /api/v1/projects/:id など）で、存在しないルートはメソッドのみです
+func Tracing(tracer *tracing.Tracer) gin.HandlerFunc {
+	if tracer == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracer.Start(ctx, c.Request.Method,
+			tracing.WithSpanKind(tracing.SpanKindServer),
+			tracing.WithAttributes(
+				tracing.String("http.request.method", c.Request.Method),
+				tracing.String("url.path", c.Request.URL.Path),
+				tracing.String("client.address", c.ClientIP()),
+				tracing.String("user_agent.original", c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if route := c.FullPath(); route != "" {
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttributes(tracing.String("http.route", route))
+		}
+		span.SetAttributes(tracing.Int("http.response.status_code", status))
+		if userID, ok := c.Get("user_id"); ok {
+			if id, ok := userID.(uint); ok {
+				span.SetAttributes(tracing.Int64("enduser.id", int64(id)))
+			}
+		}
+		// 4xxはクライアントの誤りのため、サーバーのスパンではエラーにしない
+		if status >= 500 {
+			message := strconv.Itoa(status)
+			if len(c.Errors) > 0 {
+				message = c.Errors.Last().Error()
+			}
+			span.SetStatus(tracing.StatusError, message)
+		}
+	}
+}
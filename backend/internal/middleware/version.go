@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// APIVersionHeader はAPIのバージョンを指定するヘッダー（リクエスト・レスポンス共通）
+const APIVersionHeader = "API-Version"
+
+// versionedAPIPath は /api/v1 のようにバージョンを含むパスの接頭辞
+var versionedAPIPath = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// NormalizeAPIVersion はバージョンの表記を "v1" の形式にそろえます（"1" も受け付けます）
+func NormalizeAPIVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+// IsVersionedAPIPath はパスがバージョンを含むか（/api/v1/... など）を返します
+func IsVersionedAPIPath(path string) bool {
+	return versionedAPIPath.MatchString(path)
+}
+
+// UnversionedRoute はルートテンプレートからバージョンを除いたパスを返します
+// 例: /api/v1/projects/:id → /api/projects/:id
+func UnversionedRoute(path string) string {
+	if loc := versionedAPIPath.FindStringSubmatchIndex(path); loc != nil {
+		return "/api" + path[loc[2]:]
+	}
+	return path
+}
+
+// APIVersion はルートグループのバージョンをレスポンスに付け、
+// API-Versionヘッダーで別のバージョンが指定された場合はエラーにします
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(APIVersionHeader, version)
+
+		if requested := c.GetHeader(APIVersionHeader); requested != "" && NormalizeAPIVersion(requested) != version {
+			c.Error(utils.ErrUnsupportedAPIVersion.WithDetail("API-Versionヘッダーで指定したバージョンはこのパスでは利用できません"))
+			c.Abort()
+			return
+		}
+
+		c.Set("api_version", version)
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JSONB はjsonbの列に保存するJSON文字列
+// APIのレスポンスでは文字列ではなくJSONとしてそのまま出力します
+type JSONB string
+
+// MarshalJSON は値をJSONとしてそのまま出力します（空の場合は {}）
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("{}"), nil
+	}
+	return []byte(j), nil
+}
+
+// AuditEvent はセキュリティや金銭に関わる操作の記録（追記のみ）
+type AuditEvent struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ActorID    *uint  `json:"actor_id" gorm:"index"`
+	Action     string `json:"action" gorm:"type:varchar(100);not null;index"`
+	TargetType string `json:"target_type" gorm:"type:varchar(50)"`
+	TargetID   uint   `json:"target_id"`
+	IP         string `json:"ip" gorm:"type:varchar(64)"`
+	UserAgent  string `json:"user_agent" gorm:"type:text"`
+	RequestID  string `json:"request_id" gorm:"type:varchar(128);not null;default:''"`
+	// Changes は変更前後の値（{"列名": {"from": 変更前, "to": 変更後}}）
+	Changes   JSONB     `json:"changes" gorm:"type:jsonb;not null;default:'{}'"`
+	Metadata  JSONB     `json:"metadata" gorm:"type:jsonb"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	if e.Metadata == "" {
+		e.Metadata = "{}"
+	}
+	if e.Changes == "" {
+		e.Changes = "{}"
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending DataExportStatus = "pending"
+	DataExportStatusReady   DataExportStatus = "ready"
+	DataExportStatusFailed  DataExportStatus = "failed"
+)
+
+// DataExport は本人の求めに応じて作成する個人データのアーカイブ
+type DataExport struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"user_id" gorm:"not null;index"`
+	Status    DataExportStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Archive   []byte           `json:"-" gorm:"type:bytea"`
+	Size      int64            `json:"size"`
+	ReadyAt   *time.Time       `json:"ready_at"`
+	ExpiresAt *time.Time       `json:"expires_at"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// IsDownloadable はアーカイブをダウンロードできる状態かを返します
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportStatusReady && e.ExpiresAt != nil && e.ExpiresAt.After(now)
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = time.Now()
+	if e.Status == "" {
+		e.Status = DataExportStatusPending
+	}
+	return nil
+}
+
+func (e *DataExport) BeforeUpdate(tx *gorm.DB) error {
+	e.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewStatus は請求書・経費の審査の状態
+type ReviewStatus string
+
+const (
+	// ReviewStatusSubmitted は提出済みで管理者の審査待ち
+	ReviewStatusSubmitted ReviewStatus = "submitted"
+	ReviewStatusApproved  ReviewStatus = "approved"
+	// ReviewStatusRejected は差し戻し（請求書は修正して再提出できる）
+	ReviewStatusRejected ReviewStatus = "rejected"
+	// ReviewStatusPaid は精算の入金が完了した
+	ReviewStatusPaid ReviewStatus = "paid"
+)
+
+// Invoice はビジョンの運営会社からプロジェクトへの掲出料の請求書（1プロジェクトに1件）
+type Invoice struct {
+	ID         uint `json:"id" gorm:"primaryKey"`
+	ProjectID  uint `json:"project_id" gorm:"not null;uniqueIndex"`
+	VisionID   uint `json:"vision_id" gorm:"not null"`
+	OperatorID uint `json:"operator_id" gorm:"not null;index"`
+	// Number は運営会社の請求書番号
+	Number      string       `json:"number" gorm:"type:varchar(100);not null"`
+	Amount      int64        `json:"amount" gorm:"not null"`
+	Description string       `json:"description" gorm:"type:text"`
+	Status      ReviewStatus `json:"status" gorm:"type:varchar(20);not null;default:'submitted';index"`
+	// RejectionReason は差し戻しの理由
+	RejectionReason string     `json:"rejection_reason" gorm:"type:text"`
+	ReviewedBy      *uint      `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	PaidAt          *time.Time `json:"paid_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Project         *Project   `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	Vision          *Vision    `json:"vision,omitempty" gorm:"foreignKey:VisionID"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = time.Now()
+	if i.Status == "" {
+		i.Status = ReviewStatusSubmitted
+	}
+	return nil
+}
+
+func (i *Invoice) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// Expense は主催者が立て替えた経費の精算の申請（運営会社への直接支払いのプロジェクトのみ）
+type Expense struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	ProjectID   uint         `json:"project_id" gorm:"not null;index"`
+	UserID      uint         `json:"user_id" gorm:"not null"`
+	Amount      int64        `json:"amount" gorm:"not null"`
+	Description string       `json:"description" gorm:"type:text;not null"`
+	Status      ReviewStatus `json:"status" gorm:"type:varchar(20);not null;default:'submitted';index"`
+	// RejectionReason は却下の理由
+	RejectionReason string     `json:"rejection_reason" gorm:"type:text"`
+	ReviewedBy      *uint      `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Expense) TableName() string {
+	return "expenses"
+}
+
+func (e *Expense) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = time.Now()
+	if e.Status == "" {
+		e.Status = ReviewStatusSubmitted
+	}
+	return nil
+}
+
+func (e *Expense) BeforeUpdate(tx *gorm.DB) error {
+	e.UpdatedAt = time.Now()
+	return nil
+}
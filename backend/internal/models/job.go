@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job はバックグラウンドで実行する処理のキュー
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type" gorm:"type:varchar(100);not null"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null"`
+	Status      JobStatus  `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_jobs_status_run_at"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null;default:5"`
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index:idx_jobs_status_run_at"`
+	LockedAt    *time.Time `json:"locked_at"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	// 登録したリクエストのトレース（W3C traceparent、トレーシングが無効の場合は空）
+	TraceParent string    `json:"-" gorm:"type:varchar(55);not null;default:''"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Job) TableName() string {
+	return "jobs"
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = time.Now()
+	if j.Status == "" {
+		j.Status = JobStatusPending
+	}
+	if j.RunAt.IsZero() {
+		j.RunAt = j.CreatedAt
+	}
+	if j.MaxAttempts == 0 {
+		j.MaxAttempts = 5
+	}
+	if j.Payload == "" {
+		j.Payload = "{}"
+	}
+	return nil
+}
+
+func (j *Job) BeforeUpdate(tx *gorm.DB) error {
+	j.UpdatedAt = time.Now()
+	return nil
+}
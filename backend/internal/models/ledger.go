@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LedgerAccount は複式簿記の勘定科目
+type LedgerAccount string
+
+const (
+	// LedgerAccountPlatformCash はプラットフォームのStripe残高（資産）
+	LedgerAccountPlatformCash LedgerAccount = "platform_cash"
+	// LedgerAccountProjectEscrow はプロジェクトの支援金の預り金（負債）
+	LedgerAccountProjectEscrow LedgerAccount = "project_escrow"
+	// LedgerAccountOrganizerPayable は主催者への未払金（支援金・経費の立替分。負債）
+	LedgerAccountOrganizerPayable LedgerAccount = "organizer_payable"
+	// LedgerAccountOperatorPayable はビジョンの運営会社への未払金（負債）
+	LedgerAccountOperatorPayable LedgerAccount = "operator_payable"
+	// LedgerAccountPlatformRevenue はプラットフォーム手数料の収入（収益）
+	LedgerAccountPlatformRevenue LedgerAccount = "platform_revenue"
+	// LedgerAccountStripeFees はStripeの決済手数料・チャージバック手数料（費用）
+	LedgerAccountStripeFees LedgerAccount = "stripe_fees"
+	// LedgerAccountRefunds は支援者への返金（預り金の控除。借方残高）
+	// 返金は預り金から直接差し引くため、マイグレーション 0018 より前の仕訳のみが使います（0018 で預り金へ振り替え済み）
+	LedgerAccountRefunds LedgerAccount = "refunds"
+	// LedgerAccountDisputes はチャージバックで引き落とされた額（LedgerAccountRefunds と同じく 0018 より前の仕訳のみ）
+	LedgerAccountDisputes LedgerAccount = "disputes"
+)
+
+// LedgerAccounts は勘定科目の一覧（試算表の表示順）
+var LedgerAccounts = []LedgerAccount{
+	LedgerAccountPlatformCash, LedgerAccountProjectEscrow, LedgerAccountRefunds, LedgerAccountDisputes,
+	LedgerAccountOrganizerPayable, LedgerAccountOperatorPayable, LedgerAccountPlatformRevenue, LedgerAccountStripeFees,
+}
+
+// DebitNormal は借方に残高が出る勘定科目（資産・費用・預り金の控除）かを返します
+func (a LedgerAccount) DebitNormal() bool {
+	switch a {
+	case LedgerAccountPlatformCash, LedgerAccountStripeFees, LedgerAccountRefunds, LedgerAccountDisputes:
+		return true
+	}
+	return false
+}
+
+// JournalKind は仕訳の原因となったお金の動き
+type JournalKind string
+
+const (
+	JournalKindSupportCompleted JournalKind = "support_completed"
+	JournalKindStripeFee        JournalKind = "stripe_fee"
+	JournalKindRefund           JournalKind = "refund"
+	// JournalKindDisputeWithdrawn はチャージバックによる引き落とし、JournalKindDisputeReinstated は勝訴による戻り
+	JournalKindDisputeWithdrawn  JournalKind = "dispute_withdrawn"
+	JournalKindDisputeReinstated JournalKind = "dispute_reinstated"
+	// JournalKindSettlement は精算の額（未払金と手数料の収入）の計上と、返金による修正
+	JournalKindSettlement JournalKind = "settlement"
+	// JournalKindTransfer は受取人のConnectアカウントへの送金
+	JournalKindTransfer JournalKind = "transfer"
+)
+
+// JournalEntry は仕訳（借方と貸方の合計が一致する仕訳明細の集まり。追記のみ）
+type JournalEntry struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Reference は仕訳の一意なキー（例: support:12:completed）。Webhookの再送などで同じ仕訳を二重に計上しない
+	Reference    string        `json:"reference" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Kind         JournalKind   `json:"kind" gorm:"type:varchar(30);not null"`
+	ProjectID    *uint         `json:"project_id" gorm:"index"`
+	SupportID    *uint         `json:"support_id"`
+	SettlementID *uint         `json:"settlement_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Lines        []JournalLine `json:"lines" gorm:"foreignKey:EntryID"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (JournalEntry) TableName() string {
+	return "journal_entries"
+}
+
+func (e *JournalEntry) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	return nil
+}
+
+// JournalLine は仕訳明細（借方・貸方のどちらか一方に金額を持つ）
+type JournalLine struct {
+	ID      uint          `json:"-" gorm:"primaryKey"`
+	EntryID uint          `json:"-" gorm:"not null;index"`
+	Account LedgerAccount `json:"account" gorm:"type:varchar(30);not null"`
+	// ProjectID はプロジェクト別の残高の集計に使用します（仕訳のプロジェクトと同じ）
+	ProjectID *uint `json:"project_id" gorm:"index"`
+	Debit     int64 `json:"debit" gorm:"not null;default:0"`
+	Credit    int64 `json:"credit" gorm:"not null;default:0"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (JournalLine) TableName() string {
+	return "journal_lines"
+}
+
+// AccountBalance は勘定科目の借方・貸方の合計と残高
+type AccountBalance struct {
+	Account LedgerAccount `json:"account"`
+	Debit   int64         `json:"debit"`
+	Credit  int64         `json:"credit"`
+	// Balance は勘定科目の残高の側（借方または貸方）から見た残高
+	Balance int64 `json:"balance"`
+}
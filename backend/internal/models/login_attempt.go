@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt はログイン試行の記録（アカウント・IPごとの失敗回数の集計に使用）
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"type:varchar(255);index"`
+	IP        string    `json:"ip" gorm:"type:varchar(64);index:idx_login_attempts_ip_created_at"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_login_attempts_ip_created_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// BeforeCreate は記録日時が指定されていない場合に現在時刻を設定します
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return nil
+}
@@ -15,6 +15,36 @@ const (
 	ProjectStatusCancelled ProjectStatus = "cancelled"
 )
 
+// ProjectStatuses はプロジェクトが取りうるステータスの一覧
+var ProjectStatuses = []ProjectStatus{
+	ProjectStatusDraft, ProjectStatusActive, ProjectStatusComplete, ProjectStatusCancelled,
+}
+
+// ProjectType は企画の種別（種別ごとに手数料のルールを設定できます）
+type ProjectType string
+
+const (
+	ProjectTypeDigitalSignage ProjectType = "digital_signage" // デジタルサイネージ（街頭ビジョンなど）
+	ProjectTypeStationAd      ProjectType = "station_ad"      // 駅広告
+	ProjectTypeOther          ProjectType = "other"
+)
+
+// ProjectTypes はプロジェクトが取りうる種別の一覧
+var ProjectTypes = []ProjectType{
+	ProjectTypeDigitalSignage, ProjectTypeStationAd, ProjectTypeOther,
+}
+
+// SettlementMode は集まった支援金の精算方法
+type SettlementMode string
+
+const (
+	// SettlementModeOrganizer は支援金から手数料を差し引いて主催者へ送金する
+	SettlementModeOrganizer SettlementMode = "organizer"
+	// SettlementModeOperator はビジョンの運営会社の請求書を支援金から直接支払う
+	// 主催者へは承認された経費の立替分のみを精算する
+	SettlementModeOperator SettlementMode = "operator"
+)
+
 type Project struct {
 	ID              uint           `json:"id" gorm:"primarykey"`
 	Title           string         `json:"title" gorm:"type:varchar(255);not null"`
@@ -24,12 +54,17 @@ type Project struct {
 	Deadline        time.Time      `json:"deadline" gorm:"not null"`
 	UserID          uint           `json:"user_id" gorm:"not null"`
 	Status          ProjectStatus  `json:"status" gorm:"type:character varying(20);default:'draft'"`
+	Type            ProjectType    `json:"type" gorm:"type:varchar(30);not null;default:'other'"`
+	SettlementMode  SettlementMode `json:"settlement_mode" gorm:"type:varchar(20);not null;default:'organizer'"`
 	ThumbnailURL    string         `json:"thumbnail_url" gorm:"type:varchar(255)"`
 	OfficeApproved  bool           `json:"office_approved" gorm:"default:true"` // true: 確認中, false: 承認済
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 	User            User           `json:"user" gorm:"foreignKey:UserID"`
+	VisionID        *uint          `json:"vision_id" gorm:"index"`
+	Vision          *Vision        `json:"vision,omitempty" gorm:"foreignKey:VisionID"`
+	Tags            []Tag          `json:"tags,omitempty" gorm:"many2many:project_tags"`
 	Supports        []Support      `json:"-" gorm:"foreignKey:ProjectID"`
 	SupportersCount int            `json:"supporters_count" gorm:"-"`
 }
@@ -45,6 +80,12 @@ func (p *Project) BeforeCreate(tx *gorm.DB) error {
 	if p.Status == "" {
 		p.Status = "draft"
 	}
+	if p.Type == "" {
+		p.Type = ProjectTypeOther
+	}
+	if p.SettlementMode == "" {
+		p.SettlementMode = SettlementModeOrganizer
+	}
 	return nil
 }
 
@@ -61,9 +102,9 @@ func (p *Project) AfterFind(tx *gorm.DB) error {
 	}
 	p.SupportersCount = int(count)
 
-	// 現在の支援金額を集計 (すでにcompletedになっている支援のみ)
+	// 現在の支援金額を集計 (すでにcompletedになっている支援のみ。一部返金された額は除く)
 	var totalAmount int64
-	if err := tx.Model(&Support{}).Select("COALESCE(SUM(amount), 0)").Where("project_id = ? AND status = ?", p.ID, SupportStatusCompleted).Scan(&totalAmount).Error; err != nil {
+	if err := tx.Model(&Support{}).Select("COALESCE(SUM(amount - refunded_amount), 0)").Where("project_id = ? AND status = ?", p.ID, SupportStatusCompleted).Scan(&totalAmount).Error; err != nil {
 		return err
 	}
 	p.CurrentAmount = totalAmount
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RateLimitBucket はレート制限のトークンバケットの状態（複数レプリカで共有）
+type RateLimitBucket struct {
+	Key       string    `gorm:"type:varchar(255);primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;index"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReceiptStatus は領収書の状態
+type ReceiptStatus string
+
+const (
+	ReceiptStatusIssued ReceiptStatus = "issued"
+	// ReceiptStatusVoid は再発行により無効になった領収書
+	ReceiptStatusVoid ReceiptStatus = "void"
+)
+
+// Receipt は完了した支援の領収書（適格請求書の記載事項を含む）
+// 発行時の内容を保存し、PDFはいつでも同じ内容で作り直します。内容を変える場合は無効にして再発行します
+type Receipt struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Number は領収書番号の連番（無効になった番号も欠番にせず、再利用しない）
+	Number    uint `json:"number" gorm:"not null;uniqueIndex"`
+	SupportID uint `json:"support_id" gorm:"not null;index"`
+	ProjectID uint `json:"project_id" gorm:"not null;index"`
+	UserID    uint `json:"user_id" gorm:"not null"`
+	// Addressee は宛名（空の場合は宛名なし）
+	Addressee    string `json:"addressee" gorm:"type:varchar(100);not null;default:''"`
+	ProjectTitle string `json:"project_title" gorm:"type:varchar(255);not null"`
+	// Amount は税込の領収額（返金された額を除く）、TaxAmount はそのうちの消費税額
+	Amount    int64 `json:"amount" gorm:"not null"`
+	TaxRate   int   `json:"tax_rate" gorm:"not null"`
+	TaxAmount int64 `json:"tax_amount" gorm:"not null"`
+	// PaidAt は取引年月日（決済が完了した日時）
+	PaidAt time.Time `json:"paid_at" gorm:"not null"`
+	// IssuerName / IssuerAddress / RegistrationNumber は発行時の発行者と適格請求書発行事業者の登録番号
+	IssuerName         string        `json:"issuer_name" gorm:"type:varchar(255);not null"`
+	IssuerAddress      string        `json:"issuer_address" gorm:"type:varchar(255);not null;default:''"`
+	RegistrationNumber string        `json:"registration_number" gorm:"type:varchar(14);not null;default:''"`
+	Status             ReceiptStatus `json:"status" gorm:"type:varchar(20);not null;default:'issued'"`
+	// ReissueOfID は再発行の元になった（無効にした）領収書
+	ReissueOfID *uint      `json:"reissue_of_id"`
+	VoidReason  string     `json:"void_reason,omitempty" gorm:"type:text"`
+	VoidedAt    *time.Time `json:"voided_at,omitempty"`
+	// CreatedAt は発行日時
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Receipt) TableName() string {
+	return "receipts"
+}
+
+func (r *Receipt) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	if r.Status == "" {
+		r.Status = ReceiptStatusIssued
+	}
+	return nil
+}
+
+// DisplayNumber は領収書に印字する番号を返します
+func (r *Receipt) DisplayNumber() string {
+	return fmt.Sprintf("R-%08d", r.Number)
+}
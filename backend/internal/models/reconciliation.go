@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationKind は照合で見つかった不一致の種類
+type ReconciliationKind string
+
+const (
+	// 自動で修正する不一致
+	// ReconciliationKindSupportCompleted は支払い済みの決済の支援が完了になっていなかった（Webhookの取りこぼし・誤った失敗）
+	ReconciliationKindSupportCompleted ReconciliationKind = "support_completed"
+	// ReconciliationKindRefundRecorded はStripeでの返金が支援に反映されていなかった
+	ReconciliationKindRefundRecorded ReconciliationKind = "refund_recorded"
+	// ReconciliationKindFeeRecorded は決済手数料が帳簿に記録されていなかった
+	ReconciliationKindFeeRecorded ReconciliationKind = "fee_recorded"
+
+	// 担当者の確認が必要な不一致
+	// ReconciliationKindUnknownPayment は支援に紐づかない支払い済みの決済
+	ReconciliationKindUnknownPayment ReconciliationKind = "unknown_payment"
+	// ReconciliationKindSupportNotFound は決済セッションのメタデータの支援が存在しない
+	ReconciliationKindSupportNotFound ReconciliationKind = "support_not_found"
+	// ReconciliationKindAmountMismatch は決済額と支援額が一致しない
+	ReconciliationKindAmountMismatch ReconciliationKind = "amount_mismatch"
+	// ReconciliationKindDuplicatePayment は別の決済で完了済みの支援に対する支払い（二重決済）
+	ReconciliationKindDuplicatePayment ReconciliationKind = "duplicate_payment"
+	// ReconciliationKindNotCharged は完了・返金済みの支援の決済が失敗・取り消しになっている
+	ReconciliationKindNotCharged ReconciliationKind = "not_charged"
+	// ReconciliationKindRefundMismatch は記録済みの返金額がStripeの返金額を上回っている
+	ReconciliationKindRefundMismatch ReconciliationKind = "refund_mismatch"
+	// ReconciliationKindFeeMismatch は帳簿の決済手数料がStripeの残高取引と一致しない
+	ReconciliationKindFeeMismatch ReconciliationKind = "fee_mismatch"
+)
+
+// ReconciliationItem は照合で見つかった不一致1件
+type ReconciliationItem struct {
+	Kind            ReconciliationKind `json:"kind"`
+	PaymentIntentID string             `json:"payment_intent_id,omitempty"`
+	SupportID       uint               `json:"support_id,omitempty"`
+	Detail          string             `json:"detail"`
+}
+
+// ReconciliationReport はStripeの決済・残高取引と支援の照合の結果（期間ごとに1件）
+type ReconciliationReport struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WindowFrom time.Time `json:"window_from" gorm:"not null;uniqueIndex:idx_reconciliation_reports_window"`
+	WindowTo   time.Time `json:"window_to" gorm:"not null;uniqueIndex:idx_reconciliation_reports_window"`
+	// Payments / BalanceTransactions は照合したStripeの決済と残高取引の数
+	Payments            int `json:"payments" gorm:"not null;default:0"`
+	BalanceTransactions int `json:"balance_transactions" gorm:"not null;default:0"`
+	// Repairs は自動で修正した不一致、Discrepancies は担当者の確認が必要な不一致
+	Repairs       []ReconciliationItem `json:"repairs" gorm:"type:jsonb;not null;serializer:json"`
+	Discrepancies []ReconciliationItem `json:"discrepancies" gorm:"type:jsonb;not null;serializer:json"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (ReconciliationReport) TableName() string {
+	return "reconciliation_reports"
+}
+
+func (r *ReconciliationReport) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	if r.Repairs == nil {
+		r.Repairs = []ReconciliationItem{}
+	}
+	if r.Discrepancies == nil {
+		r.Discrepancies = []ReconciliationItem{}
+	}
+	return nil
+}
+
+// Resolved は担当者の確認が必要な不一致がないかを返します
+func (r *ReconciliationReport) Resolved() bool {
+	return len(r.Discrepancies) == 0
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode は二要素認証のリカバリーコード（ハッシュのみ保存）
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	return nil
+}
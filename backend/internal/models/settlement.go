@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PayoutAccount は主催者の送金先（Stripe Connect Express アカウント）
+type PayoutAccount struct {
+	ID              uint   `json:"-" gorm:"primaryKey"`
+	UserID          uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	StripeAccountID string `json:"stripe_account_id" gorm:"type:varchar(255);not null;uniqueIndex"`
+	// DetailsSubmitted は本人確認・口座情報の登録（オンボーディング）を終えたか
+	DetailsSubmitted bool `json:"details_submitted" gorm:"not null;default:false"`
+	// PayoutsEnabled はStripeが銀行口座への入金を許可しているか
+	PayoutsEnabled bool      `json:"payouts_enabled" gorm:"not null;default:false"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (PayoutAccount) TableName() string {
+	return "payout_accounts"
+}
+
+func (a *PayoutAccount) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *PayoutAccount) BeforeUpdate(tx *gorm.DB) error {
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+// Ready は送金できる状態かを返します
+func (a *PayoutAccount) Ready() bool {
+	return a.DetailsSubmitted && a.PayoutsEnabled
+}
+
+// SettlementStatus は精算（主催者への送金）の状態
+type SettlementStatus string
+
+const (
+	// SettlementStatusPending は送金待ち（送金先のオンボーディングが終わっていない場合を含む）
+	SettlementStatusPending SettlementStatus = "pending"
+	// SettlementStatusInTransit はConnectアカウントへ送金し、銀行口座への入金を待っている
+	SettlementStatusInTransit SettlementStatus = "in_transit"
+	// SettlementStatusPaid は銀行口座への入金が完了した（送金額が0円の場合も含む）
+	SettlementStatusPaid SettlementStatus = "paid"
+	// SettlementStatusFailed は銀行口座への入金に失敗した（管理者が再送できます）
+	SettlementStatusFailed SettlementStatus = "failed"
+)
+
+// SettlementStatuses は精算が取りうる状態の一覧
+var SettlementStatuses = []SettlementStatus{
+	SettlementStatusPending, SettlementStatusInTransit, SettlementStatusPaid, SettlementStatusFailed,
+}
+
+// SettlementRecipient は精算の受取人
+type SettlementRecipient string
+
+const (
+	// SettlementRecipientOrganizer は主催者への支援金の送金
+	SettlementRecipientOrganizer SettlementRecipient = "organizer"
+	// SettlementRecipientOperator はビジョンの運営会社への請求書の支払い
+	SettlementRecipientOperator SettlementRecipient = "operator"
+	// SettlementRecipientReimbursement は主催者への経費の立替分の支払い
+	SettlementRecipientReimbursement SettlementRecipient = "reimbursement"
+)
+
+// Settlement は完了したプロジェクトの精算（支援総額から返金と手数料を差し引いた額からの受取人への送金）
+// プロジェクトごと・受取人ごとに1件作成します
+type Settlement struct {
+	ID          uint                `json:"id" gorm:"primaryKey"`
+	ProjectID   uint                `json:"project_id" gorm:"not null;uniqueIndex:idx_settlements_project_recipient"`
+	Recipient   SettlementRecipient `json:"recipient" gorm:"type:varchar(20);not null;default:'organizer';uniqueIndex:idx_settlements_project_recipient"`
+	UserID      uint                `json:"user_id" gorm:"not null;index"`
+	ProjectType ProjectType         `json:"project_type" gorm:"type:varchar(30);not null"`
+	// InvoiceID は支払う請求書（運営会社への支払いのみ）
+	InvoiceID *uint `json:"invoice_id"`
+	// RequestedAmount は請求書の額または承認された経費の合計（主催者への送金では0）
+	RequestedAmount int64 `json:"requested_amount" gorm:"not null;default:0"`
+	// GrossAmount は決済された支援の総額（返金前）
+	GrossAmount    int64 `json:"gross_amount" gorm:"not null"`
+	RefundedAmount int64 `json:"refunded_amount" gorm:"not null"`
+	FeeAmount      int64 `json:"fee_amount" gorm:"not null"`
+	// NetAmount は受取人への送金額（主催者への送金では GrossAmount - RefundedAmount - FeeAmount、
+	// 運営会社・経費の支払いではその額を上限とした RequestedAmount）
+	NetAmount int64 `json:"net_amount" gorm:"not null"`
+	// SupportCount は全額返金されていない支援の件数（手数料の固定額を掛けた件数）
+	SupportCount int `json:"support_count" gorm:"not null"`
+	// 適用した手数料のルール（料率はベーシスポイント。1000 = 10%）
+	FeeRateBasisPoints int   `json:"fee_rate_bps" gorm:"column:fee_rate_bps;not null"`
+	FeeFixedPerSupport int64 `json:"fee_fixed_per_support" gorm:"not null"`
+
+	Status           SettlementStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	StripeTransferID string           `json:"stripe_transfer_id" gorm:"type:varchar(255)"`
+	StripePayoutID   string           `json:"stripe_payout_id" gorm:"type:varchar(255);index"`
+	FailureReason    string           `json:"failure_reason" gorm:"type:text"`
+	PaidAt           *time.Time       `json:"paid_at"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	Project          *Project         `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Settlement) TableName() string {
+	return "settlements"
+}
+
+func (s *Settlement) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	if s.Status == "" {
+		s.Status = SettlementStatusPending
+	}
+	if s.Recipient == "" {
+		s.Recipient = SettlementRecipientOrganizer
+	}
+	return nil
+}
+
+func (s *Settlement) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
@@ -13,6 +13,8 @@ const (
 	SupportStatusCompleted SupportStatus = "completed"
 	SupportStatusFailed    SupportStatus = "failed"
 	SupportStatusCancelled SupportStatus = "cancelled"
+	// SupportStatusRefunded は全額返金された支援（一部返金の場合は completed のまま RefundedAmount のみ更新）
+	SupportStatusRefunded SupportStatus = "refunded"
 )
 
 type Support struct {
@@ -20,6 +22,7 @@ type Support struct {
 	UserID            uint          `json:"user_id"`
 	ProjectID         uint          `json:"project_id"`
 	Amount            int64         `json:"amount"`
+	RefundedAmount    int64         `json:"refunded_amount" gorm:"not null;default:0"`
 	Message           string        `json:"message"`
 	Status            SupportStatus `json:"status"`
 	PaymentIntentID   string        `json:"payment_intent_id" gorm:"type:varchar(255)"`
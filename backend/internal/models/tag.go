@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag はプロジェクトの分類（誕生日、周年記念など）
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Tag) TableName() string {
+	return "tags"
+}
+
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now()
+	return nil
+}
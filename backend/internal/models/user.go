@@ -3,7 +3,17 @@ package models
 import (
 	"time"
 
-	"github.com/jinzhu/gorm"
+	"gorm.io/gorm"
+)
+
+type UserRole string
+
+const (
+	UserRoleUser   UserRole = "user"
+	UserRoleAdmin  UserRole = "admin"
+	UserRoleAgency UserRole = "agency" // 事務所スタッフ
+	// UserRoleOperator はビジョンの運営会社（掲出料を請求し、支援金から直接支払いを受ける）
+	UserRoleOperator UserRole = "operator"
 )
 
 type User struct {
@@ -15,6 +25,34 @@ type User struct {
 	ProfileImageURL string    `gorm:"type:varchar(255)" json:"profile_image_url"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	Role            UserRole  `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	// Locale は表示言語（ja / en。空の場合はAccept-Languageに従う）
+	Locale string `gorm:"type:varchar(8);not null;default:''" json:"locale"`
+
+	// 二要素認証（TOTP）
+	TOTPSecret       string `gorm:"column:totp_secret;type:varchar(64)" json:"-"`
+	TOTPEnabled      bool   `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastUsedStep int64  `gorm:"column:totp_last_used_step;not null;default:0" json:"-"`
+
+	// ログイン失敗の追跡とアカウントロック
+	FailedLoginCount  int        `gorm:"not null;default:0" json:"-"`
+	LastFailedLoginAt *time.Time `json:"-"`
+	LockedUntil       *time.Time `json:"-"`
+	UnlockTokenHash   string     `gorm:"type:varchar(64);index" json:"-"`
+
+	// 退会（退会処理で個人情報を匿名化したうえで論理削除する）
+	DeletionRequestedAt *time.Time     `json:"-"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// RequiresTwoFactor は二要素認証の登録が必須のロールかを返します
+func (u *User) RequiresTwoFactor() bool {
+	return u.Role == UserRoleAdmin || u.Role == UserRoleAgency
+}
+
+// IsLocked はアカウントが一時的にロックされているかを返します
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
 }
 
 // TableName GORMのテーブル名を明示的に指定
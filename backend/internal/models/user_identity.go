@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity は外部プロバイダー（Google、X等）のアカウントとユーザーの連携
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Provider  string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string    `json:"email" gorm:"type:varchar(255)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// OAuthState は認可リクエストのstateに紐づく一時情報（コールバックで1回だけ使用）
+type OAuthState struct {
+	ID           uint   `gorm:"primaryKey"`
+	State        string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Provider     string `gorm:"type:varchar(50);not null"`
+	Nonce        string `gorm:"type:varchar(64);not null"`
+	CodeVerifier string `gorm:"type:varchar(128);not null"`
+	LinkUserID   *uint  // ログイン済みユーザーへの連携の場合に設定
+	// BrowserHash は認可リクエストを開始したブラウザに設定したCookieの値のハッシュ
+	BrowserHash string    `gorm:"type:varchar(64);not null;default:''"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
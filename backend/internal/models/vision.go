@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Vision は応援広告を掲出するデジタルサイネージ（街頭ビジョン）
+type Vision struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(255);not null"`
+	Location    string    `json:"location" gorm:"type:varchar(255)"`
+	Size        string    `json:"size" gorm:"type:varchar(255)"`
+	Period      string    `json:"period" gorm:"type:varchar(255)"` // 放映時間
+	Description string    `json:"description" gorm:"type:text"`
+	ImageURL    string    `json:"image_url" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// OperatorID はビジョンの運営会社のユーザー（運営会社への直接支払いで請求書を登録する）
+	OperatorID *uint `json:"operator_id" gorm:"index"`
+}
+
+// TableName GORMのテーブル名を明示的に指定
+func (Vision) TableName() string {
+	return "visions"
+}
+
+func (v *Vision) BeforeCreate(tx *gorm.DB) error {
+	v.CreatedAt = time.Now()
+	v.UpdatedAt = time.Now()
+	return nil
+}
+
+func (v *Vision) BeforeUpdate(tx *gorm.DB) error {
+	v.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package openapi はOpenAPI 3.1のドキュメントを組み立てます
+//
+// リクエスト・レスポンスのスキーマはGoの構造体からリフレクションで生成するため、
+// ハンドラーの入力構造体やモデルを変更するとドキュメントにも反映されます
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Version は出力するOpenAPIのバージョン
+const Version = "3.1.0"
+
+// Document はOpenAPIドキュメントのルート
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+
+	generator *generator
+}
+
+// Info はAPIの概要
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server はAPIの提供元
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag は操作の分類
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components は再利用するスキーマと認証方式
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme は認証方式
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Operation はエンドポイントの1操作
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter はパス・クエリ・ヘッダーのパラメーター
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody はリクエストボディ
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response はレスポンス
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header はレスポンスヘッダー
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType はコンテンツの形式ごとのスキーマ
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// New は新しいDocumentインスタンスを作成します
+func New(info Info) *Document {
+	d := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+	d.generator = newGenerator(d.Components.Schemas)
+	return d
+}
+
+// ginParam はginのパスパラメーター（:id, *path）
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Path はginのパス表記をOpenAPIの表記に変換します（/projects/:id → /projects/{id}）
+func Path(ginPath string) string {
+	return ginParam.ReplaceAllString(ginPath, "{$1}")
+}
+
+// Add はginのパスに操作を追加します。パスパラメーターは自動で定義します
+func (d *Document) Add(method, ginPath string, op *Operation) {
+	path := Path(ginPath)
+	for _, m := range ginParam.FindAllStringSubmatch(ginPath, -1) {
+		op.Parameters = append([]*Parameter{{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   pathParamSchema(m[1]),
+		}}, op.Parameters...)
+	}
+	if op.Responses == nil {
+		op.Responses = make(map[string]*Response)
+	}
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = make(map[string]*Operation)
+	}
+	d.Paths[path][strings.ToLower(method)] = op
+}
+
+// Has はginのパスとメソッドの操作が定義されているかを返します
+func (d *Document) Has(method, ginPath string) bool {
+	_, ok := d.Paths[Path(ginPath)][strings.ToLower(method)]
+	return ok
+}
+
+// Operations は定義されている操作を "METHOD /path" の形式で返します
+func (d *Document) Operations() []string {
+	var ops []string
+	for path, item := range d.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// Schema はGoの値の型からスキーマを生成します（名前付きの構造体は components/schemas への参照）
+func (d *Document) Schema(v interface{}) *Schema {
+	return d.generator.schemaOf(v)
+}
+
+// Enum は名前付きの型が取りうる値を登録します（スキーマの生成前に呼び出してください）
+func (d *Document) Enum(v interface{}, values ...interface{}) {
+	d.generator.enum(v, values)
+}
+
+// Type は型のスキーマを固定します（独自のJSON表現を持つ型に使用。スキーマの生成前に呼び出してください）
+func (d *Document) Type(v interface{}, schema *Schema) {
+	d.generator.fixed[reflect.TypeOf(v)] = schema
+}
+
+func pathParamSchema(name string) *Schema {
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return &Schema{Type: "integer", Minimum: ptr(1)}
+	}
+	return &Schema{Type: "string"}
+}
+
+// JSONBody はJSONのリクエストボディを作成します
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// JSONResponse はJSONのレスポンスを作成します
+func JSONResponse(description string, schema *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{"application/json": {Schema: schema}},
+	}
+}
@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema はJSON Schema（OpenAPI 3.1はJSON Schema 2020-12に準拠）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+}
+
+// Object はプロパティを指定したオブジェクトのスキーマを作成します
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// ArrayOf は配列のスキーマを作成します
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// String は文字列のスキーマを作成します
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+// Ref は components/schemas への参照を作成します
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator はGoの型からスキーマを生成します
+type generator struct {
+	schemas map[string]*Schema
+	enums   map[reflect.Type][]interface{}
+	fixed   map[reflect.Type]*Schema
+}
+
+func newGenerator(schemas map[string]*Schema) *generator {
+	return &generator{
+		schemas: schemas,
+		enums:   make(map[reflect.Type][]interface{}),
+		fixed:   make(map[reflect.Type]*Schema),
+	}
+}
+
+func (g *generator) enum(v interface{}, values []interface{}) {
+	g.enums[reflect.TypeOf(v)] = values
+}
+
+func (g *generator) schemaOf(v interface{}) *Schema {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if s, ok := g.fixed[t]; ok {
+		copied := *s
+		return &copied
+	}
+	if values, ok := g.enums[t]; ok {
+		s := g.basic(t)
+		s.Enum = values
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// 無名の構造体はその場で展開し、名前付きの構造体は components に登録して参照する
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			// 再帰的な参照に備えて先に登録する
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.object(t)
+		}
+		return Ref(name)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(g.schemaFor(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	default:
+		return g.basic(t)
+	}
+}
+
+func (g *generator) basic(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: ptr(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// object は構造体のフィールドをプロパティに変換します
+// jsonタグの名前を使い、bindingタグの検証ルールを制約として反映します
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		// 埋め込みの構造体はフィールドを展開する
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, s)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schemaFor(f.Type)
+		if applyBinding(prop, f.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding はbindingタグの検証ルールをスキーマに反映し、必須かを返します
+func applyBinding(s *Schema, binding string) bool {
+	if binding == "" || s.Ref != "" {
+		return strings.Contains(binding, "required")
+	}
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case s.Type == "string" && key == "min":
+				s.MinLength = &n
+			case s.Type == "string":
+				s.MaxLength = &n
+			case key == "min":
+				s.Minimum = ptr(float64(n))
+			default:
+				s.Maximum = ptr(float64(n))
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "gt":
+			if s.Format == "date-time" {
+				s.Description = "現在より後の日時"
+			}
+		}
+	}
+	return required
+}
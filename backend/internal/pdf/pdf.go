@@ -0,0 +1,215 @@
+// Package pdf は領収書などの帳票を出力するための最小限のPDF（1.4）の書き出しを提供します
+//
+// 日本語はPDFビューアーが備える和文フォント（平成角ゴシック。埋め込みなし）で表示するため、
+// 外部のライブラリやフォントファイルを必要としません。基本多言語面（BMP）外の文字は 〓 に置き換えます
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4縦の大きさ（ポイント）
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// 和文フォント（UniJIS-UCS2-HW-H は ASCII を半角の字形 CID 231〜 に対応させる）
+const (
+	fontName     = "HeiseiKakuGo-W5"
+	fontEncoding = "UniJIS-UCS2-HW-H"
+)
+
+// Document はPDFの文書
+type Document struct {
+	// Title / Author / Created は文書のプロパティ（Created がゼロ値の場合は出力しません）
+	Title   string
+	Author  string
+	Created time.Time
+	pages   []*Page
+}
+
+// New は新しい文書を作成します
+func New() *Document {
+	return &Document{}
+}
+
+// Page はA4縦のページ。座標はページの左上を原点とし、下方向を正とするポイントで指定します
+type Page struct {
+	content bytes.Buffer
+}
+
+// AddPage はページを追加します
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// TextWidth は文字列を size ポイントで描いたときの幅を返します（半角は全角の半分の幅）
+func TextWidth(s string, size float64) float64 {
+	var units int
+	for _, r := range s {
+		if r < 0x80 || (r >= 0xFF61 && r <= 0xFF9F) {
+			units += 500
+		} else {
+			units += 1000
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// Text は (x, y) をベースラインの左端として文字列を描きます
+func (p *Page) Text(x, y, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td %s Tj ET\n", num(size), num(x), num(PageHeight-y), encode(s))
+}
+
+// TextRight は右端を x に揃えて文字列を描きます
+func (p *Page) TextRight(x, y, size float64, s string) {
+	p.Text(x-TextWidth(s, size), y, size, s)
+}
+
+// TextCenter は中央を x に揃えて文字列を描きます
+func (p *Page) TextCenter(x, y, size float64, s string) {
+	p.Text(x-TextWidth(s, size)/2, y, size, s)
+}
+
+// Line は (x1, y1) から (x2, y2) へ線を引きます
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Rect は左上が (x, y) の矩形の枠を描きます
+func (p *Page) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s %s %s re S\n", num(width), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// FillRect は左上が (x, y) の矩形を灰色（0 が黒、1 が白）で塗ります
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "%s g %s %s %s %s re f 0 g\n", num(gray), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// WriteTo は文書をPDFとして書き出します
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &writer{}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// 1: カタログ、2: ページツリー、3〜5: フォント、6: 文書情報、7〜: ページと内容
+	const firstPage = 7
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+	out.object("<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	out.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /%s /DescendantFonts [4 0 R] >>",
+		fontName, fontEncoding))
+	out.object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> "+
+		"/FontDescriptor 5 0 R /DW 1000 /W [231 389 500] >>", fontName))
+	out.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [-92 -250 1010 922] "+
+		"/ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>", fontName))
+	out.object(d.info())
+
+	for i, p := range d.pages {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(p.content.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		out.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), firstPage+i*2+1))
+		out.object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.buf.Len()
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+	out.printf("trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, xref)
+
+	n, err := w.Write(out.buf.Bytes())
+	return int64(n), err
+}
+
+// info は文書情報の辞書を返します
+func (d *Document) info() string {
+	entries := []string{"/Producer (oshiome)"}
+	if d.Title != "" {
+		entries = append(entries, "/Title "+encodeInfo(d.Title))
+	}
+	if d.Author != "" {
+		entries = append(entries, "/Author "+encodeInfo(d.Author))
+	}
+	if !d.Created.IsZero() {
+		_, offset := d.Created.Zone()
+		sign := "+"
+		if offset < 0 {
+			sign, offset = "-", -offset
+		}
+		entries = append(entries, fmt.Sprintf("/CreationDate (D:%s%s%02d'%02d')",
+			d.Created.Format("20060102150405"), sign, offset/3600, offset%3600/60))
+	}
+	return "<< " + strings.Join(entries, " ") + " >>"
+}
+
+// writer は書き出したオブジェクトの位置（相互参照表に使用）を記録します
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&w.buf, format, args...)
+}
+
+// object は次の番号の間接オブジェクトを書き出します
+func (w *writer) object(body string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	w.printf("%d 0 obj\n%s\nendobj\n", len(w.offsets), body)
+}
+
+// encode は文字列を和文フォントの文字コード（UTF-16BE）の16進文字列にします
+func encode(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if r > 0xFFFF {
+			r = '〓'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// encodeInfo は文書情報の文字列を UTF-16BE（BOM付き）の16進文字列にします
+func encodeInfo(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// num は座標・大きさを小数点以下2桁までの文字列にします
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,134 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth/oidc"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// 退会したユーザーの表示名
+const deletedUserName = "退会済みユーザー"
+
+// handleAccountDeletion はユーザーの個人情報を匿名化して論理削除します
+// 支援の記録は会計上保存が必要なため、金額・決済IDは残して応援メッセージのみ消去します
+func (s *Service) handleAccountDeletion(ctx context.Context, payload []byte) error {
+	var p accountDeletionPayload
+	if err := decodePayload(payload, &p); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, p.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 処理済み
+			return nil
+		}
+		return err
+	}
+	email, name, locale := user.Email, user.Name, i18n.UserLocale(user.Locale)
+
+	// 匿名化による変更は差分を残さず、user.account_deleted のみ記録する
+	err := s.db.WithContext(audit.SkipTracking(ctx)).Transaction(func(tx *gorm.DB) error {
+		// 受付後にプロジェクトが公開された場合は退会を取り消す
+		if err := checkActiveProjects(tx, user.ID); err != nil {
+			return err
+		}
+		return s.anonymize(tx, &user, p.IP)
+	})
+	if errors.Is(err, ErrActiveProjects) {
+		if err := s.db.Model(&user).Update("deletion_requested_at", nil).Error; err != nil {
+			return err
+		}
+		s.notify(ctx, mail.Message{
+			To:      email,
+			Subject: i18n.T(locale, "mail.deletion_blocked.subject"),
+			Body:    i18n.T(locale, "mail.deletion_blocked.body", name, s.frontendURL),
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.notify(ctx, mail.Message{
+		To:      email,
+		Subject: i18n.T(locale, "mail.deletion_completed.subject"),
+		Body:    i18n.T(locale, "mail.deletion_completed.body", name),
+	})
+	return nil
+}
+
+// anonymize はユーザーに紐づく個人情報を消去します
+func (s *Service) anonymize(tx *gorm.DB, user *models.User, ip string) error {
+	// パスワードはランダムな値にしてログインできなくする
+	random, err := oidc.RandomString()
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := s.hasher.Hash(random)
+	if err != nil {
+		return err
+	}
+
+	// 匿名化するとメールアドレスが置き換わるため、ログイン試行の削除用に元の値を控えておく
+	originalEmail := user.Email
+	now := time.Now()
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"email":                fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+		"password":             hashedPassword,
+		"name":                 deletedUserName,
+		"bio":                  "",
+		"profile_image_url":    "",
+		"totp_secret":          "",
+		"totp_enabled":         false,
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+		"unlock_token_hash":    "",
+		"deleted_at":           now,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Support{}).Where("user_id = ?", user.ID).
+		Update("message", "").Error; err != nil {
+		return err
+	}
+
+	// 公開前のプロジェクトは削除し、終了済みのプロジェクトは支援者の記録として残す
+	if err := tx.Where("user_id = ? AND status = ?", user.ID, models.ProjectStatusDraft).
+		Delete(&models.Project{}).Error; err != nil {
+		return err
+	}
+
+	deletes := []struct {
+		model interface{}
+		query string
+		arg   interface{}
+	}{
+		{&models.UserIdentity{}, "user_id = ?", user.ID},
+		{&models.RecoveryCode{}, "user_id = ?", user.ID},
+		{&models.OAuthState{}, "link_user_id = ?", user.ID},
+		{&models.DataExport{}, "user_id = ?", user.ID},
+		{&models.LoginAttempt{}, "email = ?", originalEmail},
+	}
+	for _, d := range deletes {
+		if err := tx.Where(d.query, d.arg).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+
+	// 過去の監査ログに残るプロフィールの変更前後の値も消去する
+	if err := audit.EraseChanges(tx, "user", user.ID); err != nil {
+		return err
+	}
+	return recordAudit(tx, user.ID, audit.ActionAccountDeleted, ip)
+}
@@ -0,0 +1,226 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// exportedSupport はエクスポートする支援の情報
+type exportedSupport struct {
+	ID           uint      `json:"id"`
+	ProjectID    uint      `json:"project_id"`
+	ProjectTitle string    `json:"project_title"`
+	Amount       int64     `json:"amount"`
+	Message      string    `json:"message"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// exportedProject はエクスポートするプロジェクトの情報
+type exportedProject struct {
+	ID           uint      `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	TargetAmount int64     `json:"target_amount"`
+	Deadline     time.Time `json:"deadline"`
+	Status       string    `json:"status"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// exportedProfile はエクスポートするプロフィール
+type exportedProfile struct {
+	ID              uint      `json:"id"`
+	Email           string    `json:"email"`
+	Name            string    `json:"name"`
+	Bio             string    `json:"bio"`
+	ProfileImageURL string    `json:"profile_image_url"`
+	Role            string    `json:"role"`
+	TOTPEnabled     bool      `json:"totp_enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// exportedIdentity はエクスポートする外部アカウントの連携情報
+type exportedIdentity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportedLogin はエクスポートするログイン履歴
+type exportedLogin struct {
+	IP        string    `json:"ip"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleDataExport はアーカイブを作成し、完了をメールで通知します
+func (s *Service) handleDataExport(ctx context.Context, payload []byte) error {
+	var p dataExportPayload
+	if err := decodePayload(payload, &p); err != nil {
+		return err
+	}
+
+	var export models.DataExport
+	if err := s.db.First(&export, p.ExportID).Error; err != nil {
+		return err
+	}
+	if export.Status != models.DataExportStatusPending {
+		return nil
+	}
+
+	var user models.User
+	if err := s.db.First(&user, export.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// エクスポートの作成前に退会した
+			return s.db.Model(&export).Update("status", models.DataExportStatusFailed).Error
+		}
+		return err
+	}
+
+	archive, err := s.buildArchive(&user)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(exportTTL)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&export).Updates(map[string]interface{}{
+			"status":     models.DataExportStatusReady,
+			"archive":    archive,
+			"size":       len(archive),
+			"ready_at":   now,
+			"expires_at": expiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		// 古いアーカイブは不要になるため削除する
+		if err := tx.Where("user_id = ? AND id <> ?", user.ID, export.ID).
+			Delete(&models.DataExport{}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, user.ID, audit.ActionDataExported, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	locale := i18n.UserLocale(user.Locale)
+	s.notify(ctx, mail.Message{
+		To:      user.Email,
+		Subject: i18n.T(locale, "mail.export_ready.subject"),
+		Body:    i18n.T(locale, "mail.export_ready.body", user.Name, expiresAt.Format(i18n.T(locale, "format.datetime")), s.frontendURL),
+	})
+	return nil
+}
+
+// buildArchive はユーザーの個人データをJSONファイルにまとめたzipを作成します
+func (s *Service) buildArchive(user *models.User) ([]byte, error) {
+	var supports []models.Support
+	if err := s.db.Preload("Project").Where("user_id = ?", user.ID).
+		Order("created_at").Find(&supports).Error; err != nil {
+		return nil, err
+	}
+	exportedSupports := make([]exportedSupport, 0, len(supports))
+	for _, sp := range supports {
+		exportedSupports = append(exportedSupports, exportedSupport{
+			ID:           sp.ID,
+			ProjectID:    sp.ProjectID,
+			ProjectTitle: sp.Project.Title,
+			Amount:       sp.Amount,
+			Message:      sp.Message,
+			Status:       string(sp.Status),
+			CreatedAt:    sp.CreatedAt,
+		})
+	}
+
+	var projects []models.Project
+	if err := s.db.Where("user_id = ?", user.ID).Order("created_at").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	exportedProjects := make([]exportedProject, 0, len(projects))
+	for _, pj := range projects {
+		exportedProjects = append(exportedProjects, exportedProject{
+			ID:           pj.ID,
+			Title:        pj.Title,
+			Description:  pj.Description,
+			TargetAmount: pj.TargetAmount,
+			Deadline:     pj.Deadline,
+			Status:       string(pj.Status),
+			ThumbnailURL: pj.ThumbnailURL,
+			CreatedAt:    pj.CreatedAt,
+		})
+	}
+
+	var identities []models.UserIdentity
+	if err := s.db.Where("user_id = ?", user.ID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	exportedIdentities := make([]exportedIdentity, 0, len(identities))
+	for _, id := range identities {
+		exportedIdentities = append(exportedIdentities, exportedIdentity{
+			Provider:  id.Provider,
+			Email:     id.Email,
+			CreatedAt: id.CreatedAt,
+		})
+	}
+
+	var attempts []models.LoginAttempt
+	if err := s.db.Where("email = ?", user.Email).Order("created_at").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	logins := make([]exportedLogin, 0, len(attempts))
+	for _, a := range attempts {
+		logins = append(logins, exportedLogin{IP: a.IP, Success: a.Success, CreatedAt: a.CreatedAt})
+	}
+
+	// コメント・お気に入りは機能の追加時にここへ含める
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", exportedProfile{
+			ID:              user.ID,
+			Email:           user.Email,
+			Name:            user.Name,
+			Bio:             user.Bio,
+			ProfileImageURL: user.ProfileImageURL,
+			Role:            string(user.Role),
+			TOTPEnabled:     user.TOTPEnabled,
+			CreatedAt:       user.CreatedAt,
+		}},
+		{"supports.json", exportedSupports},
+		{"projects.json", exportedProjects},
+		{"identities.json", exportedIdentities},
+		{"login_history.json", logins},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
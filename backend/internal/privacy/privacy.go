@@ -0,0 +1,151 @@
+// Package privacy は個人情報保護法に基づく本人からの請求（データの開示・退会による削除）を処理します
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/jobs"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ジョブの種類
+const (
+	JobDataExport      = "privacy.data_export"
+	JobAccountDeletion = "privacy.account_deletion"
+)
+
+// エクスポートしたアーカイブのダウンロード期限
+const exportTTL = 7 * 24 * time.Hour
+
+// ErrActiveProjects は公開中のプロジェクトがあり退会できない場合のエラー
+var ErrActiveProjects = errors.New("privacy: user owns active projects")
+
+// Service はデータのエクスポートと退会処理を行います
+type Service struct {
+	db          *gorm.DB
+	mailer      mail.Mailer
+	hasher      *utils.PasswordHasher
+	frontendURL string
+}
+
+// NewService は新しいServiceインスタンスを作成します
+// frontendURLはメールに記載するリンクに使用します
+func NewService(db *gorm.DB, mailer mail.Mailer, hasher *utils.PasswordHasher, frontendURL string) *Service {
+	return &Service{db: db, mailer: mailer, hasher: hasher, frontendURL: frontendURL}
+}
+
+// Register はジョブの処理をWorkerに登録します
+func (s *Service) Register(w *jobs.Worker) {
+	w.Register(JobDataExport, s.handleDataExport)
+	w.Register(JobAccountDeletion, s.handleAccountDeletion)
+}
+
+type dataExportPayload struct {
+	ExportID uint `json:"export_id"`
+}
+
+type accountDeletionPayload struct {
+	UserID uint   `json:"user_id"`
+	IP     string `json:"ip"`
+}
+
+// RequestExport はエクスポートを受け付けます
+// 作成中または有効期限内のエクスポートがある場合はそれを返し、新たには作成しません
+func (s *Service) RequestExport(ctx context.Context, userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 同時リクエストで二重に作成しないようにユーザー行をロックする
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", userID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("user_id = ? AND (status = ? OR (status = ? AND expires_at > ?))",
+			userID, models.DataExportStatusPending, models.DataExportStatusReady, time.Now()).
+			Order("created_at DESC").
+			First(&export).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		export = models.DataExport{UserID: userID}
+		if err := tx.Create(&export).Error; err != nil {
+			return err
+		}
+		_, err = jobs.Enqueue(tx, JobDataExport, dataExportPayload{ExportID: export.ID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// RequestDeletion は退会を受け付けます
+// 公開中のプロジェクトがある場合はErrActiveProjectsを返します
+func (s *Service) RequestDeletion(ctx context.Context, userID uint, ip string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkActiveProjects(tx, userID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("deletion_requested_at", now).Error; err != nil {
+			return err
+		}
+		_, err := jobs.Enqueue(tx, JobAccountDeletion, accountDeletionPayload{UserID: userID, IP: ip})
+		return err
+	})
+}
+
+// checkActiveProjects は公開中のプロジェクトを所有していないかを確認します
+func checkActiveProjects(tx *gorm.DB, userID uint) error {
+	var count int64
+	if err := tx.Model(&models.Project{}).
+		Where("user_id = ? AND status = ?", userID, models.ProjectStatusActive).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrActiveProjects
+	}
+	return nil
+}
+
+// notify はメールを送信します（送信の失敗でジョブを失敗させない）
+func (s *Service) notify(ctx context.Context, msg mail.Message) {
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logging.FromContext(ctx).Error("通知メールの送信に失敗しました", "error", err)
+	}
+}
+
+// recordAudit は監査ログを記録します
+func recordAudit(tx *gorm.DB, userID uint, action, ip string) error {
+	return audit.Record(tx, audit.Entry{
+		ActorID:    &userID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		IP:         ip,
+	})
+}
+
+// decodePayload はジョブのペイロードを読み込みます
+func decodePayload(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Channel はプロジェクトイベントを配信するPostgreSQLの通知チャンネル名
+const Channel = "project_events"
+
+// 再接続までの待機時間
+const reconnectDelay = 5 * time.Second
+
+// 購読者ごとのバッファサイズ（溢れた場合は古いクライアントとみなしてイベントを破棄）
+const subscriberBuffer = 16
+
+// Cheer は支援時に寄せられた公開応援メッセージ
+type Cheer struct {
+	SupportID uint      `json:"support_id"`
+	UserName  string    `json:"user_name"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectEvent はプロジェクトの支援状況を表すイベント
+type ProjectEvent struct {
+	ProjectID       uint   `json:"project_id"`
+	CurrentAmount   int64  `json:"current_amount"`
+	SupportersCount int    `json:"supporters_count"`
+	Cheer           *Cheer `json:"cheer,omitempty"`
+}
+
+// notification はNOTIFYのペイロード（8000バイト制限のためIDのみを送る）
+type notification struct {
+	ProjectID uint `json:"project_id"`
+	SupportID uint `json:"support_id"`
+}
+
+// Hub はLISTEN/NOTIFYで受け取ったイベントを接続中のクライアントへ配信します
+type Hub struct {
+	db          *gorm.DB
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan ProjectEvent]struct{}
+	closed      bool
+}
+
+// NewHub は新しいHubインスタンスを作成します
+func NewHub(db *gorm.DB) *Hub {
+	return &Hub{
+		db:          db,
+		subscribers: make(map[uint]map[chan ProjectEvent]struct{}),
+	}
+}
+
+// Subscribe はプロジェクトのイベントを購読し、購読解除用の関数を返します
+// Close の後に購読した場合は閉じたチャネルを返します
+func (h *Hub) Subscribe(projectID uint) (<-chan ProjectEvent, func()) {
+	ch := make(chan ProjectEvent, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subscribers[projectID] == nil {
+		h.subscribers[projectID] = make(map[chan ProjectEvent]struct{})
+	}
+	h.subscribers[projectID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			// Close で閉じ済みの場合は何もしない
+			if _, ok := h.subscribers[projectID][ch]; !ok {
+				return
+			}
+			delete(h.subscribers[projectID], ch)
+			if len(h.subscribers[projectID]) == 0 {
+				delete(h.subscribers, projectID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Close はすべての購読を終了します（シャットダウン時にSSEの接続を閉じるため）
+// 購読者はバッファに残ったイベントを受け取った後、チャネルが閉じられたことを検知します
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for projectID, subs := range h.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(h.subscribers, projectID)
+	}
+}
+
+// hasSubscribers はプロジェクトの購読者が存在するかを返します
+func (h *Hub) hasSubscribers(projectID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[projectID]) > 0
+}
+
+// broadcast はプロジェクトの購読者全員にイベントを送信します
+func (h *Hub) broadcast(ctx context.Context, event ProjectEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[event.ProjectID] {
+		select {
+		case ch <- event:
+		default:
+			logging.FromContext(ctx).Warn("SSE購読者のバッファが溢れたためイベントを破棄しました", "project_id", event.ProjectID)
+		}
+	}
+}
+
+// Listen はPostgreSQLの通知を購読し、切断時は再接続を繰り返します
+func (h *Hub) Listen(ctx context.Context, dsn string) {
+	for {
+		err := h.listen(ctx, dsn)
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("通知リスナーが切断されました。再接続します", "error", err)
+
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listen は1本の接続でLISTENし、通知を処理します
+func (h *Hub) listen(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		h.dispatch(ctx, n.Payload)
+	}
+}
+
+// dispatch は通知ペイロードから最新の支援状況を読み込み配信します
+func (h *Hub) dispatch(ctx context.Context, payload string) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		logging.FromContext(ctx).Warn("不正な通知ペイロードです", "error", err)
+		return
+	}
+
+	// このレプリカに購読者がいなければ集計クエリを省略
+	if !h.hasSubscribers(n.ProjectID) {
+		return
+	}
+
+	event, err := LoadSnapshot(h.db, n.ProjectID)
+	if err != nil {
+		logging.FromContext(ctx).Error("支援状況の取得に失敗しました", "project_id", n.ProjectID, "error", err)
+		return
+	}
+
+	if n.SupportID != 0 {
+		var support models.Support
+		if err := h.db.Preload("User").First(&support, n.SupportID).Error; err == nil && support.Message != "" {
+			cheer := &Cheer{
+				SupportID: support.ID,
+				Message:   support.Message,
+				CreatedAt: support.CreatedAt,
+			}
+			if support.User != nil {
+				cheer.UserName = support.User.Name
+			}
+			event.Cheer = cheer
+		}
+	}
+
+	h.broadcast(ctx, event)
+}
+
+// LoadSnapshot はプロジェクトの現在の支援状況を取得します
+func LoadSnapshot(db *gorm.DB, projectID uint) (ProjectEvent, error) {
+	// 支援総額と支援者数はProject.AfterFindで集計される
+	var project models.Project
+	if err := db.First(&project, projectID).Error; err != nil {
+		return ProjectEvent{}, err
+	}
+	return ProjectEvent{
+		ProjectID:       project.ID,
+		CurrentAmount:   project.CurrentAmount,
+		SupportersCount: project.SupportersCount,
+	}, nil
+}
+
+// NotifySupportCompleted は支援完了を通知します
+// トランザクション内で呼び出した場合、通知はコミット時に配信されます
+func NotifySupportCompleted(tx *gorm.DB, projectID, supportID uint) error {
+	payload, err := json.Marshal(notification{ProjectID: projectID, SupportID: supportID})
+	if err != nil {
+		return err
+	}
+	if err := tx.Exec("SELECT pg_notify(?, ?)", Channel, string(payload)).Error; err != nil {
+		return fmt.Errorf("支援完了の通知に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Notifier は支援完了をNOTIFYで全レプリカのHubへ配信します
+type Notifier struct {
+	db *gorm.DB
+}
+
+// NewNotifier は新しいNotifierインスタンスを作成します
+func NewNotifier(db *gorm.DB) *Notifier {
+	return &Notifier{db: db}
+}
+
+// SupportCompleted は支援完了を通知します
+func (n *Notifier) SupportCompleted(ctx context.Context, projectID, supportID uint) error {
+	return NotifySupportCompleted(n.db.WithContext(ctx), projectID, supportID)
+}
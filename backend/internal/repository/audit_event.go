@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditEventFilter は監査ログの絞り込み条件（ゼロ値の項目は条件にしない）
+type AuditEventFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       time.Time
+	To         time.Time
+	// BeforeID より前（古い）の記録のみ返します（ページング用）
+	BeforeID uint
+	Limit    int
+}
+
+// AuditEventRepository は監査ログの参照と保持期間による削除
+// 記録は audit パッケージ（GORMのコールバックを含む）が行います
+type AuditEventRepository interface {
+	// List は新しい順に監査ログを返します
+	List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, error)
+	// DeleteBefore は指定時刻より前の監査ログを削除し、件数を返します
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+func (r *auditEventRepository) List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Order("id DESC")
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var events []models.AuditEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, translate(err)
+	}
+	return events, nil
+}
+
+func (r *auditEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	// 監査ログの削除はトリガーで拒否されるため、削除を許可された関数を経由する
+	var deleted int64
+	if err := r.db.WithContext(ctx).Raw("SELECT prune_audit_events(?)", before).Scan(&deleted).Error; err != nil {
+		return 0, translate(err)
+	}
+	return deleted, nil
+}
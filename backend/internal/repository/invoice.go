@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// VisionRepository はビジョンの永続化
+type VisionRepository interface {
+	Get(ctx context.Context, id uint) (*models.Vision, error)
+	// SetOperator はビジョンの運営会社を設定します
+	SetOperator(ctx context.Context, vision *models.Vision, operatorID uint) error
+}
+
+type visionRepository struct {
+	db *gorm.DB
+}
+
+func (r *visionRepository) Get(ctx context.Context, id uint) (*models.Vision, error) {
+	var vision models.Vision
+	if err := r.db.WithContext(ctx).First(&vision, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &vision, nil
+}
+
+func (r *visionRepository) SetOperator(ctx context.Context, vision *models.Vision, operatorID uint) error {
+	return translate(r.db.WithContext(ctx).Model(vision).Update("operator_id", operatorID).Error)
+}
+
+// InvoiceFilter は請求書一覧の絞り込み条件（ゼロ値の項目は条件にしない）
+type InvoiceFilter struct {
+	OperatorID uint
+	Status     models.ReviewStatus
+}
+
+// InvoiceRepository はビジョンの運営会社の請求書の永続化
+type InvoiceRepository interface {
+	// Get はプロジェクトとビジョンを読み込んだ請求書を返します
+	Get(ctx context.Context, id uint) (*models.Invoice, error)
+	GetByProject(ctx context.Context, projectID uint) (*models.Invoice, error)
+	// List はプロジェクトとビジョンを読み込んだ請求書を新しい順に返します
+	List(ctx context.Context, filter InvoiceFilter) ([]models.Invoice, error)
+	// Create は請求書を作成します（プロジェクトの請求書が作成済みの場合は ErrDuplicate）
+	Create(ctx context.Context, invoice *models.Invoice) error
+	Update(ctx context.Context, invoice *models.Invoice, updates map[string]interface{}) error
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func (r *invoiceRepository) Get(ctx context.Context, id uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).Preload("Project").Preload("Vision").First(&invoice, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) GetByProject(ctx context.Context, projectID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).First(&invoice).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) List(ctx context.Context, filter InvoiceFilter) ([]models.Invoice, error) {
+	query := r.db.WithContext(ctx).Preload("Project").Preload("Vision").Order("id DESC")
+	if filter.OperatorID != 0 {
+		query = query.Where("operator_id = ?", filter.OperatorID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Find(&invoices).Error; err != nil {
+		return nil, translate(err)
+	}
+	return invoices, nil
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *models.Invoice) error {
+	return translate(r.db.WithContext(ctx).Create(invoice).Error)
+}
+
+func (r *invoiceRepository) Update(ctx context.Context, invoice *models.Invoice, updates map[string]interface{}) error {
+	return translate(r.db.WithContext(ctx).Model(invoice).Updates(updates).Error)
+}
+
+// ExpenseFilter は経費一覧の絞り込み条件（ゼロ値の項目は条件にしない）
+type ExpenseFilter struct {
+	ProjectID uint
+	Status    models.ReviewStatus
+}
+
+// ExpenseRepository は主催者の経費の立替の申請の永続化
+type ExpenseRepository interface {
+	Get(ctx context.Context, id uint) (*models.Expense, error)
+	// List は経費を申請の古い順に返します
+	List(ctx context.Context, filter ExpenseFilter) ([]models.Expense, error)
+	Create(ctx context.Context, expense *models.Expense) error
+	Update(ctx context.Context, expense *models.Expense, updates map[string]interface{}) error
+}
+
+type expenseRepository struct {
+	db *gorm.DB
+}
+
+func (r *expenseRepository) Get(ctx context.Context, id uint) (*models.Expense, error) {
+	var expense models.Expense
+	if err := r.db.WithContext(ctx).First(&expense, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &expense, nil
+}
+
+func (r *expenseRepository) List(ctx context.Context, filter ExpenseFilter) ([]models.Expense, error) {
+	query := r.db.WithContext(ctx).Order("id")
+	if filter.ProjectID != 0 {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var expenses []models.Expense
+	if err := query.Find(&expenses).Error; err != nil {
+		return nil, translate(err)
+	}
+	return expenses, nil
+}
+
+func (r *expenseRepository) Create(ctx context.Context, expense *models.Expense) error {
+	return translate(r.db.WithContext(ctx).Create(expense).Error)
+}
+
+func (r *expenseRepository) Update(ctx context.Context, expense *models.Expense, updates map[string]interface{}) error {
+	return translate(r.db.WithContext(ctx).Model(expense).Updates(updates).Error)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LedgerRepository は複式簿記の仕訳の永続化（追記のみ）
+type LedgerRepository interface {
+	// Post は仕訳と仕訳明細を作成します（同じ Reference の仕訳が作成済みの場合は何もせず false）
+	Post(ctx context.Context, entry *models.JournalEntry) (bool, error)
+	// Balance はプロジェクトの勘定科目の残高（借方 - 貸方）を返します
+	Balance(ctx context.Context, account models.LedgerAccount, projectID uint) (int64, error)
+	// Balances は勘定科目ごとの借方・貸方の合計を返します（projectID が 0 の場合はすべてのプロジェクト）
+	Balances(ctx context.Context, projectID uint) ([]models.AccountBalance, error)
+	// ListEntries はプロジェクトの仕訳を仕訳明細とともに古い順に返します
+	ListEntries(ctx context.Context, projectID uint) ([]models.JournalEntry, error)
+	// GetEntry は Reference の仕訳を仕訳明細とともに返します
+	GetEntry(ctx context.Context, reference string) (*models.JournalEntry, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func (r *ledgerRepository) Post(ctx context.Context, entry *models.JournalEntry) (bool, error) {
+	created := false
+	// 貸借の一致はコミット時にトリガーで確認するため、仕訳と仕訳明細を同じトランザクションで作成する
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "reference"}}, DoNothing: true}).
+			Omit(clause.Associations).
+			Create(entry)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		for i := range entry.Lines {
+			entry.Lines[i].EntryID = entry.ID
+			entry.Lines[i].ProjectID = entry.ProjectID
+		}
+		if err := tx.Create(&entry.Lines).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, translate(err)
+}
+
+func (r *ledgerRepository) Balance(ctx context.Context, account models.LedgerAccount, projectID uint) (int64, error) {
+	var balance int64
+	err := r.db.WithContext(ctx).Model(&models.JournalLine{}).
+		Select("COALESCE(SUM(debit - credit), 0)").
+		Where("account = ? AND project_id = ?", account, projectID).
+		Scan(&balance).Error
+	return balance, translate(err)
+}
+
+func (r *ledgerRepository) Balances(ctx context.Context, projectID uint) ([]models.AccountBalance, error) {
+	query := r.db.WithContext(ctx).Model(&models.JournalLine{}).
+		Select("account, COALESCE(SUM(debit), 0) AS debit, COALESCE(SUM(credit), 0) AS credit").
+		Group("account")
+	if projectID != 0 {
+		query = query.Where("project_id = ?", projectID)
+	}
+
+	var rows []models.AccountBalance
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, translate(err)
+	}
+	totals := make(map[models.LedgerAccount]models.AccountBalance, len(rows))
+	for _, row := range rows {
+		totals[row.Account] = row
+	}
+
+	// 仕訳のない勘定科目も0円として返す
+	balances := make([]models.AccountBalance, 0, len(models.LedgerAccounts))
+	for _, account := range models.LedgerAccounts {
+		b := totals[account]
+		b.Account = account
+		if account.DebitNormal() {
+			b.Balance = b.Debit - b.Credit
+		} else {
+			b.Balance = b.Credit - b.Debit
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+func (r *ledgerRepository) ListEntries(ctx context.Context, projectID uint) ([]models.JournalEntry, error) {
+	var entries []models.JournalEntry
+	if err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("project_id = ?", projectID).
+		Order("id").
+		Find(&entries).Error; err != nil {
+		return nil, translate(err)
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepository) GetEntry(ctx context.Context, reference string) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	if err := r.db.WithContext(ctx).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("reference = ?", reference).
+		First(&entry).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ProjectFilter はプロジェクト一覧の絞り込み条件（ゼロ値の項目は条件にしない）
+type ProjectFilter struct {
+	Status      models.ProjectStatus
+	OwnerID     uint
+	SupporterID uint
+}
+
+// ProjectRepository はプロジェクトの永続化
+type ProjectRepository interface {
+	Get(ctx context.Context, id uint) (*models.Project, error)
+	// GetWithSupports は主催者と支援（支援者を含む）を読み込んだプロジェクトを返します
+	GetWithSupports(ctx context.Context, id uint) (*models.Project, error)
+	List(ctx context.Context, filter ProjectFilter) ([]models.Project, error)
+	Create(ctx context.Context, project *models.Project) error
+	// Update はゼロ値以外のフィールドを更新します
+	Update(ctx context.Context, project *models.Project, updates models.Project) error
+	UpdateStatus(ctx context.Context, id uint, status models.ProjectStatus) error
+	Delete(ctx context.Context, project *models.Project) error
+	// ListExpired は締め切りを過ぎた実施中のプロジェクトを返します
+	ListExpired(ctx context.Context, now time.Time) ([]models.Project, error)
+	// ListUnsettled は精算を作成していない完了済みのプロジェクトを返します
+	ListUnsettled(ctx context.Context) ([]models.Project, error)
+	// CountByStatus はステータスごとのプロジェクト数を返します
+	CountByStatus(ctx context.Context) (map[models.ProjectStatus]int64, error)
+}
+
+type projectRepository struct {
+	db *gorm.DB
+}
+
+func (r *projectRepository) Get(ctx context.Context, id uint) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.WithContext(ctx).First(&project, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) GetWithSupports(ctx context.Context, id uint) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Supports").
+		Preload("Supports.User").
+		First(&project, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) List(ctx context.Context, filter ProjectFilter) ([]models.Project, error) {
+	query := r.db.WithContext(ctx).Preload("User")
+	if filter.Status != "" {
+		query = query.Where("projects.status = ?", filter.Status)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where("projects.user_id = ?", filter.OwnerID)
+	}
+	if filter.SupporterID != 0 {
+		query = query.
+			Joins("JOIN supports ON supports.project_id = projects.id").
+			Where("supports.user_id = ?", filter.SupporterID)
+	}
+
+	var projects []models.Project
+	if err := query.Find(&projects).Error; err != nil {
+		return nil, translate(err)
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *models.Project) error {
+	return translate(r.db.WithContext(ctx).Create(project).Error)
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *models.Project, updates models.Project) error {
+	return translate(r.db.WithContext(ctx).Model(project).Updates(updates).Error)
+}
+
+func (r *projectRepository) UpdateStatus(ctx context.Context, id uint, status models.ProjectStatus) error {
+	result := r.db.WithContext(ctx).Model(&models.Project{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *projectRepository) Delete(ctx context.Context, project *models.Project) error {
+	return translate(r.db.WithContext(ctx).Delete(project).Error)
+}
+
+func (r *projectRepository) ListExpired(ctx context.Context, now time.Time) ([]models.Project, error) {
+	var projects []models.Project
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND deadline < ?", models.ProjectStatusActive, now).
+		Find(&projects).Error; err != nil {
+		return nil, translate(err)
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) ListUnsettled(ctx context.Context) ([]models.Project, error) {
+	var projects []models.Project
+	if err := r.db.WithContext(ctx).
+		Where("status = ?", models.ProjectStatusComplete).
+		Where("NOT EXISTS (SELECT 1 FROM settlements WHERE settlements.project_id = projects.id)").
+		Find(&projects).Error; err != nil {
+		return nil, translate(err)
+	}
+	return projects, nil
+}
+
+func (r *projectRepository) CountByStatus(ctx context.Context) (map[models.ProjectStatus]int64, error) {
+	var rows []struct {
+		Status models.ProjectStatus
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Project{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, translate(err)
+	}
+
+	counts := make(map[models.ProjectStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// receiptNumberLock は領収書番号の採番を直列化するアドバイザリロックのキー
+const receiptNumberLock = 0x7265636e // "recn"
+
+// ReceiptRepository は領収書の永続化
+type ReceiptRepository interface {
+	// Current は支援の有効な領収書を返します（無い場合は ErrNotFound）
+	Current(ctx context.Context, supportID uint) (*models.Receipt, error)
+	// ListBySupport は支援の領収書を無効になったものも含めて発行順に返します
+	ListBySupport(ctx context.Context, supportID uint) ([]models.Receipt, error)
+	// Create は次の領収書番号を採番して保存します（トランザクション内で呼び出してください）
+	// 支援に有効な領収書が既にある場合は ErrDuplicate
+	Create(ctx context.Context, receipt *models.Receipt) error
+	// Void は有効な領収書を無効にします（既に無効の場合は ErrNotFound）
+	Void(ctx context.Context, id uint, reason string, at time.Time) error
+}
+
+type receiptRepository struct {
+	db *gorm.DB
+}
+
+func (r *receiptRepository) Current(ctx context.Context, supportID uint) (*models.Receipt, error) {
+	var receipt models.Receipt
+	if err := r.db.WithContext(ctx).
+		Where("support_id = ? AND status = ?", supportID, models.ReceiptStatusIssued).
+		First(&receipt).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &receipt, nil
+}
+
+func (r *receiptRepository) ListBySupport(ctx context.Context, supportID uint) ([]models.Receipt, error) {
+	var receipts []models.Receipt
+	if err := r.db.WithContext(ctx).Where("support_id = ?", supportID).Order("number").Find(&receipts).Error; err != nil {
+		return nil, translate(err)
+	}
+	return receipts, nil
+}
+
+func (r *receiptRepository) Create(ctx context.Context, receipt *models.Receipt) error {
+	db := r.db.WithContext(ctx)
+	// 番号に欠番や重複が出ないよう、採番から保存までを他のトランザクションと直列化する
+	if err := db.Exec("SELECT pg_advisory_xact_lock(?)", receiptNumberLock).Error; err != nil {
+		return translate(err)
+	}
+	var last uint
+	if err := db.Model(&models.Receipt{}).Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+		return translate(err)
+	}
+	receipt.Number = last + 1
+	return translate(db.Create(receipt).Error)
+}
+
+func (r *receiptRepository) Void(ctx context.Context, id uint, reason string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.Receipt{}).
+		Where("id = ? AND status = ?", id, models.ReceiptStatusIssued).
+		Updates(map[string]interface{}{
+			"status":      models.ReceiptStatusVoid,
+			"void_reason": reason,
+			"voided_at":   at,
+		})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
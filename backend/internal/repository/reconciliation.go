@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReconciliationRepository はStripeとの照合の結果の永続化
+type ReconciliationRepository interface {
+	// Create は照合の結果を保存します（同じ期間の結果が保存済みの場合は ErrDuplicate）
+	Create(ctx context.Context, report *models.ReconciliationReport) error
+	// List は照合の結果を新しい順に最大 limit 件返します
+	List(ctx context.Context, limit int) ([]models.ReconciliationReport, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func (r *reconciliationRepository) Create(ctx context.Context, report *models.ReconciliationReport) error {
+	return translate(r.db.WithContext(ctx).Create(report).Error)
+}
+
+func (r *reconciliationRepository) List(ctx context.Context, limit int) ([]models.ReconciliationReport, error) {
+	var reports []models.ReconciliationReport
+	if err := r.db.WithContext(ctx).Order("window_to DESC, id DESC").Limit(limit).Find(&reports).Error; err != nil {
+		return nil, translate(err)
+	}
+	return reports, nil
+}
@@ -0,0 +1,82 @@
+// Package repository はモデルの永続化をインターフェースとして提供します
+// サービス層はこのインターフェースにのみ依存し、GORMの実装はNewStoreで差し込みます
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound は対象のレコードが存在しない場合のエラー
+	ErrNotFound = errors.New("repository: record not found")
+	// ErrDuplicate は一意制約に違反した場合のエラー
+	ErrDuplicate = errors.New("repository: duplicate key")
+)
+
+// Store はリポジトリの集合
+type Store interface {
+	Users() UserRepository
+	Projects() ProjectRepository
+	Supports() SupportRepository
+	AuditEvents() AuditEventRepository
+	PayoutAccounts() PayoutAccountRepository
+	Settlements() SettlementRepository
+	Visions() VisionRepository
+	Invoices() InvoiceRepository
+	Expenses() ExpenseRepository
+	Ledger() LedgerRepository
+	Reconciliations() ReconciliationRepository
+	Receipts() ReceiptRepository
+	// Transaction はfnをトランザクション内で実行します（fnがエラーを返すとロールバック）
+	Transaction(ctx context.Context, fn func(tx Store) error) error
+}
+
+// gormStore はGORMによるStoreの実装
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore はGORMの接続からStoreを作成します
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository             { return &userRepository{db: s.db} }
+func (s *gormStore) Projects() ProjectRepository       { return &projectRepository{db: s.db} }
+func (s *gormStore) Supports() SupportRepository       { return &supportRepository{db: s.db} }
+func (s *gormStore) AuditEvents() AuditEventRepository { return &auditEventRepository{db: s.db} }
+func (s *gormStore) PayoutAccounts() PayoutAccountRepository {
+	return &payoutAccountRepository{db: s.db}
+}
+func (s *gormStore) Settlements() SettlementRepository { return &settlementRepository{db: s.db} }
+func (s *gormStore) Visions() VisionRepository         { return &visionRepository{db: s.db} }
+func (s *gormStore) Invoices() InvoiceRepository       { return &invoiceRepository{db: s.db} }
+func (s *gormStore) Expenses() ExpenseRepository       { return &expenseRepository{db: s.db} }
+func (s *gormStore) Ledger() LedgerRepository          { return &ledgerRepository{db: s.db} }
+func (s *gormStore) Reconciliations() ReconciliationRepository {
+	return &reconciliationRepository{db: s.db}
+}
+func (s *gormStore) Receipts() ReceiptRepository { return &receiptRepository{db: s.db} }
+
+func (s *gormStore) Transaction(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// translate はGORMのエラーをリポジトリのエラーに変換します
+func translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case strings.Contains(err.Error(), "duplicate"):
+		return ErrDuplicate
+	}
+	return err
+}
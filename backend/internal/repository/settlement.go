@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// PayoutAccountRepository は主催者の送金先（Stripe Connect アカウント）の永続化
+type PayoutAccountRepository interface {
+	GetByUser(ctx context.Context, userID uint) (*models.PayoutAccount, error)
+	GetByStripeAccount(ctx context.Context, stripeAccountID string) (*models.PayoutAccount, error)
+	Create(ctx context.Context, account *models.PayoutAccount) error
+	// UpdateStatus はオンボーディングと入金の可否を更新します
+	UpdateStatus(ctx context.Context, account *models.PayoutAccount, detailsSubmitted, payoutsEnabled bool) error
+}
+
+type payoutAccountRepository struct {
+	db *gorm.DB
+}
+
+func (r *payoutAccountRepository) GetByUser(ctx context.Context, userID uint) (*models.PayoutAccount, error) {
+	var account models.PayoutAccount
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&account).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &account, nil
+}
+
+func (r *payoutAccountRepository) GetByStripeAccount(ctx context.Context, stripeAccountID string) (*models.PayoutAccount, error) {
+	var account models.PayoutAccount
+	if err := r.db.WithContext(ctx).Where("stripe_account_id = ?", stripeAccountID).First(&account).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &account, nil
+}
+
+func (r *payoutAccountRepository) Create(ctx context.Context, account *models.PayoutAccount) error {
+	return translate(r.db.WithContext(ctx).Create(account).Error)
+}
+
+func (r *payoutAccountRepository) UpdateStatus(ctx context.Context, account *models.PayoutAccount, detailsSubmitted, payoutsEnabled bool) error {
+	return translate(r.db.WithContext(ctx).Model(account).Updates(map[string]interface{}{
+		"details_submitted": detailsSubmitted,
+		"payouts_enabled":   payoutsEnabled,
+	}).Error)
+}
+
+// SettlementFilter は精算一覧の絞り込み条件（ゼロ値の項目は条件にしない）
+type SettlementFilter struct {
+	ProjectID uint
+	UserID    uint
+	Status    models.SettlementStatus
+}
+
+// SettlementRepository は精算の永続化
+type SettlementRepository interface {
+	// Get はプロジェクトを読み込んだ精算を返します
+	Get(ctx context.Context, id uint) (*models.Settlement, error)
+	GetByStripePayout(ctx context.Context, payoutID string) (*models.Settlement, error)
+	// List はプロジェクトを読み込んだ精算を新しい順に返します
+	List(ctx context.Context, filter SettlementFilter) ([]models.Settlement, error)
+	// Create は精算を作成します（プロジェクトの同じ受取人の精算が作成済みの場合は ErrDuplicate）
+	Create(ctx context.Context, settlement *models.Settlement) error
+	// Update は精算を更新します
+	Update(ctx context.Context, settlement *models.Settlement, updates map[string]interface{}) error
+}
+
+type settlementRepository struct {
+	db *gorm.DB
+}
+
+func (r *settlementRepository) Get(ctx context.Context, id uint) (*models.Settlement, error) {
+	var settlement models.Settlement
+	if err := r.db.WithContext(ctx).Preload("Project").First(&settlement, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &settlement, nil
+}
+
+func (r *settlementRepository) GetByStripePayout(ctx context.Context, payoutID string) (*models.Settlement, error) {
+	var settlement models.Settlement
+	if err := r.db.WithContext(ctx).Where("stripe_payout_id = ?", payoutID).First(&settlement).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &settlement, nil
+}
+
+func (r *settlementRepository) List(ctx context.Context, filter SettlementFilter) ([]models.Settlement, error) {
+	query := r.db.WithContext(ctx).Preload("Project").Order("id DESC")
+	if filter.ProjectID != 0 {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var settlements []models.Settlement
+	if err := query.Find(&settlements).Error; err != nil {
+		return nil, translate(err)
+	}
+	return settlements, nil
+}
+
+func (r *settlementRepository) Create(ctx context.Context, settlement *models.Settlement) error {
+	return translate(r.db.WithContext(ctx).Create(settlement).Error)
+}
+
+func (r *settlementRepository) Update(ctx context.Context, settlement *models.Settlement, updates map[string]interface{}) error {
+	return translate(r.db.WithContext(ctx).Model(settlement).Updates(updates).Error)
+}
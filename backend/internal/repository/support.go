@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SupportRepository は支援の永続化
+type SupportRepository interface {
+	// Get は支援者とプロジェクトを読み込んだ支援を返します
+	Get(ctx context.Context, id uint) (*models.Support, error)
+	GetByCheckoutSession(ctx context.Context, sessionID string) (*models.Support, error)
+	ListByProject(ctx context.Context, projectID uint) ([]models.Support, error)
+	Create(ctx context.Context, support *models.Support) error
+	SetCheckoutSession(ctx context.Context, id uint, sessionID string) error
+	// UpdatePayment は状態が from のいずれかである支援の状態と決済IDを更新します
+	// 支援が無い場合や状態が from に含まれない場合は ErrNotFound
+	UpdatePayment(ctx context.Context, id uint, from []models.SupportStatus, status models.SupportStatus, paymentIntentID string) error
+	// ListPaid は決済済み（返金されたものを含む）の支援を古い順に返します
+	ListPaid(ctx context.Context, projectID uint) ([]models.Support, error)
+	// LockPaidByPaymentIntent は決済の決済済み（返金されたものを含む）の支援を行ロックを取得して返します
+	LockPaidByPaymentIntent(ctx context.Context, paymentIntentID string) ([]models.Support, error)
+	// ListPaidByPaymentIntent は決済の決済済み（返金されたものを含む）の支援を行ロックを取得せずに返します
+	ListPaidByPaymentIntent(ctx context.Context, paymentIntentID string) ([]models.Support, error)
+	// SetRefundedAmount は決済の返金額を記録し、全額返金された支援を返金済みにします（更新した件数を返します）
+	SetRefundedAmount(ctx context.Context, paymentIntentID string, refunded int64) (int64, error)
+	// CancelPendingBefore は指定時刻より前に作成された決済待ちの支援を取り消し、件数を返します
+	CancelPendingBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type supportRepository struct {
+	db *gorm.DB
+}
+
+func (r *supportRepository) Get(ctx context.Context, id uint) (*models.Support, error) {
+	var support models.Support
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Project").
+		First(&support, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &support, nil
+}
+
+func (r *supportRepository) GetByCheckoutSession(ctx context.Context, sessionID string) (*models.Support, error) {
+	var support models.Support
+	if err := r.db.WithContext(ctx).
+		Where("checkout_session_id = ?", sessionID).
+		Preload("User").
+		Preload("Project").
+		First(&support).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &support, nil
+}
+
+func (r *supportRepository) ListByProject(ctx context.Context, projectID uint) ([]models.Support, error) {
+	var supports []models.Support
+	if err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Preload("User").
+		Preload("Project").
+		Preload("Project.User").
+		Find(&supports).Error; err != nil {
+		return nil, translate(err)
+	}
+	return supports, nil
+}
+
+func (r *supportRepository) Create(ctx context.Context, support *models.Support) error {
+	return translate(r.db.WithContext(ctx).Create(support).Error)
+}
+
+func (r *supportRepository) SetCheckoutSession(ctx context.Context, id uint, sessionID string) error {
+	return translate(r.db.WithContext(ctx).Model(&models.Support{}).Where("id = ?", id).
+		Update("checkout_session_id", sessionID).Error)
+}
+
+func (r *supportRepository) UpdatePayment(ctx context.Context, id uint, from []models.SupportStatus, status models.SupportStatus, paymentIntentID string) error {
+	result := r.db.WithContext(ctx).Model(&models.Support{}).Where("id = ? AND status IN ?", id, from).
+		Updates(map[string]interface{}{
+			"status":            status,
+			"payment_intent_id": paymentIntentID,
+			"updated_at":        time.Now(),
+		})
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *supportRepository) ListPaid(ctx context.Context, projectID uint) ([]models.Support, error) {
+	var supports []models.Support
+	if err := r.db.WithContext(ctx).
+		Where("project_id = ? AND status IN ?", projectID,
+			[]models.SupportStatus{models.SupportStatusCompleted, models.SupportStatusRefunded}).
+		Order("id").
+		Find(&supports).Error; err != nil {
+		return nil, translate(err)
+	}
+	return supports, nil
+}
+
+func (r *supportRepository) LockPaidByPaymentIntent(ctx context.Context, paymentIntentID string) ([]models.Support, error) {
+	return r.paidByPaymentIntent(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), paymentIntentID)
+}
+
+func (r *supportRepository) ListPaidByPaymentIntent(ctx context.Context, paymentIntentID string) ([]models.Support, error) {
+	return r.paidByPaymentIntent(r.db.WithContext(ctx), paymentIntentID)
+}
+
+func (r *supportRepository) paidByPaymentIntent(db *gorm.DB, paymentIntentID string) ([]models.Support, error) {
+	var supports []models.Support
+	if err := db.
+		Where("payment_intent_id = ? AND status IN ?", paymentIntentID,
+			[]models.SupportStatus{models.SupportStatusCompleted, models.SupportStatusRefunded}).
+		Order("id").
+		Find(&supports).Error; err != nil {
+		return nil, translate(err)
+	}
+	return supports, nil
+}
+
+func (r *supportRepository) SetRefundedAmount(ctx context.Context, paymentIntentID string, refunded int64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Support{}).
+		Where("payment_intent_id = ? AND status IN ?", paymentIntentID,
+			[]models.SupportStatus{models.SupportStatusCompleted, models.SupportStatusRefunded}).
+		Updates(map[string]interface{}{
+			"refunded_amount": gorm.Expr("LEAST(amount, ?)", refunded),
+			"status": gorm.Expr("CASE WHEN ? >= amount THEN ? ELSE ? END",
+				refunded, models.SupportStatusRefunded, models.SupportStatusCompleted),
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, translate(result.Error)
+}
+
+func (r *supportRepository) CancelPendingBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Support{}).
+		Where("status = ? AND created_at < ?", models.SupportStatusPending, before).
+		Updates(map[string]interface{}{
+			"status":     models.SupportStatusCancelled,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, translate(result.Error)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// UserRepository はユーザーの永続化
+type UserRepository interface {
+	Get(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	// Update はゼロ値以外のフィールドを更新します
+	Update(ctx context.Context, user *models.User, updates models.User) error
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+	UpdateRole(ctx context.Context, id uint, role models.UserRole) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) Get(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User, updates models.User) error {
+	return translate(r.db.WithContext(ctx).Model(user).Updates(updates).Error)
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return translate(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", hash).Error)
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role models.UserRole) error {
+	return translate(r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error)
+}
@@ -0,0 +1,164 @@
+// Package scheduler は定期実行するタスクを管理します
+// タスクは冪等に実装し、複数レプリカで同時に実行されても問題ないようにします
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+)
+
+// 決済されないまま支援を取り消すまでの時間（Stripe Checkoutのセッション有効期限に合わせる）
+const pendingSupportTTL = 24 * time.Hour
+
+// Stripeとの照合の期間（前回の期間と重ねて、照合が失敗した日の分も次の実行で照合する）と、Webhookの到着を待つ時間
+const (
+	reconcileLookback = 48 * time.Hour
+	reconcileDelay    = time.Hour
+)
+
+// Task は定期実行するタスク
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler はタスクを一定間隔で実行します
+type Scheduler struct {
+	tasks map[string]Task
+}
+
+// New は新しいSchedulerインスタンスを作成します
+func New() *Scheduler {
+	return &Scheduler{tasks: make(map[string]Task)}
+}
+
+// NewDefault はアプリケーションの定期タスクを登録したSchedulerを作成します
+func NewDefault(services *service.Services) *Scheduler {
+	s := New()
+	s.Add(Task{
+		Name:     "close-expired-projects",
+		Interval: 5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := services.Projects.CloseExpired(ctx)
+			return err
+		},
+	})
+	s.Add(Task{
+		Name:     "cancel-stale-supports",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := services.Supports.CancelStalePending(ctx, pendingSupportTTL)
+			if n > 0 {
+				logging.FromContext(ctx).Info("決済されなかった支援を取り消しました", "count", n)
+			}
+			return err
+		},
+	})
+	s.Add(Task{
+		Name:     "settle-completed-projects",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := services.Payouts.SettleCompleted(ctx)
+			if n > 0 {
+				logging.FromContext(ctx).Info("完了したプロジェクトの精算を作成しました", "count", n)
+			}
+			return err
+		},
+	})
+	s.Add(Task{
+		Name:     "reconcile-stripe-payments",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := services.Reconciliation.ReconcileRecent(ctx, reconcileLookback, reconcileDelay)
+			return err
+		},
+	})
+	s.Add(Task{
+		Name:     "prune-audit-events",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := services.Audit.PruneExpired(ctx)
+			if n > 0 {
+				logging.FromContext(ctx).Info("保持期間を過ぎた監査ログを削除しました", "count", n)
+			}
+			return err
+		},
+	})
+	s.Add(Task{
+		Name:     "prune-login-attempts",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := services.Users.PruneLoginAttempts(ctx)
+			if n > 0 {
+				logging.FromContext(ctx).Info("保持期間を過ぎたログイン試行の記録を削除しました", "count", n)
+			}
+			return err
+		},
+	})
+	return s
+}
+
+// Add はタスクを登録します
+func (s *Scheduler) Add(task Task) {
+	s.tasks[task.Name] = task
+}
+
+// Names は登録されたタスク名を返します
+func (s *Scheduler) Names() []string {
+	names := make([]string, 0, len(s.tasks))
+	for name := range s.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run はctxがキャンセルされるまで各タスクを実行します
+// キャンセル後は実行中のタスクの終了を待ってから戻ります
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, task := range s.tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			s.loop(ctx, task)
+		}(task)
+	}
+	wg.Wait()
+}
+
+// RunOnce は名前を指定してタスクを1回実行します
+func (s *Scheduler) RunOnce(ctx context.Context, name string) error {
+	task, ok := s.tasks[name]
+	if !ok {
+		return fmt.Errorf("scheduler: unknown task %q", name)
+	}
+	return task.Run(ctx)
+}
+
+// loop は起動直後と一定間隔ごとにタスクを実行します
+// タスクによる変更は監査ログにシステムの操作（source: scheduler）として記録されます
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	ctx = audit.WithActor(logging.With(ctx, "task", task.Name), audit.Actor{Source: "scheduler"})
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := task.Run(ctx); err != nil {
+			logging.FromContext(ctx).Error("定期タスクの実行に失敗しました", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package seed
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+)
+
+// demoFan はデモ用のファン
+type demoFan struct {
+	email, name, bio string
+}
+
+// demoProject はデモ用のプロジェクトと、その企画者・支援者
+type demoProject struct {
+	title, description, thumbnail string
+	targetAmount                  int64
+	deadlineDays                  int
+	tags                          []string
+	visionIndex                   int
+	fans                          []demoFan // 先頭が企画者
+}
+
+var demoTags = []string{"誕生日", "生誕祭", "デビュー記念", "周年", "渋谷", "新宿", "池袋"}
+
+var demoVisions = []models.Vision{
+	{Name: "リア・エイド 渋谷センター街ビジョン", Location: "渋谷区宇田川町29-2 　Lighting BOX", Size: "縦 2,500ｍｍ×横 4,250ｍｍ（4.8ｍｍピッチ）", Period: "9：00～26：00（17時間／日）", Description: "渋谷センタ－街の中心部の目線の低い宣伝効果の高い媒体", ImageURL: "/images/visions/shibuya-center.png"},
+	{Name: "リア・エイド 渋谷宇田川町ビジョン", Location: "渋谷区宇田川町11-6　宇田川KKビル", Size: "縦 3ｍ×横 4m（3.9ピッチ）", Period: "9：00～24：00（15時間／日）", Description: "渋谷東急ハンズ前、Abemaタワ－付近の目線の低い媒体", ImageURL: "https://picsum.photos/seed/shibuya-udagawa/800/450"},
+	{Name: "渋谷道玄坂ビジョン", Location: "渋谷区道玄坂2-11-4　　ストーク道玄坂", Size: "縦 2,880ｍｍ×横 5,280ｍｍ（4.8ｍｍピッチ）", Period: "9：00～24：00（15時間／日）", Description: "音声ありのビジョンの全くない道玄坂地区の初ビジョン", ImageURL: "/images/visions/shibuya-dougenzaka.png"},
+	{Name: "リア・エイド 三軒茶屋ビジョン", Location: "世田谷区太子堂4-23-2 ブンカビル", Size: "縦 3ｍ×横 5ｍ（3.9ピッチ）", Period: "8：00～23：00（15時間／日）", Description: "国道246号線と世田谷通りの交差する三軒茶屋で一番賑わう場所", ImageURL: "https://picsum.photos/seed/sangenjaya/800/450"},
+	{Name: "リア・エイド 新宿ビジョン", Location: "新宿区新宿3-21-7　東新ビル", Size: "縦 4ｍ×横 4ｍ（3.9ｍｍピッチ）", Period: "9：00～25：00（16時間／日）", Description: "歩行者・車両の多い新宿モア一番街と靖国通りの交差点媒体", ImageURL: "https://picsum.photos/seed/shinjuku/800/450"},
+	{Name: "リア・エイド 歌舞伎町ビジョン【２面】", Location: "新宿区歌舞伎町1-21-12   カド－ビル", Size: "大型＝縦 2.5ｍ×横 2.5ｍ／小型＝縦 2ｍ×横 3ｍ（3.9ピッチ）", Period: "9：00～26：00（17時間／日）", Description: "歌舞伎町東宝タワ－前の広場に位置する歩行者で賑わう場所", ImageURL: "https://picsum.photos/seed/kabukicho/800/450"},
+	{Name: "リア・エイド 高田馬場ビジョン", Location: "新宿区高田馬場4-7-3　グランド東京ビル", Size: "縦 2ｍ×横 3.5ｍ（3.9ｍｍピッチ）", Period: "7：00～24：00（17時間／日）", Description: "山手線「高田馬場」駅ホーム階段前の一番乗降客が溜まる場所", ImageURL: "https://picsum.photos/seed/takadanobaba/800/450"},
+	{Name: "リア・エイド 新大久保ビジョン", Location: "新宿区百人町1-10-11 　フレスカビル", Size: "縦 2ｍ×横 2.5ｍ（3.9ピッチ）", Period: "7：00～23：00（16時間／日）", Description: "若者で賑わう山手線「新大久保」駅ホ－ム中心部に位置", ImageURL: "https://picsum.photos/seed/shinokubo/800/450"},
+	{Name: "リア・エイド 池袋ビジョン", Location: "豊島区東池袋1-8-6 　藤久ビル", Size: "縦 3ｍ×横 4ｍ（3.9ピッチ）", Period: "8：00～24：00（16時間／日）", Description: "池袋駅東口の明治通り沿いの大型商業施設の多い場所", ImageURL: "https://picsum.photos/seed/ikebukuro/800/450"},
+	{Name: "アメ横　Ｙｓ ビジョン", Location: "台東区4-7-8 アメ横センタービル", Size: "縦 3ｍ×横 5ｍ（3.9ピッチ）", Period: "7：00～22：00（15時間／日）", Description: "歩行者で賑わうアメ横の中心部の分岐地点の正面に可視出来る媒体", ImageURL: "https://picsum.photos/seed/ameyoko/800/450"},
+	{Name: "リア・エイド 立川ビジョン", Location: "立川市柴崎町3-4-18 ＴＲＮ立川ビル", Size: "縦 3ｍ×横 5ｍ（3.9ピッチ）", Period: "8：00～23：00（15時間／日）", Description: "終日賑わう立川駅南口の歓楽街に位置する媒体", ImageURL: "https://picsum.photos/seed/tachikawa/800/450"},
+}
+
+var demoProjects = []demoProject{
+	{
+		title: "【祝】佐藤かなみ 22nd Birthday Project", description: "佐藤かなみさんの22歳の誕生日をお祝いするプロジェクトです！駅中広告とサプライズプレゼントを贈ります！",
+		thumbnail: "https://picsum.photos/seed/kanami/800/600", targetAmount: 110000, deadlineDays: 30, tags: []string{"誕生日", "渋谷"}, visionIndex: 0,
+		fans: []demoFan{
+			{"sato.taro@example.com", "佐藤 太郎", "音楽が趣味で、かなみさんの歌声に癒されています。"},
+			{"suzuki.hiroshi@example.com", "鈴木 寛", "地元でカフェを経営しています。かなみさんの明るい笑顔が大好きです。"},
+			{"tanaka.yumi@example.com", "田中 由美", "大学で音楽を専攻しています。かなみさんの歌声に憧れています。"},
+		},
+	},
+	{
+		title: "【生誕祭】高橋はるか 24th Anniversary", description: "高橋はるかさんの24歳の誕生日を盛大にお祝いするプロジェクトです！",
+		thumbnail: "https://picsum.photos/seed/haruka/800/600", targetAmount: 120000, deadlineDays: 45, tags: []string{"生誕祭", "新宿"}, visionIndex: 4,
+		fans: []demoFan{
+			{"yamamoto.akira@example.com", "山本 晃", "IT企業で働く会社員です。はるかさんの歌声に元気をもらっています。"},
+			{"watanabe.ayaka@example.com", "渡辺 彩香", "看護師をしています。はるかさんの優しい歌声に癒されています。"},
+			{"ito.takashi@example.com", "伊藤 隆", "高校の音楽教師です。はるかさんの音楽性に共感しています。"},
+		},
+	},
+	{
+		title: "田中ひより バースデーサプライズ2025", description: "田中ひよりさんの23歳の誕生日を盛大にお祝いします！",
+		thumbnail: "https://picsum.photos/seed/hiyori/800/600", targetAmount: 100000, deadlineDays: 60, tags: []string{"誕生日", "池袋"}, visionIndex: 8,
+		fans: []demoFan{
+			{"nakamura.rika@example.com", "中村 里香", "ダンススクールのインストラクターです。ひよりさんのダンスに刺激を受けています。"},
+			{"kobayashi.yuuki@example.com", "小林 悠希", "大学生です。ひよりさんの明るいキャラクターが大好きです。"},
+			{"yoshida.megumi@example.com", "吉田 恵", "ファッションデザイナーをしています。ひよりさんのスタイルがお手本です。"},
+		},
+	},
+	{
+		title: "【祝】山本あき 25th Birthday Project", description: "山本あきさんの25歳の誕生日をお祝いするプロジェクトです！",
+		thumbnail: "https://picsum.photos/seed/aki/800/600", targetAmount: 130000, deadlineDays: 75, tags: []string{"誕生日", "渋谷"}, visionIndex: 2,
+		fans: []demoFan{
+			{"takahashi.naoto@example.com", "高橋 直人", "会社員です。あきさんのパフォーマンスに感動しました。"},
+			{"suzuki.maiko@example.com", "鈴木 舞子", "ダンサーを目指しています。あきさんが目標です。"},
+			{"sato.haruka@example.com", "佐藤 はるか", "音楽大学の学生です。あきさんの歌声に憧れています。"},
+		},
+	},
+	{
+		title: "中村ひとか 生誕祭2025 応援広告", description: "中村ひとかさんの22歳の誕生日をお祝いする応援広告を出稿します！",
+		thumbnail: "https://picsum.photos/seed/hitoka/800/600", targetAmount: 110000, deadlineDays: 90, tags: []string{"生誕祭", "新宿"}, visionIndex: 5,
+		fans: []demoFan{
+			{"watanabe.ryo@example.com", "渡辺 涼", "会社員です。ひとかさんの明るいキャラクターが大好きです。"},
+			{"ito.misaki@example.com", "伊藤 美咲", "高校生です。ひとかさんのファッションセンスがお手本です。"},
+			{"yamada.yuuta@example.com", "山田 悠太", "大学生です。ひとかさんのダンスに感動しました。"},
+		},
+	},
+	{
+		title: "【祝デビュー5周年】鈴木じゅりあ 生誕祭2025", description: "鈴木じゅりあさんの24歳の誕生日＆デビュー5周年を記念した特別プロジェクト！",
+		thumbnail: "https://picsum.photos/seed/juria/800/600", targetAmount: 150000, deadlineDays: 120, tags: []string{"生誕祭", "デビュー記念", "周年"}, visionIndex: 1,
+		fans: []demoFan{
+			{"tanaka.akira@example.com", "田中 晶", "会社員です。じゅりあさんの5年間の活躍に感動しています。"},
+			{"suzuki.yui@example.com", "鈴木 結衣", "大学生です。じゅりあさんの歌声が大好きです。"},
+			{"yamamoto.haruto@example.com", "山本 陽翔", "高校生です。じゅりあさんのダンスに憧れています。"},
+		},
+	},
+}
+
+// 複数のプロジェクトを支援するファン
+var demoOtherFans = []demoFan{
+	{"sato.yuuki@example.com", "佐藤 優希", "音楽ライターをしています。新しい才能を応援するのが趣味です。"},
+	{"suzuki.rika@example.com", "鈴木 里佳", "カフェのオーナーです。地元のアーティストを応援しています。"},
+	{"takahashi.hiroshi@example.com", "高橋 宏", "会社員です。休日は音楽ライブに行くのが楽しみです。"},
+	{"tanaka.misaki@example.com", "田中 美咲", "大学生です。新しいアーティストの発掘が趣味です。"},
+	{"watanabe.takashi@example.com", "渡辺 貴志", "音楽プロデューサーを目指しています。才能あるアーティストを応援しています。"},
+	{"suzuki.yuuki@example.com", "鈴木 悠希", "デザイン会社で働いています。クリエイティブな活動を応援しています。"},
+}
+
+var demoMessages = []string{
+	"誕生日おめでとうございます！",
+	"素敵な1年になりますように。",
+	"これからも素敵な歌声を楽しみにしています！",
+	"地元から応援しています！",
+	"これからも応援しています！",
+	"今後のご活躍を心から応援しています！",
+	"いつも元気をもらっています。ありがとう！",
+	"最高の1日になりますように！",
+}
+
+var demoAmounts = []int64{3000, 5000, 8000, 10000, 12000, 15000, 20000, 30000}
+
+// seedDemo はデモ環境用のデータ（6件の生誕祭プロジェクトとその支援）を作成します
+func seedDemo(ctx context.Context, s *Seeder) error {
+	visions := make([]models.Vision, len(demoVisions))
+	copy(visions, demoVisions)
+	if err := s.db.Create(&visions).Error; err != nil {
+		return err
+	}
+
+	tags, err := s.createTags(demoTags)
+	if err != nil {
+		return err
+	}
+
+	others := make([]*models.User, len(demoOtherFans))
+	for i, f := range demoOtherFans {
+		others[i] = s.user(f.email, f.name, f.bio, f.email)
+	}
+	if err := s.db.Create(others).Error; err != nil {
+		return err
+	}
+
+	for _, dp := range demoProjects {
+		fans := make([]*models.User, len(dp.fans))
+		for i, f := range dp.fans {
+			fans[i] = s.user(f.email, f.name, f.bio, f.email)
+		}
+		if err := s.db.Create(fans).Error; err != nil {
+			return err
+		}
+
+		visionID := visions[dp.visionIndex].ID
+		project := &models.Project{
+			Title:        dp.title,
+			Description:  dp.description,
+			TargetAmount: dp.targetAmount,
+			Deadline:     s.now.AddDate(0, 0, dp.deadlineDays),
+			UserID:       fans[0].ID,
+			Status:       models.ProjectStatusActive,
+			ThumbnailURL: dp.thumbnail,
+			VisionID:     &visionID,
+		}
+		for _, name := range dp.tags {
+			project.Tags = append(project.Tags, tags[name])
+		}
+		if err := s.db.Create(project).Error; err != nil {
+			return err
+		}
+
+		// 企画者以外のファンと、ランダムに選んだ他のファンが支援する
+		supporters := append([]*models.User{}, fans[1:]...)
+		for _, i := range s.rng.Perm(len(others))[:2+s.rng.Intn(3)] {
+			supporters = append(supporters, others[i])
+		}
+		supports := make([]*models.Support, 0, len(supporters))
+		for _, u := range supporters {
+			supports = append(supports, s.support(u.ID, project.ID,
+				pick(s, demoAmounts), pick(s, demoMessages), s.daysAgo(14)))
+		}
+		if err := s.db.Create(supports).Error; err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,79 @@
+package seed
+
+import (
+	"context"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+)
+
+// E2Eテストで使用するアカウント（パスワードはすべて DefaultPassword）
+const (
+	E2EOwnerEmail     = "e2e-owner@example.com"
+	E2ESupporterEmail = "e2e-supporter@example.com"
+	E2EAdminEmail     = "e2e-admin@example.com"
+)
+
+// seedE2E はE2Eテスト用の最小限で固定のデータを作成します
+// テストはタイトルやメールアドレスでデータを特定できます
+func seedE2E(ctx context.Context, s *Seeder) error {
+	vision := demoVisions[0]
+	if err := s.db.Create(&vision).Error; err != nil {
+		return err
+	}
+	tags, err := s.createTags([]string{"誕生日", "渋谷"})
+	if err != nil {
+		return err
+	}
+
+	owner := s.user(E2EOwnerEmail, "E2E 企画者", "E2Eテスト用の企画者です。", "e2e-owner")
+	supporter := s.user(E2ESupporterEmail, "E2E 支援者", "E2Eテスト用の支援者です。", "e2e-supporter")
+	// 管理者は初回ログイン時に二要素認証の登録を求められる
+	admin := s.user(E2EAdminEmail, "E2E 管理者", "", "e2e-admin")
+	admin.Role = models.UserRoleAdmin
+	if err := s.db.Create([]*models.User{owner, supporter, admin}).Error; err != nil {
+		return err
+	}
+
+	projects := []*models.Project{
+		{
+			Title:        "E2E 公開中プロジェクト",
+			Description:  "支援のテストに使用します。",
+			TargetAmount: 100000,
+			Deadline:     s.now.AddDate(0, 1, 0),
+			Status:       models.ProjectStatusActive,
+			VisionID:     &vision.ID,
+			Tags:         []models.Tag{tags["誕生日"], tags["渋谷"]},
+		},
+		{
+			Title:        "E2E 下書きプロジェクト",
+			Description:  "編集・削除のテストに使用します。",
+			TargetAmount: 50000,
+			Deadline:     s.now.AddDate(0, 2, 0),
+			Status:       models.ProjectStatusDraft,
+		},
+		{
+			Title:        "E2E 終了済みプロジェクト",
+			Description:  "目標を達成して終了したプロジェクトです。",
+			TargetAmount: 30000,
+			Deadline:     s.now.AddDate(0, 0, -7),
+			Status:       models.ProjectStatusComplete,
+		},
+	}
+	for _, p := range projects {
+		p.UserID = owner.ID
+		p.ThumbnailURL = "https://picsum.photos/seed/e2e/800/600"
+	}
+	if err := s.db.Create(projects).Error; err != nil {
+		return err
+	}
+
+	supports := []*models.Support{
+		s.support(supporter.ID, projects[0].ID, 10000, "E2Eテストの応援メッセージです。", s.now.AddDate(0, 0, -1)),
+		s.support(supporter.ID, projects[2].ID, 30000, "達成おめでとうございます！", s.now.AddDate(0, 0, -10)),
+	}
+	if err := s.db.Create(supports).Error; err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,92 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+)
+
+// 負荷試験用のデータ件数
+const (
+	loadTestUsers           = 5000
+	loadTestProjects        = 1000
+	loadTestSupports        = 30000
+	loadTestVisions         = 50
+	loadTestTagsPerProject  = 2
+	loadTestMaxDeadlineDays = 180
+)
+
+var loadTestStatuses = []models.ProjectStatus{
+	models.ProjectStatusActive, models.ProjectStatusActive, models.ProjectStatusActive,
+	models.ProjectStatusDraft, models.ProjectStatusComplete, models.ProjectStatusCancelled,
+}
+
+// seedLoadTest は一覧・検索・集計の負荷試験用に大量のデータを作成します
+func seedLoadTest(ctx context.Context, s *Seeder) error {
+	visions := make([]models.Vision, loadTestVisions)
+	for i := range visions {
+		visions[i] = demoVisions[i%len(demoVisions)]
+		visions[i].Name = fmt.Sprintf("%s #%d", visions[i].Name, i+1)
+	}
+	if err := s.db.CreateInBatches(&visions, batchSize).Error; err != nil {
+		return err
+	}
+
+	tags, err := s.createTags(demoTags)
+	if err != nil {
+		return err
+	}
+	tagList := make([]models.Tag, 0, len(tags))
+	for _, name := range demoTags {
+		tagList = append(tagList, tags[name])
+	}
+
+	users := make([]*models.User, loadTestUsers)
+	for i := range users {
+		email := fmt.Sprintf("load%05d@example.com", i+1)
+		users[i] = s.user(email, fmt.Sprintf("負荷試験ユーザー%05d", i+1), "", email)
+	}
+	if err := s.db.CreateInBatches(users, batchSize).Error; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	projects := make([]*models.Project, loadTestProjects)
+	for i := range projects {
+		visionID := visions[s.rng.Intn(len(visions))].ID
+		status := pick(s, loadTestStatuses)
+		deadline := s.now.AddDate(0, 0, 1+s.rng.Intn(loadTestMaxDeadlineDays))
+		if status == models.ProjectStatusComplete || status == models.ProjectStatusCancelled {
+			deadline = s.daysAgo(loadTestMaxDeadlineDays)
+		}
+		projects[i] = &models.Project{
+			Title:        fmt.Sprintf("負荷試験プロジェクト%04d", i+1),
+			Description:  "負荷試験用に自動生成したプロジェクトです。",
+			TargetAmount: int64(50+s.rng.Intn(450)) * 1000,
+			Deadline:     deadline,
+			UserID:       pick(s, users).ID,
+			Status:       status,
+			ThumbnailURL: fmt.Sprintf("https://picsum.photos/seed/load%d/800/600", i+1),
+			VisionID:     &visionID,
+		}
+		for _, j := range s.rng.Perm(len(tagList))[:loadTestTagsPerProject] {
+			projects[i].Tags = append(projects[i].Tags, tagList[j])
+		}
+	}
+	if err := s.db.CreateInBatches(projects, batchSize).Error; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	supports := make([]*models.Support, loadTestSupports)
+	for i := range supports {
+		supports[i] = s.support(pick(s, users).ID, pick(s, projects).ID,
+			pick(s, demoAmounts), pick(s, demoMessages), s.daysAgo(90))
+	}
+	return s.db.CreateInBatches(supports, batchSize).Error
+}
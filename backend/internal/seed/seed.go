@@ -0,0 +1,214 @@
+// Package seed はローカル・デモ・負荷試験・E2Eテスト用のデータを投入します
+// データはモデル経由で作成し、パスワードは実際にログインできるハッシュを使用します
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// DefaultPassword は投入するユーザー共通のパスワード
+const DefaultPassword = "password123"
+
+// バッチ挿入の件数
+const batchSize = 500
+
+// ErrResetNotAllowed は本番環境でリセットしようとした場合のエラー
+var ErrResetNotAllowed = errors.New("seed: reset is not allowed in production (set ENV to development, test or staging)")
+
+// Options は投入の設定
+type Options struct {
+	// Profile は投入するデータの種類（demo / load-test / e2e）
+	Profile string
+	// RandomSeed は乱数のシード（同じ値なら同じデータになります）
+	RandomSeed int64
+	// Reset が true の場合は投入前に既存のデータを削除します
+	Reset bool
+	// Env は実行環境（本番環境ではリセットできません）
+	Env string
+	// Hasher はパスワードのハッシュ化に使用します
+	Hasher *utils.PasswordHasher
+}
+
+// Seeder はプロファイルに応じてデータを作成します
+type Seeder struct {
+	db           *gorm.DB
+	rng          *rand.Rand
+	now          time.Time
+	passwordHash string
+}
+
+// profile は投入処理
+type profile func(ctx context.Context, s *Seeder) error
+
+var profiles = map[string]profile{
+	"demo":      seedDemo,
+	"load-test": seedLoadTest,
+	"e2e":       seedE2E,
+}
+
+// Profiles は利用できるプロファイル名を返します
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run はデータを投入します
+func Run(ctx context.Context, db *gorm.DB, opts Options) error {
+	run, ok := profiles[opts.Profile]
+	if !ok {
+		return fmt.Errorf("seed: unknown profile %q (%s)", opts.Profile, strings.Join(Profiles(), ", "))
+	}
+
+	if opts.Reset {
+		if err := Reset(ctx, db, opts.Env); err != nil {
+			return err
+		}
+	}
+
+	// パスワードのハッシュ化は遅いため、全ユーザーで同じハッシュを使う
+	hash, err := opts.Hasher.Hash(DefaultPassword)
+	if err != nil {
+		return err
+	}
+
+	s := &Seeder{
+		rng:          rand.New(rand.NewSource(opts.RandomSeed)),
+		now:          time.Now(),
+		passwordHash: hash,
+	}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		s.db = tx
+		if err := run(ctx, s); err != nil {
+			return err
+		}
+		return s.journalSupports()
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("シードデータを投入しました: profile=%s seed=%d（パスワード: %s）", opts.Profile, opts.RandomSeed, DefaultPassword)
+	return nil
+}
+
+// resetTables はリセット対象のテーブル（schema_migrationsは含めない）
+var resetTables = []string{
+	"receipts", "reconciliation_reports", "journal_lines", "journal_entries", "project_tags", "tags", "settlements", "expenses", "invoices", "payout_accounts", "supports", "projects", "visions",
+	"user_identities", "oauth_states", "user_recovery_codes", "data_exports",
+	"login_attempts", "rate_limit_buckets", "jobs", "users",
+}
+
+// Reset はアプリケーションのデータを全て削除し、IDの採番を初期化します
+// 本番環境では実行しません
+func Reset(ctx context.Context, db *gorm.DB, env string) error {
+	if env == "" || env == config.EnvProduction {
+		return ErrResetNotAllowed
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sql := "TRUNCATE TABLE " + strings.Join(resetTables, ", ") + " RESTART IDENTITY CASCADE"
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		// 監査ログはTRUNCATEできないため、保持期間による削除と同じ関数で全件削除する
+		if err := tx.Exec("SELECT prune_audit_events('infinity')").Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER SEQUENCE audit_events_id_seq RESTART").Error
+	})
+	if err != nil {
+		return fmt.Errorf("seed: reset failed: %w", err)
+	}
+	log.Println("既存のデータを削除しました")
+	return nil
+}
+
+// user はユーザーのモデルを組み立てます
+func (s *Seeder) user(email, name, bio, avatarSeed string) *models.User {
+	return &models.User{
+		Email:           email,
+		Password:        s.passwordHash,
+		Name:            name,
+		Bio:             bio,
+		ProfileImageURL: fmt.Sprintf("https://api.dicebear.com/7.x/adventurer/svg?seed=%s", avatarSeed),
+		Role:            models.UserRoleUser,
+	}
+}
+
+// journalSupports は投入した完了済みの支援の仕訳（platform_cash / project_escrow）を帳簿に記録します
+func (s *Seeder) journalSupports() error {
+	return s.db.Exec(`
+WITH entries AS (
+    INSERT INTO journal_entries (reference, kind, project_id, support_id, created_at)
+    SELECT 'support:' || id || ':completed', ?, project_id, id, created_at
+    FROM supports
+    WHERE status = ? AND amount > 0
+    ON CONFLICT (reference) DO NOTHING
+    RETURNING id, project_id, support_id
+)
+INSERT INTO journal_lines (entry_id, account, project_id, debit, credit)
+SELECT e.id, l.account, e.project_id, l.debit, l.credit
+FROM entries e
+JOIN supports s ON s.id = e.support_id
+CROSS JOIN LATERAL (VALUES (?::text, s.amount, 0::bigint), (?::text, 0::bigint, s.amount)) AS l (account, debit, credit)`,
+		models.JournalKindSupportCompleted, models.SupportStatusCompleted,
+		models.LedgerAccountPlatformCash, models.LedgerAccountProjectEscrow).Error
+}
+
+// support は完了済みの支援のモデルを組み立てます
+func (s *Seeder) support(userID, projectID uint, amount int64, message string, at time.Time) *models.Support {
+	return &models.Support{
+		UserID:            userID,
+		ProjectID:         projectID,
+		Amount:            amount,
+		Message:           message,
+		Status:            models.SupportStatusCompleted,
+		PaymentIntentID:   fmt.Sprintf("pi_seed_%d_%d_%d", projectID, userID, s.rng.Int63()),
+		CheckoutSessionID: fmt.Sprintf("cs_seed_%d_%d_%d", projectID, userID, s.rng.Int63()),
+		CreatedAt:         at,
+		UpdatedAt:         at,
+	}
+}
+
+// pick は候補からランダムに1つ選びます
+func pick[T any](s *Seeder, items []T) T {
+	return items[s.rng.Intn(len(items))]
+}
+
+// daysAgo は基準時刻から過去のランダムな時刻を返します
+func (s *Seeder) daysAgo(maxDays int) time.Time {
+	return s.now.Add(-time.Duration(s.rng.Intn(maxDays*24*60)) * time.Minute)
+}
+
+// createTags はタグを作成して名前で引けるようにします
+func (s *Seeder) createTags(names []string) (map[string]models.Tag, error) {
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	if err := s.db.Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]models.Tag, len(tags))
+	for _, t := range tags {
+		byName[t.Name] = t
+	}
+	return byName, nil
+}
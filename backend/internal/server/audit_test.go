@@ -0,0 +1,179 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+	"gorm.io/gorm"
+)
+
+type auditEvent struct {
+	ID         uint                              `json:"id"`
+	ActorID    *uint                             `json:"actor_id"`
+	Action     string                            `json:"action"`
+	TargetType string                            `json:"target_type"`
+	TargetID   uint                              `json:"target_id"`
+	RequestID  string                            `json:"request_id"`
+	Changes    map[string]map[string]interface{} `json:"changes"`
+}
+
+type auditEventPage struct {
+	Events       []auditEvent `json:"events"`
+	NextBeforeID uint         `json:"next_before_id"`
+}
+
+// promoteToAdmin はユーザーを管理者に変更します（二要素認証は登録しない）
+func promoteToAdmin(t *testing.T, h *testutil.Harness, user *testutil.User) *testutil.User {
+	t.Helper()
+	if _, err := h.Server.Services.Users.SetRole(context.Background(), user.Email, models.UserRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newAdmin は二要素認証を登録した管理者のユーザーを登録し、二要素認証を経たトークンを設定します
+func newAdmin(t *testing.T, h *testutil.Harness) *testutil.User {
+	t.Helper()
+	admin := promoteToAdmin(t, h, h.Register("admin"))
+	admin.Token = enrollTwoFactor(t, h, admin.Token).Token
+	return admin
+}
+
+func TestAuditRecordsProjectChangesWithActorAndRequest(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+	p := createProject(t, h, owner, "")
+
+	input := projectInput(p.Title)
+	input["target_amount"] = 50000
+	req := h.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token)
+	req.Header.Set("X-Request-ID", "req-audit-1")
+	req.Header.Set("User-Agent", "audit-test/1.0")
+	h.Serve(req).Expect(t, http.StatusOK)
+	h.Do(http.MethodDelete, fmt.Sprintf("/api/v1/projects/%d", p.ID), nil, owner.Token).Expect(t, http.StatusOK)
+
+	var page auditEventPage
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/audit-events?target_type=project&target_id=%d", p.ID), nil, admin.Token).
+		Expect(t, http.StatusOK).Decode(t, &page)
+	if len(page.Events) != 2 {
+		t.Fatalf("監査ログの件数: got %d, want 2\n%+v", len(page.Events), page.Events)
+	}
+
+	deleted, updated := page.Events[0], page.Events[1]
+	if deleted.Action != "project.deleted" || deleted.Changes["title"]["from"] != p.Title {
+		t.Errorf("削除の記録: got %+v", deleted)
+	}
+	if updated.Action != "project.updated" || updated.ActorID == nil || *updated.ActorID != owner.ID {
+		t.Fatalf("更新の記録: got %+v", updated)
+	}
+	if updated.RequestID != "req-audit-1" {
+		t.Errorf("request_id: got %q, want req-audit-1", updated.RequestID)
+	}
+	change := updated.Changes["target_amount"]
+	if change["from"] != float64(10000) || change["to"] != float64(50000) {
+		t.Errorf("target_amount: got %v, want 10000 -> 50000", change)
+	}
+	if _, ok := updated.Changes["updated_at"]; ok {
+		t.Errorf("updated_at は差分に含めない: got %v", updated.Changes)
+	}
+}
+
+func TestAuditRecordsUserChangesWithoutSecrets(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("user")
+	admin := newAdmin(t, h)
+
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", user.ID), map[string]string{"bio": "推し活しています"}, user.Token).
+		Expect(t, http.StatusOK)
+	if err := h.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("password", "new-hash").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var page auditEventPage
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/audit-events?target_type=user&target_id=%d", user.ID), nil, admin.Token).
+		Expect(t, http.StatusOK).Decode(t, &page)
+	if len(page.Events) != 2 {
+		t.Fatalf("監査ログの件数: got %d, want 2\n%+v", len(page.Events), page.Events)
+	}
+	if got := page.Events[0].Changes["password"]; got["from"] != "[REDACTED]" || got["to"] != "[REDACTED]" {
+		t.Errorf("パスワードの値は伏せる: got %v", got)
+	}
+	profile := page.Events[1]
+	if profile.Action != "user.profile_updated" || profile.Changes["bio"]["to"] != "推し活しています" {
+		t.Errorf("プロフィールの更新: got %+v", profile)
+	}
+}
+
+func TestAuditEventsAreAdminOnlyAndPaginated(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+	p := createProject(t, h, owner, "")
+	for _, amount := range []int{20000, 30000, 40000} {
+		input := projectInput(p.Title)
+		input["target_amount"] = amount
+		h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusOK)
+	}
+
+	h.Do(http.MethodGet, "/api/v1/admin/audit-events", nil, owner.Token).Expect(t, http.StatusForbidden)
+	h.Do(http.MethodGet, "/api/v1/admin/audit-events?limit=1000", nil, admin.Token).Expect(t, http.StatusBadRequest)
+
+	path := "/api/v1/admin/audit-events?action=project.updated&limit=2"
+	var first, second auditEventPage
+	h.Do(http.MethodGet, path, nil, admin.Token).Expect(t, http.StatusOK).Decode(t, &first)
+	if len(first.Events) != 2 || first.NextBeforeID == 0 {
+		t.Fatalf("1ページ目: got %+v", first)
+	}
+	h.Do(http.MethodGet, fmt.Sprintf("%s&before_id=%d", path, first.NextBeforeID), nil, admin.Token).
+		Expect(t, http.StatusOK).Decode(t, &second)
+	if len(second.Events) != 1 || second.NextBeforeID != 0 || second.Events[0].ID >= first.NextBeforeID {
+		t.Fatalf("2ページ目: got %+v", second)
+	}
+}
+
+func TestAuditEventsAreAppendOnlyExceptRetention(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	createProject(t, h, owner, "active")
+
+	if err := h.DB.Exec("UPDATE audit_events SET action = 'tampered'").Error; err == nil {
+		t.Error("監査ログを更新できてしまいます")
+	}
+	if err := h.DB.Exec("DELETE FROM audit_events").Error; err == nil {
+		t.Error("監査ログを削除できてしまいます")
+	}
+	if err := h.DB.Exec("TRUNCATE audit_events").Error; err == nil {
+		t.Error("監査ログをTRUNCATEできてしまいます")
+	}
+	// 以前の保守用の設定値では削除を許可しない
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('oshiome.audit_maintenance', 'on', true)").Error; err != nil {
+			return err
+		}
+		return tx.Exec("DELETE FROM audit_events").Error
+	}); err == nil {
+		t.Error("oshiome.audit_maintenance で監査ログを削除できてしまいます")
+	}
+
+	// 保持期間（既定365日）を過ぎた記録のみ削除する
+	ctx := context.Background()
+	if n, err := h.Server.Services.Audit.PruneExpired(ctx); err != nil || n != 0 {
+		t.Fatalf("保持期間内: got %d, %v, want 0", n, err)
+	}
+	h.Clock.Advance(366 * 24 * time.Hour)
+	n, err := h.Server.Services.Audit.PruneExpired(ctx)
+	if err != nil || n == 0 {
+		t.Fatalf("保持期間の経過後: got %d, %v, want >0", n, err)
+	}
+	var remaining int64
+	h.DB.Model(&models.AuditEvent{}).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("残った監査ログ: got %d, want 0", remaining)
+	}
+}
@@ -0,0 +1,211 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	h := testutil.New(t)
+
+	user := h.Register("alice")
+	if user.ID == 0 || user.Token == "" {
+		t.Fatalf("登録結果が不正です: %+v", user)
+	}
+
+	var me struct {
+		ID    uint   `json:"id"`
+		Email string `json:"email"`
+	}
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, user.Token).Expect(t, http.StatusOK).Decode(t, &me)
+	if me.ID != user.ID || me.Email != user.Email {
+		t.Fatalf("/api/v1/auth/me: got %+v, want id=%d email=%s", me, user.ID, user.Email)
+	}
+
+	token := h.Login(user.Email, user.Password)
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, token).Expect(t, http.StatusOK)
+}
+
+func TestRegisterRejectsInvalidInput(t *testing.T) {
+	h := testutil.New(t)
+
+	tests := []struct {
+		name  string
+		body  map[string]string
+		field string
+		rule  string
+	}{
+		{"メールアドレスなし", map[string]string{"name": "bob", "password": "password123"}, "email", "required"},
+		{"不正なメールアドレス", map[string]string{"name": "bob", "email": "bob", "password": "password123"}, "email", "email"},
+		{"短いパスワード", map[string]string{"name": "bob", "email": "bob@example.com", "password": "123"}, "password", "min"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := h.Do(http.MethodPost, "/api/v1/register", tt.body, "").Expect(t, http.StatusBadRequest).APIError()
+			if apiErr.Code != "INVALID_INPUT" {
+				t.Fatalf("エラーコード: got %q, want INVALID_INPUT", apiErr.Code)
+			}
+			if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != tt.field || apiErr.Fields[0].Rule != tt.rule || apiErr.Fields[0].Message == "" {
+				t.Fatalf("項目ごとのエラー: got %+v, want field=%s rule=%s", apiErr.Fields, tt.field, tt.rule)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsMalformedJSON(t *testing.T) {
+	h := testutil.New(t)
+
+	res := h.Do(http.MethodPost, "/api/v1/register", map[string]interface{}{"name": 1, "email": "x@example.com", "password": "password123"}, "")
+	apiErr := res.Expect(t, http.StatusBadRequest).APIError()
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "name" || apiErr.Fields[0].Rule != "type" {
+		t.Fatalf("項目ごとのエラー: got %+v, want field=name rule=type", apiErr.Fields)
+	}
+}
+
+func TestUnknownRoute(t *testing.T) {
+	h := testutil.New(t)
+
+	if code := h.Do(http.MethodGet, "/api/v1/no-such-route", nil, "").Expect(t, http.StatusNotFound).ErrorCode(); code != "NOT_FOUND" {
+		t.Fatalf("エラーコード: got %q, want NOT_FOUND", code)
+	}
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("carol")
+
+	res := h.Do(http.MethodPost, "/api/v1/register", map[string]string{
+		"name":     "carol2",
+		"email":    user.Email,
+		"password": "password123",
+	}, "").Expect(t, http.StatusConflict)
+	if code := res.ErrorCode(); code != "DUPLICATE_EMAIL" {
+		t.Fatalf("エラーコード: got %q, want DUPLICATE_EMAIL", code)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("dave")
+
+	res := h.Do(http.MethodPost, "/api/v1/login", map[string]string{
+		"email":    user.Email,
+		"password": "wrong-password",
+	}, "").Expect(t, http.StatusUnauthorized)
+	if code := res.ErrorCode(); code != "INVALID_CREDENTIALS" {
+		t.Fatalf("エラーコード: got %q, want INVALID_CREDENTIALS\n%s", code, res.Body)
+	}
+}
+
+func TestAuthRequired(t *testing.T) {
+	h := testutil.New(t)
+
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, "").Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, "not-a-token").Expect(t, http.StatusUnauthorized)
+}
+
+func TestLoginLockoutExpires(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("erin")
+
+	wrong := map[string]string{"email": user.Email, "password": "wrong-password"}
+	// 段階的な遅延を待たずに済むよう、失敗のたびに時計を進める
+	for i := 0; i < 5; i++ {
+		h.Do(http.MethodPost, "/api/v1/login", wrong, "")
+		h.Clock.Advance(2 * time.Minute)
+	}
+
+	// 正しいパスワードでもロック中はログインできない
+	correct := map[string]string{"email": user.Email, "password": user.Password}
+	h.Do(http.MethodPost, "/api/v1/login", correct, "").Expect(t, http.StatusLocked)
+
+	// ロック期間が明ければログインできる
+	h.Clock.Advance(15 * time.Minute)
+	h.Login(user.Email, user.Password)
+}
+
+var unlockTokenPattern = regexp.MustCompile(`unlock\?token=([0-9a-f]+)`)
+
+func TestLockedAccountIsUnlockedByMail(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("grace")
+
+	wrong := map[string]string{"email": user.Email, "password": "wrong-password"}
+	for i := 0; i < 5; i++ {
+		h.Do(http.MethodPost, "/api/v1/login", wrong, "")
+		h.Clock.Advance(2 * time.Minute)
+	}
+
+	// ロック解除メールはジョブとして送信する
+	if n := len(h.Mailer.Messages()); n != 0 {
+		t.Fatalf("ジョブの実行前に送信されたメール: got %d", n)
+	}
+	runJobs(t, h)
+	messages := h.Mailer.Messages()
+	if len(messages) != 1 || messages[0].To != user.Email {
+		t.Fatalf("ロック解除メール: got %+v", messages)
+	}
+	match := unlockTokenPattern.FindStringSubmatch(messages[0].Body)
+	if match == nil {
+		t.Fatalf("ロック解除メールにリンクがありません: %q", messages[0].Body)
+	}
+
+	h.Do(http.MethodPost, "/api/v1/auth/unlock", map[string]string{"token": match[1]}, "").Expect(t, http.StatusOK)
+	h.Login(user.Email, user.Password)
+	h.Do(http.MethodPost, "/api/v1/auth/unlock", map[string]string{"token": match[1]}, "").Expect(t, http.StatusBadRequest)
+}
+
+func TestLoginAttemptsArePrunedAfterRetention(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("heidi")
+	h.Do(http.MethodPost, "/api/v1/login", map[string]string{"email": user.Email, "password": "wrong-password"}, "").
+		Expect(t, http.StatusUnauthorized)
+
+	// 保持期間（既定90日）を過ぎた記録のみ削除する
+	h.Clock.Advance(91 * 24 * time.Hour)
+	h.Login(user.Email, user.Password)
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "prune-login-attempts"); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts []models.LoginAttempt
+	h.DB.Where("email = ?", user.Email).Find(&attempts)
+	if len(attempts) != 1 || !attempts[0].Success {
+		t.Fatalf("残ったログイン試行の記録: got %+v", attempts)
+	}
+}
+
+func TestConcurrentLoginFailuresLockAccount(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("frank")
+
+	// 同時に失敗しても回数を取りこぼさず、閾値でロックする
+	wrong := map[string]string{"email": user.Email, "password": "wrong-password"}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		req := h.NewRequest(http.MethodPost, "/api/v1/login", wrong, "")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Serve(req)
+		}()
+	}
+	wg.Wait()
+
+	var stored models.User
+	if err := h.DB.First(&stored, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.FailedLoginCount != 8 || stored.LockedUntil == nil {
+		t.Fatalf("ログイン失敗の記録: got count=%d locked_until=%v", stored.FailedLoginCount, stored.LockedUntil)
+	}
+	correct := map[string]string{"email": user.Email, "password": user.Password}
+	h.Do(http.MethodPost, "/api/v1/login", correct, "").Expect(t, http.StatusLocked)
+}
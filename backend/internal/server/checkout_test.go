@@ -0,0 +1,212 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type support struct {
+	ID                uint   `json:"id"`
+	UserID            uint   `json:"user_id"`
+	ProjectID         uint   `json:"project_id"`
+	Amount            int64  `json:"amount"`
+	Status            string `json:"status"`
+	PaymentIntentID   string `json:"payment_intent_id"`
+	CheckoutSessionID string `json:"checkout_session_id"`
+}
+
+type checkout struct {
+	CheckoutSessionID string `json:"checkout_session_id"`
+	CheckoutURL       string `json:"checkout_url"`
+	SupportID         uint   `json:"support_id"`
+}
+
+func startCheckout(t *testing.T, h *testutil.Harness, supporter *testutil.User, projectID uint, amount int64) checkout {
+	t.Helper()
+
+	var c checkout
+	h.Do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/supports", projectID), map[string]interface{}{
+		"amount":  amount,
+		"message": "応援しています",
+	}, supporter.Token).Expect(t, http.StatusCreated).Decode(t, &c)
+	if c.CheckoutSessionID == "" || c.SupportID == 0 {
+		t.Fatalf("決済セッションの作成結果が不正です: %+v", c)
+	}
+	return c
+}
+
+func TestCheckoutCompletedByWebhook(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+
+	c := startCheckout(t, h, supporter, p.ID, 3000)
+	req, ok := h.Payments.Session(c.CheckoutSessionID)
+	if !ok || req.Amount != 3000 || req.SupportID != c.SupportID || req.UserID != supporter.ID {
+		t.Fatalf("決済セッションの内容が不正です: %+v", req)
+	}
+
+	var s support
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	if s.Status != "pending" {
+		t.Fatalf("Webhook前の支援の状態: got %q, want pending", s.Status)
+	}
+
+	completed, collected := metrics.SupportsCompleted.Value(), metrics.CollectedYen.Value()
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+	if got := metrics.SupportsCompleted.Value() - completed; got != 1 {
+		t.Errorf("oshiome_supports_completed_total の増分: got %v, want 1", got)
+	}
+	if got := metrics.CollectedYen.Value() - collected; got != 3000 {
+		t.Errorf("oshiome_supports_collected_yen_total の増分: got %v, want 3000", got)
+	}
+
+	h.Do(http.MethodGet, "/api/v1/payments/verify?session_id="+c.CheckoutSessionID, nil, "").
+		Expect(t, http.StatusOK)
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	if s.Status != "completed" || s.PaymentIntentID == "" {
+		t.Fatalf("Webhook後の支援: got %+v, want status=completed", s)
+	}
+
+	// 主催者は支援を参照でき、第三者は参照できない
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, owner.Token).Expect(t, http.StatusOK)
+	stranger := h.Register("stranger")
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, stranger.Token).Expect(t, http.StatusForbidden)
+
+	var detail struct {
+		CurrentAmount   int64 `json:"current_amount"`
+		SupportersCount int   `json:"supporters_count"`
+	}
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", p.ID), nil, "").Expect(t, http.StatusOK).Decode(t, &detail)
+	if detail.CurrentAmount != 3000 || detail.SupportersCount != 1 {
+		t.Fatalf("プロジェクトの支援額: got %+v, want current_amount=3000 supporters_count=1", detail)
+	}
+}
+
+func TestWebhookRedeliveryIsIdempotent(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+	c := startCheckout(t, h, supporter, p.ID, 1000)
+
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+
+	var supports []support
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d/supports", p.ID), nil, "").
+		Expect(t, http.StatusOK).Decode(t, &supports)
+	if len(supports) != 1 || supports[0].Status != "completed" {
+		t.Fatalf("支援一覧: got %+v, want 完了済み1件", supports)
+	}
+}
+
+func TestLatePaymentEventsDoNotRegressSupportStatus(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	admin := newAdmin(t, h)
+	p := createProject(t, h, owner, "active")
+	c := startCheckout(t, h, supporter, p.ID, 2000)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+
+	var s support
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	paymentIntent := map[string]interface{}{"id": s.PaymentIntentID, "object": "payment_intent"}
+
+	// 完了済みの支援は遅れて届いた失敗の通知で失敗にならない
+	h.SendWebhook("payment_intent.payment_failed", paymentIntent).Expect(t, http.StatusOK)
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	if s.Status != "completed" {
+		t.Fatalf("失敗の通知後の支援の状態: got %q, want completed", s.Status)
+	}
+
+	// 返金済みの支援は完了の通知の再送で完了に戻らず、帳簿にも二重に記録しない
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id": "ch_test_1", "object": "charge", "payment_intent": s.PaymentIntentID, "amount_refunded": 2000,
+	}).Expect(t, http.StatusOK)
+	h.SendWebhook("payment_intent.succeeded", paymentIntent).Expect(t, http.StatusOK)
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	if s.Status != "refunded" {
+		t.Fatalf("完了の通知の再送後の支援の状態: got %q, want refunded", s.Status)
+	}
+	completed := 0
+	for _, entry := range getProjectLedger(t, h, admin, p.ID).Entries {
+		if entry.Kind == "support_completed" {
+			completed++
+		}
+	}
+	if completed != 1 {
+		t.Errorf("支援の完了の仕訳: got %d, want 1", completed)
+	}
+}
+
+func TestWebhookRejectsInvalidSignature(t *testing.T) {
+	h := testutil.New(t)
+
+	res := h.Do(http.MethodPost, "/api/v1/webhook", map[string]string{"type": "checkout.session.completed"}, "")
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("署名なしのWebhook: got %d, want 400", res.Code)
+	}
+}
+
+func TestCheckoutRequiresActiveProject(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "")
+
+	h.Do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/supports", p.ID), map[string]interface{}{
+		"amount": 1000,
+	}, supporter.Token).Expect(t, http.StatusBadRequest)
+	if n := h.Payments.Sessions(); n != 0 {
+		t.Fatalf("決済セッションが作成されました: %d件", n)
+	}
+}
+
+func TestCheckoutPaymentProviderFailure(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+
+	h.Payments.Err = errors.New("stripe is down")
+	h.Do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/supports", p.ID), map[string]interface{}{
+		"amount": 1000,
+	}, supporter.Token).Expect(t, http.StatusInternalServerError)
+}
+
+func TestStalePendingSupportIsCancelled(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+	c := startCheckout(t, h, supporter, p.ID, 1000)
+
+	// 決済されないまま有効期限（24時間）を過ぎると取り消される
+	h.Clock.Advance(25 * time.Hour)
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "cancel-stale-supports"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 時計を進めたため、有効期限の切れたトークンではなくログインし直したトークンで確認する
+	var s support
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d", c.SupportID), nil, h.Login(supporter.Email, supporter.Password)).
+		Expect(t, http.StatusOK).Decode(t, &s)
+	if s.Status != "cancelled" {
+		t.Fatalf("支援の状態: got %q, want cancelled", s.Status)
+	}
+}
@@ -0,0 +1,142 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// readiness は /readyz のレスポンス
+type readiness struct {
+	Status string                            `json:"status"`
+	Checks map[string]map[string]interface{} `json:"checks"`
+}
+
+func getReadiness(t *testing.T, h http.Handler) (int, readiness) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body readiness
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("/readyz のレスポンスが不正です: %v\n%s", err, rec.Body)
+	}
+	return rec.Code, body
+}
+
+func TestLivenessDoesNotDependOnDatabase(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/healthz: got %d, want 200", rec.Code)
+	}
+
+	code, body := getReadiness(t, srv.Handler())
+	if code != http.StatusServiceUnavailable || body.Status != "not_ready" {
+		t.Fatalf("/readyz: got %d %s, want 503 not_ready", code, body.Status)
+	}
+	if got := body.Checks["database"]["status"]; got != "error" {
+		t.Errorf("database: got %v, want error", got)
+	}
+	if got := body.Checks["payments"]; got["status"] != "ok" || got["mode"] != "test" {
+		t.Errorf("payments: got %v, want ok (test)", got)
+	}
+}
+
+func TestReadinessReportsEachDependency(t *testing.T) {
+	h := testutil.New(t)
+
+	code, body := getReadiness(t, h.Server.Handler())
+	if code != http.StatusOK || body.Status != "ready" {
+		t.Fatalf("/readyz: got %d %+v, want 200 ready", code, body)
+	}
+	for _, name := range []string{"database", "migrations", "payments"} {
+		if got := body.Checks[name]["status"]; got != "ok" {
+			t.Errorf("%s: got %v, want ok", name, got)
+		}
+	}
+	migrations := body.Checks["migrations"]
+	if migrations["version"] != migrations["latest"] {
+		t.Errorf("migrations: got version %v, latest %v", migrations["version"], migrations["latest"])
+	}
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.Router.POST("/api/v1/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Post("http://"+ln.Addr().String()+"/api/v1/slow", "text/plain", nil)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		responses <- result{code: resp.StatusCode, body: string(b)}
+	}()
+	<-started
+
+	// 停止を始めると /readyz は503になり、処理中のリクエストは完了まで待つ
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, body := getReadiness(t, srv.Handler()); body.Status == "shutting_down" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("/readyz が shutting_down になりません")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("処理中のリクエストの完了前に停止しました: %v", err)
+	default:
+	}
+
+	close(release)
+	res := <-responses
+	if res.err != nil || res.code != http.StatusOK || res.body != "done" {
+		t.Fatalf("処理中のリクエスト: got %d %q %v, want 200 done", res.code, res.body, res.err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("Serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve が戻りません")
+	}
+}
@@ -0,0 +1,59 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+func TestErrorsFollowAcceptLanguage(t *testing.T) {
+	h := testutil.New(t)
+
+	tests := []struct {
+		acceptLanguage string
+		message        string
+		fieldMessage   string
+	}{
+		{"", "入力が無効です", "必須項目です"},
+		{"en-US,en;q=0.9", "The request is invalid", "is required"},
+		// 対応していない言語は日本語にフォールバック
+		{"fr-FR", "入力が無効です", "必須項目です"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			req := h.NewRequest(http.MethodPost, "/api/v1/register", map[string]string{"name": "bob", "password": "password123"}, "")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			apiErr := h.Serve(req).Expect(t, http.StatusBadRequest).APIError()
+			if apiErr.Message != tt.message {
+				t.Fatalf("message: got %q, want %q", apiErr.Message, tt.message)
+			}
+			if len(apiErr.Fields) != 1 || apiErr.Fields[0].Message != tt.fieldMessage {
+				t.Fatalf("fields: got %+v, want message=%q", apiErr.Fields, tt.fieldMessage)
+			}
+		})
+	}
+}
+
+func TestUserLocalePreferenceOverridesAcceptLanguage(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("frank")
+
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", user.ID), map[string]string{"locale": "en"}, user.Token).
+		Expect(t, http.StatusOK)
+
+	req := h.NewRequest(http.MethodGet, "/api/v1/supports/999999", nil, user.Token)
+	req.Header.Set("Accept-Language", "ja")
+	res := h.Serve(req).Expect(t, http.StatusNotFound)
+	if apiErr := res.APIError(); apiErr.Detail != "Support not found" {
+		t.Fatalf("detail: got %q, want %q", apiErr.Detail, "Support not found")
+	}
+	if lang := res.Header.Get("Content-Language"); lang != "en" {
+		t.Fatalf("Content-Language: got %q, want en", lang)
+	}
+
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/users/%d", user.ID), map[string]string{"locale": "fr"}, user.Token).
+		Expect(t, http.StatusBadRequest)
+}
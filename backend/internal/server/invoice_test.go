@@ -0,0 +1,180 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type invoice struct {
+	ID        uint   `json:"id"`
+	ProjectID uint   `json:"project_id"`
+	Amount    int64  `json:"amount"`
+	Status    string `json:"status"`
+}
+
+type expense struct {
+	ID     uint   `json:"id"`
+	Amount int64  `json:"amount"`
+	Status string `json:"status"`
+}
+
+// newOperator はビジョンと、その運営会社のユーザーを登録します
+func newOperator(t *testing.T, h *testutil.Harness) (*testutil.User, *models.Vision) {
+	t.Helper()
+	ctx := context.Background()
+
+	operator := h.Register("operator")
+	if _, err := h.Server.Services.Users.SetRole(ctx, operator.Email, models.UserRoleOperator); err != nil {
+		t.Fatal(err)
+	}
+	vision := &models.Vision{Name: "テストビジョン"}
+	if err := h.DB.Create(vision).Error; err != nil {
+		t.Fatal(err)
+	}
+	vision, err := h.Server.Services.Invoices.SetVisionOperator(ctx, vision.ID, operator.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return operator, vision
+}
+
+func review(t *testing.T, h *testutil.Harness, admin *testutil.User, path string, decision string) {
+	t.Helper()
+	h.Do(http.MethodPost, path, map[string]interface{}{"decision": decision, "reason": "確認しました"}, admin.Token).
+		Expect(t, http.StatusOK)
+}
+
+func TestDirectPaymentToVisionOperator(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	admin := newAdmin(t, h)
+	operator, vision := newOperator(t, h)
+
+	// 運営会社が登録されていないビジョンでは直接支払いを選べない
+	other := &models.Vision{Name: "運営会社なし"}
+	if err := h.DB.Create(other).Error; err != nil {
+		t.Fatal(err)
+	}
+	input := projectInput("運営会社へ直接支払う企画")
+	input["settlement_mode"] = "operator"
+	input["vision_id"] = other.ID
+	h.Do(http.MethodPost, "/api/v1/projects", input, owner.Token).Expect(t, http.StatusBadRequest)
+
+	var p project
+	input["vision_id"] = vision.ID
+	h.Do(http.MethodPost, "/api/v1/projects", input, owner.Token).Expect(t, http.StatusCreated).Decode(t, &p)
+	input["status"] = "active"
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusOK)
+
+	// 公開後は精算方法を変更できない
+	input["settlement_mode"] = "organizer"
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusConflict)
+	input["settlement_mode"] = "operator"
+
+	c := startCheckout(t, h, supporter, p.ID, 20000)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+
+	// 請求書は運営会社のみ提出できる
+	invoiceInput := map[string]interface{}{"project_id": p.ID, "number": "INV-001", "amount": 15000}
+	h.Do(http.MethodPost, "/api/v1/operator/invoices", invoiceInput, owner.Token).Expect(t, http.StatusForbidden)
+	var inv invoice
+	h.Do(http.MethodPost, "/api/v1/operator/invoices", invoiceInput, operator.Token).Expect(t, http.StatusOK).Decode(t, &inv)
+	if inv.Status != "submitted" || inv.Amount != 15000 {
+		t.Fatalf("提出した請求書: got %+v", inv)
+	}
+	var exp expense
+	h.Do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/expenses", p.ID), map[string]interface{}{
+		"amount": 1500, "description": "データ制作の外注費",
+	}, owner.Token).Expect(t, http.StatusCreated).Decode(t, &exp)
+
+	input["status"] = "complete"
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusOK)
+
+	// 請求書と経費の審査が終わるまで精算しない
+	settle(t, h)
+	review(t, h, admin, fmt.Sprintf("/api/v1/admin/invoices/%d/review", inv.ID), "approve")
+	settle(t, h)
+	if list := mySettlements(t, h, operator); len(list) != 0 {
+		t.Fatalf("経費の審査前に精算されました: %+v", list)
+	}
+	review(t, h, admin, fmt.Sprintf("/api/v1/admin/expenses/%d/review", exp.ID), "approve")
+	settle(t, h)
+
+	operatorAccount := onboard(t, h, operator)
+	ownerAccount := onboard(t, h, owner)
+
+	// 手数料（10%）を差し引いた 18000円 から請求額と経費を支払い、残りは送金しない
+	transfers := map[string]int64{}
+	for _, tr := range h.Connect.Transfers() {
+		transfers[tr.AccountID] += tr.Amount
+	}
+	if len(transfers) != 2 || transfers[operatorAccount] != 15000 || transfers[ownerAccount] != 1500 {
+		t.Fatalf("送金: got %+v", transfers)
+	}
+
+	list := mySettlements(t, h, operator)
+	if len(list) != 1 || list[0].NetAmount != 15000 || list[0].Status != "in_transit" {
+		t.Fatalf("運営会社への精算: got %+v", list)
+	}
+	h.SendConnectWebhook("payout.paid", operatorAccount, map[string]interface{}{
+		"id": list[0].StripePayoutID, "object": "payout", "status": "paid",
+	}).Expect(t, http.StatusOK)
+
+	var invoices []invoice
+	h.Do(http.MethodGet, "/api/v1/operator/invoices", nil, operator.Token).Expect(t, http.StatusOK).Decode(t, &invoices)
+	if len(invoices) != 1 || invoices[0].Status != "paid" {
+		t.Fatalf("入金後の請求書: got %+v", invoices)
+	}
+
+	// 精算の作成後は経費を申請できない
+	h.Do(http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/expenses", p.ID), map[string]interface{}{
+		"amount": 500, "description": "追加の経費",
+	}, owner.Token).Expect(t, http.StatusConflict)
+}
+
+func TestRejectedInvoiceCanBeResubmitted(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+	operator, vision := newOperator(t, h)
+
+	var p project
+	input := projectInput("差し戻しの確認")
+	input["settlement_mode"] = "operator"
+	input["vision_id"] = vision.ID
+	h.Do(http.MethodPost, "/api/v1/projects", input, owner.Token).Expect(t, http.StatusCreated).Decode(t, &p)
+
+	var inv invoice
+	h.Do(http.MethodPost, "/api/v1/operator/invoices", map[string]interface{}{
+		"project_id": p.ID, "number": "INV-002", "amount": 80000,
+	}, operator.Token).Expect(t, http.StatusOK).Decode(t, &inv)
+
+	path := fmt.Sprintf("/api/v1/admin/invoices/%d/review", inv.ID)
+	h.Do(http.MethodPost, path, map[string]interface{}{"decision": "reject"}, admin.Token).Expect(t, http.StatusBadRequest)
+	h.Do(http.MethodPost, path, map[string]interface{}{"decision": "approve"}, operator.Token).Expect(t, http.StatusForbidden)
+	review(t, h, admin, path, "reject")
+
+	h.Do(http.MethodPost, "/api/v1/operator/invoices", map[string]interface{}{
+		"project_id": p.ID, "number": "INV-002-R", "amount": 60000,
+	}, operator.Token).Expect(t, http.StatusOK).Decode(t, &inv)
+	if inv.Status != "submitted" || inv.Amount != 60000 {
+		t.Fatalf("再提出した請求書: got %+v", inv)
+	}
+
+	var pending []invoice
+	h.Do(http.MethodGet, "/api/v1/admin/invoices?status=submitted", nil, admin.Token).Expect(t, http.StatusOK).Decode(t, &pending)
+	if len(pending) != 1 || pending[0].ID != inv.ID {
+		t.Fatalf("審査待ちの請求書: got %+v", pending)
+	}
+
+	review(t, h, admin, path, "approve")
+	h.Do(http.MethodPost, "/api/v1/operator/invoices", map[string]interface{}{
+		"project_id": p.ID, "number": "INV-002-R2", "amount": 70000,
+	}, operator.Token).Expect(t, http.StatusConflict)
+}
@@ -0,0 +1,184 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type accountBalance struct {
+	Account string `json:"account"`
+	Debit   int64  `json:"debit"`
+	Credit  int64  `json:"credit"`
+	Balance int64  `json:"balance"`
+}
+
+type projectLedger struct {
+	Accounts []accountBalance `json:"accounts"`
+	Held     int64            `json:"held"`
+	Entries  []struct {
+		Reference string `json:"reference"`
+		Kind      string `json:"kind"`
+	} `json:"entries"`
+}
+
+func (l projectLedger) balance(account string) int64 {
+	for _, a := range l.Accounts {
+		if a.Account == account {
+			return a.Balance
+		}
+	}
+	return 0
+}
+
+func getProjectLedger(t *testing.T, h *testutil.Harness, admin *testutil.User, projectID uint) projectLedger {
+	t.Helper()
+	var ledger projectLedger
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/admin/ledger/projects/%d", projectID), nil, admin.Token).
+		Expect(t, http.StatusOK).Decode(t, &ledger)
+	return ledger
+}
+
+// dispute はチャージバックのイベントの内容を組み立てます（balance は残高取引の額と手数料の組）
+func dispute(paymentIntentID string, amount int64, balance ...[2]int64) map[string]interface{} {
+	var txns []map[string]interface{}
+	for i, b := range balance {
+		txns = append(txns, map[string]interface{}{
+			"id": fmt.Sprintf("txn_test_%d", i+1), "object": "balance_transaction", "amount": b[0], "fee": b[1],
+		})
+	}
+	return map[string]interface{}{
+		"id": "dp_test_1", "object": "dispute", "amount": amount,
+		"payment_intent": paymentIntentID, "balance_transactions": txns,
+	}
+}
+
+func TestLedgerRecordsMoneyMovements(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+
+	h.Do(http.MethodGet, "/api/v1/admin/ledger/trial-balance", nil, owner.Token).Expect(t, http.StatusForbidden)
+
+	p, checkouts := completedProject(t, h, owner, 30000, 70000)
+	refunded := map[string]interface{}{
+		"id": "ch_test_1", "object": "charge",
+		"payment_intent": "pi_" + checkouts[1].CheckoutSessionID, "amount_refunded": 20000,
+	}
+	// 同じ返金の再送は二重に計上しない
+	h.SendWebhook("charge.refunded", refunded).Expect(t, http.StatusOK)
+	h.SendWebhook("charge.refunded", refunded).Expect(t, http.StatusOK)
+
+	paymentIntent := "pi_" + checkouts[0].CheckoutSessionID
+	h.SendWebhook("charge.dispute.funds_withdrawn", dispute(paymentIntent, 30000, [2]int64{-30000, 1500})).
+		Expect(t, http.StatusOK)
+	// 返金とチャージバックは預り金から差し引く
+	ledger := getProjectLedger(t, h, admin, p.ID)
+	if ledger.Held != 50000 || ledger.balance("project_escrow") != 50000 ||
+		ledger.balance("disputes") != 0 || ledger.balance("refunds") != 0 {
+		t.Fatalf("チャージバック後の帳簿: got %+v", ledger)
+	}
+	h.SendWebhook("charge.dispute.funds_reinstated", dispute(paymentIntent, 30000, [2]int64{-30000, 1500}, [2]int64{30000, 0})).
+		Expect(t, http.StatusOK)
+
+	settle(t, h)
+	onboard(t, h, owner)
+
+	// 手数料 10%: (100000 - 20000) × 10% = 8000円、送金額 72000円
+	// 決済手数料 3.6%: 1080 + 2520 円、チャージバック手数料 1500円
+	ledger = getProjectLedger(t, h, admin, p.ID)
+	want := map[string]int64{
+		"platform_cash":     2900,
+		"project_escrow":    0,
+		"refunds":           0,
+		"disputes":          0,
+		"organizer_payable": 0,
+		"operator_payable":  0,
+		"platform_revenue":  8000,
+		"stripe_fees":       5100,
+	}
+	for account, balance := range want {
+		if got := ledger.balance(account); got != balance {
+			t.Errorf("%s の残高: got %d, want %d", account, got, balance)
+		}
+	}
+	if ledger.Held != 0 {
+		t.Errorf("送金後の預り金: got %d, want 0", ledger.Held)
+	}
+	kinds := map[string]int{}
+	for _, e := range ledger.Entries {
+		kinds[e.Kind]++
+	}
+	if kinds["refund"] != 1 || kinds["transfer"] != 1 || kinds["support_completed"] != 2 {
+		t.Errorf("仕訳: got %v", kinds)
+	}
+
+	var tb struct {
+		TotalDebit  int64 `json:"total_debit"`
+		TotalCredit int64 `json:"total_credit"`
+		Balanced    bool  `json:"balanced"`
+	}
+	h.Do(http.MethodGet, "/api/v1/admin/ledger/trial-balance", nil, admin.Token).Expect(t, http.StatusOK).Decode(t, &tb)
+	if !tb.Balanced || tb.TotalDebit != tb.TotalCredit || tb.TotalDebit == 0 {
+		t.Errorf("試算表: got %+v", tb)
+	}
+}
+
+func TestLedgerAdjustsSettlementAfterRefund(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+
+	p, checkouts := completedProject(t, h, owner, 10000)
+	settle(t, h)
+	ledger := getProjectLedger(t, h, admin, p.ID)
+	if ledger.balance("organizer_payable") != 9000 || ledger.balance("platform_revenue") != 1000 {
+		t.Fatalf("精算の計上: got %+v", ledger)
+	}
+
+	// 精算の作成後・送金前の返金は、送金時に未払金と手数料の計上額を修正する
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id": "ch_test_1", "object": "charge",
+		"payment_intent": "pi_" + checkouts[0].CheckoutSessionID, "amount_refunded": 4000,
+	}).Expect(t, http.StatusOK)
+	onboard(t, h, owner)
+
+	ledger = getProjectLedger(t, h, admin, p.ID)
+	if ledger.balance("organizer_payable") != 0 || ledger.balance("platform_revenue") != 600 ||
+		ledger.balance("project_escrow") != 0 || ledger.Held != 0 {
+		t.Fatalf("返金後の帳簿: got %+v", ledger)
+	}
+	if transfers := h.Connect.Transfers(); len(transfers) != 1 || transfers[0].Amount != 5400 {
+		t.Fatalf("送金: got %+v", transfers)
+	}
+}
+
+func TestLedgerSettlesRefundedProjectFromEscrow(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+
+	p, checkouts := completedProject(t, h, owner, 10000)
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id": "ch_test_1", "object": "charge",
+		"payment_intent": "pi_" + checkouts[0].CheckoutSessionID, "amount_refunded": 4000,
+	}).Expect(t, http.StatusOK)
+	ledger := getProjectLedger(t, h, admin, p.ID)
+	if ledger.balance("project_escrow") != 6000 || ledger.Held != 6000 {
+		t.Fatalf("返金後の預り金: got %+v", ledger)
+	}
+
+	// 精算は返金を差し引いた預り金から計上し、送金後の預り金は残らない
+	settle(t, h)
+	onboard(t, h, owner)
+	ledger = getProjectLedger(t, h, admin, p.ID)
+	if ledger.balance("project_escrow") != 0 || ledger.Held != 0 ||
+		ledger.balance("organizer_payable") != 0 || ledger.balance("platform_revenue") != 600 {
+		t.Fatalf("精算後の帳簿: got %+v", ledger)
+	}
+	if transfers := h.Connect.Transfers(); len(transfers) != 1 || transfers[0].Amount != 5400 {
+		t.Fatalf("送金: got %+v", transfers)
+	}
+}
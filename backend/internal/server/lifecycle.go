@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/db/migrations"
+	"github.com/masvc/oshiome_go/backend/internal/handlers"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// readHeaderTimeout はリクエストヘッダーの受信を待つ時間（Slowloris対策）
+const readHeaderTimeout = 10 * time.Second
+
+// Serve は ln でHTTPリクエストを受け付け、ctx がキャンセルされると停止します
+//
+// 停止時は /readyz を503にしてSSEの購読を閉じ、処理中のリクエスト（Webhookなど）の完了を
+// SHUTDOWN_TIMEOUT まで待ちます。バックグラウンド処理の停止は Stop で行います
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- httpServer.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("サーバーを停止します。処理中のリクエストの完了を待ちます", "timeout", s.shutdownTimeout.String())
+	s.draining.Store(true)
+	// SSEの接続は終わらないため、先に購読を閉じてストリームを終了させる
+	s.Hub.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+		return fmt.Errorf("処理中のリクエストが時間内に完了しませんでした: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop はバックグラウンド処理（ジョブ、リアルタイム配信、定期タスク）を止め、
+// 実行中のジョブ・定期タスクの終了を ctx の期限まで待ちます
+func (s *Server) Stop(ctx context.Context) error {
+	if s.cancelBackground == nil {
+		return nil
+	}
+	s.cancelBackground()
+
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("バックグラウンド処理が時間内に停止しませんでした: %w", ctx.Err())
+	}
+}
+
+// isDraining は停止処理中かを返します
+func (s *Server) isDraining() bool {
+	return s.draining.Load()
+}
+
+// readinessChecks は /readyz で確認する依存先（データベース・マイグレーション・決済）を返します
+func readinessChecks(database *gorm.DB, stripeCfg config.Stripe) []handlers.ReadinessCheck {
+	return []handlers.ReadinessCheck{
+		{Name: "database", Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if database == nil {
+				return nil, errors.New("データベースが設定されていません")
+			}
+			sqlDB, err := database.DB()
+			if err == nil {
+				err = sqlDB.PingContext(ctx)
+			}
+			if err != nil {
+				// 接続先などの詳細はレスポンスに含めずログに出力する
+				logging.FromContext(ctx).Error("データベースに接続できません", "error", err)
+				return nil, errors.New("データベースに接続できません")
+			}
+			return nil, nil
+		}},
+		{Name: "migrations", Check: func(ctx context.Context) (map[string]interface{}, error) {
+			if database == nil {
+				return nil, errors.New("データベースが設定されていません")
+			}
+			v, err := migrations.CheckVersion(ctx, database)
+			if err != nil {
+				logging.FromContext(ctx).Error("スキーマのバージョンを確認できません", "error", err)
+				return nil, errors.New("スキーマのバージョンを確認できません")
+			}
+			detail := map[string]interface{}{"version": v.Current, "latest": v.Latest}
+			if v.Pending > 0 {
+				return detail, fmt.Errorf("未適用のマイグレーションがあります（%d件）", v.Pending)
+			}
+			return detail, nil
+		}},
+		{Name: "payments", Check: func(ctx context.Context) (map[string]interface{}, error) {
+			mode := utils.StripeMode(stripeCfg.SecretKey)
+			switch {
+			case stripeCfg.SecretKey == "":
+				return nil, errors.New("STRIPE_SECRET_KEY が設定されていません")
+			case mode == "":
+				return nil, errors.New("STRIPE_SECRET_KEY の形式が不正です")
+			case stripeCfg.WebhookSecret == "":
+				return map[string]interface{}{"mode": mode}, errors.New("STRIPE_WEBHOOK_SECRET が設定されていません")
+			}
+			return map[string]interface{}{"mode": mode}, nil
+		}},
+	}
+}
@@ -0,0 +1,165 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// newServerWithLogs はJSONのログをバッファに出力するサーバーを組み立てます（データベースなし）
+func newServerWithLogs(t *testing.T) (*server.Server, *bytes.Buffer) {
+	t.Helper()
+	cfg := testutil.Config()
+	cfg.Log.Level = "debug"
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, cfg.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := server.New(server.Deps{
+		Config:   cfg,
+		Mailer:   &testutil.Mailer{},
+		Payments: testutil.NewFakePayments(),
+		Logger:   logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, &buf
+}
+
+// logEntries はバッファのJSONログを1行ずつデコードします
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("JSONではないログがあります: %v\n%s", err, scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// requestLog はアクセスログ（msg=request）を返します
+func requestLog(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	for _, entry := range logEntries(t, buf) {
+		if entry["msg"] == "request" {
+			return entry
+		}
+	}
+	t.Fatalf("アクセスログがありません:\n%s", buf)
+	return nil
+}
+
+func TestRequestIDIsPropagatedToResponseAndLogs(t *testing.T) {
+	srv, buf := newServerWithLogs(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	req.Header.Set("X-Request-ID", "req-123.abc")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "req-123.abc" {
+		t.Fatalf("X-Request-ID: got %q, want req-123.abc", got)
+	}
+
+	entry := requestLog(t, buf)
+	want := map[string]interface{}{
+		"request_id": "req-123.abc",
+		"method":     "GET",
+		"route":      "/api/v1/health",
+		"status":     float64(200),
+		"level":      "INFO",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s: got %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("latency_ms がありません: %v", entry)
+	}
+}
+
+func TestRequestIDIsGeneratedWhenMissingOrInvalid(t *testing.T) {
+	srv, _ := newServerWithLogs(t)
+
+	for _, incoming := range []string{"", "has spaces\nand newline", strings.Repeat("a", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+		if incoming != "" {
+			req.Header.Set("X-Request-ID", incoming)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("X-Request-ID"); !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(got) {
+			t.Errorf("incoming %q: X-Request-ID got %q, want generated id", incoming, got)
+		}
+	}
+}
+
+func TestWebhookDoesNotLogSignature(t *testing.T) {
+	srv, buf := newServerWithLogs(t)
+
+	signature := "t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd"
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhook", strings.NewReader(`{"id":"evt_1"}`))
+	req.Header.Set("Stripe-Signature", signature)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status: got %d, want 400", rec.Code)
+	}
+	if strings.Contains(buf.String(), "5257a869") {
+		t.Fatalf("署名がログに出力されています:\n%s", buf)
+	}
+	if entry := requestLog(t, buf); entry["level"] != "WARN" {
+		t.Errorf("level: got %v, want WARN", entry["level"])
+	}
+}
+
+func TestContextLoggerRedactsSensitiveValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, testutil.Config().Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("決済を確認しました: alice@example.com pi_3NxYzAbCdEfGh1234",
+		slog.String("password", "hunter2"),
+		slog.String("email", "bob@example.jp"),
+		slog.String("checkout_session", "cs_test_a1B2c3D4e5F6g7H8"),
+		slog.Any("error", errString("stripe key sk_live_51HxxSECRETxx is invalid")),
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"alice@", "bob@", "hunter2", "AbCdEfGh", "a1B2c3D4", "SECRET"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("%q がログに出力されています:\n%s", leaked, out)
+		}
+	}
+	for _, kept := range []string{"***@example.com", "pi_****1234", "cs_test_****g7H8", "sk_live_[REDACTED]"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("%q がログにありません:\n%s", kept, out)
+		}
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -0,0 +1,12 @@
+package server_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunMain(m))
+}
@@ -0,0 +1,100 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+const metricsToken = "metrics-test-token"
+
+// newServerWithMetrics は METRICS_TOKEN を設定したサーバーを組み立てます（データベースなし）
+func newServerWithMetrics(t *testing.T) *server.Server {
+	t.Helper()
+	cfg := testutil.Config()
+	cfg.Metrics.Token = metricsToken
+	srv, err := server.New(server.Deps{
+		Config:   cfg,
+		Mailer:   &testutil.Mailer{},
+		Payments: testutil.NewFakePayments(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func scrape(t *testing.T, srv *server.Server, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMetricsRequireToken(t *testing.T) {
+	srv := newServerWithMetrics(t)
+
+	if rec := scrape(t, srv, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("トークンなし: got %d, want 401", rec.Code)
+	}
+	if rec := scrape(t, srv, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("誤ったトークン: got %d, want 401", rec.Code)
+	}
+
+	// METRICS_TOKEN が未設定の場合はAPIのポートでは公開しない（内部向けのリスナーのみ）
+	if rec := scrape(t, newServerWithoutDB(t), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("METRICS_TOKEN 未設定: got %d, want 404", rec.Code)
+	}
+}
+
+func TestMetricsExposeHTTPAndBusinessMetrics(t *testing.T) {
+	srv := newServerWithMetrics(t)
+
+	for _, path := range []string{"/api/v1/health", "/api/v1/no-such-route"} {
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	webhookFailures := metrics.WebhookEvents.Value("unknown", metrics.WebhookInvalidSignature)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhook", strings.NewReader("{}"))
+	req.Header.Set("Stripe-Signature", "t=1,v1=00")
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	if got := metrics.WebhookEvents.Value("unknown", metrics.WebhookInvalidSignature) - webhookFailures; got != 1 {
+		t.Errorf("署名が無効なWebhookの件数の増分: got %v, want 1", got)
+	}
+
+	rec := scrape(t, srv, metricsToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type: got %q", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE oshiome_http_requests_total counter",
+		`oshiome_http_requests_total{method="GET",route="/api/v1/health",status="200"}`,
+		`oshiome_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		"# TYPE oshiome_http_request_duration_seconds histogram",
+		`oshiome_http_request_duration_seconds_bucket{method="GET",route="/api/v1/health",le="+Inf"}`,
+		`oshiome_http_request_duration_seconds_count{method="GET",route="/api/v1/health"}`,
+		"# TYPE oshiome_http_requests_in_flight gauge",
+		"oshiome_supports_created_total ",
+		"oshiome_supports_completed_total ",
+		"oshiome_supports_failed_total ",
+		"oshiome_supports_collected_yen_total ",
+		`oshiome_webhook_events_total{type="unknown",result="invalid_signature"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("%q がありません:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/db/migrations"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// newMigrator はスキーマへの接続からMigratorを作成します
+func newMigrator(t *testing.T, database *gorm.DB) *migrations.Migrator {
+	t.Helper()
+	sqlDB, err := database.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator
+}
+
+func TestMigrationsRunWithoutSuperuser(t *testing.T) {
+	// 本番（マネージドのPostgreSQL）と同じく、スーパーユーザーでもロールの作成権限もないスキーマの所有者で実行する
+	database := testutil.NewSchema(t, "oshiome_test_owner")
+	migrator := newMigrator(t, database)
+	ctx := context.Background()
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("マイグレーションの適用: %v", err)
+	}
+
+	// 監査ログは所有者でも直接は更新・削除できず、保守用の関数でのみ削除できる
+	if err := database.Exec("INSERT INTO audit_events (action, target_type, target_id, created_at) VALUES ('test', 'user', 1, now() - interval '1 day')").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, sql := range []string{
+		"UPDATE audit_events SET action = 'tampered'",
+		"DELETE FROM audit_events",
+		"TRUNCATE audit_events",
+	} {
+		if err := database.Exec(sql).Error; err == nil {
+			t.Errorf("%s: 監査ログを変更できてしまいます", sql)
+		}
+	}
+	var erased, pruned int64
+	if err := database.Raw("SELECT erase_audit_event_changes('user', 1)").Scan(&erased).Error; err != nil {
+		t.Errorf("変更前後の値の消去: %v", err)
+	}
+	if err := database.Raw("SELECT prune_audit_events(now())").Scan(&pruned).Error; err != nil || pruned != 1 {
+		t.Errorf("保持期間による削除: got %d, %v, want 1", pruned, err)
+	}
+
+	// 全てのマイグレーションを取り消せる
+	for {
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatalf("マイグレーションの取り消し: %v", err)
+		}
+		if reverted == nil {
+			break
+		}
+	}
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) == 0 {
+		t.Fatalf("取り消し後の未適用のマイグレーション: got %d, %v", len(pending), err)
+	}
+}
+
+// appliedCount は適用済みのマイグレーションの数を返します
+func appliedCount(t *testing.T, migrator *migrations.Migrator) int {
+	t.Helper()
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := 0
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			applied++
+		}
+	}
+	return applied
+}
+
+func TestMigratorUpDownRedo(t *testing.T) {
+	migrator := newMigrator(t, testutil.NewSchema(t, ""))
+	ctx := context.Background()
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("マイグレーションの適用: %v", err)
+	}
+	total := len(applied)
+	if total == 0 || appliedCount(t, migrator) != total {
+		t.Fatalf("適用したマイグレーション: got %d, applied %d", total, appliedCount(t, migrator))
+	}
+	last := applied[total-1]
+	if again, err := migrator.Up(ctx); err != nil || len(again) != 0 {
+		t.Errorf("2回目の適用: got %d, %v", len(again), err)
+	}
+
+	// 最後のマイグレーションを取り消して再適用する
+	redone, err := migrator.Redo(ctx)
+	if err != nil || redone == nil || redone.Version != last.Version {
+		t.Fatalf("再適用: got %+v, %v, want %04d", redone, err, last.Version)
+	}
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("再適用後の未適用のマイグレーション: got %d, %v", len(pending), err)
+	}
+
+	// 最後のマイグレーションだけを取り消し、次の適用でそれだけを適用する
+	reverted, err := migrator.Down(ctx)
+	if err != nil || reverted == nil || reverted.Version != last.Version {
+		t.Fatalf("取り消し: got %+v, %v, want %04d", reverted, err, last.Version)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil || len(pending) != 1 || pending[0].Version != last.Version {
+		t.Fatalf("取り消し後の未適用のマイグレーション: got %+v, %v", pending, err)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 1 || applied[0].Version != last.Version {
+		t.Errorf("取り消し後の適用: got %+v, %v", applied, err)
+	}
+
+	// 一度も適用していないスキーマでは取り消すものがない
+	empty := newMigrator(t, testutil.NewSchema(t, ""))
+	if reverted, err := empty.Down(ctx); err != nil || reverted != nil {
+		t.Errorf("未適用のスキーマの取り消し: got %+v, %v", reverted, err)
+	}
+	if redone, err := empty.Redo(ctx); err != nil || redone != nil {
+		t.Errorf("未適用のスキーマの再適用: got %+v, %v", redone, err)
+	}
+}
+
+func TestMigratorLockSerializesConcurrentRuns(t *testing.T) {
+	database := testutil.NewSchema(t, "")
+	ctx := context.Background()
+
+	// 複数のレプリカが同時に起動しても、各マイグレーションはどちらか一方だけが適用する
+	const replicas = 3
+	results := make(chan []migrations.Migration, replicas)
+	errs := make(chan error, replicas)
+	for i := 0; i < replicas; i++ {
+		migrator := newMigrator(t, database)
+		go func() {
+			applied, err := migrator.Up(ctx)
+			results <- applied
+			errs <- err
+		}()
+	}
+
+	seen := map[int]int{}
+	for i := 0; i < replicas; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("同時のマイグレーション: %v", err)
+		}
+		for _, m := range <-results {
+			seen[m.Version]++
+		}
+	}
+	migrator := newMigrator(t, database)
+	if total := appliedCount(t, migrator); len(seen) != total {
+		t.Errorf("適用したマイグレーション: got %d, want %d", len(seen), total)
+	}
+	for version, n := range seen {
+		if n != 1 {
+			t.Errorf("%04d を %d 回適用しました", version, n)
+		}
+	}
+	if pending, err := migrator.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("同時のマイグレーション後の未適用のマイグレーション: got %d, %v", len(pending), err)
+	}
+}
@@ -0,0 +1,254 @@
+package server_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/auth/oidc"
+	"github.com/masvc/oshiome_go/backend/internal/auth/oidc/oidctest"
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// newOAuthHarness はモックのOIDC発行者をプロバイダー mock として設定したサーバーを組み立てます
+func newOAuthHarness(t *testing.T) (*testutil.Harness, *oidctest.Issuer) {
+	t.Helper()
+	issuer := oidctest.NewIssuer()
+	t.Cleanup(issuer.Close)
+	h := testutil.New(t, func(cfg *config.Config) {
+		cfg.OAuth.Providers = []config.OAuthProvider{{
+			Name:         "mock",
+			ClientID:     issuer.ClientID,
+			ClientSecret: issuer.ClientSecret,
+			Issuer:       issuer.URL(),
+		}}
+	})
+	return h, issuer
+}
+
+// oauthFlow は認可リクエストの開始から発行者の承認までを進めた状態
+type oauthFlow struct {
+	// callback はコールバックのパス（クエリに code と state を含む）
+	callback string
+	// cookie は認可リクエストを開始したブラウザに設定されたCookie
+	cookie *http.Cookie
+}
+
+// authorize は発行者の認可画面へ遷移し、承認後のコールバックのパスを返します
+func authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	location, err := url.Parse(res.Header.Get("Location"))
+	if res.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("発行者の認可: got %d %q", res.StatusCode, res.Header.Get("Location"))
+	}
+	return location.RequestURI()
+}
+
+func browserCookie(t *testing.T, res *testutil.Response) *http.Cookie {
+	t.Helper()
+	for _, cookie := range (&http.Response{Header: res.Header}).Cookies() {
+		if cookie.Name == "oshiome_oauth" {
+			if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+				t.Errorf("Cookieの属性: got %+v", cookie)
+			}
+			return cookie
+		}
+	}
+	t.Fatalf("認可リクエストのCookieが設定されていません: %v", res.Header)
+	return nil
+}
+
+// startOAuth は GET /auth/oauth/mock/start でログインを開始します
+func startOAuth(t *testing.T, h *testutil.Harness) oauthFlow {
+	t.Helper()
+	res := h.Do(http.MethodGet, "/api/v1/auth/oauth/mock/start", nil, "").Expect(t, http.StatusFound)
+	return oauthFlow{callback: authorize(t, res.Header.Get("Location")), cookie: browserCookie(t, res)}
+}
+
+// linkOAuth は POST /auth/oauth/mock/link でログイン済みユーザーへの連携を開始します
+func linkOAuth(t *testing.T, h *testutil.Harness, user *testutil.User) oauthFlow {
+	t.Helper()
+	res := h.Do(http.MethodPost, "/api/v1/auth/oauth/mock/link", nil, user.Token).Expect(t, http.StatusOK)
+	var data struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	res.Decode(t, &data)
+	return oauthFlow{callback: authorize(t, data.AuthorizationURL), cookie: browserCookie(t, res)}
+}
+
+// finishOAuth はコールバックを呼び出し、フロントエンドへ戻すURLのフラグメントを返します
+func finishOAuth(t *testing.T, h *testutil.Harness, callback string, cookie *http.Cookie) url.Values {
+	t.Helper()
+	req := h.NewRequest(http.MethodGet, callback, nil, "")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	res := h.Serve(req).Expect(t, http.StatusFound)
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || !strings.HasSuffix(location.Path, "/oauth/callback") {
+		t.Fatalf("フロントエンドへのリダイレクト: got %q", res.Header.Get("Location"))
+	}
+	values, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func currentUser(t *testing.T, h *testutil.Harness, token string) (id uint, email string) {
+	t.Helper()
+	var user struct {
+		ID    uint   `json:"id"`
+		Email string `json:"email"`
+	}
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, token).Expect(t, http.StatusOK).Decode(t, &user)
+	return user.ID, user.Email
+}
+
+func TestOAuthLoginCreatesUser(t *testing.T) {
+	h, issuer := newOAuthHarness(t)
+	issuer.SetIdentity(oidc.Identity{Subject: "new-user", Email: "New.User@example.com", EmailVerified: true, Name: "新規ユーザー"})
+
+	flow := startOAuth(t, h)
+	result := finishOAuth(t, h, flow.callback, flow.cookie)
+	if result.Get("token") == "" {
+		t.Fatalf("ログインの結果: got %v", result)
+	}
+	id, email := currentUser(t, h, result.Get("token"))
+	if email != "new.user@example.com" {
+		t.Errorf("作成したユーザーのメールアドレス: got %q", email)
+	}
+
+	// 2回目以降は連携済みの同じユーザーでログインする
+	flow = startOAuth(t, h)
+	if again, _ := currentUser(t, h, finishOAuth(t, h, flow.callback, flow.cookie).Get("token")); again != id {
+		t.Errorf("2回目のログイン: got user %d, want %d", again, id)
+	}
+}
+
+func TestOAuthLoginDoesNotLinkExistingEmail(t *testing.T) {
+	h, issuer := newOAuthHarness(t)
+	user := h.Register("alice")
+	issuer.SetIdentity(oidc.Identity{Subject: "alice-subject", Email: strings.ToUpper(user.Email), EmailVerified: true})
+
+	// メールアドレスが一致しても、ログインしていない外部アカウントは既存のユーザーに連携しない
+	flow := startOAuth(t, h)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("error") != "account_exists" || result.Get("token") != "" {
+		t.Errorf("登録済みのメールアドレス: got %v", result)
+	}
+	var count int64
+	h.DB.Model(&models.UserIdentity{}).Where("subject = ?", "alice-subject").Count(&count)
+	if count != 0 {
+		t.Fatalf("連携された外部アカウント: got %d, want 0", count)
+	}
+
+	// 確認されていないメールアドレスでもログインできない
+	issuer.SetIdentity(oidc.Identity{Subject: "unverified", Email: user.Email, EmailVerified: false})
+	flow = startOAuth(t, h)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("error") != "email_not_verified" {
+		t.Errorf("未確認のメールアドレス: got %v", result)
+	}
+
+	// ログインして連携した後は外部アカウントでログインできる
+	issuer.SetIdentity(oidc.Identity{Subject: "alice-subject", Email: user.Email, EmailVerified: true})
+	flow = linkOAuth(t, h, user)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("linked") != "mock" {
+		t.Fatalf("連携の結果: got %v", result)
+	}
+	flow = startOAuth(t, h)
+	if id, _ := currentUser(t, h, finishOAuth(t, h, flow.callback, flow.cookie).Get("token")); id != user.ID {
+		t.Errorf("連携後のログイン: got user %d, want %d", id, user.ID)
+	}
+}
+
+func TestOAuthCallbackRejectsReplayedExpiredAndForeignState(t *testing.T) {
+	h, _ := newOAuthHarness(t)
+
+	// stateは1回限り
+	flow := startOAuth(t, h)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("token") == "" {
+		t.Fatalf("ログインの結果: got %v", result)
+	}
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("error") != "invalid_state" {
+		t.Errorf("再利用したstate: got %v", result)
+	}
+
+	// 期限切れのstate
+	flow = startOAuth(t, h)
+	if err := h.DB.Model(&models.OAuthState{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("error") != "invalid_state" {
+		t.Errorf("期限切れのstate: got %v", result)
+	}
+
+	// 他のブラウザで開始したコールバック（ログインCSRF）はCookieがないため受け付けない
+	flow = startOAuth(t, h)
+	if result := finishOAuth(t, h, flow.callback, nil); result.Get("error") != "invalid_state" {
+		t.Errorf("Cookieのないコールバック: got %v", result)
+	}
+	other := startOAuth(t, h)
+	flow = startOAuth(t, h)
+	if result := finishOAuth(t, h, flow.callback, other.cookie); result.Get("error") != "invalid_state" {
+		t.Errorf("別の認可リクエストのCookie: got %v", result)
+	}
+}
+
+func TestOAuthLinkRequiresInitiatingBrowser(t *testing.T) {
+	h, issuer := newOAuthHarness(t)
+	attacker := h.Register("attacker")
+	victim := h.Register("victim")
+	issuer.SetIdentity(oidc.Identity{Subject: "victim-subject", Email: victim.Email, EmailVerified: true})
+
+	// 攻撃者が発行した連携用の認可URLを被害者が開いても、被害者の外部アカウントは連携されない
+	flow := linkOAuth(t, h, attacker)
+	if result := finishOAuth(t, h, flow.callback, nil); result.Get("error") != "invalid_state" {
+		t.Errorf("他人の連携用URL: got %v", result)
+	}
+	var count int64
+	h.DB.Model(&models.UserIdentity{}).Where("subject = ?", "victim-subject").Count(&count)
+	if count != 0 {
+		t.Fatalf("連携された外部アカウント: got %d, want 0", count)
+	}
+
+	// 本人のブラウザでは連携できる
+	flow = linkOAuth(t, h, victim)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("linked") != "mock" {
+		t.Errorf("連携の結果: got %v", result)
+	}
+	var identity models.UserIdentity
+	if err := h.DB.Where("subject = ?", "victim-subject").First(&identity).Error; err != nil || identity.UserID != victim.ID {
+		t.Errorf("連携した外部アカウント: got %+v, %v", identity, err)
+	}
+}
+
+func TestOAuthLoginHandsOffToTwoFactor(t *testing.T) {
+	h, issuer := newOAuthHarness(t)
+	user := h.Register("admin")
+	issuer.SetIdentity(oidc.Identity{Subject: "admin-subject", Email: user.Email, EmailVerified: true})
+	flow := linkOAuth(t, h, user)
+	if result := finishOAuth(t, h, flow.callback, flow.cookie); result.Get("linked") != "mock" {
+		t.Fatalf("連携の結果: got %v", result)
+	}
+	promoteToAdmin(t, h, user)
+
+	// 二要素認証が必須のユーザーはトークンではなく二要素認証待ちのトークンを受け取る
+	flow = startOAuth(t, h)
+	result := finishOAuth(t, h, flow.callback, flow.cookie)
+	if result.Get("token") != "" || result.Get("pre_auth_token") == "" || result.Get("enrollment_required") != "true" {
+		t.Fatalf("ログインの結果: got %v", result)
+	}
+	preAuth := result.Get("pre_auth_token")
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, preAuth).Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/enroll", nil, preAuth).Expect(t, http.StatusOK)
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/handlers"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/openapi"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// apiSpec はAPIのOpenAPIドキュメントを組み立てます
+// ルートを追加・変更した場合はここにも追加してください（server_test でルーターとの差分を検出します）
+func apiSpec(backendURL string) *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title:       "推しおめ API",
+		Version:     "1.0.0",
+		Description: "推し活クラウドファンディング「推しおめ」のバックエンドAPI。成功時は `{status, message, data}`、エラー時は `APIError` の形式で返します。バージョンのない `/api/...` は移行期間中の別名で、`Deprecation` / `Sunset` ヘッダーを付けて返します。",
+	})
+	d.Servers = []openapi.Server{{URL: backendURL}}
+	d.Tags = []openapi.Tag{
+		{Name: "auth", Description: "登録・ログイン・二要素認証・ソーシャルログイン"},
+		{Name: "users", Description: "ユーザー情報と個人データ"},
+		{Name: "projects", Description: "プロジェクト"},
+		{Name: "supports", Description: "支援と決済"},
+		{Name: "receipts", Description: "支援の領収書（適格請求書）"},
+		{Name: "payouts", Description: "主催者・運営会社への送金と精算"},
+		{Name: "invoices", Description: "ビジョンの運営会社への直接支払い（請求書・経費の立替）"},
+		{Name: "admin", Description: "管理者向けの操作"},
+		{Name: "system", Description: "ヘルスチェック・Webhook・APIドキュメント"},
+	}
+	d.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "ログインで発行されたトークン",
+	}
+	d.Components.SecuritySchemes["preAuthToken"] = &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "二要素認証の登録が必要なユーザーに発行される二要素認証待ちのトークン",
+	}
+
+	d.Enum(models.ProjectStatus(""), "draft", "active", "complete", "cancelled")
+	d.Enum(models.SupportStatus(""), "pending", "completed", "failed", "cancelled", "refunded")
+	d.Enum(models.ProjectType(""), "digital_signage", "station_ad", "other")
+	d.Enum(models.SettlementStatus(""), "pending", "in_transit", "paid", "failed")
+	d.Enum(models.SettlementMode(""), "organizer", "operator")
+	d.Enum(models.SettlementRecipient(""), "organizer", "operator", "reimbursement")
+	d.Enum(models.ReviewStatus(""), "submitted", "approved", "rejected", "paid")
+	d.Enum(models.ReceiptStatus(""), "issued", "void")
+	d.Enum(models.UserRole(""), "user", "admin", "agency", "operator")
+	d.Enum(models.LedgerAccount(""), "platform_cash", "project_escrow", "refunds", "disputes",
+		"organizer_payable", "operator_payable", "platform_revenue", "stripe_fees")
+	d.Enum(models.JournalKind(""), "support_completed", "stripe_fee", "refund", "dispute_withdrawn", "dispute_reinstated",
+		"settlement", "transfer")
+	d.Enum(models.ReconciliationKind(""), "support_completed", "refund_recorded", "fee_recorded",
+		"unknown_payment", "support_not_found", "amount_mismatch", "duplicate_payment", "not_charged", "refund_mismatch", "fee_mismatch")
+	d.Type(models.JSONB(""), &openapi.Schema{Type: "object"})
+	apiError := d.Schema(utils.APIError{})
+
+	var (
+		user        = d.Schema(models.User{})
+		project     = d.Schema(models.Project{})
+		support     = d.Schema(models.Support{})
+		projects    = openapi.ArrayOf(project)
+		settlement  = d.Schema(models.Settlement{})
+		settlements = openapi.ArrayOf(settlement)
+		auth        = d.Schema(struct {
+			User  models.User `json:"user"`
+			Token string      `json:"token"`
+		}{})
+		message = d.Schema(struct {
+			Message string `json:"message"`
+		}{})
+		recoveryCodes = d.Schema(struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		}{})
+	)
+
+	op := func(tag, summary string, data *openapi.Schema) *openapi.Operation {
+		return &openapi.Operation{
+			Tags:    []string{tag},
+			Summary: summary,
+			Responses: map[string]*openapi.Response{
+				"200":     openapi.JSONResponse("成功", envelope(data)),
+				"default": openapi.JSONResponse("エラー", apiError),
+			},
+		}
+	}
+	secured := func(o *openapi.Operation) *openapi.Operation {
+		o.Security = []map[string][]string{{"bearerAuth": {}}}
+		o.Responses["401"] = openapi.JSONResponse("認証が必要です", apiError)
+		return o
+	}
+	status := func(o *openapi.Operation, code string) *openapi.Operation {
+		o.Responses[code] = o.Responses["200"]
+		delete(o.Responses, "200")
+		return o
+	}
+	body := func(o *openapi.Operation, v interface{}) *openapi.Operation {
+		o.RequestBody = openapi.JSONBody(d.Schema(v))
+		return o
+	}
+	query := func(o *openapi.Operation, name, description string, required bool) *openapi.Operation {
+		o.Parameters = append(o.Parameters, &openapi.Parameter{
+			Name: name, In: "query", Description: description, Required: required, Schema: &openapi.Schema{Type: "string"},
+		})
+		return o
+	}
+
+	// システム
+	d.Add(http.MethodGet, "/api/v1/health", &openapi.Operation{
+		Tags:    []string{"system"},
+		Summary: "ヘルスチェック",
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSONResponse("稼働中", openapi.Object(map[string]*openapi.Schema{
+				"status":  openapi.String(""),
+				"service": openapi.String(""),
+			})),
+		},
+	})
+	d.Add(http.MethodPost, "/api/v1/webhook", &openapi.Operation{
+		Tags:        []string{"system"},
+		Summary:     "StripeのWebhook",
+		Description: "Stripe-Signatureヘッダーで署名を検証します。checkout.session.completed / payment_intent.succeeded / payment_intent.payment_failed / charge.refunded / charge.dispute.funds_withdrawn / charge.dispute.funds_reinstated を処理します。",
+		Parameters: []*openapi.Parameter{{
+			Name: "Stripe-Signature", In: "header", Required: true, Schema: &openapi.Schema{Type: "string"},
+		}},
+		RequestBody: openapi.JSONBody(&openapi.Schema{Type: "object", Description: "Stripeのイベント"}),
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSONResponse("受信しました", openapi.Object(map[string]*openapi.Schema{
+				"received": {Type: "boolean"},
+			})),
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	})
+	d.Add(http.MethodPost, "/api/v1/webhook/connect", &openapi.Operation{
+		Tags:        []string{"system"},
+		Summary:     "Stripe ConnectのWebhook",
+		Description: "Connectアカウントのイベント用のエンドポイントの署名シークレットで検証します。account.updated / payout.paid / payout.failed を処理します。",
+		Parameters: []*openapi.Parameter{{
+			Name: "Stripe-Signature", In: "header", Required: true, Schema: &openapi.Schema{Type: "string"},
+		}},
+		RequestBody: openapi.JSONBody(&openapi.Schema{Type: "object", Description: "Stripeのイベント"}),
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSONResponse("受信しました", openapi.Object(map[string]*openapi.Schema{
+				"received": {Type: "boolean"},
+			})),
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	})
+	d.Add(http.MethodGet, "/api/v1/openapi.json", &openapi.Operation{
+		Tags:      []string{"system"},
+		Summary:   "このOpenAPIドキュメント",
+		Responses: map[string]*openapi.Response{"200": openapi.JSONResponse("OpenAPI 3.1", &openapi.Schema{Type: "object"})},
+	})
+	d.Add(http.MethodGet, "/api/v1/docs", &openapi.Operation{
+		Tags:    []string{"system"},
+		Summary: "APIドキュメントの閲覧画面",
+		Responses: map[string]*openapi.Response{"200": {
+			Description: "HTML",
+			Content:     map[string]*openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}},
+		}},
+	})
+
+	// 認証
+	d.Add(http.MethodPost, "/api/v1/register", status(body(op("auth", "ユーザー登録", auth), handlers.CreateUserInput{}), "201"))
+	d.Add(http.MethodPost, "/api/v1/login", body(op("auth", "ログイン", d.Schema(struct {
+		User               *models.User `json:"user,omitempty"`
+		Token              string       `json:"token,omitempty"`
+		MFARequired        bool         `json:"mfa_required,omitempty"`
+		EnrollmentRequired bool         `json:"enrollment_required,omitempty"`
+		PreAuthToken       string       `json:"pre_auth_token,omitempty"`
+	}{})), handlers.LoginInput{}))
+	d.Add(http.MethodPost, "/api/v1/login/2fa", body(op("auth", "二要素認証でログインを完了", auth), handlers.TwoFactorLoginInput{}))
+	d.Add(http.MethodPost, "/api/v1/auth/unlock", body(op("auth", "メールのリンクからアカウントのロックを解除", nil), handlers.UnlockInput{}))
+	d.Add(http.MethodGet, "/api/v1/auth/me", secured(op("auth", "ログイン中のユーザー", user)))
+
+	enroll := func(o *openapi.Operation) *openapi.Operation {
+		o.Security = []map[string][]string{{"preAuthToken": {}}, {"bearerAuth": {}}}
+		return o
+	}
+	d.Add(http.MethodPost, "/api/v1/auth/2fa/enroll", enroll(op("auth", "二要素認証の登録を開始", d.Schema(struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}{}))))
+	d.Add(http.MethodPost, "/api/v1/auth/2fa/confirm", enroll(body(op("auth", "二要素認証の登録を完了", d.Schema(struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+		Token         string   `json:"token"`
+	}{})), handlers.TwoFactorCodeInput{})))
+	d.Add(http.MethodDelete, "/api/v1/auth/2fa", secured(body(op("auth", "二要素認証を無効化", nil), handlers.TwoFactorCodeInput{})))
+	d.Add(http.MethodPost, "/api/v1/auth/2fa/recovery-codes", secured(body(op("auth", "リカバリーコードを再発行", recoveryCodes), handlers.TwoFactorCodeInput{})))
+
+	d.Add(http.MethodGet, "/api/v1/auth/oauth/providers", op("auth", "利用できるソーシャルログイン", d.Schema(struct {
+		Providers []string `json:"providers"`
+	}{})))
+	redirect := func(summary string) *openapi.Operation {
+		return &openapi.Operation{
+			Tags:    []string{"auth"},
+			Summary: summary,
+			Responses: map[string]*openapi.Response{
+				"302": {Description: "リダイレクト", Headers: map[string]*openapi.Header{
+					"Location": {Schema: &openapi.Schema{Type: "string", Format: "uri"}},
+				}},
+			},
+		}
+	}
+	d.Add(http.MethodGet, "/api/v1/auth/oauth/:provider/start", redirect("プロバイダーの認可画面へリダイレクト"))
+	d.Add(http.MethodGet, "/api/v1/auth/oauth/:provider/callback", query(query(
+		redirect("プロバイダーからのコールバック（結果を付けてフロントエンドへリダイレクト）"),
+		"code", "認可コード", false), "state", "認可リクエストのstate", false))
+	d.Add(http.MethodPost, "/api/v1/auth/oauth/:provider/link", secured(op("auth", "外部アカウントを連携する認可URLを発行", d.Schema(struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}{}))))
+
+	// ユーザー
+	d.Add(http.MethodGet, "/api/v1/users/:id", secured(op("users", "ユーザー情報", user)))
+	d.Add(http.MethodPut, "/api/v1/users/:id", secured(body(op("users", "プロフィールを更新（本人のみ）", user), handlers.UpdateUserInput{})))
+	d.Add(http.MethodGet, "/api/v1/users/me/export", secured(op("users", "個人データのエクスポートを依頼・状況を確認", d.Schema(struct {
+		ID          uint       `json:"id"`
+		Status      string     `json:"status"`
+		CreatedAt   time.Time  `json:"created_at"`
+		DownloadURL string     `json:"download_url,omitempty"`
+		Size        int        `json:"size,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	}{}))))
+	d.Add(http.MethodGet, "/api/v1/users/me/export/download", secured(&openapi.Operation{
+		Tags:    []string{"users"},
+		Summary: "作成済みの個人データ（zip）をダウンロード",
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "zipアーカイブ",
+				Content:     map[string]*openapi.MediaType{"application/zip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			},
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	}))
+	d.Add(http.MethodDelete, "/api/v1/users/me", secured(status(op("users", "退会を申請", message), "202")))
+
+	// プロジェクト
+	d.Add(http.MethodGet, "/api/v1/projects", query(op("projects", "プロジェクト一覧（未指定の場合は実施中のみ）", projects),
+		"status", "プロジェクトの状態", false))
+	d.Add(http.MethodGet, "/api/v1/projects/:id", op("projects", "プロジェクトの詳細", project))
+	d.Add(http.MethodGet, "/api/v1/projects/:id/stream", &openapi.Operation{
+		Tags:        []string{"projects"},
+		Summary:     "支援状況のリアルタイム配信",
+		Description: "Server-Sent Eventsで支援の完了を通知します。",
+		Responses: map[string]*openapi.Response{"200": {
+			Description: "イベントストリーム",
+			Content:     map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}},
+		}},
+	})
+	d.Add(http.MethodPost, "/api/v1/projects", secured(status(body(op("projects", "プロジェクトを作成（下書き）", project), handlers.ProjectInput{}), "201")))
+	d.Add(http.MethodGet, "/api/v1/projects/my", secured(op("projects", "自分が主催するプロジェクト", projects)))
+	d.Add(http.MethodGet, "/api/v1/projects/supported", secured(op("projects", "自分が支援したプロジェクト", projects)))
+	d.Add(http.MethodPut, "/api/v1/projects/:id", secured(body(op("projects", "プロジェクトを更新（主催者のみ）", project), handlers.ProjectInput{})))
+	d.Add(http.MethodDelete, "/api/v1/projects/:id", secured(op("projects", "プロジェクトを削除（主催者のみ）", message)))
+
+	// 支援
+	d.Add(http.MethodGet, "/api/v1/projects/:id/supports", op("supports", "プロジェクトの支援一覧", openapi.ArrayOf(support)))
+	d.Add(http.MethodPost, "/api/v1/projects/:id/supports", secured(status(body(op("supports", "支援を作成し決済セッションを発行", d.Schema(struct {
+		CheckoutSessionID string `json:"checkout_session_id"`
+		CheckoutURL       string `json:"checkout_url"`
+		SupportID         uint   `json:"support_id"`
+	}{})), handlers.CreateSupportInput{}), "201")))
+	d.Add(http.MethodGet, "/api/v1/supports/:id", secured(op("supports", "支援の状態（支援者本人または主催者のみ）", support)))
+	d.Add(http.MethodGet, "/api/v1/payments/verify", query(op("supports", "決済セッションIDから支援を確認", support),
+		"session_id", "Stripe Checkoutの決済セッションID", true))
+
+	// 領収書
+	receipt := d.Schema(models.Receipt{})
+	d.Add(http.MethodGet, "/api/v1/supports/:id/receipt", secured(&openapi.Operation{
+		Tags:        []string{"receipts"},
+		Summary:     "支援の領収書（PDF）をダウンロード（支援者本人・主催者・管理者）",
+		Description: "決済が完了した支援の有効な領収書。未発行の場合は発行します（返金による無効化・再発行は返金の記録時に行い、ダウンロードでは発行済みの領収書を変更しません）。全額返金された支援は 409 を返します。",
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "PDF（A4）",
+				Content:     map[string]*openapi.MediaType{"application/pdf": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			},
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	}))
+	reissueReceipt := secured(body(op("receipts", "宛名を指定して領収書を発行（支援者本人のみ）", receipt), handlers.ReissueReceiptInput{}))
+	reissueReceipt.Description = "発行済みの場合は理由を記録して無効にし、新しい番号で再発行します（理由は必須）。"
+	d.Add(http.MethodPost, "/api/v1/supports/:id/receipt", reissueReceipt)
+	d.Add(http.MethodGet, "/api/v1/supports/:id/receipts", secured(op("receipts", "支援の領収書の発行・無効化の履歴", openapi.ArrayOf(receipt))))
+	d.Add(http.MethodGet, "/api/v1/projects/:id/receipts", secured(&openapi.Operation{
+		Tags:        []string{"receipts"},
+		Summary:     "プロジェクトの支援の領収書（zip）をまとめてダウンロード（主催者本人または管理者）",
+		Description: "決済が完了した支援の有効な領収書のPDF。未発行の支援には発行します。",
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "zipアーカイブ",
+				Content:     map[string]*openapi.MediaType{"application/zip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			},
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	}))
+
+	// 送金
+	payoutAccount := d.Schema(models.PayoutAccount{})
+	d.Add(http.MethodGet, "/api/v1/payouts/account", secured(op("payouts", "送金先の登録状況", payoutAccount)))
+	d.Add(http.MethodPost, "/api/v1/payouts/account", secured(op("payouts", "送金先（Stripe Connect）の登録画面のURLを発行", d.Schema(service.Onboarding{}))))
+	d.Add(http.MethodGet, "/api/v1/payouts/settlements", secured(op("payouts", "自分が主催したプロジェクトの精算と送金の状態", settlements)))
+	d.Add(http.MethodGet, "/api/v1/payouts/settlements/:id/statement", secured(&openapi.Operation{
+		Tags:        []string{"payouts"},
+		Summary:     "精算の明細（CSV）をダウンロード（主催者本人または管理者）",
+		Description: "支援額・返金額・手数料・送金額の内訳と支援ごとの明細。",
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "CSV（UTF-8、BOM付き）",
+				Content:     map[string]*openapi.MediaType{"text/csv": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+			"default": openapi.JSONResponse("エラー", apiError),
+		},
+	}))
+
+	// 運営会社への直接支払い
+	invoice := d.Schema(models.Invoice{})
+	expense := d.Schema(models.Expense{})
+	d.Add(http.MethodGet, "/api/v1/operator/invoices", secured(op("invoices", "自分（運営会社）が提出した請求書", openapi.ArrayOf(invoice))))
+	submitInvoice := secured(body(op("invoices", "掲出料の請求書を提出（ビジョンの運営会社のみ）", invoice), handlers.InvoiceInput{}))
+	submitInvoice.Description = "精算方法が `operator` のプロジェクトのビジョンの運営会社が提出します。審査前または差し戻された請求書は内容を置き換えて再提出します。"
+	d.Add(http.MethodPost, "/api/v1/operator/invoices", submitInvoice)
+	d.Add(http.MethodGet, "/api/v1/projects/:id/expenses", secured(op("invoices", "プロジェクトの経費の申請（主催者本人または管理者）", openapi.ArrayOf(expense))))
+	d.Add(http.MethodPost, "/api/v1/projects/:id/expenses", secured(status(body(op("invoices", "立て替えた経費の精算を申請（主催者のみ、精算の作成前）", expense), handlers.ExpenseInput{}), "201")))
+
+	// 管理者
+	auditEvents := secured(op("admin", "監査ログ（管理者のみ、新しい順）", d.Schema(service.AuditEventPage{})))
+	auditEvents.Description = "プロジェクト・支援・ユーザーの変更（変更前後の値）とセキュリティ関連の操作の記録。次のページは `next_before_id` を `before_id` に指定して取得します。"
+	for _, p := range []struct{ name, description string }{
+		{"actor_id", "操作したユーザーのID"},
+		{"action", "アクション（例: project.updated）"},
+		{"target_type", "対象の種類（user / project / support / settlement / invoice / expense / receipt）"},
+		{"target_id", "対象のID"},
+		{"from", "この日時以降（RFC 3339）"},
+		{"to", "この日時より前（RFC 3339）"},
+		{"before_id", "このIDより前の記録（ページング）"},
+		{"limit", "件数（1〜200、既定は50）"},
+	} {
+		auditEvents = query(auditEvents, p.name, p.description, false)
+	}
+	d.Add(http.MethodGet, "/api/v1/admin/audit-events", auditEvents)
+	d.Add(http.MethodGet, "/api/v1/admin/settlements", query(secured(op("admin", "すべての精算と送金の状態（管理者のみ）", settlements)),
+		"status", "精算の状態", false))
+	d.Add(http.MethodPost, "/api/v1/admin/settlements/:id/retry", secured(op("admin", "入金に失敗した精算を再送（管理者のみ）", settlement)))
+	d.Add(http.MethodGet, "/api/v1/admin/invoices", query(secured(op("admin", "運営会社の請求書（管理者のみ、新しい順）", openapi.ArrayOf(invoice))),
+		"status", "審査の状態", false))
+	d.Add(http.MethodPost, "/api/v1/admin/invoices/:id/review", secured(body(op("admin", "請求書を承認または差し戻し（管理者のみ）", invoice), handlers.ReviewInput{})))
+	d.Add(http.MethodGet, "/api/v1/admin/expenses", query(secured(op("admin", "経費の申請（管理者のみ、古い順）", openapi.ArrayOf(expense))),
+		"status", "審査の状態", false))
+	d.Add(http.MethodPost, "/api/v1/admin/expenses/:id/review", secured(body(op("admin", "経費の申請を承認または却下（管理者のみ）", expense), handlers.ReviewInput{})))
+	trialBalance := secured(op("admin", "試算表（管理者のみ）", d.Schema(service.TrialBalance{})))
+	trialBalance.Description = "勘定科目ごとの借方・貸方の合計と残高。すべての仕訳は貸借が一致するため、`balanced` は常に true になります。"
+	d.Add(http.MethodGet, "/api/v1/admin/ledger/trial-balance", trialBalance)
+	projectLedger := secured(op("admin", "プロジェクト別の残高と仕訳（管理者のみ）", d.Schema(service.ProjectLedger{})))
+	projectLedger.Description = "`held` は預り金から返金とチャージバックを差し引いた、プラットフォームが保管している支援金です。"
+	d.Add(http.MethodGet, "/api/v1/admin/ledger/projects/:id", projectLedger)
+	reconciliations := secured(op("admin", "Stripeとの照合の結果（管理者のみ、新しい順に30件）", openapi.ArrayOf(d.Schema(models.ReconciliationReport{}))))
+	reconciliations.Description = "毎日、直近の期間のStripeの決済・残高取引を支援と帳簿に照合した結果。" +
+		"`repairs` は自動で修正した不一致（Webhookの取りこぼしなど）、`discrepancies` は担当者の確認が必要な不一致です。"
+	d.Add(http.MethodGet, "/api/v1/admin/ledger/reconciliations", reconciliations)
+
+	return d
+}
+
+// envelope は成功時のレスポンス（utils.Response）のスキーマ（dataがnilの場合はメッセージのみ）
+func envelope(data *openapi.Schema) *openapi.Schema {
+	s := openapi.Object(map[string]*openapi.Schema{
+		"status":  {Type: "string", Enum: []interface{}{"success"}},
+		"message": openapi.String(""),
+	}, "status")
+	if data != nil {
+		s.Properties["data"] = data
+	}
+	return s
+}
+
+// docsPage はOpenAPIドキュメントを閲覧するページ（Swagger UI）
+const docsPage = `<!DOCTYPE html>
+<html lang="ja">
+<head>
+  <meta charset="utf-8">
+  <title>推しおめ API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/v1/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
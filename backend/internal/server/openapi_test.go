@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/openapi"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// newServerWithoutDB はデータベースに接続せずにルーターを組み立てます（ルート定義の検査用）
+func newServerWithoutDB(t *testing.T) *server.Server {
+	t.Helper()
+	srv, err := server.New(server.Deps{
+		Config:   testutil.Config(),
+		Mailer:   &testutil.Mailer{},
+		Payments: testutil.NewFakePayments(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	routes := make(map[string]bool)
+	for _, r := range srv.Router.Routes() {
+		// /api 以外（/metrics など運用向けのエンドポイント）はAPIドキュメントの対象外
+		if !strings.HasPrefix(r.Path, "/api/") {
+			continue
+		}
+		op := r.Method + " " + openapi.Path(r.Path)
+		routes[op] = true
+		if !srv.Spec.Has(r.Method, r.Path) {
+			t.Errorf("ルートがOpenAPIドキュメントにありません: %s %s", r.Method, r.Path)
+		}
+	}
+	for _, op := range srv.Spec.Operations() {
+		if !routes[op] {
+			t.Errorf("OpenAPIドキュメントの操作に対応するルートがありません: %s", op)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                     `json:"openapi"`
+		Paths      map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		t.Fatalf("openapi: got %q, want 3.1.x", doc.OpenAPI)
+	}
+
+	// すべての参照先が定義されていること
+	refs := regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(rec.Body.String(), -1)
+	if len(refs) == 0 {
+		t.Fatal("スキーマの参照がありません")
+	}
+	for _, ref := range refs {
+		if _, ok := doc.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("未定義のスキーマを参照しています: %s", ref[1])
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/api/v1/openapi.json") {
+		t.Fatalf("/api/v1/docs: got %d", rec.Code)
+	}
+}
+
+func TestOpenAPIRequestSchemasFollowInputStructs(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	schema := srv.Spec.Components.Schemas["ProjectInput"]
+	if schema == nil {
+		t.Fatal("ProjectInput のスキーマがありません")
+	}
+	for _, name := range []string{"title", "description", "target_amount", "deadline"} {
+		if !contains(schema.Required, name) {
+			t.Errorf("ProjectInput.%s が必須になっていません: %v", name, schema.Required)
+		}
+	}
+	if min := schema.Properties["target_amount"].Minimum; min == nil || *min != 1000 {
+		t.Errorf("ProjectInput.target_amount の最小値: got %v, want 1000", min)
+	}
+	if enum := schema.Properties["status"].Enum; len(enum) == 0 {
+		t.Error("ProjectInput.status に取りうる値がありません")
+	}
+
+	if format := srv.Spec.Components.Schemas["CreateUserInput"].Properties["email"].Format; format != "email" {
+		t.Errorf("CreateUserInput.email の形式: got %q, want email", format)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type payoutAccount struct {
+	StripeAccountID  string `json:"stripe_account_id"`
+	DetailsSubmitted bool   `json:"details_submitted"`
+	PayoutsEnabled   bool   `json:"payouts_enabled"`
+}
+
+type settlement struct {
+	ID             uint   `json:"id"`
+	ProjectID      uint   `json:"project_id"`
+	GrossAmount    int64  `json:"gross_amount"`
+	RefundedAmount int64  `json:"refunded_amount"`
+	FeeAmount      int64  `json:"fee_amount"`
+	NetAmount      int64  `json:"net_amount"`
+	SupportCount   int    `json:"support_count"`
+	Status         string `json:"status"`
+	StripePayoutID string `json:"stripe_payout_id"`
+	FailureReason  string `json:"failure_reason"`
+}
+
+func TestParseFeeRules(t *testing.T) {
+	rules, err := service.ParseFeeRules("station_ad:12.5%:30; *:10%:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rules.For(models.ProjectTypeStationAd); got.RateBasisPoints != 1250 || got.FixedPerSupport != 30 {
+		t.Errorf("station_ad のルール: got %+v", got)
+	}
+	if got := rules.For(models.ProjectTypeDigitalSignage); got.RateBasisPoints != 1000 || got.FixedPerSupport != 0 {
+		t.Errorf("既定のルール: got %+v", got)
+	}
+
+	for _, s := range []string{
+		"station_ad:10%:0",       // 既定のルールがない
+		"*:10%:0;*:5%:0",         // 重複
+		"bus:10%:0;*:10%:0",      // 不明な種別
+		"*:10:0",                 // % がない
+		"*:150%:0",               // 100%を超える
+		"*:10%:-1",               // 負の固定額
+		"station_ad:10%;*:10%:0", // 項目の不足
+	} {
+		if _, err := service.ParseFeeRules(s); err == nil {
+			t.Errorf("ParseFeeRules(%q): エラーになりません", s)
+		}
+	}
+}
+
+func TestFeeRuleCalculate(t *testing.T) {
+	rule := service.FeeRule{RateBasisPoints: 1250, FixedPerSupport: 30}
+	got := rule.Calculate([]models.Support{
+		{Amount: 3000},
+		{Amount: 5000, RefundedAmount: 1001},
+		{Amount: 2000, RefundedAmount: 2000}, // 全額返金は固定額の対象外
+	})
+	// (10000 - 3001) * 12.5% = 874.875 → 874、固定額 30円 × 2件
+	want := service.SettlementAmounts{Gross: 10000, Refunded: 3001, Fee: 934, Net: 6065, Supports: 2}
+	if got != want {
+		t.Errorf("Calculate: got %+v, want %+v", got, want)
+	}
+
+	// 手数料は返金後の額を上限とする
+	got = service.FeeRule{FixedPerSupport: 500}.Calculate([]models.Support{{Amount: 300}})
+	if got.Fee != 300 || got.Net != 0 {
+		t.Errorf("少額の支援の手数料: got %+v, want fee=300 net=0", got)
+	}
+}
+
+// completedProject は支援を決済したうえで完了にしたプロジェクトを作成します
+func completedProject(t *testing.T, h *testutil.Harness, owner *testutil.User, amounts ...int64) (project, []checkout) {
+	t.Helper()
+
+	p := createProject(t, h, owner, "active")
+	supporter := h.Register("supporter")
+	var checkouts []checkout
+	for _, amount := range amounts {
+		c := startCheckout(t, h, supporter, p.ID, amount)
+		h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+		checkouts = append(checkouts, c)
+	}
+
+	input := projectInput(p.Title)
+	input["status"] = "complete"
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusOK)
+	return p, checkouts
+}
+
+// onboard は主催者の送金先を登録し、本人確認を終えた状態を account.updated で通知します
+func onboard(t *testing.T, h *testutil.Harness, owner *testutil.User) string {
+	t.Helper()
+
+	var onboarding struct {
+		URL     string        `json:"url"`
+		Account payoutAccount `json:"account"`
+	}
+	h.Do(http.MethodPost, "/api/v1/payouts/account", nil, owner.Token).Expect(t, http.StatusOK).Decode(t, &onboarding)
+	if onboarding.URL == "" || onboarding.Account.StripeAccountID == "" {
+		t.Fatalf("送金先の登録: got %+v", onboarding)
+	}
+	account := h.Connect.Complete(onboarding.Account.StripeAccountID)
+	h.SendConnectWebhook("account.updated", account.ID, map[string]interface{}{
+		"id":                account.ID,
+		"object":            "account",
+		"details_submitted": true,
+		"payouts_enabled":   true,
+	}).Expect(t, http.StatusOK)
+	return account.ID
+}
+
+func settle(t *testing.T, h *testutil.Harness) {
+	t.Helper()
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "settle-completed-projects"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mySettlements(t *testing.T, h *testutil.Harness, owner *testutil.User) []settlement {
+	t.Helper()
+	var list []settlement
+	h.Do(http.MethodGet, "/api/v1/payouts/settlements", nil, owner.Token).Expect(t, http.StatusOK).Decode(t, &list)
+	return list
+}
+
+func TestSettlementIsPaidAfterOnboarding(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	p, checkouts := completedProject(t, h, owner, 3000, 7000)
+
+	// 一部返金は支援額から差し引く
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id":              "ch_test_1",
+		"object":          "charge",
+		"payment_intent":  "pi_" + checkouts[1].CheckoutSessionID,
+		"amount_refunded": 2000,
+	}).Expect(t, http.StatusOK)
+
+	// 送金先の登録前は精算だけを作成して送金しない
+	settle(t, h)
+	list := mySettlements(t, h, owner)
+	if len(list) != 1 || list[0].ProjectID != p.ID || list[0].Status != "pending" {
+		t.Fatalf("登録前の精算: got %+v", list)
+	}
+	if n := len(h.Connect.Transfers()); n != 0 {
+		t.Fatalf("登録前に送金されました: %d件", n)
+	}
+
+	accountID := onboard(t, h, owner)
+	var account payoutAccount
+	h.Do(http.MethodGet, "/api/v1/payouts/account", nil, owner.Token).Expect(t, http.StatusOK).Decode(t, &account)
+	if !account.PayoutsEnabled || !account.DetailsSubmitted {
+		t.Fatalf("送金先の状態: got %+v", account)
+	}
+
+	// 既定の手数料（10%）: (10000 - 2000) * 10% = 800
+	list = mySettlements(t, h, owner)
+	s := list[0]
+	if s.Status != "in_transit" || s.GrossAmount != 10000 || s.RefundedAmount != 2000 || s.FeeAmount != 800 || s.NetAmount != 7200 {
+		t.Fatalf("送金後の精算: got %+v", s)
+	}
+	transfers, payouts := h.Connect.Transfers(), h.Connect.Payouts()
+	if len(transfers) != 1 || transfers[0].Amount != 7200 || transfers[0].AccountID != accountID {
+		t.Fatalf("送金: got %+v", transfers)
+	}
+	if len(payouts) != 1 || payouts[0].Amount != 7200 {
+		t.Fatalf("入金: got %+v", payouts)
+	}
+
+	// 定期タスクを再実行しても二重に送金しない
+	settle(t, h)
+	if n := len(h.Connect.Transfers()); n != 1 {
+		t.Fatalf("送金が重複しました: %d件", n)
+	}
+
+	h.SendConnectWebhook("payout.paid", accountID, map[string]interface{}{
+		"id": s.StripePayoutID, "object": "payout", "status": "paid",
+	}).Expect(t, http.StatusOK)
+	if got := mySettlements(t, h, owner)[0]; got.Status != "paid" {
+		t.Fatalf("入金後の精算: got %+v", got)
+	}
+
+	res := h.Do(http.MethodGet, fmt.Sprintf("/api/v1/payouts/settlements/%d/statement", s.ID), nil, owner.Token).
+		Expect(t, http.StatusOK)
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("明細の Content-Type: got %q", ct)
+	}
+	body := string(res.Body)
+	for _, want := range []string{"支援総額,10000", "返金額,2000", "手数料,800", "送金額,7200", "手数料のルール,10.00% + 0円/件"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("明細に %q がありません\n%s", want, body)
+		}
+	}
+
+	other := h.Register("other")
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/payouts/settlements/%d/statement", s.ID), nil, other.Token).
+		Expect(t, http.StatusForbidden)
+}
+
+func TestFailedTransferDoesNotExposeStripeError(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	completedProject(t, h, owner, 5000)
+	onboard(t, h, owner)
+
+	// Stripeのエラーの詳細（アカウントIDなど）は主催者に返さない
+	h.Connect.TransferErr = errors.New("stripe: insufficient funds in platform account acct_platform_secret")
+	h.Server.Scheduler.RunOnce(context.Background(), "settle-completed-projects")
+	s := mySettlements(t, h, owner)[0]
+	if s.FailureReason != "transfer_failed" {
+		t.Fatalf("送金に失敗した精算の失敗理由: got %q, want transfer_failed", s.FailureReason)
+	}
+}
+
+func TestFailedPayoutIsRetriedByAdmin(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	admin := newAdmin(t, h)
+	completedProject(t, h, owner, 5000)
+	accountID := onboard(t, h, owner)
+	settle(t, h)
+
+	s := mySettlements(t, h, owner)[0]
+	h.SendConnectWebhook("payout.failed", accountID, map[string]interface{}{
+		"id": s.StripePayoutID, "object": "payout", "status": "failed",
+		"failure_code": "account_closed", "failure_message": "The bank account has been closed",
+	}).Expect(t, http.StatusOK)
+
+	var failed []settlement
+	h.Do(http.MethodGet, "/api/v1/admin/settlements?status=failed", nil, admin.Token).
+		Expect(t, http.StatusOK).Decode(t, &failed)
+	if len(failed) != 1 || failed[0].ID != s.ID || failed[0].FailureReason != "account_closed" {
+		t.Fatalf("入金に失敗した精算: got %+v", failed)
+	}
+
+	path := fmt.Sprintf("/api/v1/admin/settlements/%d/retry", s.ID)
+	h.Do(http.MethodPost, path, nil, owner.Token).Expect(t, http.StatusForbidden)
+
+	var retried settlement
+	h.Do(http.MethodPost, path, nil, admin.Token).Expect(t, http.StatusOK).Decode(t, &retried)
+	if retried.Status != "in_transit" || retried.StripePayoutID == s.StripePayoutID {
+		t.Fatalf("再送した精算: got %+v", retried)
+	}
+	// 送金（Transfer）は済んでいるため、入金だけを作り直す
+	payouts := h.Connect.Payouts()
+	if len(h.Connect.Transfers()) != 1 || len(payouts) != 2 || payouts[0].IdempotencyKey == payouts[1].IdempotencyKey {
+		t.Fatalf("再送の送金・入金: transfers=%+v payouts=%+v", h.Connect.Transfers(), payouts)
+	}
+
+	// 入金待ちの精算は再送できない
+	h.Do(http.MethodPost, path, nil, admin.Token).Expect(t, http.StatusConflict)
+}
@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// runJobs はキューに溜まったジョブを全て処理します
+func runJobs(t *testing.T, h *testutil.Harness) {
+	t.Helper()
+	for {
+		ran, err := h.Server.Worker.RunNext(context.Background())
+		if err != nil {
+			t.Fatalf("ジョブの処理に失敗しました: %v", err)
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestAccountDeletionRevokesTokensAndErasesLoginAttempts(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("leaver")
+	h.Do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"email":    user.Email,
+		"password": "wrong-password",
+	}, "").Expect(t, http.StatusUnauthorized)
+
+	h.Do(http.MethodDelete, "/api/v1/users/me", nil, user.Token).Expect(t, http.StatusAccepted)
+	runJobs(t, h)
+
+	// 退会前に発行したトークンは有効期限内でも使えない
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, user.Token).Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodGet, "/api/v1/users/me/export", nil, user.Token).Expect(t, http.StatusUnauthorized)
+
+	// ログイン試行の記録は匿名化前のメールアドレスで削除する
+	var count int64
+	if err := h.DB.Model(&models.LoginAttempt{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("残ったログイン試行: got %d, want 0", count)
+	}
+}
@@ -0,0 +1,137 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type project struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	UserID uint   `json:"user_id"`
+}
+
+func projectInput(title string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":         title,
+		"description":   "テスト用のプロジェクトです",
+		"target_amount": 10000,
+		"deadline":      time.Now().Add(30 * 24 * time.Hour).Format(time.RFC3339),
+	}
+}
+
+// createProject はプロジェクトを作成し、status を指定した場合は更新します
+func createProject(t *testing.T, h *testutil.Harness, owner *testutil.User, status string) project {
+	t.Helper()
+
+	var p project
+	h.Do(http.MethodPost, "/api/v1/projects", projectInput("テストプロジェクト"), owner.Token).
+		Expect(t, http.StatusCreated).Decode(t, &p)
+	if status != "" {
+		input := projectInput(p.Title)
+		input["status"] = status
+		h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).
+			Expect(t, http.StatusOK).Decode(t, &p)
+	}
+	return p
+}
+
+func TestProjectCRUD(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+
+	p := createProject(t, h, owner, "")
+	if p.Status != "draft" || p.UserID != owner.ID {
+		t.Fatalf("作成したプロジェクト: got %+v, want status=draft user_id=%d", p, owner.ID)
+	}
+
+	// 下書きは公開一覧に表示されない
+	var list []project
+	h.Do(http.MethodGet, "/api/v1/projects", nil, "").Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list) != 0 {
+		t.Fatalf("公開一覧: got %d件, want 0件", len(list))
+	}
+
+	input := projectInput("更新後のタイトル")
+	input["status"] = "active"
+	var updated project
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).
+		Expect(t, http.StatusOK).Decode(t, &updated)
+	if updated.Title != "更新後のタイトル" || updated.Status != "active" {
+		t.Fatalf("更新したプロジェクト: got %+v", updated)
+	}
+
+	h.Do(http.MethodGet, "/api/v1/projects", nil, "").Expect(t, http.StatusOK).Decode(t, &list)
+	if len(list) != 1 || list[0].ID != p.ID {
+		t.Fatalf("公開一覧: got %+v, want [%d]", list, p.ID)
+	}
+
+	var mine []project
+	h.Do(http.MethodGet, "/api/v1/projects/my", nil, owner.Token).Expect(t, http.StatusOK).Decode(t, &mine)
+	if len(mine) != 1 {
+		t.Fatalf("マイプロジェクト: got %d件, want 1件", len(mine))
+	}
+
+	h.Do(http.MethodDelete, fmt.Sprintf("/api/v1/projects/%d", p.ID), nil, owner.Token).Expect(t, http.StatusOK)
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", p.ID), nil, "").Expect(t, http.StatusNotFound)
+}
+
+func TestProjectOwnership(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	other := h.Register("other")
+	p := createProject(t, h, owner, "active")
+	path := fmt.Sprintf("/api/v1/projects/%d", p.ID)
+
+	h.Do(http.MethodPut, path, projectInput("乗っ取り"), other.Token).Expect(t, http.StatusForbidden)
+	h.Do(http.MethodDelete, path, nil, other.Token).Expect(t, http.StatusForbidden)
+	h.Do(http.MethodPut, path, projectInput("未ログイン"), "").Expect(t, http.StatusUnauthorized)
+
+	var got project
+	h.Do(http.MethodGet, path, nil, "").Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Title != p.Title {
+		t.Fatalf("他のユーザーの操作でプロジェクトが変更されました: %+v", got)
+	}
+}
+
+func TestProjectValidation(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+
+	input := projectInput("少額")
+	input["target_amount"] = 10
+	h.Do(http.MethodPost, "/api/v1/projects", input, owner.Token).Expect(t, http.StatusBadRequest)
+
+	input = projectInput("期限切れ")
+	input["deadline"] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+	h.Do(http.MethodPost, "/api/v1/projects", input, owner.Token).Expect(t, http.StatusBadRequest)
+
+	p := createProject(t, h, owner, "")
+	input = projectInput(p.Title)
+	input["status"] = "unknown"
+	h.Do(http.MethodPut, fmt.Sprintf("/api/v1/projects/%d", p.ID), input, owner.Token).Expect(t, http.StatusBadRequest)
+}
+
+func TestCloseExpiredProjects(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	p := createProject(t, h, owner, "active")
+
+	// 期限（30日後）を過ぎるまで時計を進めて定期タスクを実行する
+	h.Clock.Advance(31 * 24 * time.Hour)
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "close-expired-projects"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got project
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", p.ID), nil, "").Expect(t, http.StatusOK).Decode(t, &got)
+	if got.Status != "complete" {
+		t.Fatalf("期限切れのプロジェクトが完了になっていません: %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// newRateLimitedServer はIPごとに1分2回までのレート制限を設定したサーバーを組み立てます（データベースなし）
+func newRateLimitedServer(t *testing.T, trustedProxies ...string) *server.Server {
+	t.Helper()
+	cfg := testutil.Config()
+	cfg.RateLimit.Policies = "global-ip:ip::2/1m"
+	cfg.Server.TrustedProxies = trustedProxies
+	srv, err := server.New(server.Deps{
+		Config:   cfg,
+		Mailer:   &testutil.Mailer{},
+		Payments: testutil.NewFakePayments(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+// healthWithForwardedFor は X-Forwarded-For を変えながらリクエストし、ステータスコードを返します
+func healthWithForwardedFor(srv *server.Server, n int) []int {
+	codes := make([]int, n)
+	for i := range codes {
+		// httptest のリクエストの接続元は 192.0.2.1
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		codes[i] = rec.Code
+	}
+	return codes
+}
+
+func TestRateLimitIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	// 信頼するプロキシがない場合、X-Forwarded-For を変えてもレート制限を回避できない
+	codes := healthWithForwardedFor(newRateLimitedServer(t), 3)
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("信頼するプロキシなし: got %v, want 3回目が429", codes)
+	}
+
+	// 信頼するプロキシからの接続では X-Forwarded-For の接続元ごとに数える
+	codes = healthWithForwardedFor(newRateLimitedServer(t, "192.0.2.0/24"), 3)
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("信頼するプロキシ経由の %d 回目: got %d, want 200", i+1, code)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type receipt struct {
+	ID                 uint   `json:"id"`
+	Number             uint   `json:"number"`
+	SupportID          uint   `json:"support_id"`
+	Addressee          string `json:"addressee"`
+	Amount             int64  `json:"amount"`
+	TaxAmount          int64  `json:"tax_amount"`
+	RegistrationNumber string `json:"registration_number"`
+	Status             string `json:"status"`
+	ReissueOfID        *uint  `json:"reissue_of_id"`
+	VoidReason         string `json:"void_reason"`
+}
+
+// downloadReceipt は支援の領収書をダウンロードし、PDFであることを確認してファイル名を返します
+func downloadReceipt(t *testing.T, h *testutil.Harness, user *testutil.User, supportID uint) string {
+	t.Helper()
+	res := h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d/receipt", supportID), nil, user.Token).
+		Expect(t, http.StatusOK)
+	if ct := res.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("領収書の Content-Type: got %q", ct)
+	}
+	if !bytes.HasPrefix(res.Body, []byte("%PDF-")) {
+		t.Fatalf("領収書がPDFではありません: %q", res.Body[:min(len(res.Body), 16)])
+	}
+	return res.Header.Get("Content-Disposition")
+}
+
+func receiptHistory(t *testing.T, h *testutil.Harness, user *testutil.User, supportID uint) []receipt {
+	t.Helper()
+	var receipts []receipt
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d/receipts", supportID), nil, user.Token).
+		Expect(t, http.StatusOK).Decode(t, &receipts)
+	return receipts
+}
+
+func TestReceiptIsIssuedOnceAndReissuedWithHistory(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	other := h.Register("other")
+	p := createProject(t, h, owner, "active")
+
+	c := startCheckout(t, h, supporter, p.ID, 11000)
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d/receipt", c.SupportID), nil, supporter.Token).
+		Expect(t, http.StatusConflict)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+
+	// 繰り返しダウンロードしても同じ番号の領収書を返す
+	disposition := downloadReceipt(t, h, supporter, c.SupportID)
+	if disposition != `attachment; filename="receipt-R-00000001.pdf"` {
+		t.Errorf("Content-Disposition: got %q", disposition)
+	}
+	if got := downloadReceipt(t, h, owner, c.SupportID); got != disposition {
+		t.Errorf("主催者のダウンロード: got %q, want %q", got, disposition)
+	}
+	h.Do(http.MethodGet, fmt.Sprintf("/api/v1/supports/%d/receipt", c.SupportID), nil, other.Token).
+		Expect(t, http.StatusForbidden)
+
+	history := receiptHistory(t, h, supporter, c.SupportID)
+	if len(history) != 1 || history[0].Amount != 11000 || history[0].TaxAmount != 1000 ||
+		history[0].RegistrationNumber != "T1234567890123" {
+		t.Fatalf("発行した領収書: got %+v", history)
+	}
+
+	// 再発行は支援者本人のみ、理由が必要
+	path := fmt.Sprintf("/api/v1/supports/%d/receipt", c.SupportID)
+	h.Do(http.MethodPost, path, map[string]string{"addressee": "株式会社テスト"}, supporter.Token).
+		Expect(t, http.StatusBadRequest)
+	h.Do(http.MethodPost, path, map[string]string{"addressee": "株式会社テスト", "reason": "宛名の追加"}, owner.Token).
+		Expect(t, http.StatusForbidden)
+
+	var reissued receipt
+	h.Do(http.MethodPost, path, map[string]string{"addressee": "株式会社テスト", "reason": "宛名の追加"}, supporter.Token).
+		Expect(t, http.StatusOK).Decode(t, &reissued)
+	if reissued.Number != 2 || reissued.Addressee != "株式会社テスト" || reissued.ReissueOfID == nil || *reissued.ReissueOfID != history[0].ID {
+		t.Fatalf("再発行した領収書: got %+v", reissued)
+	}
+
+	history = receiptHistory(t, h, owner, c.SupportID)
+	if len(history) != 2 || history[0].Status != "void" || history[0].VoidReason != "宛名の追加" || history[1].Status != "issued" {
+		t.Errorf("領収書の履歴: got %+v", history)
+	}
+	if got := downloadReceipt(t, h, supporter, c.SupportID); got != `attachment; filename="receipt-R-00000002.pdf"` {
+		t.Errorf("再発行後のダウンロード: got %q", got)
+	}
+
+	// 一部返金された場合は返金の記録時に返金後の金額で再発行する
+	refund := func(amount int64) {
+		h.SendWebhook("charge.refunded", map[string]interface{}{
+			"id": "ch_test_1", "object": "charge",
+			"payment_intent": "pi_" + c.CheckoutSessionID, "amount_refunded": amount,
+		}).Expect(t, http.StatusOK)
+	}
+	refund(5500)
+	history = receiptHistory(t, h, supporter, c.SupportID)
+	if len(history) != 3 || history[1].Status != "void" || history[1].VoidReason != "返金による金額の変更" ||
+		history[2].Amount != 5500 || history[2].TaxAmount != 500 || history[2].Addressee != "株式会社テスト" {
+		t.Errorf("返金後の領収書: got %+v", history)
+	}
+	// ダウンロードでは再発行しない
+	if got := downloadReceipt(t, h, supporter, c.SupportID); got != `attachment; filename="receipt-R-00000003.pdf"` {
+		t.Errorf("返金後のダウンロード: got %q", got)
+	}
+	if history = receiptHistory(t, h, supporter, c.SupportID); len(history) != 3 {
+		t.Errorf("ダウンロード後の領収書の数: got %d, want 3", len(history))
+	}
+
+	// 全額返金された場合は無効にするだけで、以後は発行しない
+	refund(11000)
+	history = receiptHistory(t, h, supporter, c.SupportID)
+	if len(history) != 3 || history[2].Status != "void" || history[2].VoidReason != "全額返金" {
+		t.Errorf("全額返金後の領収書: got %+v", history)
+	}
+	h.Do(http.MethodGet, path, nil, supporter.Token).Expect(t, http.StatusConflict)
+}
+
+func TestRefundBeforeIssueDoesNotIssueReceipt(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+
+	c := startCheckout(t, h, supporter, p.ID, 8000)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id": "ch_test_1", "object": "charge",
+		"payment_intent": "pi_" + c.CheckoutSessionID, "amount_refunded": 3000,
+	}).Expect(t, http.StatusOK)
+	if history := receiptHistory(t, h, supporter, c.SupportID); len(history) != 0 {
+		t.Fatalf("返金時に領収書を発行しました: got %+v", history)
+	}
+
+	// 初回のダウンロードで返金後の金額の領収書を発行する
+	downloadReceipt(t, h, supporter, c.SupportID)
+	history := receiptHistory(t, h, supporter, c.SupportID)
+	if len(history) != 1 || history[0].Amount != 5000 || history[0].Addressee != "" {
+		t.Errorf("返金後に発行した領収書: got %+v", history)
+	}
+}
+
+func TestOrganizerDownloadsProjectReceipts(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+
+	for _, amount := range []int64{3000, 5000} {
+		c := startCheckout(t, h, supporter, p.ID, amount)
+		h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+	}
+	// 決済が完了していない支援は含めない
+	startCheckout(t, h, supporter, p.ID, 1000)
+
+	path := fmt.Sprintf("/api/v1/projects/%d/receipts", p.ID)
+	h.Do(http.MethodGet, path, nil, supporter.Token).Expect(t, http.StatusForbidden)
+	res := h.Do(http.MethodGet, path, nil, owner.Token).Expect(t, http.StatusOK)
+	if ct := res.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type: got %q", ct)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(res.Body), int64(len(res.Body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || names[0] != "receipt-R-00000001.pdf" || names[1] != "receipt-R-00000002.pdf" {
+		t.Errorf("ZIPの内容: got %v", names)
+	}
+
+	// 発行済みの領収書はまとめてダウンロードしても再発行しない
+	h.Do(http.MethodGet, path, nil, owner.Token).Expect(t, http.StatusOK)
+	var count int64
+	if err := h.DB.Table("receipts").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("発行した領収書の数: got %d, want 2", count)
+	}
+}
@@ -0,0 +1,181 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+type reconciliationReport struct {
+	Payments      int                  `json:"payments"`
+	Repairs       []reconciliationItem `json:"repairs"`
+	Discrepancies []reconciliationItem `json:"discrepancies"`
+}
+
+type reconciliationItem struct {
+	Kind      string `json:"kind"`
+	SupportID uint   `json:"support_id"`
+}
+
+func kinds(items []reconciliationItem) map[string]int {
+	counts := map[string]int{}
+	for _, item := range items {
+		counts[item.Kind]++
+	}
+	return counts
+}
+
+// reconcile はWebhookの到着を待つ時間が過ぎるまで時計を進め、照合のタスクを実行します
+func reconcile(t *testing.T, h *testutil.Harness) {
+	t.Helper()
+	h.Clock.Advance(2 * time.Hour)
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "reconcile-stripe-payments"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func reconciliationReports(t *testing.T, h *testutil.Harness, admin *testutil.User) []reconciliationReport {
+	t.Helper()
+	var reports []reconciliationReport
+	h.Do(http.MethodGet, "/api/v1/admin/ledger/reconciliations", nil, admin.Token).Expect(t, http.StatusOK).Decode(t, &reports)
+	return reports
+}
+
+func getSupport(t *testing.T, h *testutil.Harness, id uint) models.Support {
+	t.Helper()
+	var support models.Support
+	if err := h.DB.First(&support, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return support
+}
+
+func TestReconciliationRepairsMissedWebhooks(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	admin := newAdmin(t, h)
+	p := createProject(t, h, owner, "active")
+
+	// Webhookを取りこぼした支払い
+	missed := startCheckout(t, h, supporter, p.ID, 3000)
+	if _, err := h.Payments.Pay(missed.CheckoutSessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 失敗の通知の後、やり直して支払われた決済
+	retried := startCheckout(t, h, supporter, p.ID, 5000)
+	paymentIntent, err := h.Payments.Fail(retried.CheckoutSessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SendWebhook("payment_intent.payment_failed", map[string]interface{}{
+		"id": paymentIntent, "object": "payment_intent",
+	}).Expect(t, http.StatusOK)
+	if _, err := h.Payments.Pay(retried.CheckoutSessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 返金のWebhookを取りこぼした決済
+	refunded := startCheckout(t, h, supporter, p.ID, 10000)
+	h.CompleteCheckout(refunded.CheckoutSessionID).Expect(t, http.StatusOK)
+	if _, err := h.Payments.Refund("pi_"+refunded.CheckoutSessionID, 4000); err != nil {
+		t.Fatal(err)
+	}
+
+	// 決済手数料を取得できないまま完了した決済
+	noFee := startCheckout(t, h, supporter, p.ID, 2000)
+	h.SendWebhook("checkout.session.completed", map[string]interface{}{
+		"id": noFee.CheckoutSessionID, "object": "checkout.session", "mode": "payment", "payment_status": "paid",
+		"payment_intent": "pi_" + noFee.CheckoutSessionID,
+		"metadata":       map[string]string{"support_id": strconv.FormatUint(uint64(noFee.SupportID), 10)},
+	}).Expect(t, http.StatusOK)
+	if _, err := h.Payments.Pay(noFee.CheckoutSessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 支払われていない決済セッションは照合の対象外
+	startCheckout(t, h, supporter, p.ID, 1000)
+
+	h.Do(http.MethodGet, "/api/v1/admin/ledger/reconciliations", nil, owner.Token).Expect(t, http.StatusForbidden)
+	reconcile(t, h)
+
+	for _, id := range []uint{missed.SupportID, retried.SupportID} {
+		if s := getSupport(t, h, id); s.Status != models.SupportStatusCompleted || s.PaymentIntentID == "" {
+			t.Errorf("支援 %d: got %s %q", id, s.Status, s.PaymentIntentID)
+		}
+	}
+	if s := getSupport(t, h, refunded.SupportID); s.RefundedAmount != 4000 {
+		t.Errorf("返金額: got %d, want 4000", s.RefundedAmount)
+	}
+
+	reports := reconciliationReports(t, h, admin)
+	if len(reports) != 1 || reports[0].Payments != 4 || len(reports[0].Discrepancies) != 0 {
+		t.Fatalf("照合の結果: got %+v", reports)
+	}
+	repairs := kinds(reports[0].Repairs)
+	if repairs["support_completed"] != 2 || repairs["refund_recorded"] != 1 || repairs["fee_recorded"] != 1 {
+		t.Errorf("修正: got %v", repairs)
+	}
+
+	// 修正した支援と返金も帳簿に記録される（預り金は 3000 + 5000 + 10000 + 2000 - 4000 円、決済手数料は支援の3.6%）
+	ledger := getProjectLedger(t, h, admin, p.ID)
+	if ledger.balance("project_escrow") != 16000 || ledger.balance("refunds") != 0 || ledger.balance("stripe_fees") != 720 {
+		t.Errorf("帳簿: got %+v", ledger)
+	}
+
+	// 同じ期間の照合は結果を重複して保存しない
+	if err := h.Server.Scheduler.RunOnce(context.Background(), "reconcile-stripe-payments"); err != nil {
+		t.Fatal(err)
+	}
+	if reports := reconciliationReports(t, h, admin); len(reports) != 1 {
+		t.Errorf("照合の結果の数: got %d, want 1", len(reports))
+	}
+}
+
+func TestReconciliationReportsDiscrepancies(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	admin := newAdmin(t, h)
+	p := createProject(t, h, owner, "active")
+
+	// 支援は完了しているが、Stripeでは決済が失敗している
+	notCharged := startCheckout(t, h, supporter, p.ID, 3000)
+	h.CompleteCheckout(notCharged.CheckoutSessionID).Expect(t, http.StatusOK)
+	if _, err := h.Payments.Fail(notCharged.CheckoutSessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stripeの返金額を上回る返金が記録されている
+	overRefunded := startCheckout(t, h, supporter, p.ID, 8000)
+	h.CompleteCheckout(overRefunded.CheckoutSessionID).Expect(t, http.StatusOK)
+	h.SendWebhook("charge.refunded", map[string]interface{}{
+		"id": "ch_test_1", "object": "charge",
+		"payment_intent": "pi_" + overRefunded.CheckoutSessionID, "amount_refunded": 8000,
+	}).Expect(t, http.StatusOK)
+
+	reconcile(t, h)
+
+	reports := reconciliationReports(t, h, admin)
+	if len(reports) != 1 || len(reports[0].Repairs) != 0 {
+		t.Fatalf("照合の結果: got %+v", reports)
+	}
+	discrepancies := kinds(reports[0].Discrepancies)
+	if len(reports[0].Discrepancies) != 2 || discrepancies["not_charged"] != 1 || discrepancies["refund_mismatch"] != 1 {
+		t.Errorf("要確認の不一致: got %+v", reports[0].Discrepancies)
+	}
+
+	// 確認が必要な不一致は自動で修正しない
+	if s := getSupport(t, h, notCharged.SupportID); s.Status != models.SupportStatusCompleted {
+		t.Errorf("決済に失敗した支援: got %s", s.Status)
+	}
+	if s := getSupport(t, h, overRefunded.SupportID); s.Status != models.SupportStatusRefunded || s.RefundedAmount != 8000 {
+		t.Errorf("返金済みの支援: got %s %d", s.Status, s.RefundedAmount)
+	}
+}
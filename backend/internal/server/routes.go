@@ -0,0 +1,155 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/handlers"
+	"github.com/masvc/oshiome_go/backend/internal/openapi"
+)
+
+// apiVersion はAPIのバージョンとそのルートの登録
+// 互換性のない変更は新しいバージョンとして追加し、既存のバージョンのルートは廃止まで残します
+type apiVersion struct {
+	name     string
+	register func(api *gin.RouterGroup, h *routeHandlers)
+}
+
+// apiVersions は提供中のバージョン（/api/{name}/... に登録します）
+var apiVersions = []apiVersion{
+	{name: "v1", register: registerV1},
+}
+
+// legacyAPIVersion はバージョンのない /api/... をAPI-Versionヘッダーなしで呼び出した場合のバージョン
+const legacyAPIVersion = "v1"
+
+// routeHandlers はルートの登録に使用するハンドラーとミドルウェア
+type routeHandlers struct {
+	users     *handlers.UserHandler
+	twoFactor *handlers.TwoFactorHandler
+	oauth     *handlers.OAuthHandler
+	privacy   *handlers.PrivacyHandler
+	projects  *handlers.ProjectHandler
+	supports  *handlers.SupportHandler
+	webhook   *handlers.WebhookHandler
+	audit     *handlers.AuditHandler
+	payouts   *handlers.PayoutHandler
+	invoices  *handlers.InvoiceHandler
+	ledger    *handlers.LedgerHandler
+	receipts  *handlers.ReceiptHandler
+	health    *handlers.HealthHandler
+	spec      *openapi.Document
+
+	// auth は認証が必要なルートのミドルウェア
+	auth []gin.HandlerFunc
+	// preAuth は二要素認証待ちのトークンも受け付けるルートのミドルウェア
+	preAuth []gin.HandlerFunc
+}
+
+// registerV1 は v1 のルートを登録します
+func registerV1(api *gin.RouterGroup, h *routeHandlers) {
+	// パブリックルート
+	public := api.Group("")
+	{
+		// ヘルスチェック
+		public.GET("/health", h.health.HealthCheck)
+
+		// APIドキュメント（OpenAPI 3.1）
+		public.GET("/openapi.json", func(c *gin.Context) { c.JSON(http.StatusOK, h.spec) })
+		public.GET("/docs", func(c *gin.Context) { c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage)) })
+
+		// ユーザー関連
+		public.POST("/register", h.users.CreateUser)
+		public.POST("/login", h.users.Login)
+		public.POST("/login/2fa", h.twoFactor.VerifyLogin)
+
+		// ソーシャルログイン（認可コード + PKCE）
+		public.GET("/auth/oauth/providers", h.oauth.ListProviders)
+		public.GET("/auth/oauth/:provider/start", h.oauth.Start)
+		public.GET("/auth/oauth/:provider/callback", h.oauth.Callback)
+		public.POST("/auth/unlock", h.users.UnlockAccount)
+
+		// プロジェクト一覧と詳細は認証不要
+		public.GET("/projects", h.projects.ListProjects)
+		public.GET("/projects/:id", h.projects.GetProject)
+		public.GET("/projects/:id/supports", h.supports.GetProjectSupports)
+		public.GET("/projects/:id/stream", h.projects.StreamProject)
+
+		// Webhook（Stripe-Signatureヘッダーを許可）
+		public.POST("/webhook", h.webhook.HandleStripeWebhook)
+		public.POST("/webhook/connect", h.webhook.HandleConnectWebhook)
+
+		// 支払い検証（セッションIDから支援情報を取得）
+		public.GET("/payments/verify", h.supports.VerifyPaymentBySession)
+	}
+
+	// 二要素認証の登録（登録必須ユーザーは二要素認証待ちのトークンで登録する）
+	enrollment := api.Group("/auth/2fa", h.preAuth...)
+	{
+		enrollment.POST("/enroll", h.twoFactor.Enroll)
+		enrollment.POST("/confirm", h.twoFactor.ConfirmEnrollment)
+	}
+
+	// 認証が必要なルート
+	protected := api.Group("", h.auth...)
+	{
+		// ユーザー関連
+		protected.GET("/auth/me", h.users.GetCurrentUser)
+		// 個人データの開示と退会（:idパラメータを使用するルートより先に定義）
+		protected.GET("/users/me/export", h.privacy.ExportData)
+		protected.GET("/users/me/export/download", h.privacy.DownloadExport)
+		protected.DELETE("/users/me", h.privacy.DeleteAccount)
+		protected.GET("/users/:id", h.users.GetUser)
+		protected.PUT("/users/:id", h.users.UpdateUser)
+
+		// 外部アカウントの連携
+		protected.POST("/auth/oauth/:provider/link", h.oauth.Link)
+
+		// 二要素認証の管理
+		protected.DELETE("/auth/2fa", h.twoFactor.Disable)
+		protected.POST("/auth/2fa/recovery-codes", h.twoFactor.RegenerateRecoveryCodes)
+
+		// プロジェクト関連（作成・更新・削除は認証必要）
+		protected.POST("/projects", h.projects.CreateProject)
+		// マイプロジェクトと支援プロジェクト（:idパラメータを使用するルートより先に定義）
+		protected.GET("/projects/my", h.projects.ListMyProjects)
+		protected.GET("/projects/supported", h.projects.ListSupportedProjects)
+		// IDパラメータを使用するルート
+		protected.PUT("/projects/:id", h.projects.UpdateProject)
+		protected.DELETE("/projects/:id", h.projects.DeleteProject)
+
+		// サポート関連
+		protected.POST("/projects/:id/supports", h.supports.CreateSupport)
+		protected.GET("/supports/:id", h.supports.GetSupportStatus)
+
+		// 領収書（支援ごとのPDF・主催者向けの一括ダウンロード）
+		protected.GET("/supports/:id/receipt", h.receipts.DownloadReceipt)
+		protected.POST("/supports/:id/receipt", h.receipts.ReissueReceipt)
+		protected.GET("/supports/:id/receipts", h.receipts.ListReceipts)
+		protected.GET("/projects/:id/receipts", h.receipts.DownloadProjectReceipts)
+
+		// 主催者・運営会社への送金（送金先の登録・精算・明細）
+		protected.GET("/payouts/account", h.payouts.GetAccount)
+		protected.POST("/payouts/account", h.payouts.StartOnboarding)
+		protected.GET("/payouts/settlements", h.payouts.ListSettlements)
+		protected.GET("/payouts/settlements/:id/statement", h.payouts.DownloadStatement)
+
+		// 運営会社への直接支払い（運営会社の請求書・主催者の経費の立替）
+		protected.GET("/operator/invoices", h.invoices.ListMyInvoices)
+		protected.POST("/operator/invoices", h.invoices.SubmitInvoice)
+		protected.GET("/projects/:id/expenses", h.invoices.ListProjectExpenses)
+		protected.POST("/projects/:id/expenses", h.invoices.SubmitExpense)
+
+		// 管理者向け（ロールはサービス層で確認）
+		protected.GET("/admin/audit-events", h.audit.ListAuditEvents)
+		protected.GET("/admin/settlements", h.payouts.ListAllSettlements)
+		protected.POST("/admin/settlements/:id/retry", h.payouts.RetrySettlement)
+		protected.GET("/admin/invoices", h.invoices.ListInvoices)
+		protected.POST("/admin/invoices/:id/review", h.invoices.ReviewInvoice)
+		protected.GET("/admin/expenses", h.invoices.ListExpenses)
+		protected.POST("/admin/expenses/:id/review", h.invoices.ReviewExpense)
+		protected.GET("/admin/ledger/trial-balance", h.ledger.GetTrialBalance)
+		protected.GET("/admin/ledger/projects/:id", h.ledger.GetProjectLedger)
+		protected.GET("/admin/ledger/reconciliations", h.ledger.ListReconciliationReports)
+	}
+}
@@ -0,0 +1,147 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/seed"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// runSeed はプロファイルのシードデータを投入します（reset の場合は既存のデータを削除してから投入）
+func runSeed(t *testing.T, h *testutil.Harness, profile string, reset bool) {
+	t.Helper()
+	err := seed.Run(context.Background(), h.DB, seed.Options{
+		Profile:    profile,
+		RandomSeed: 42,
+		Reset:      reset,
+		Env:        config.EnvTest,
+		Hasher:     utils.NewPasswordHasher(h.Config.Auth.BcryptCost),
+	})
+	if err != nil {
+		t.Fatalf("シードデータの投入（%s）: %v", profile, err)
+	}
+}
+
+func countRows(t *testing.T, h *testutil.Harness, model interface{}, query ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	db := h.DB.Model(model)
+	if len(query) > 0 {
+		db = db.Where(query[0], query[1:]...)
+	}
+	if err := db.Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// expectBalancedLedger は完了した支援がすべて帳簿に記録され、試算表の貸借が一致することを確認します
+func expectBalancedLedger(t *testing.T, h *testutil.Harness) {
+	t.Helper()
+	completed := countRows(t, h, &models.Support{}, "status = ?", models.SupportStatusCompleted)
+	if entries := countRows(t, h, &models.JournalEntry{}, "kind = ?", models.JournalKindSupportCompleted); entries != completed {
+		t.Errorf("支援の仕訳: got %d, want %d", entries, completed)
+	}
+	var tb struct {
+		Balanced bool `json:"balanced"`
+	}
+	h.Do(http.MethodGet, "/api/v1/admin/ledger/trial-balance", nil, newAdmin(t, h).Token).
+		Expect(t, http.StatusOK).Decode(t, &tb)
+	if !tb.Balanced {
+		t.Error("試算表の貸借が一致しません")
+	}
+}
+
+func TestSeedE2EProfile(t *testing.T) {
+	h := testutil.New(t)
+	runSeed(t, h, "e2e", false)
+
+	if n := countRows(t, h, &models.Project{}); n != 3 {
+		t.Errorf("プロジェクトの数: got %d, want 3", n)
+	}
+	if n := countRows(t, h, &models.Support{}); n != 2 {
+		t.Errorf("支援の数: got %d, want 2", n)
+	}
+	expectBalancedLedger(t, h)
+
+	// 固定のアカウントでログインでき、管理者は二要素認証の登録を求められる
+	if token := h.Login(seed.E2ESupporterEmail, seed.DefaultPassword); token == "" {
+		t.Error("E2Eの支援者でログインできません")
+	}
+	var login twoFactorLogin
+	h.Do(http.MethodPost, "/api/v1/login", map[string]string{"email": seed.E2EAdminEmail, "password": seed.DefaultPassword}, "").
+		Expect(t, http.StatusOK).Decode(t, &login)
+	if !login.MFARequired || !login.EnrollmentRequired {
+		t.Errorf("E2Eの管理者のログイン: got %+v", login)
+	}
+}
+
+func TestSeedDemoProfileIsReproducible(t *testing.T) {
+	h := testutil.New(t)
+
+	amounts := func() []int64 {
+		var amounts []int64
+		if err := h.DB.Model(&models.Support{}).Order("id").Pluck("amount", &amounts).Error; err != nil {
+			t.Fatal(err)
+		}
+		return amounts
+	}
+	runSeed(t, h, "demo", false)
+	first := amounts()
+	if len(first) == 0 || countRows(t, h, &models.Project{}) == 0 {
+		t.Fatalf("デモデータ: got %d supports", len(first))
+	}
+
+	// リセットして同じシードで投入すると同じデータになる
+	runSeed(t, h, "demo", true)
+	second := amounts()
+	if len(second) != len(first) {
+		t.Fatalf("再投入した支援の数: got %d, want %d", len(second), len(first))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("%d番目の支援の金額: got %d, want %d", i, second[i], first[i])
+		}
+	}
+	expectBalancedLedger(t, h)
+}
+
+func TestSeedLoadTestProfile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("負荷試験用のデータは件数が多いため -short ではスキップします")
+	}
+	h := testutil.New(t)
+	runSeed(t, h, "load-test", false)
+
+	if n := countRows(t, h, &models.Project{}); n != 1000 {
+		t.Errorf("プロジェクトの数: got %d, want 1000", n)
+	}
+	if n := countRows(t, h, &models.User{}); n < 5000 {
+		t.Errorf("ユーザーの数: got %d, want at least 5000", n)
+	}
+	expectBalancedLedger(t, h)
+}
+
+func TestSeedRejectsUnknownProfileAndProductionReset(t *testing.T) {
+	h := testutil.New(t)
+	hasher := utils.NewPasswordHasher(h.Config.Auth.BcryptCost)
+
+	if err := seed.Run(context.Background(), h.DB, seed.Options{Profile: "unknown", Hasher: hasher}); err == nil {
+		t.Error("不明なプロファイルを受け付けました")
+	}
+	for _, env := range []string{config.EnvProduction, ""} {
+		err := seed.Run(context.Background(), h.DB, seed.Options{Profile: "e2e", Reset: true, Env: env, Hasher: hasher})
+		if !errors.Is(err, seed.ErrResetNotAllowed) {
+			t.Errorf("ENV=%q でのリセット: got %v", env, err)
+		}
+	}
+	if n := countRows(t, h, &models.User{}); n != 0 {
+		t.Errorf("拒否した投入で作成されたユーザー: got %d", n)
+	}
+}
@@ -0,0 +1,356 @@
+// Package server はHTTPサーバーの依存関係を組み立て、ルーティングを定義します
+// cmd/main.go と統合テストのハーネスは同じ組み立てを使用します
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/auth/oidc"
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/handlers"
+	"github.com/masvc/oshiome_go/backend/internal/jobs"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/middleware"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/openapi"
+	"github.com/masvc/oshiome_go/backend/internal/privacy"
+	"github.com/masvc/oshiome_go/backend/internal/realtime"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/scheduler"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Deps はサーバーの組み立てに必要な依存（nilの項目は本番用の実装を使用）
+type Deps struct {
+	Config *config.Config
+	DB     *gorm.DB
+	// Mailer が nil の場合は設定から作成します
+	Mailer mail.Mailer
+	// Payments が nil の場合はStripeを使用します
+	Payments service.PaymentGateway
+	// Connect が nil の場合は Stripe Connect を使用します
+	Connect service.ConnectGateway
+	// Now が nil の場合は time.Now を使用します
+	Now func() time.Time
+	// Logger が nil の場合は slog.Default を使用します
+	Logger *slog.Logger
+	// Tracer が nil の場合はトレーシングを無効にします
+	Tracer *tracing.Tracer
+}
+
+// Server は組み立て済みのルーターとバックグラウンド処理
+type Server struct {
+	Router    *gin.Engine
+	Services  *service.Services
+	Worker    *jobs.Worker
+	Hub       *realtime.Hub
+	Scheduler *scheduler.Scheduler
+	// Spec はAPIのOpenAPIドキュメント
+	Spec *openapi.Document
+	// Metrics は /metrics で公開するメトリクス（Prometheus形式）
+	Metrics *metrics.Registry
+
+	cfg            *config.Config
+	logger         *slog.Logger
+	rateLimitStore middleware.RateLimitStore
+	// バージョンのない /api/... を廃止する日時
+	legacySunset time.Time
+	// 停止時に処理中のリクエストの完了を待つ時間
+	shutdownTimeout time.Duration
+	// 停止処理中（/readyz を503にする）
+	draining atomic.Bool
+	// バックグラウンド処理の停止と終了の待機（Start / Stop）
+	cancelBackground context.CancelFunc
+	background       sync.WaitGroup
+}
+
+// New は依存関係を組み立て、ルーティングを定義したServerを作成します
+func New(deps Deps) (*Server, error) {
+	cfg := deps.Config
+	if deps.Mailer == nil {
+		deps.Mailer = mail.New(cfg.Mail)
+	}
+	if deps.Payments == nil {
+		deps.Payments = service.NewStripeGateway()
+	}
+	if deps.Connect == nil {
+		deps.Connect = service.NewStripeConnectGateway()
+	}
+	if deps.Now == nil {
+		deps.Now = time.Now
+	}
+	if deps.Logger == nil {
+		deps.Logger = slog.Default()
+	}
+
+	tokens, err := utils.NewTokenManager(cfg.Auth.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	tokens.SetClock(deps.Now)
+	hasher := utils.NewPasswordHasher(cfg.Auth.BcryptCost)
+
+	policies := middleware.DefaultRateLimitPolicies
+	if cfg.RateLimit.Policies != "" {
+		if policies, err = middleware.ParseRateLimitPolicies(cfg.RateLimit.Policies); err != nil {
+			return nil, fmt.Errorf("レート制限ポリシーの読み込みに失敗しました: %w", err)
+		}
+	}
+
+	feeRules, err := service.LoadFeeRules(cfg.Payout.FeeRules)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthRegistry, err := oidc.NewRegistryFromConfig(cfg.OAuth.Providers, cfg.Server.BackendURL)
+	if err != nil {
+		return nil, fmt.Errorf("ソーシャルログインの設定に誤りがあります: %w", err)
+	}
+
+	// バックグラウンドジョブ（データのエクスポート・退会処理）
+	worker := jobs.NewWorker(deps.DB)
+	worker.SetTracer(deps.Tracer)
+	privacyService := privacy.NewService(deps.DB, deps.Mailer, hasher, cfg.Server.FrontendURL)
+	privacyService.Register(worker)
+
+	// サービス層（ハンドラー・Webhook・定期タスク・管理CLIで共有）
+	loginGuard := auth.NewLoginGuard(deps.DB, deps.Mailer, cfg.Server.FrontendURL)
+	loginGuard.SetClock(deps.Now)
+	loginGuard.Register(worker)
+	services := service.New(repository.NewStore(deps.DB), service.Deps{
+		Payments:              deps.Payments,
+		Connect:               deps.Connect,
+		FeeRules:              feeRules,
+		Notifier:              realtime.NewNotifier(deps.DB),
+		Hasher:                hasher,
+		Guard:                 loginGuard,
+		AuditRetention:        cfg.Audit.Retention(),
+		LoginAttemptRetention: cfg.Auth.LoginAttemptRetention(),
+		ReceiptIssuer: service.ReceiptIssuer{
+			Name:               cfg.Receipt.IssuerName,
+			Address:            cfg.Receipt.IssuerAddress,
+			RegistrationNumber: cfg.Receipt.RegistrationNumber,
+		},
+		Now: deps.Now,
+	})
+
+	sunset, err := cfg.Server.LegacyAPISunsetTime()
+	if err != nil {
+		return nil, fmt.Errorf("LEGACY_API_SUNSET の形式が不正です: %w", err)
+	}
+	shutdownTimeout, err := cfg.Server.ShutdownTimeoutDuration()
+	if err != nil {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT の形式が不正です: %w", err)
+	}
+
+	s := &Server{
+		Router:          gin.New(),
+		Services:        services,
+		Worker:          worker,
+		Hub:             realtime.NewHub(deps.DB),
+		Scheduler:       scheduler.NewDefault(services),
+		Spec:            apiSpec(cfg.Server.BackendURL),
+		cfg:             cfg,
+		logger:          deps.Logger,
+		rateLimitStore:  newRateLimitStore(cfg.RateLimit, deps.DB),
+		legacySunset:    sunset,
+		shutdownTimeout: shutdownTimeout,
+	}
+	// X-Forwarded-For は設定したプロキシからの接続でのみ信頼する（gin の既定はすべてのプロキシを信頼する）
+	if err := s.Router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES の形式が不正です: %w", err)
+	}
+	health := handlers.NewHealthHandler(s.isDraining, readinessChecks(deps.DB, cfg.Stripe)...)
+
+	// ロードバランサー・Kubernetesのプローブ（数秒ごとに呼ばれるため、アクセスログ・レート制限より前に登録）
+	s.Router.GET("/healthz", health.Liveness)
+	s.Router.GET("/readyz", health.Readiness)
+
+	// トレース・リクエストID・アクセスログ（プリフライトリクエストも含めるため最初に設定）
+	s.Router.Use(middleware.Tracing(deps.Tracer))
+	s.Router.Use(middleware.RequestLogger(deps.Logger))
+	s.Router.Use(middleware.Metrics())
+	s.Router.Use(middleware.AuditActor())
+	// ErrorHandlerより前のミドルウェアで発生したパニックの最後の受け皿
+	s.Router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		logging.FromContext(c.Request.Context()).Error("パニックから復帰しました", "panic", fmt.Sprint(err))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+
+	// CORSの設定
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.Server.CORSOrigins
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{
+		"Origin",
+		"Content-Type",
+		"Authorization",
+		"Stripe-Signature",
+		"Accept",
+		"X-Requested-With",
+		middleware.APIVersionHeader,
+		middleware.RequestIDHeader,
+	}
+	corsConfig.AllowCredentials = true
+	corsConfig.ExposeHeaders = []string{"Content-Length", middleware.RequestIDHeader, middleware.APIVersionHeader, "Deprecation", "Sunset", "Link"}
+	corsConfig.MaxAge = 86400 // プリフライトリクエストのキャッシュ時間（24時間）
+	s.Router.Use(cors.New(corsConfig))
+
+	// ミドルウェアの設定
+	s.Router.Use(middleware.Locale())
+	s.Router.Use(middleware.ErrorHandler())
+	s.Router.Use(middleware.RateLimit(s.rateLimitStore, policies, tokens))
+
+	// ハンドラーのインスタンス化
+	twoFactor := handlers.NewTwoFactorHandler(deps.DB, loginGuard, tokens)
+	twoFactor.SetClock(deps.Now)
+	h := &routeHandlers{
+		users:     handlers.NewUserHandler(services.Users, loginGuard, tokens),
+		twoFactor: twoFactor,
+		oauth:     handlers.NewOAuthHandler(deps.DB, oauthRegistry, tokens, hasher, cfg.Server.FrontendURL, cfg.Server.BackendURL),
+		privacy:   handlers.NewPrivacyHandler(deps.DB, privacyService),
+		projects:  handlers.NewProjectHandler(services.Projects, s.Hub),
+		supports:  handlers.NewSupportHandler(services.Supports, cfg.Server),
+		webhook:   handlers.NewWebhookHandler(services.Supports, services.Payouts, cfg.Stripe),
+		audit:     handlers.NewAuditHandler(services.Audit),
+		payouts:   handlers.NewPayoutHandler(services.Payouts, cfg.Server.FrontendURL),
+		invoices:  handlers.NewInvoiceHandler(services.Invoices),
+		ledger:    handlers.NewLedgerHandler(services.Ledger, services.Reconciliation),
+		receipts:  handlers.NewReceiptHandler(services.Receipts),
+		health:    health,
+		spec:      s.Spec,
+		auth:      []gin.HandlerFunc{middleware.AuthMiddleware(tokens, deps.DB), middleware.UserLocale(deps.DB)},
+		preAuth:   []gin.HandlerFunc{middleware.PreAuthMiddleware(tokens, deps.DB), middleware.UserLocale(deps.DB)},
+	}
+
+	// プロジェクト・支援・ユーザー・精算の更新と削除を監査ログに記録
+	if deps.DB != nil {
+		if err := audit.Register(deps.DB); err != nil {
+			return nil, err
+		}
+	}
+
+	// クエリごとのスパン（親はリクエストやジョブのスパン）
+	if deps.DB != nil && deps.Tracer != nil {
+		if err := deps.DB.Use(tracing.GormPlugin{Tracer: deps.Tracer}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return nil, fmt.Errorf("GORMのトレーシングの登録に失敗しました: %w", err)
+		}
+	}
+
+	// メトリクス（METRICS_TOKEN を設定した場合のみAPIと同じポートで公開）
+	s.Metrics = newMetricsRegistry(deps.DB, services)
+	if cfg.Metrics.Token != "" {
+		s.Router.GET("/metrics", middleware.MetricsToken(cfg.Metrics.Token), gin.WrapH(s.Metrics))
+	}
+
+	// 存在しないルートも統一されたエラー形式で返す
+	s.Router.NoRoute(func(c *gin.Context) {
+		c.Error(utils.ErrNotFound.WithDetail("APIが見つかりません"))
+	})
+
+	// バージョンごとのルート（/api/v1/...）
+	for _, v := range apiVersions {
+		v.register(s.Router.Group("/api/"+v.name, middleware.APIVersion(v.name)), h)
+	}
+
+	return s, nil
+}
+
+// Start はバックグラウンド処理（ジョブ、リアルタイム配信、定期タスク）を開始します
+// 処理はctxがキャンセルされるか Stop を呼ぶまで続きます
+func (s *Server) Start(ctx context.Context) {
+	ctx, s.cancelBackground = context.WithCancel(logging.NewContext(ctx, s.logger))
+	s.goBackground(func() { s.Worker.Run(ctx) })
+	s.goBackground(func() { s.Hub.Listen(ctx, s.cfg.Database.DSN()) })
+	s.goBackground(func() { s.Scheduler.Run(ctx) })
+
+	if store, ok := s.rateLimitStore.(*middleware.PostgresRateLimitStore); ok {
+		s.goBackground(func() { pruneRateLimitBuckets(ctx, store) })
+	}
+}
+
+// goBackground は Stop で終了を待つバックグラウンド処理を開始します
+func (s *Server) goBackground(fn func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn()
+	}()
+}
+
+// newMetricsRegistry はHTTP・データベース・業務のメトリクスを登録したRegistryを作成します
+func newMetricsRegistry(database *gorm.DB, services *service.Services) *metrics.Registry {
+	reg := metrics.NewRegistry()
+	reg.MustRegister(metrics.AppCollectors()...)
+
+	// データベースなしで組み立てた場合（ルート定義の検査など）はHTTPと業務のメトリクスのみ
+	if database == nil {
+		return reg
+	}
+
+	// ステータスごとのプロジェクト数（スクレイプ時に集計）
+	reg.MustRegister(metrics.NewGaugeFunc("oshiome_projects", "ステータスごとのプロジェクト数", []string{"status"},
+		func(r *http.Request) []metrics.Sample {
+			counts, err := services.Projects.CountByStatus(r.Context())
+			if err != nil {
+				logging.FromContext(r.Context()).Error("プロジェクト数の集計に失敗しました", "error", err)
+				return nil
+			}
+			var samples []metrics.Sample
+			for _, status := range models.ProjectStatuses {
+				samples = append(samples, metrics.Sample{LabelValues: []string{string(status)}, Value: float64(counts[status])})
+			}
+			return samples
+		}))
+
+	// クエリの所要時間とコネクションプールの状態
+	if err := database.Use(metrics.GormPlugin{}); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+		slog.Error("GORMのメトリクスの登録に失敗しました", "error", err)
+	}
+	if sqlDB, err := database.DB(); err == nil {
+		reg.MustRegister(metrics.DBStatsCollectors(sqlDB)...)
+	}
+	return reg
+}
+
+// newRateLimitStore RATE_LIMIT_STOREに応じたレート制限ストアを作成します
+// 複数レプリカで運用する場合は postgres を指定してください
+func newRateLimitStore(cfg config.RateLimit, database *gorm.DB) middleware.RateLimitStore {
+	if cfg.Store != "postgres" {
+		return middleware.NewMemoryRateLimitStore()
+	}
+	return middleware.NewPostgresRateLimitStore(database)
+}
+
+// pruneRateLimitBuckets は期限切れのレート制限バケットを1時間ごとに削除します
+func pruneRateLimitBuckets(ctx context.Context, store *middleware.PostgresRateLimitStore) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Prune(ctx); err != nil {
+				logging.FromContext(ctx).Error("レート制限バケットの削除に失敗しました", "error", err)
+			}
+		}
+	}
+}
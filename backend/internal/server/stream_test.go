@@ -0,0 +1,177 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/realtime"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// streamEvent はSSEで受け取ったイベント
+type streamEvent struct {
+	Name string
+	Data string
+}
+
+// openStream はプロジェクトの支援状況のSSEに接続し、受け取ったイベントを返すチャネルを返します
+// 接続はテストの終了時に閉じます
+func openStream(t *testing.T, srv *httptest.Server, projectID uint) <-chan streamEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/projects/%d/stream", srv.URL, projectID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("SSEの接続: got status %d", res.StatusCode)
+	}
+
+	events := make(chan streamEvent, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(events)
+		var event streamEvent
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				event.Data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			case line == "" && event.Name != "":
+				events <- event
+				event = streamEvent{}
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		res.Body.Close()
+		<-done
+	})
+	return events
+}
+
+// nextProgress は支援状況のイベントを受け取るまで待ちます（他のテストの通知による古い状況は読み飛ばします）
+func nextProgress(t *testing.T, events <-chan streamEvent, want func(realtime.ProjectEvent) bool) realtime.ProjectEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("SSEの接続が閉じられました")
+			}
+			if event.Name != "progress" {
+				continue
+			}
+			var progress realtime.ProjectEvent
+			if err := json.Unmarshal([]byte(event.Data), &progress); err != nil {
+				t.Fatalf("支援状況のイベント: %v: %q", err, event.Data)
+			}
+			if want(progress) {
+				return progress
+			}
+		case <-timeout:
+			t.Fatal("支援状況のイベントを受け取れませんでした")
+		}
+	}
+}
+
+// listen はHubにPostgreSQLの通知を購読させ、LISTENが完了するまで待ちます
+func listen(t *testing.T, h *testutil.Harness) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Server.Hub.Listen(ctx, testutil.DSN(t))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var listening int64
+		if err := h.DB.Raw("SELECT count(*) FROM pg_stat_activity WHERE query = ?", "LISTEN "+realtime.Channel).
+			Scan(&listening).Error; err != nil {
+			t.Fatal(err)
+		}
+		if listening > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("通知の購読を開始できませんでした")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProjectStreamFansOutToSubscribers(t *testing.T) {
+	h := testutil.New(t)
+	owner := h.Register("owner")
+	supporter := h.Register("supporter")
+	p := createProject(t, h, owner, "active")
+	other := createProject(t, h, owner, "active")
+
+	srv := httptest.NewServer(h.Server.Handler())
+	t.Cleanup(srv.Close)
+	listen(t, h)
+
+	// 接続直後に現在の支援状況を受け取る
+	subscribers := []<-chan streamEvent{openStream(t, srv, p.ID), openStream(t, srv, p.ID)}
+	for _, events := range subscribers {
+		initial := nextProgress(t, events, func(realtime.ProjectEvent) bool { return true })
+		if initial.ProjectID != p.ID || initial.CurrentAmount != 0 || initial.SupportersCount != 0 {
+			t.Fatalf("接続直後の支援状況: got %+v", initial)
+		}
+	}
+	otherEvents := openStream(t, srv, other.ID)
+	nextProgress(t, otherEvents, func(realtime.ProjectEvent) bool { return true })
+
+	// 支援が完了すると、同じプロジェクトの購読者全員に支援状況が届く
+	c := startCheckout(t, h, supporter, p.ID, 3000)
+	h.CompleteCheckout(c.CheckoutSessionID).Expect(t, http.StatusOK)
+	for i, events := range subscribers {
+		progress := nextProgress(t, events, func(e realtime.ProjectEvent) bool { return e.CurrentAmount == 3000 })
+		if progress.ProjectID != p.ID || progress.SupportersCount != 1 {
+			t.Errorf("購読者%dの支援状況: got %+v", i+1, progress)
+		}
+		if progress.Cheer == nil || progress.Cheer.SupportID != c.SupportID || progress.Cheer.Message != "応援しています" ||
+			progress.Cheer.UserName != "supporter" {
+			t.Errorf("購読者%dの応援メッセージ: got %+v", i+1, progress.Cheer)
+		}
+	}
+
+	// 他のプロジェクトの購読者には届かない
+	select {
+	case event := <-otherEvents:
+		if event.Name == "progress" && strings.Contains(event.Data, `"current_amount":3000`) {
+			t.Errorf("他のプロジェクトの購読者に届いたイベント: %+v", event)
+		}
+	default:
+	}
+
+	// シャットダウン時は購読中の接続を閉じる
+	h.Server.Hub.Close()
+	for _, events := range subscribers {
+		for range events {
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+	"github.com/masvc/oshiome_go/backend/internal/tracing"
+)
+
+// newTracer は標準出力のエクスポーターの代わりにバッファへ書き出すTracerを作成します
+func newTracer(t *testing.T) (*tracing.Tracer, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	tracer := tracing.NewTracer("oshiome-test", 1, tracing.NewStdoutExporter(&buf))
+	t.Cleanup(func() { tracer.Shutdown(context.Background()) })
+	return tracer, &buf
+}
+
+// flushSpans は終了したスパンを書き出し、1行ずつデコードして返します
+func flushSpans(t *testing.T, tracer *tracing.Tracer, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return logEntries(t, buf)
+}
+
+func TestTracingContinuesIncomingTraceAndTagsLogs(t *testing.T) {
+	tracer, spans := newTracer(t)
+	cfg := testutil.Config()
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, cfg.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := server.New(server.Deps{
+		Config:   cfg,
+		Mailer:   &testutil.Mailer{},
+		Payments: testutil.NewFakePayments(),
+		Logger:   logger,
+		Tracer:   tracer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+
+	entries := flushSpans(t, tracer, spans)
+	if len(entries) != 1 {
+		t.Fatalf("スパンの件数: got %d, want 1\n%s", len(entries), spans)
+	}
+	span := entries[0]
+	want := map[string]interface{}{
+		"name":           "GET /api/v1/health",
+		"kind":           "server",
+		"trace_id":       traceID,
+		"parent_span_id": parentID,
+		"service":        "oshiome-test",
+	}
+	for key, value := range want {
+		if span[key] != value {
+			t.Errorf("%s: got %v, want %v", key, span[key], value)
+		}
+	}
+	attrs, _ := span["attributes"].(map[string]interface{})
+	if attrs["http.route"] != "/api/v1/health" || attrs["http.response.status_code"] != float64(200) {
+		t.Errorf("attributes: got %v", attrs)
+	}
+
+	entry := requestLog(t, &logs)
+	if entry["trace_id"] != traceID || entry["span_id"] != span["span_id"] {
+		t.Errorf("ログのトレース: got trace_id=%v span_id=%v, want %s %v", entry["trace_id"], entry["span_id"], traceID, span["span_id"])
+	}
+}
+
+func TestTracingIsDisabledWithoutTracer(t *testing.T) {
+	srv, buf := newServerWithLogs(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	if entry := requestLog(t, buf); entry["trace_id"] != nil {
+		t.Errorf("トレーシングが無効なのに trace_id があります: %v", entry)
+	}
+}
+
+func TestStripeTransportRecordsClientSpan(t *testing.T) {
+	tracer, spans := newTracer(t)
+
+	var received string
+	stripeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+		w.Header().Set("Request-Id", "req_abc123")
+		w.WriteHeader(http.StatusPaymentRequired)
+	}))
+	defer stripeAPI.Close()
+
+	ctx, parent := tracer.Start(context.Background(), "POST /api/v1/supports")
+	client := &http.Client{Transport: &tracing.Transport{Tracer: tracer, Peer: "stripe"}}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, stripeAPI.URL+"/v1/checkout/sessions/cs_test_a1B2c3D4e5F6g7H8", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	entries := flushSpans(t, tracer, spans)
+	if len(entries) != 2 {
+		t.Fatalf("スパンの件数: got %d, want 2\n%s", len(entries), spans)
+	}
+	client0 := entries[0]
+	if client0["kind"] != "client" || client0["parent_span_id"] != parent.SpanContext().SpanID.String() {
+		t.Errorf("クライアントのスパン: got %v", client0)
+	}
+	if client0["status"] != "error" {
+		t.Errorf("status: got %v, want error", client0["status"])
+	}
+	if want := "stripe GET /v1/checkout/sessions/cs_test_****g7H8"; client0["name"] != want {
+		t.Errorf("name: got %v, want %s（決済IDは伏せる）", client0["name"], want)
+	}
+	attrs, _ := client0["attributes"].(map[string]interface{})
+	if attrs["stripe.request_id"] != "req_abc123" {
+		t.Errorf("stripe.request_id: got %v", attrs["stripe.request_id"])
+	}
+
+	sc, ok := tracing.ParseTraceparent(received)
+	if !ok || sc.TraceID != parent.SpanContext().TraceID || sc.SpanID.String() != client0["span_id"] {
+		t.Errorf("traceparent: got %q", received)
+	}
+}
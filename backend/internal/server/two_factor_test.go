@@ -0,0 +1,211 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/testutil"
+)
+
+// twoFactorEnrollment は二要素認証の登録の結果
+type twoFactorEnrollment struct {
+	Secret        string
+	RecoveryCodes []string `json:"recovery_codes"`
+	// Token は登録の完了時に発行される、二要素認証を経たトークン
+	Token string `json:"token"`
+}
+
+type twoFactorLogin struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	PreAuthToken       string `json:"pre_auth_token"`
+	Token              string `json:"token"`
+}
+
+// totpCode は時計の現在時刻に認証アプリが表示するコードを返します
+func totpCode(t *testing.T, h *testutil.Harness, secret string) string {
+	t.Helper()
+	code, err := auth.GenerateTOTPCode(secret, h.Clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// nextTOTPCode は時計を次のタイムステップまで進め、使用済みでないコードを返します
+func nextTOTPCode(t *testing.T, h *testutil.Harness, secret string) string {
+	t.Helper()
+	h.Clock.Advance(30 * time.Second)
+	return totpCode(t, h, secret)
+}
+
+// enrollTwoFactor は token（通常のトークンまたは二要素認証待ちのトークン）で二要素認証を登録します
+func enrollTwoFactor(t *testing.T, h *testutil.Harness, token string) twoFactorEnrollment {
+	t.Helper()
+	var enrollment twoFactorEnrollment
+	var started struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/enroll", nil, token).Expect(t, http.StatusOK).Decode(t, &started)
+	if started.Secret == "" || started.ProvisioningURI == "" {
+		t.Fatalf("登録の開始: got %+v", started)
+	}
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/confirm", map[string]string{"code": totpCode(t, h, started.Secret)}, token).
+		Expect(t, http.StatusOK).Decode(t, &enrollment)
+	if len(enrollment.RecoveryCodes) == 0 || enrollment.Token == "" {
+		t.Fatalf("登録の完了: got %+v", enrollment)
+	}
+	enrollment.Secret = started.Secret
+	return enrollment
+}
+
+// loginWithPassword はパスワードでログインし、二要素認証待ちの結果を返します
+func loginWithPassword(t *testing.T, h *testutil.Harness, user *testutil.User) twoFactorLogin {
+	t.Helper()
+	var login twoFactorLogin
+	h.Do(http.MethodPost, "/api/v1/login", map[string]string{"email": user.Email, "password": user.Password}, "").
+		Expect(t, http.StatusOK).Decode(t, &login)
+	if !login.MFARequired || login.PreAuthToken == "" || login.Token != "" {
+		t.Fatalf("二要素認証が有効なユーザーのログイン: got %+v", login)
+	}
+	return login
+}
+
+func TestTwoFactorEnrollmentAndLogin(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("grace")
+
+	// 誤ったコードでは有効にならない
+	var started struct {
+		Secret string `json:"secret"`
+	}
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/enroll", nil, user.Token).Expect(t, http.StatusOK).Decode(t, &started)
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/confirm", map[string]string{"code": "000000"}, user.Token).
+		Expect(t, http.StatusUnauthorized)
+	var stored models.User
+	if err := h.DB.First(&stored, user.ID).Error; err != nil || stored.TOTPEnabled {
+		t.Fatalf("誤ったコードの後の状態: got enabled=%v, %v", stored.TOTPEnabled, err)
+	}
+
+	enrollment := enrollTwoFactor(t, h, user.Token)
+	if len(enrollment.RecoveryCodes) != 10 {
+		t.Errorf("リカバリーコードの数: got %d, want 10", len(enrollment.RecoveryCodes))
+	}
+	if id, _ := currentUser(t, h, enrollment.Token); id != user.ID {
+		t.Errorf("登録の完了時のトークンのユーザー: got %d, want %d", id, user.ID)
+	}
+
+	// パスワードだけではトークンを受け取れず、二要素認証待ちのトークンではAPIにアクセスできない
+	login := loginWithPassword(t, h, user)
+	if login.EnrollmentRequired {
+		t.Errorf("登録済みのユーザーに登録を求めました: %+v", login)
+	}
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, login.PreAuthToken).Expect(t, http.StatusUnauthorized)
+
+	// 登録の確認に使ったコードは再利用できない
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "code": totpCode(t, h, enrollment.Secret),
+	}, "").Expect(t, http.StatusUnauthorized)
+
+	var verified twoFactorLogin
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "code": nextTOTPCode(t, h, enrollment.Secret),
+	}, "").Expect(t, http.StatusOK).Decode(t, &verified)
+	if id, _ := currentUser(t, h, verified.Token); id != user.ID {
+		t.Errorf("二要素認証後のトークンのユーザー: got %d, want %d", id, user.ID)
+	}
+}
+
+func TestTwoFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("heidi")
+	enrollment := enrollTwoFactor(t, h, user.Token)
+	recoveryCode := enrollment.RecoveryCodes[0]
+
+	login := loginWithPassword(t, h, user)
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "recovery_code": recoveryCode,
+	}, "").Expect(t, http.StatusOK)
+
+	// 使用済みのリカバリーコードでは再度ログインできない
+	login = loginWithPassword(t, h, user)
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "recovery_code": recoveryCode,
+	}, "").Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "recovery_code": enrollment.RecoveryCodes[1],
+	}, "").Expect(t, http.StatusOK)
+
+	var used int64
+	h.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NOT NULL", user.ID).Count(&used)
+	if used != 2 {
+		t.Errorf("使用済みのリカバリーコード: got %d, want 2", used)
+	}
+}
+
+func TestTwoFactorPreAuthTokenExpires(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("ivan")
+	enrollment := enrollTwoFactor(t, h, user.Token)
+	login := loginWithPassword(t, h, user)
+
+	// 二要素認証待ちのトークンの有効期限（5分）を過ぎると、正しいコードでもログインできない
+	h.Clock.Advance(6 * time.Minute)
+	h.Do(http.MethodPost, "/api/v1/login/2fa", map[string]string{
+		"pre_auth_token": login.PreAuthToken, "code": totpCode(t, h, enrollment.Secret),
+	}, "").Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/enroll", nil, login.PreAuthToken).Expect(t, http.StatusUnauthorized)
+}
+
+func TestTwoFactorWrongCodesCountAsLoginFailures(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("judy")
+	enrollment := enrollTwoFactor(t, h, user.Token)
+
+	// 無効化・リカバリーコードの再発行での誤ったコードもログインの失敗として数える
+	wrong := map[string]string{"code": "000000"}
+	h.Do(http.MethodDelete, "/api/v1/auth/2fa", wrong, enrollment.Token).Expect(t, http.StatusUnauthorized)
+	h.Do(http.MethodPost, "/api/v1/auth/2fa/recovery-codes", wrong, enrollment.Token).Expect(t, http.StatusUnauthorized)
+
+	var stored models.User
+	if err := h.DB.First(&stored, user.ID).Error; err != nil || stored.FailedLoginCount != 2 {
+		t.Fatalf("ログインの失敗回数: got %d, %v, want 2", stored.FailedLoginCount, err)
+	}
+	var attempts int64
+	h.DB.Model(&models.LoginAttempt{}).Where("email = ? AND success = ?", user.Email, false).Count(&attempts)
+	if attempts != 2 {
+		t.Errorf("失敗したログイン試行の記録: got %d, want 2", attempts)
+	}
+
+	// 正しいコードでは無効化できる
+	h.Do(http.MethodDelete, "/api/v1/auth/2fa", map[string]string{"code": nextTOTPCode(t, h, enrollment.Secret)}, enrollment.Token).
+		Expect(t, http.StatusOK)
+}
+
+func TestTwoFactorRequiredForPromotedUser(t *testing.T) {
+	h := testutil.New(t)
+	user := h.Register("ken")
+	registered := user.Token
+	promoteToAdmin(t, h, user)
+
+	// 管理者への変更前に発行された、二要素認証を経ていないトークンでは管理者のAPIにアクセスできない
+	res := h.Do(http.MethodGet, "/api/v1/admin/audit-events", nil, registered).Expect(t, http.StatusUnauthorized)
+	if code := res.ErrorCode(); code != "UNAUTHORIZED" {
+		t.Errorf("エラーコード: got %q, want UNAUTHORIZED", code)
+	}
+	h.Do(http.MethodGet, "/api/v1/auth/me", nil, registered).Expect(t, http.StatusUnauthorized)
+
+	// パスワードでログインすると二要素認証の登録を求められる
+	login := loginWithPassword(t, h, user)
+	if !login.EnrollmentRequired {
+		t.Errorf("未登録の管理者のログイン: got %+v", login)
+	}
+
+	// 登録を完了したトークンでは管理者のAPIにアクセスできる
+	enrollment := enrollTwoFactor(t, h, login.PreAuthToken)
+	h.Do(http.MethodGet, "/api/v1/admin/audit-events", nil, enrollment.Token).Expect(t, http.StatusOK)
+}
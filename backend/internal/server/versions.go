@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/middleware"
+)
+
+// legacyAPIDeprecatedAt はバージョンのない /api/... を非推奨にした日時（/api/v1 の導入日）
+var legacyAPIDeprecatedAt = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+// Handler はHTTPサーバーに渡すハンドラーを返します
+//
+// バージョンのない /api/... は移行期間中の別名として、API-Versionヘッダーで指定したバージョン
+// （省略時は legacyAPIVersion）のルートで処理し、Deprecation / Sunset / Link ヘッダーを付けます
+func (s *Server) Handler() http.Handler {
+	deprecation := "@" + strconv.FormatInt(legacyAPIDeprecatedAt.Unix(), 10)
+	sunset := s.legacySunset.UTC().Format(http.TimeFormat)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLegacyAPIPath(r.URL.Path) {
+			s.Router.ServeHTTP(w, r)
+			return
+		}
+
+		version := negotiateAPIVersion(r.Header.Get(middleware.APIVersionHeader))
+		path := "/api/" + version + strings.TrimPrefix(r.URL.Path, "/api")
+
+		header := w.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", sunset)
+		header.Add("Link", "<"+path+`>; rel="successor-version"`)
+
+		// http.StripPrefix と同様に、元のリクエストを変更せずにパスだけを差し替える
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		s.Router.ServeHTTP(w, r2)
+	})
+}
+
+// isLegacyAPIPath はバージョンのない /api/... のパスかを返します
+func isLegacyAPIPath(path string) bool {
+	if path != "/api" && !strings.HasPrefix(path, "/api/") {
+		return false
+	}
+	return !middleware.IsVersionedAPIPath(path)
+}
+
+// negotiateAPIVersion はAPI-Versionヘッダーから使用するバージョンを決めます
+// 対応していないバージョンの場合は既定のバージョンで処理し、middleware.APIVersion でエラーにします
+func negotiateAPIVersion(requested string) string {
+	requested = middleware.NormalizeAPIVersion(requested)
+	for _, v := range apiVersions {
+		if v.name == requested {
+			return v.name
+		}
+	}
+	return legacyAPIVersion
+}
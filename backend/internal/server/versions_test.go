@@ -0,0 +1,94 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+func TestVersionedRoutesHaveNoDeprecationHeaders(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("API-Version"); got != "v1" {
+		t.Errorf("API-Version: got %q, want v1", got)
+	}
+	for _, name := range []string{"Deprecation", "Sunset", "Link"} {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("%s: got %q, want empty", name, got)
+		}
+	}
+}
+
+func TestLegacyRoutesAliasV1WithDeprecationHeaders(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health?x=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+
+	header := rec.Header()
+	if got := header.Get("API-Version"); got != "v1" {
+		t.Errorf("API-Version: got %q, want v1", got)
+	}
+	if got := header.Get("Deprecation"); len(got) < 2 || got[0] != '@' {
+		t.Errorf("Deprecation: got %q, want @<unix time>", got)
+	}
+	if got, want := header.Get("Sunset"), "Thu, 01 Apr 2027 00:00:00 GMT"; got != want {
+		t.Errorf("Sunset: got %q, want %q", got, want)
+	}
+	if got, want := header.Get("Link"), `</api/v1/health>; rel="successor-version"`; got != want {
+		t.Errorf("Link: got %q, want %q", got, want)
+	}
+}
+
+func TestAPIVersionHeaderNegotiation(t *testing.T) {
+	srv := newServerWithoutDB(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		version string
+		code    int
+	}{
+		{"legacy path with v1", "/api/health", "v1", http.StatusOK},
+		{"legacy path with bare number", "/api/health", "1", http.StatusOK},
+		{"versioned path with matching header", "/api/v1/health", "V1", http.StatusOK},
+		{"legacy path with unsupported version", "/api/health", "v9", http.StatusBadRequest},
+		{"versioned path with different version", "/api/v1/health", "v2", http.StatusBadRequest},
+		{"unknown version in path", "/api/v9/health", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.version != "" {
+				req.Header.Set("API-Version", tt.version)
+			}
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Fatalf("status: got %d, want %d\n%s", rec.Code, tt.code, rec.Body)
+			}
+			if tt.code != http.StatusBadRequest {
+				return
+			}
+
+			var apiErr utils.APIError
+			if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+				t.Fatal(err)
+			}
+			if apiErr.Code != "UNSUPPORTED_API_VERSION" {
+				t.Errorf("code: got %q, want UNSUPPORTED_API_VERSION", apiErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// 監査ログの一覧で1回に返す件数
+const (
+	defaultAuditEventLimit = 50
+	maxAuditEventLimit     = 200
+)
+
+// AuditEventPage は監査ログの一覧の1ページ
+type AuditEventPage struct {
+	Events []models.AuditEvent `json:"events"`
+	// NextBeforeID は次のページを取得する before_id（最後のページの場合は0）
+	NextBeforeID uint `json:"next_before_id,omitempty"`
+}
+
+// AuditService は監査ログの参照と保持期間の管理
+type AuditService struct {
+	store repository.Store
+	// retention は監査ログの保持期間（0の場合は削除しない）
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewAuditService は新しいAuditServiceインスタンスを作成します
+func NewAuditService(store repository.Store, retention time.Duration) *AuditService {
+	return &AuditService{store: store, retention: retention, now: time.Now}
+}
+
+// List は管理者に監査ログを新しい順に返します
+func (s *AuditService) List(ctx context.Context, actorID uint, filter repository.AuditEventFilter) (*AuditEventPage, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "監査ログは管理者のみ参照できます"); err != nil {
+		return nil, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, utils.ErrInvalidInput.WithDetail("from は to より前の日時を指定してください")
+	}
+
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = defaultAuditEventLimit
+	case filter.Limit > maxAuditEventLimit:
+		filter.Limit = maxAuditEventLimit
+	}
+	// 次のページの有無を判定するため1件多く取得する
+	limit := filter.Limit
+	filter.Limit++
+	events, err := s.store.AuditEvents().List(ctx, filter)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("監査ログの取得に失敗しました")
+	}
+
+	page := &AuditEventPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		page.NextBeforeID = page.Events[limit-1].ID
+	}
+	return page, nil
+}
+
+// PruneExpired は保持期間を過ぎた監査ログを削除し、件数を返します
+func (s *AuditService) PruneExpired(ctx context.Context) (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.store.AuditEvents().DeleteBefore(ctx, s.now().Add(-s.retention))
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/account"
+	"github.com/stripe/stripe-go/v72/accountlink"
+	"github.com/stripe/stripe-go/v72/payout"
+	"github.com/stripe/stripe-go/v72/transfer"
+)
+
+// ConnectAccount は主催者の Stripe Connect アカウントの状態
+type ConnectAccount struct {
+	ID               string
+	DetailsSubmitted bool
+	PayoutsEnabled   bool
+}
+
+// PayoutRequest は主催者への送金の内容
+type PayoutRequest struct {
+	SettlementID uint
+	AccountID    string
+	Amount       int64
+	// IdempotencyKey は同じ送金の重複を防ぐキー
+	IdempotencyKey string
+}
+
+// ConnectGateway は主催者の送金先（Stripe Connect Express）とのやり取りを抽象化したインターフェース
+type ConnectGateway interface {
+	// CreateAccount は主催者のExpressアカウントを作成します
+	CreateAccount(ctx context.Context, userID uint, email string) (*ConnectAccount, error)
+	GetAccount(ctx context.Context, accountID string) (*ConnectAccount, error)
+	// CreateOnboardingLink は本人確認・口座登録の画面のURLを返します
+	CreateOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (string, error)
+	// Transfer はプラットフォームの残高からConnectアカウントへ送金し、送金IDを返します
+	Transfer(ctx context.Context, req PayoutRequest) (string, error)
+	// Payout はConnectアカウントの残高から主催者の銀行口座への入金を作成し、入金IDを返します
+	Payout(ctx context.Context, req PayoutRequest) (string, error)
+}
+
+// StripeConnectGateway は Stripe Connect を使ったConnectGatewayの実装
+// Connectアカウントの入金は手動（精算ごとに Payout を作成）に設定し、入金の状態を精算と対応付けます
+type StripeConnectGateway struct{}
+
+// NewStripeConnectGateway は新しいStripeConnectGatewayインスタンスを作成します（APIキーはutils.InitStripeで設定）
+func NewStripeConnectGateway() *StripeConnectGateway {
+	return &StripeConnectGateway{}
+}
+
+func (g *StripeConnectGateway) CreateAccount(ctx context.Context, userID uint, email string) (*ConnectAccount, error) {
+	params := &stripe.AccountParams{
+		Type:    stripe.String(string(stripe.AccountTypeExpress)),
+		Country: stripe.String("JP"),
+		Email:   stripe.String(email),
+		Capabilities: &stripe.AccountCapabilitiesParams{
+			Transfers: &stripe.AccountCapabilitiesTransfersParams{Requested: stripe.Bool(true)},
+		},
+		Settings: &stripe.AccountSettingsParams{
+			Payouts: &stripe.AccountSettingsPayoutsParams{
+				Schedule: &stripe.PayoutScheduleParams{Interval: stripe.String(string(stripe.PayoutIntervalManual))},
+			},
+		},
+	}
+	params.AddMetadata("user_id", strconv.FormatUint(uint64(userID), 10))
+	// 同時に登録を開始しても1人に1つのアカウントになるようにする
+	params.SetIdempotencyKey("payout-account-" + strconv.FormatUint(uint64(userID), 10))
+	params.Context = ctx
+
+	a, err := account.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return toConnectAccount(a), nil
+}
+
+func (g *StripeConnectGateway) GetAccount(ctx context.Context, accountID string) (*ConnectAccount, error) {
+	params := &stripe.AccountParams{}
+	params.Context = ctx
+	a, err := account.GetByID(accountID, params)
+	if err != nil {
+		return nil, err
+	}
+	return toConnectAccount(a), nil
+}
+
+func (g *StripeConnectGateway) CreateOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (string, error) {
+	params := &stripe.AccountLinkParams{
+		Account:    stripe.String(accountID),
+		RefreshURL: stripe.String(refreshURL),
+		ReturnURL:  stripe.String(returnURL),
+		Type:       stripe.String(string(stripe.AccountLinkTypeAccountOnboarding)),
+	}
+	params.Context = ctx
+	link, err := accountlink.New(params)
+	if err != nil {
+		return "", err
+	}
+	return link.URL, nil
+}
+
+func (g *StripeConnectGateway) Transfer(ctx context.Context, req PayoutRequest) (string, error) {
+	params := &stripe.TransferParams{
+		Amount:      stripe.Int64(req.Amount),
+		Currency:    stripe.String(string(stripe.CurrencyJPY)),
+		Destination: stripe.String(req.AccountID),
+	}
+	params.AddMetadata("settlement_id", strconv.FormatUint(uint64(req.SettlementID), 10))
+	params.SetIdempotencyKey(req.IdempotencyKey)
+	params.Context = ctx
+	t, err := transfer.New(params)
+	if err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
+
+func (g *StripeConnectGateway) Payout(ctx context.Context, req PayoutRequest) (string, error) {
+	params := &stripe.PayoutParams{
+		Amount:   stripe.Int64(req.Amount),
+		Currency: stripe.String(string(stripe.CurrencyJPY)),
+	}
+	params.AddMetadata("settlement_id", strconv.FormatUint(uint64(req.SettlementID), 10))
+	params.SetStripeAccount(req.AccountID)
+	params.SetIdempotencyKey(req.IdempotencyKey)
+	params.Context = ctx
+	p, err := payout.New(params)
+	if err != nil {
+		return "", err
+	}
+	return p.ID, nil
+}
+
+func toConnectAccount(a *stripe.Account) *ConnectAccount {
+	return &ConnectAccount{ID: a.ID, DetailsSubmitted: a.DetailsSubmitted, PayoutsEnabled: a.PayoutsEnabled}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+)
+
+// FeeRule はプラットフォーム手数料のルール（支援額の料率 + 支援1件あたりの固定額）
+type FeeRule struct {
+	// ProjectType は対象の企画の種別（空の場合はその他すべての種別）
+	ProjectType models.ProjectType
+	// RateBasisPoints は料率（ベーシスポイント。1000 = 10%）
+	RateBasisPoints int
+	// FixedPerSupport は支援1件あたりの固定額（円。全額返金された支援には掛けない）
+	FixedPerSupport int64
+}
+
+// FeeRules は企画の種別ごとの手数料のルール
+type FeeRules []FeeRule
+
+// DefaultFeeRules はPAYOUT_FEE_RULES未設定時のルール
+var DefaultFeeRules = FeeRules{
+	{RateBasisPoints: 1000},
+}
+
+// For は企画の種別に適用するルールを返します（種別のルールがなければ既定のルール）
+func (r FeeRules) For(projectType models.ProjectType) FeeRule {
+	var fallback FeeRule
+	for _, rule := range r {
+		switch rule.ProjectType {
+		case projectType:
+			return rule
+		case "":
+			fallback = rule
+		}
+	}
+	return fallback
+}
+
+// LoadFeeRules は設定値 PAYOUT_FEE_RULES から手数料のルールを読み込みます（空の場合は DefaultFeeRules）
+func LoadFeeRules(s string) (FeeRules, error) {
+	if s == "" {
+		return DefaultFeeRules, nil
+	}
+	rules, err := ParseFeeRules(s)
+	if err != nil {
+		return nil, fmt.Errorf("手数料のルールの読み込みに失敗しました: %w", err)
+	}
+	return rules, nil
+}
+
+// ParseFeeRules は「種別:料率:固定額」をセミコロン区切りで並べた文字列を解析します
+// 種別に * を指定したルールはその他すべての種別に適用します（必須）
+// 例: "digital_signage:10%:0;station_ad:12.5%:30;*:10%:0"
+func ParseFeeRules(s string) (FeeRules, error) {
+	validTypes := make(map[models.ProjectType]bool, len(models.ProjectTypes))
+	for _, t := range models.ProjectTypes {
+		validTypes[t] = true
+	}
+
+	var rules FeeRules
+	seen := make(map[models.ProjectType]bool)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("手数料のルールの形式が不正です: %q", entry)
+		}
+
+		var rule FeeRule
+		if parts[0] != "*" {
+			rule.ProjectType = models.ProjectType(parts[0])
+			if !validTypes[rule.ProjectType] {
+				return nil, fmt.Errorf("手数料のルールの種別が不正です: %q", entry)
+			}
+		}
+		if seen[rule.ProjectType] {
+			return nil, fmt.Errorf("手数料のルールの種別が重複しています: %q", entry)
+		}
+		seen[rule.ProjectType] = true
+
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(parts[1], "%"), 64)
+		if err != nil || !strings.HasSuffix(parts[1], "%") || rate < 0 || rate > 100 {
+			return nil, fmt.Errorf("手数料のルールの料率が不正です（0%%〜100%%）: %q", entry)
+		}
+		rule.RateBasisPoints = int(math.Round(rate * 100))
+
+		if rule.FixedPerSupport, err = strconv.ParseInt(parts[2], 10, 64); err != nil || rule.FixedPerSupport < 0 {
+			return nil, fmt.Errorf("手数料のルールの固定額が不正です: %q", entry)
+		}
+
+		rules = append(rules, rule)
+	}
+	if !seen[""] {
+		return nil, fmt.Errorf("手数料のルールに既定のルール（種別 *）がありません")
+	}
+	return rules, nil
+}
+
+// SettlementAmounts は精算の金額の内訳
+type SettlementAmounts struct {
+	Gross    int64
+	Refunded int64
+	Fee      int64
+	Net      int64
+	// Supports は手数料の固定額を掛けた支援（全額返金されていない支援）の件数
+	Supports int
+}
+
+// Calculate は決済済みの支援から精算の金額を計算します
+// 手数料は返金を差し引いた額に料率を掛けた額（1円未満切り捨て）と固定額の合計で、返金後の額を上限とします
+func (r FeeRule) Calculate(supports []models.Support) SettlementAmounts {
+	var a SettlementAmounts
+	for _, s := range supports {
+		refunded := min(s.RefundedAmount, s.Amount)
+		a.Gross += s.Amount
+		a.Refunded += refunded
+		if refunded < s.Amount {
+			a.Supports++
+		}
+	}
+
+	collected := a.Gross - a.Refunded
+	a.Fee = collected*int64(r.RateBasisPoints)/10000 + r.FixedPerSupport*int64(a.Supports)
+	if a.Fee > collected {
+		a.Fee = collected
+	}
+	a.Net = collected - a.Fee
+	return a
+}
+
+// Describe はルールを明細に表示する形式で返します（例: 10.00% + 30円/件）
+func (r FeeRule) Describe() string {
+	return fmt.Sprintf("%d.%02d%% + %d円/件", r.RateBasisPoints/100, r.RateBasisPoints%100, r.FixedPerSupport)
+}
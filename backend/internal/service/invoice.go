@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// InvoiceInput はビジョンの運営会社の請求書の内容
+type InvoiceInput struct {
+	ProjectID   uint
+	Number      string
+	Amount      int64
+	Description string
+}
+
+// ExpenseInput は主催者の経費の立替の申請の内容
+type ExpenseInput struct {
+	Amount      int64
+	Description string
+}
+
+// Review は請求書・経費の審査の結果
+type Review struct {
+	Approve bool
+	// Reason は差し戻し・却下の理由（必須）
+	Reason string
+}
+
+// InvoiceService はビジョンの運営会社への直接支払い（請求書と経費の立替の提出・審査）のユースケース
+//
+// 精算方法が operator のプロジェクトでは、完了後に承認された請求書の額を運営会社へ、
+// 承認された経費の額を主催者へ PayoutService が精算として支払います
+type InvoiceService struct {
+	store repository.Store
+	now   func() time.Time
+}
+
+// NewInvoiceService は新しいInvoiceServiceインスタンスを作成します
+func NewInvoiceService(store repository.Store) *InvoiceService {
+	return &InvoiceService{store: store, now: time.Now}
+}
+
+var (
+	errInvoiceNotFound = utils.ErrNotFound.WithDetail("請求書が見つかりません")
+	errExpenseNotFound = utils.ErrNotFound.WithDetail("経費が見つかりません")
+)
+
+// SetVisionOperator はビジョンの運営会社を設定します（管理コマンドによる操作）
+func (s *InvoiceService) SetVisionOperator(ctx context.Context, visionID uint, email string) (*models.Vision, error) {
+	vision, err := s.store.Visions().Get(ctx, visionID)
+	if err != nil {
+		return nil, mapError(err, utils.ErrNotFound.WithDetail("ビジョンが見つかりません"), utils.ErrInternalServer)
+	}
+	operator, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return nil, mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	if operator.Role != models.UserRoleOperator {
+		return nil, utils.ErrInvalidInput.WithDetail("運営会社に設定できるのは operator ロールのユーザーのみです")
+	}
+	if err := s.store.Visions().SetOperator(ctx, vision, operator.ID); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("ビジョンの更新に失敗しました")
+	}
+	vision.OperatorID = &operator.ID
+	return vision, nil
+}
+
+// SubmitInvoice は運営会社の請求書を提出します
+// 差し戻された（または審査前の）請求書は内容を置き換えて再提出します
+func (s *InvoiceService) SubmitInvoice(ctx context.Context, operatorID uint, input InvoiceInput) (*models.Invoice, error) {
+	operator, err := s.store.Users().Get(ctx, operatorID)
+	if err != nil {
+		return nil, mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	if operator.Role != models.UserRoleOperator {
+		return nil, utils.ErrForbidden.WithDetail("請求書を提出できるのはビジョンの運営会社のみです")
+	}
+
+	project, err := s.store.Projects().Get(ctx, input.ProjectID)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	if project.VisionID == nil {
+		return nil, utils.ErrForbidden.WithDetail("このプロジェクトのビジョンの運営会社ではありません")
+	}
+	vision, err := s.store.Visions().Get(ctx, *project.VisionID)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の登録に失敗しました")
+	}
+	if vision.OperatorID == nil || *vision.OperatorID != operatorID {
+		return nil, utils.ErrForbidden.WithDetail("このプロジェクトのビジョンの運営会社ではありません")
+	}
+	if err := checkDirectPayment(project); err != nil {
+		return nil, err
+	}
+
+	ctx = audit.WithActorID(ctx, operatorID)
+	invoice, err := s.store.Invoices().GetByProject(ctx, project.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		invoice = &models.Invoice{
+			ProjectID:   project.ID,
+			VisionID:    vision.ID,
+			OperatorID:  operatorID,
+			Number:      input.Number,
+			Amount:      input.Amount,
+			Description: input.Description,
+		}
+		if err := s.store.Invoices().Create(ctx, invoice); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				return nil, utils.ErrConflict.WithDetail("このプロジェクトの請求書は提出済みです")
+			}
+			return nil, utils.ErrInternalServer.WithDetail("請求書の登録に失敗しました")
+		}
+		return invoice, nil
+	}
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の登録に失敗しました")
+	}
+
+	if invoice.Status != models.ReviewStatusSubmitted && invoice.Status != models.ReviewStatusRejected {
+		return nil, utils.ErrConflict.WithDetail("承認済みの請求書は変更できません")
+	}
+	if err := s.store.Invoices().Update(ctx, invoice, map[string]interface{}{
+		"vision_id":        vision.ID,
+		"number":           input.Number,
+		"amount":           input.Amount,
+		"description":      input.Description,
+		"status":           models.ReviewStatusSubmitted,
+		"rejection_reason": "",
+		"reviewed_by":      nil,
+		"reviewed_at":      nil,
+	}); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の登録に失敗しました")
+	}
+	return s.store.Invoices().Get(ctx, invoice.ID)
+}
+
+// ListForOperator は運営会社の請求書を新しい順に返します
+func (s *InvoiceService) ListForOperator(ctx context.Context, operatorID uint) ([]models.Invoice, error) {
+	invoices, err := s.store.Invoices().List(ctx, repository.InvoiceFilter{OperatorID: operatorID})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の取得に失敗しました")
+	}
+	return invoices, nil
+}
+
+// ListInvoices は管理者に請求書を新しい順に返します（審査用）
+func (s *InvoiceService) ListInvoices(ctx context.Context, actorID uint, status models.ReviewStatus) ([]models.Invoice, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "請求書の審査は管理者のみ行えます"); err != nil {
+		return nil, err
+	}
+	invoices, err := s.store.Invoices().List(ctx, repository.InvoiceFilter{Status: status})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の取得に失敗しました")
+	}
+	return invoices, nil
+}
+
+// ReviewInvoice は提出された請求書を承認または差し戻します（管理者による操作）
+func (s *InvoiceService) ReviewInvoice(ctx context.Context, actorID, id uint, review Review) (*models.Invoice, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "請求書の審査は管理者のみ行えます"); err != nil {
+		return nil, err
+	}
+	if !review.Approve && review.Reason == "" {
+		return nil, utils.ErrInvalidInput.WithDetail("差し戻し・却下の理由を入力してください")
+	}
+	invoice, err := s.store.Invoices().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errInvoiceNotFound, utils.ErrInternalServer)
+	}
+	if invoice.Status != models.ReviewStatusSubmitted {
+		return nil, utils.ErrConflict.WithDetail("審査できるのは審査待ちの請求書・経費のみです")
+	}
+
+	if err := s.store.Invoices().Update(audit.WithActorID(ctx, actorID), invoice, s.reviewUpdates(actorID, review)); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("請求書の更新に失敗しました")
+	}
+	return s.store.Invoices().Get(ctx, id)
+}
+
+// SubmitExpense は主催者が立て替えた経費の精算を申請します（精算の作成前のみ）
+func (s *InvoiceService) SubmitExpense(ctx context.Context, userID, projectID uint, input ExpenseInput) (*models.Expense, error) {
+	project, err := s.store.Projects().Get(ctx, projectID)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	if project.UserID != userID {
+		return nil, utils.ErrForbidden.WithDetail(utils.ErrMsgUnauthorizedAccess)
+	}
+	if err := checkDirectPayment(project); err != nil {
+		return nil, err
+	}
+	if err := s.checkUnsettled(ctx, projectID); err != nil {
+		return nil, err
+	}
+
+	expense := &models.Expense{
+		ProjectID:   projectID,
+		UserID:      userID,
+		Amount:      input.Amount,
+		Description: input.Description,
+	}
+	if err := s.store.Expenses().Create(ctx, expense); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("経費の登録に失敗しました")
+	}
+	return expense, nil
+}
+
+// ListProjectExpenses は主催者本人または管理者にプロジェクトの経費を申請の古い順に返します
+func (s *InvoiceService) ListProjectExpenses(ctx context.Context, actorID, projectID uint) ([]models.Expense, error) {
+	project, err := s.store.Projects().Get(ctx, projectID)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	if project.UserID != actorID {
+		if err := requireAdmin(ctx, s.store, actorID, utils.ErrMsgUnauthorizedAccess); err != nil {
+			return nil, err
+		}
+	}
+	return s.listExpenses(ctx, repository.ExpenseFilter{ProjectID: projectID})
+}
+
+// ListExpenses は管理者に経費を申請の古い順に返します（審査用）
+func (s *InvoiceService) ListExpenses(ctx context.Context, actorID uint, status models.ReviewStatus) ([]models.Expense, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "経費の審査は管理者のみ行えます"); err != nil {
+		return nil, err
+	}
+	return s.listExpenses(ctx, repository.ExpenseFilter{Status: status})
+}
+
+func (s *InvoiceService) listExpenses(ctx context.Context, filter repository.ExpenseFilter) ([]models.Expense, error) {
+	expenses, err := s.store.Expenses().List(ctx, filter)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("経費の取得に失敗しました")
+	}
+	return expenses, nil
+}
+
+// ReviewExpense は申請された経費を承認または却下します（管理者による操作）
+func (s *InvoiceService) ReviewExpense(ctx context.Context, actorID, id uint, review Review) (*models.Expense, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "経費の審査は管理者のみ行えます"); err != nil {
+		return nil, err
+	}
+	if !review.Approve && review.Reason == "" {
+		return nil, utils.ErrInvalidInput.WithDetail("差し戻し・却下の理由を入力してください")
+	}
+	expense, err := s.store.Expenses().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errExpenseNotFound, utils.ErrInternalServer)
+	}
+	if expense.Status != models.ReviewStatusSubmitted {
+		return nil, utils.ErrConflict.WithDetail("審査できるのは審査待ちの請求書・経費のみです")
+	}
+	if err := s.checkUnsettled(ctx, expense.ProjectID); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Expenses().Update(audit.WithActorID(ctx, actorID), expense, s.reviewUpdates(actorID, review)); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("経費の更新に失敗しました")
+	}
+	return s.store.Expenses().Get(ctx, id)
+}
+
+func (s *InvoiceService) reviewUpdates(actorID uint, review Review) map[string]interface{} {
+	updates := map[string]interface{}{
+		"status":           models.ReviewStatusApproved,
+		"rejection_reason": "",
+		"reviewed_by":      actorID,
+		"reviewed_at":      s.now(),
+	}
+	if !review.Approve {
+		updates["status"], updates["rejection_reason"] = models.ReviewStatusRejected, review.Reason
+	}
+	return updates
+}
+
+// checkUnsettled は精算の作成後であればエラーを返します（精算の額が確定しているため）
+func (s *InvoiceService) checkUnsettled(ctx context.Context, projectID uint) error {
+	settlements, err := s.store.Settlements().List(ctx, repository.SettlementFilter{ProjectID: projectID})
+	if err != nil {
+		return utils.ErrInternalServer.WithDetail("精算の取得に失敗しました")
+	}
+	if len(settlements) > 0 {
+		return utils.ErrConflict.WithDetail("精算済みのプロジェクトの経費は変更できません")
+	}
+	return nil
+}
+
+// checkDirectPayment は運営会社へ直接支払うプロジェクトでなければエラーを返します
+func checkDirectPayment(project *models.Project) error {
+	if project.SettlementMode != models.SettlementModeOperator {
+		return utils.ErrConflict.WithDetail("運営会社への直接支払いのプロジェクトではありません")
+	}
+	if project.Status == models.ProjectStatusCancelled {
+		return utils.ErrConflict.WithDetail("中止されたプロジェクトです")
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// LedgerService は複式簿記の帳簿（試算表・プロジェクト別の残高）の参照のユースケース
+//
+// 仕訳は支援の完了・返金・チャージバック・精算・送金の各ユースケースが、状態の更新と同じトランザクションで記録します
+//
+//	支援の完了       platform_cash     / project_escrow
+//	決済手数料       stripe_fees       / platform_cash
+//	返金             project_escrow    / platform_cash
+//	チャージバック   project_escrow    / platform_cash（勝訴して戻った場合は逆仕訳）
+//	精算             project_escrow    / organizer_payable・operator_payable・platform_revenue
+//	送金             organizer_payable・operator_payable / platform_cash
+type LedgerService struct {
+	store repository.Store
+}
+
+// NewLedgerService は新しいLedgerServiceインスタンスを作成します
+func NewLedgerService(store repository.Store) *LedgerService {
+	return &LedgerService{store: store}
+}
+
+// TrialBalance は試算表
+type TrialBalance struct {
+	Accounts    []models.AccountBalance `json:"accounts"`
+	TotalDebit  int64                   `json:"total_debit"`
+	TotalCredit int64                   `json:"total_credit"`
+	// Balanced は借方と貸方の合計が一致しているか
+	Balanced bool `json:"balanced"`
+}
+
+// ProjectLedger はプロジェクト別の残高と仕訳
+type ProjectLedger struct {
+	ProjectID uint                    `json:"project_id"`
+	Accounts  []models.AccountBalance `json:"accounts"`
+	// Held はプラットフォームが保管している支援金（預り金の残高）
+	Held    int64                 `json:"held"`
+	Entries []models.JournalEntry `json:"entries"`
+}
+
+var errLedgerFetchFail = utils.ErrInternalServer.WithDetail("帳簿の取得に失敗しました")
+
+// TrialBalance は管理者に試算表を返します
+func (s *LedgerService) TrialBalance(ctx context.Context, actorID uint) (*TrialBalance, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "帳簿は管理者のみ参照できます"); err != nil {
+		return nil, err
+	}
+	balances, err := s.store.Ledger().Balances(ctx, 0)
+	if err != nil {
+		return nil, errLedgerFetchFail
+	}
+
+	tb := &TrialBalance{Accounts: balances}
+	for _, b := range balances {
+		tb.TotalDebit += b.Debit
+		tb.TotalCredit += b.Credit
+	}
+	tb.Balanced = tb.TotalDebit == tb.TotalCredit
+	return tb, nil
+}
+
+// ProjectLedger は管理者にプロジェクト別の残高と仕訳を返します
+func (s *LedgerService) ProjectLedger(ctx context.Context, actorID, projectID uint) (*ProjectLedger, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "帳簿は管理者のみ参照できます"); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.Projects().Get(ctx, projectID); err != nil {
+		return nil, mapError(err, errProjectNotFound, errLedgerFetchFail)
+	}
+	balances, err := s.store.Ledger().Balances(ctx, projectID)
+	if err != nil {
+		return nil, errLedgerFetchFail
+	}
+	entries, err := s.store.Ledger().ListEntries(ctx, projectID)
+	if err != nil {
+		return nil, errLedgerFetchFail
+	}
+
+	ledger := &ProjectLedger{ProjectID: projectID, Accounts: balances, Entries: entries}
+	for _, b := range balances {
+		if b.Account == models.LedgerAccountProjectEscrow {
+			ledger.Held = b.Balance
+		}
+	}
+	return ledger, nil
+}
+
+// journal は借方 debit・貸方 credit の2行の仕訳を組み立てます
+// amount が負の場合は貸借を入れ替え、0の場合は nil を返します（post は nil を無視します）
+func journal(kind models.JournalKind, reference string, projectID uint, debit, credit models.LedgerAccount, amount int64) *models.JournalEntry {
+	if amount == 0 {
+		return nil
+	}
+	if amount < 0 {
+		debit, credit, amount = credit, debit, -amount
+	}
+	return &models.JournalEntry{
+		Reference: reference,
+		Kind:      kind,
+		ProjectID: &projectID,
+		Lines: []models.JournalLine{
+			{Account: debit, Debit: amount},
+			{Account: credit, Credit: amount},
+		},
+	}
+}
+
+// post は仕訳を記録します。貸借が一致しない仕訳はエラーにします
+// 同じ Reference の仕訳が記録済みの場合は何もしません（Webhookの再送や複数のレプリカによる重複に備える）
+func post(ctx context.Context, tx repository.Store, entries ...*models.JournalEntry) error {
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		var debit, credit int64
+		for _, line := range entry.Lines {
+			if line.Debit < 0 || line.Credit < 0 || (line.Debit == 0) == (line.Credit == 0) {
+				return fmt.Errorf("ledger: invalid line in %s: %+v", entry.Reference, line)
+			}
+			debit += line.Debit
+			credit += line.Credit
+		}
+		if len(entry.Lines) == 0 || debit != credit {
+			return fmt.Errorf("ledger: unbalanced entry %s (debit %d, credit %d)", entry.Reference, debit, credit)
+		}
+		if _, err := tx.Ledger().Post(ctx, entry); err != nil {
+			return fmt.Errorf("ledger: %s: %w", entry.Reference, err)
+		}
+	}
+	return nil
+}
+
+// payableAccount は精算の受取人の未払金の勘定科目を返します
+func payableAccount(recipient models.SettlementRecipient) models.LedgerAccount {
+	if recipient == models.SettlementRecipientOperator {
+		return models.LedgerAccountOperatorPayable
+	}
+	return models.LedgerAccountOrganizerPayable
+}
+
+// recognizeSettlement は精算の送金額を受取人への未払金に、手数料をプラットフォームの収入に預り金から振り替えます
+// 計上済みの額との差額を仕訳するため、返金を反映して精算を計算し直した後に呼ぶと計上額を修正します
+// （未払金はプロジェクトの受取人ごと、手数料はプロジェクトごとに1つの精算にのみ対応します）
+func recognizeSettlement(ctx context.Context, tx repository.Store, settlement *models.Settlement) error {
+	payable := payableAccount(settlement.Recipient)
+	payableBalance, err := tx.Ledger().Balance(ctx, payable, settlement.ProjectID)
+	if err != nil {
+		return err
+	}
+	revenueBalance, err := tx.Ledger().Balance(ctx, models.LedgerAccountPlatformRevenue, settlement.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// 負債・収益の残高は貸方のため、計上済みの額は残高の符号を反転した額
+	net := journal(models.JournalKindSettlement,
+		fmt.Sprintf("settlement:%d:net:%d", settlement.ID, settlement.NetAmount), settlement.ProjectID,
+		models.LedgerAccountProjectEscrow, payable, settlement.NetAmount+payableBalance)
+	fee := journal(models.JournalKindSettlement,
+		fmt.Sprintf("project:%d:fee:%d", settlement.ProjectID, settlement.FeeAmount), settlement.ProjectID,
+		models.LedgerAccountProjectEscrow, models.LedgerAccountPlatformRevenue, settlement.FeeAmount+revenueBalance)
+	for _, entry := range []*models.JournalEntry{net, fee} {
+		if entry != nil {
+			entry.SettlementID = &settlement.ID
+		}
+	}
+	return post(ctx, tx, net, fee)
+}
+
+// transferEntry は受取人のConnectアカウントへの送金の仕訳を組み立てます
+func transferEntry(settlement *models.Settlement) *models.JournalEntry {
+	entry := journal(models.JournalKindTransfer, fmt.Sprintf("settlement:%d:transfer", settlement.ID), settlement.ProjectID,
+		payableAccount(settlement.Recipient), models.LedgerAccountPlatformCash, settlement.NetAmount)
+	if entry != nil {
+		entry.SettlementID = &settlement.ID
+	}
+	return entry
+}
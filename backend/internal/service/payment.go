@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/balancetransaction"
+	"github.com/stripe/stripe-go/v72/checkout/session"
+	"github.com/stripe/stripe-go/v72/paymentintent"
+)
+
+// CheckoutRequest は決済セッションの作成内容
+type CheckoutRequest struct {
+	ProjectID    uint
+	ProjectTitle string
+	SupportID    uint
+	UserID       uint
+	Amount       int64
+	SuccessURL   string
+	CancelURL    string
+}
+
+// CheckoutSession は作成された決済セッション
+type CheckoutSession struct {
+	ID  string
+	URL string
+}
+
+// PaymentGateway は決済サービスとのやり取りを抽象化したインターフェース
+type PaymentGateway interface {
+	CreateCheckoutSession(ctx context.Context, req CheckoutRequest) (*CheckoutSession, error)
+	// SupportIDsByPaymentIntent は決済に紐づく決済セッションのメタデータから支援IDを返します
+	SupportIDsByPaymentIntent(ctx context.Context, paymentIntentID string) ([]uint, error)
+	// ProcessingFee は決済にかかったStripeの決済手数料を返します
+	ProcessingFee(ctx context.Context, paymentIntentID string) (int64, error)
+	// ListPayments は期間内に作成された決済を返します（照合に使用します）
+	ListPayments(ctx context.Context, from, to time.Time) ([]Payment, error)
+	// GetPayment は決済を返します
+	GetPayment(ctx context.Context, paymentIntentID string) (*Payment, error)
+	// ListBalanceTransactions は期間内に作成された決済・返金の残高取引を返します
+	ListBalanceTransactions(ctx context.Context, from, to time.Time) ([]BalanceTransaction, error)
+}
+
+// PaymentStatus はStripeの決済の状態
+type PaymentStatus string
+
+const (
+	PaymentStatusSucceeded PaymentStatus = "succeeded"
+	PaymentStatusFailed    PaymentStatus = "failed"
+	PaymentStatusCanceled  PaymentStatus = "canceled"
+	// PaymentStatusProcessing は支払い前・処理中の決済
+	PaymentStatusProcessing PaymentStatus = "processing"
+)
+
+// Payment はStripeの決済（PaymentIntent）
+type Payment struct {
+	ID string
+	// SupportIDs は決済セッションのメタデータの支援ID
+	SupportIDs []uint
+	Status     PaymentStatus
+	Amount     int64
+	// Refunded は返金額の累計
+	Refunded int64
+	Created  time.Time
+}
+
+// BalanceTransactionType は照合の対象とする残高取引の種類
+type BalanceTransactionType string
+
+const (
+	BalanceTransactionCharge BalanceTransactionType = "charge"
+	BalanceTransactionRefund BalanceTransactionType = "refund"
+)
+
+// BalanceTransaction はStripeの残高取引（決済・返金）
+type BalanceTransaction struct {
+	ID              string
+	Type            BalanceTransactionType
+	PaymentIntentID string
+	// Amount は残高の増減（返金は負の値）、Fee はStripeの手数料
+	Amount  int64
+	Fee     int64
+	Created time.Time
+}
+
+// StripeGateway はStripe Checkoutを使ったPaymentGatewayの実装
+type StripeGateway struct{}
+
+// NewStripeGateway は新しいStripeGatewayインスタンスを作成します（APIキーはutils.InitStripeで設定）
+func NewStripeGateway() *StripeGateway {
+	return &StripeGateway{}
+}
+
+func (g *StripeGateway) CreateCheckoutSession(ctx context.Context, req CheckoutRequest) (*CheckoutSession, error) {
+	s, err := utils.CreateCheckoutSession(ctx, req.ProjectID, req.ProjectTitle, req.Amount, req.SupportID, req.UserID, req.SuccessURL, req.CancelURL)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckoutSession{ID: s.ID, URL: s.URL}, nil
+}
+
+func (g *StripeGateway) SupportIDsByPaymentIntent(ctx context.Context, paymentIntentID string) ([]uint, error) {
+	params := &stripe.CheckoutSessionListParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+	}
+	params.Context = ctx
+
+	var ids []uint
+	iter := session.List(params)
+	for iter.Next() {
+		s := iter.Current().(*stripe.CheckoutSession)
+		id, err := strconv.ParseUint(s.Metadata["support_id"], 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, iter.Err()
+}
+
+func (g *StripeGateway) ProcessingFee(ctx context.Context, paymentIntentID string) (int64, error) {
+	params := &stripe.PaymentIntentParams{}
+	params.AddExpand("charges.data.balance_transaction")
+	params.Context = ctx
+	pi, err := paymentintent.Get(paymentIntentID, params)
+	if err != nil {
+		return 0, err
+	}
+
+	var fee int64
+	if pi.Charges != nil {
+		for _, charge := range pi.Charges.Data {
+			if charge.BalanceTransaction != nil {
+				fee += charge.BalanceTransaction.Fee
+			}
+		}
+	}
+	return fee, nil
+}
+
+func (g *StripeGateway) ListPayments(ctx context.Context, from, to time.Time) ([]Payment, error) {
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.Context = ctx
+
+	var payments []Payment
+	iter := paymentintent.List(params)
+	for iter.Next() {
+		payment, err := g.payment(ctx, iter.PaymentIntent())
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, iter.Err()
+}
+
+func (g *StripeGateway) GetPayment(ctx context.Context, paymentIntentID string) (*Payment, error) {
+	params := &stripe.PaymentIntentParams{}
+	params.Context = ctx
+	pi, err := paymentintent.Get(paymentIntentID, params)
+	if err != nil {
+		return nil, err
+	}
+	return g.payment(ctx, pi)
+}
+
+// payment はPaymentIntentを Payment に変換し、決済セッションから支援IDを取得します
+func (g *StripeGateway) payment(ctx context.Context, pi *stripe.PaymentIntent) (*Payment, error) {
+	ids, err := g.SupportIDsByPaymentIntent(ctx, pi.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &Payment{ID: pi.ID, SupportIDs: ids, Amount: pi.Amount, Created: time.Unix(pi.Created, 0)}
+	switch {
+	case pi.Status == stripe.PaymentIntentStatusSucceeded:
+		payment.Status = PaymentStatusSucceeded
+		payment.Amount = pi.AmountReceived
+	case pi.Status == stripe.PaymentIntentStatusCanceled:
+		payment.Status = PaymentStatusCanceled
+	case pi.Status == stripe.PaymentIntentStatusRequiresPaymentMethod && pi.LastPaymentError != nil:
+		payment.Status = PaymentStatusFailed
+	default:
+		payment.Status = PaymentStatusProcessing
+	}
+	if pi.Charges != nil {
+		for _, charge := range pi.Charges.Data {
+			payment.Refunded += charge.AmountRefunded
+		}
+	}
+	return payment, nil
+}
+
+func (g *StripeGateway) ListBalanceTransactions(ctx context.Context, from, to time.Time) ([]BalanceTransaction, error) {
+	params := &stripe.BalanceTransactionListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.AddExpand("data.source")
+	params.Context = ctx
+
+	var txns []BalanceTransaction
+	iter := balancetransaction.List(params)
+	for iter.Next() {
+		bt := iter.BalanceTransaction()
+		txn := BalanceTransaction{ID: bt.ID, Amount: bt.Amount, Fee: bt.Fee, Created: time.Unix(bt.Created, 0)}
+		switch {
+		case bt.Source == nil:
+			continue
+		case bt.Source.Charge != nil && bt.Source.Charge.PaymentIntent != nil:
+			txn.Type = BalanceTransactionCharge
+			txn.PaymentIntentID = bt.Source.Charge.PaymentIntent.ID
+		case bt.Source.Refund != nil && bt.Source.Refund.PaymentIntent != nil:
+			txn.Type = BalanceTransactionRefund
+			txn.PaymentIntentID = bt.Source.Refund.PaymentIntent.ID
+		default:
+			// 送金・入金・チャージバックなどは照合の対象外
+			continue
+		}
+		txns = append(txns, txn)
+	}
+	return txns, iter.Err()
+}
@@ -0,0 +1,624 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// OnboardingLinks は送金先の登録画面から戻るフロントエンドのURL
+type OnboardingLinks struct {
+	// RefreshURL は登録画面のリンクの有効期限が切れた場合に戻るURL
+	RefreshURL string
+	// ReturnURL は登録を終えた（または中断した）場合に戻るURL
+	ReturnURL string
+}
+
+// Onboarding は送金先の登録の開始結果
+type Onboarding struct {
+	URL     string                `json:"url"`
+	Account *models.PayoutAccount `json:"account"`
+}
+
+// PayoutService は主催者・運営会社への送金（送金先の登録・精算・入金の状態の追跡）のユースケース
+//
+// 完了したプロジェクトごとに受取人ごとの精算を作成し、送金先の登録を終えた受取人のConnectアカウントへ
+// 送金（Transfer）してから銀行口座への入金（Payout）を作成します。入金の結果はWebhookで反映します
+// 精算方法が operator のプロジェクトでは、承認された請求書を運営会社へ、承認された経費を主催者へ支払います
+type PayoutService struct {
+	store   repository.Store
+	connect ConnectGateway
+	fees    FeeRules
+	now     func() time.Time
+}
+
+// NewPayoutService は新しいPayoutServiceインスタンスを作成します
+func NewPayoutService(store repository.Store, connect ConnectGateway, fees FeeRules) *PayoutService {
+	if len(fees) == 0 {
+		fees = DefaultFeeRules
+	}
+	return &PayoutService{store: store, connect: connect, fees: fees, now: time.Now}
+}
+
+var (
+	errPayoutAccountNotFound = utils.ErrNotFound.WithDetail("送金先が登録されていません")
+	errSettlementNotFound    = utils.ErrNotFound.WithDetail("精算が見つかりません")
+)
+
+// StartOnboarding は主催者の送金先（Connectアカウント）を作成し、登録画面のURLを返します
+// 作成済みの場合は登録画面のURLのみ発行します（登録の再開や口座の変更に使用）
+func (s *PayoutService) StartOnboarding(ctx context.Context, userID uint, links OnboardingLinks) (*Onboarding, error) {
+	account, err := s.store.PayoutAccounts().GetByUser(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		account, err = s.createAccount(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.connect.CreateOnboardingLink(ctx, account.StripeAccountID, links.RefreshURL, links.ReturnURL)
+	if err != nil {
+		logging.FromContext(ctx).Error("送金先の登録画面の発行に失敗しました", "user_id", userID, "error", err)
+		return nil, utils.ErrInternalServer.WithDetail("送金先の登録画面の発行に失敗しました")
+	}
+	return &Onboarding{URL: url, Account: account}, nil
+}
+
+func (s *PayoutService) createAccount(ctx context.Context, userID uint) (*models.PayoutAccount, error) {
+	user, err := s.store.Users().Get(ctx, userID)
+	if err != nil {
+		return nil, mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	remote, err := s.connect.CreateAccount(ctx, userID, user.Email)
+	if err != nil {
+		logging.FromContext(ctx).Error("送金先のアカウントの作成に失敗しました", "user_id", userID, "error", err)
+		return nil, utils.ErrInternalServer.WithDetail("送金先の登録に失敗しました")
+	}
+
+	account := &models.PayoutAccount{
+		UserID:           userID,
+		StripeAccountID:  remote.ID,
+		DetailsSubmitted: remote.DetailsSubmitted,
+		PayoutsEnabled:   remote.PayoutsEnabled,
+	}
+	err = s.store.PayoutAccounts().Create(ctx, account)
+	if errors.Is(err, repository.ErrDuplicate) {
+		// 同時に登録を開始した場合（Stripe側は冪等キーで同じアカウントが返る）
+		account, err = s.store.PayoutAccounts().GetByUser(ctx, userID)
+	}
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("送金先の登録に失敗しました")
+	}
+	return account, nil
+}
+
+// Account は主催者の送金先を返します
+// 登録が終わっていない場合はStripeから最新の状態を取得します（Webhookの遅延に備える）
+func (s *PayoutService) Account(ctx context.Context, userID uint) (*models.PayoutAccount, error) {
+	account, err := s.store.PayoutAccounts().GetByUser(ctx, userID)
+	if err != nil {
+		return nil, mapError(err, errPayoutAccountNotFound, utils.ErrInternalServer)
+	}
+	if account.Ready() {
+		return account, nil
+	}
+
+	remote, err := s.connect.GetAccount(ctx, account.StripeAccountID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("送金先の状態を取得できません", "user_id", userID, "error", err)
+		return account, nil
+	}
+	if err := s.syncAccount(ctx, account, remote); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("送金先の更新に失敗しました")
+	}
+	return account, nil
+}
+
+// UpdateAccount はStripeから通知された送金先の状態を反映します（account.updated）
+// 送金できる状態になった場合は、送金待ちの精算を送金します
+func (s *PayoutService) UpdateAccount(ctx context.Context, remote ConnectAccount) error {
+	account, err := s.store.PayoutAccounts().GetByStripeAccount(ctx, remote.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		logging.FromContext(ctx).Warn("送金先が見つかりません", "stripe_account", remote.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.syncAccount(ctx, account, &remote)
+}
+
+func (s *PayoutService) syncAccount(ctx context.Context, account *models.PayoutAccount, remote *ConnectAccount) error {
+	if account.DetailsSubmitted == remote.DetailsSubmitted && account.PayoutsEnabled == remote.PayoutsEnabled {
+		return nil
+	}
+	wasReady := account.Ready()
+	if err := s.store.PayoutAccounts().UpdateStatus(ctx, account, remote.DetailsSubmitted, remote.PayoutsEnabled); err != nil {
+		return err
+	}
+	account.DetailsSubmitted, account.PayoutsEnabled = remote.DetailsSubmitted, remote.PayoutsEnabled
+	logging.FromContext(ctx).Info("送金先の状態を更新しました", "user_id", account.UserID,
+		"details_submitted", account.DetailsSubmitted, "payouts_enabled", account.PayoutsEnabled)
+
+	if account.Ready() && !wasReady {
+		s.payPending(ctx, repository.SettlementFilter{UserID: account.UserID, Status: models.SettlementStatusPending})
+	}
+	return nil
+}
+
+// SettleCompleted は完了したプロジェクトの精算を作成し、送金待ちの精算を送金します（定期タスク）
+// 作成した精算の件数を返します
+func (s *PayoutService) SettleCompleted(ctx context.Context) (int, error) {
+	projects, err := s.store.Projects().ListUnsettled(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for i := range projects {
+		created, err := s.settle(ctx, &projects[i])
+		if err != nil {
+			return settled, fmt.Errorf("project %d: %w", projects[i].ID, err)
+		}
+		if created {
+			settled++
+		}
+	}
+
+	s.payPending(ctx, repository.SettlementFilter{Status: models.SettlementStatusPending})
+	return settled, nil
+}
+
+// settle はプロジェクトの精算を作成します（他のレプリカが作成済みの場合や、請求書・経費の審査待ちの場合は false）
+func (s *PayoutService) settle(ctx context.Context, project *models.Project) (bool, error) {
+	supports, err := s.store.Supports().ListPaid(ctx, project.ID)
+	if err != nil {
+		return false, err
+	}
+	rule := s.fees.For(project.Type)
+	amounts := rule.Calculate(supports)
+
+	base := models.Settlement{
+		ProjectID:          project.ID,
+		Recipient:          models.SettlementRecipientOrganizer,
+		UserID:             project.UserID,
+		ProjectType:        project.Type,
+		GrossAmount:        amounts.Gross,
+		RefundedAmount:     amounts.Refunded,
+		FeeAmount:          amounts.Fee,
+		NetAmount:          amounts.Net,
+		SupportCount:       amounts.Supports,
+		FeeRateBasisPoints: rule.RateBasisPoints,
+		FeeFixedPerSupport: rule.FixedPerSupport,
+		Status:             models.SettlementStatusPending,
+	}
+	settlements := []*models.Settlement{&base}
+	if project.SettlementMode == models.SettlementModeOperator {
+		if settlements, err = s.directPayments(ctx, project, base); err != nil || settlements == nil {
+			return false, err
+		}
+	}
+
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		for _, settlement := range settlements {
+			if err := tx.Settlements().Create(ctx, settlement); err != nil {
+				return err
+			}
+			if err := recognizeSettlement(ctx, tx, settlement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, settlement := range settlements {
+		logging.FromContext(ctx).Info("プロジェクトの精算を作成しました", "project_id", project.ID,
+			"settlement_id", settlement.ID, "recipient", settlement.Recipient,
+			"gross", amounts.Gross, "fee", amounts.Fee, "net", settlement.NetAmount)
+	}
+	return true, nil
+}
+
+// directPayments は運営会社への直接支払いのプロジェクトの精算（運営会社への支払いと経費の立替分）を組み立てます
+// 請求書が承認されていない場合や、審査待ちの経費がある場合は nil を返します（次回の定期タスクで再確認する）
+func (s *PayoutService) directPayments(ctx context.Context, project *models.Project, base models.Settlement) ([]*models.Settlement, error) {
+	logger := logging.FromContext(ctx).With("project_id", project.ID)
+	invoice, err := s.store.Invoices().GetByProject(ctx, project.ID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && invoice.Status != models.ReviewStatusApproved) {
+		logger.Info("運営会社の請求書の承認を待っています")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.store.Expenses().List(ctx, repository.ExpenseFilter{ProjectID: project.ID})
+	if err != nil {
+		return nil, err
+	}
+	var claimed int64
+	for _, expense := range expenses {
+		switch expense.Status {
+		case models.ReviewStatusSubmitted:
+			logger.Info("経費の審査を待っています", "expense_id", expense.ID)
+			return nil, nil
+		case models.ReviewStatusApproved:
+			claimed += expense.Amount
+		}
+	}
+	if invoice.Amount+claimed > base.NetAmount {
+		logger.Warn("支援金が請求書と経費の合計に足りません", "net", base.NetAmount,
+			"invoice", invoice.Amount, "expenses", claimed)
+	}
+
+	operator := base
+	operator.Recipient = models.SettlementRecipientOperator
+	operator.UserID = invoice.OperatorID
+	operator.InvoiceID = &invoice.ID
+	operator.RequestedAmount = invoice.Amount
+	operator.NetAmount = allocate(operator.Recipient, base.NetAmount, invoice.Amount, invoice.Amount)
+	settlements := []*models.Settlement{&operator}
+
+	if claimed > 0 {
+		reimbursement := base
+		reimbursement.Recipient = models.SettlementRecipientReimbursement
+		reimbursement.RequestedAmount = claimed
+		reimbursement.NetAmount = allocate(reimbursement.Recipient, base.NetAmount, claimed, invoice.Amount)
+		settlements = append(settlements, &reimbursement)
+	}
+	return settlements, nil
+}
+
+// allocate は返金と手数料を差し引いた額（available）から受取人へ送金する額を返します
+// 運営会社への支払いを優先し、経費の立替分には残りを充てます。請求額を超えた残りはプラットフォームの残高に残ります
+func allocate(recipient models.SettlementRecipient, available, requested, invoiced int64) int64 {
+	switch recipient {
+	case models.SettlementRecipientOperator:
+		return min(requested, available)
+	case models.SettlementRecipientReimbursement:
+		return max(0, min(requested, available-invoiced))
+	}
+	return available
+}
+
+// payPending は送金先の登録を終えた主催者の精算を送金します
+// 送金に失敗した精算はログに出力し、他の精算の送金を続けます
+func (s *PayoutService) payPending(ctx context.Context, filter repository.SettlementFilter) {
+	logger := logging.FromContext(ctx)
+	settlements, err := s.store.Settlements().List(ctx, filter)
+	if err != nil {
+		logger.Error("送金待ちの精算を取得できません", "error", err)
+		return
+	}
+	for i := range settlements {
+		settlement := &settlements[i]
+		account, err := s.store.PayoutAccounts().GetByUser(ctx, settlement.UserID)
+		if err != nil || !account.Ready() {
+			continue
+		}
+		if err := s.pay(ctx, settlement, account); err != nil {
+			logger.Error("精算の送金に失敗しました", "settlement_id", settlement.ID, "error", err)
+		}
+	}
+}
+
+// pay は精算を送金します
+// 送金（Transfer）前であれば、精算の作成後の返金を反映して金額を計算し直し、帳簿の計上額を修正します
+// Stripeへの要求は冪等キーを付けるため、複数のレプリカが同時に送金しても二重にはなりません
+func (s *PayoutService) pay(ctx context.Context, settlement *models.Settlement, account *models.PayoutAccount) error {
+	if settlement.StripeTransferID == "" {
+		supports, err := s.store.Supports().ListPaid(ctx, settlement.ProjectID)
+		if err != nil {
+			return err
+		}
+		amounts := settlementRule(settlement).Calculate(supports)
+		net, err := s.netAmount(ctx, settlement, amounts.Net)
+		if err != nil {
+			return err
+		}
+		settlement.GrossAmount, settlement.RefundedAmount = amounts.Gross, amounts.Refunded
+		settlement.FeeAmount, settlement.NetAmount = amounts.Fee, net
+		settlement.SupportCount = amounts.Supports
+
+		updates := map[string]interface{}{
+			"gross_amount":    amounts.Gross,
+			"refunded_amount": amounts.Refunded,
+			"fee_amount":      amounts.Fee,
+			"net_amount":      net,
+			"support_count":   amounts.Supports,
+		}
+		// 全額返金などで送金額がない場合は送金せずに完了にする
+		if net <= 0 {
+			now := s.now()
+			updates["status"], updates["paid_at"] = models.SettlementStatusPaid, now
+			return s.saveAmounts(ctx, settlement, updates)
+		}
+
+		transferID, err := s.connect.Transfer(ctx, PayoutRequest{
+			SettlementID:   settlement.ID,
+			AccountID:      account.StripeAccountID,
+			Amount:         settlement.NetAmount,
+			IdempotencyKey: fmt.Sprintf("settlement-%d-transfer", settlement.ID),
+		})
+		if err != nil {
+			// 送金待ちのまま次回の定期タスクで再試行する
+			logging.FromContext(ctx).Error("精算の送金に失敗しました", "settlement_id", settlement.ID, "error", err)
+			updates["failure_reason"] = failureReasonTransfer
+			if updateErr := s.saveAmounts(ctx, settlement, updates); updateErr != nil {
+				return updateErr
+			}
+			return err
+		}
+		updates["stripe_transfer_id"] = transferID
+		if err := s.saveAmounts(ctx, settlement, updates, transferEntry(settlement)); err != nil {
+			return err
+		}
+		settlement.StripeTransferID = transferID
+	}
+
+	// 入金に失敗した後の再送では、前回の入金IDを冪等キーに含めて新しい入金を作成する
+	key := fmt.Sprintf("settlement-%d-payout", settlement.ID)
+	if settlement.StripePayoutID != "" {
+		key += "-after-" + settlement.StripePayoutID
+	}
+	payoutID, err := s.connect.Payout(ctx, PayoutRequest{
+		SettlementID:   settlement.ID,
+		AccountID:      account.StripeAccountID,
+		Amount:         settlement.NetAmount,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("精算の入金の作成に失敗しました", "settlement_id", settlement.ID, "error", err)
+		if updateErr := s.store.Settlements().Update(ctx, settlement, map[string]interface{}{
+			"status":         models.SettlementStatusFailed,
+			"failure_reason": failureReasonPayout,
+		}); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	if err := s.store.Settlements().Update(ctx, settlement, map[string]interface{}{
+		"status":           models.SettlementStatusInTransit,
+		"stripe_payout_id": payoutID,
+		"failure_reason":   "",
+	}); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("精算を送金しました", "settlement_id", settlement.ID, "amount", settlement.NetAmount, "payout", payoutID)
+	return nil
+}
+
+// saveAmounts は計算し直した精算の金額を保存し、同じトランザクションで帳簿の計上額の修正と entries を記録します
+func (s *PayoutService) saveAmounts(ctx context.Context, settlement *models.Settlement, updates map[string]interface{}, entries ...*models.JournalEntry) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Settlements().Update(ctx, settlement, updates); err != nil {
+			return err
+		}
+		if err := recognizeSettlement(ctx, tx, settlement); err != nil {
+			return err
+		}
+		return post(ctx, tx, entries...)
+	})
+}
+
+// netAmount は返金を反映した手数料の差し引き後の額（available）から、精算の受取人への送金額を返します
+func (s *PayoutService) netAmount(ctx context.Context, settlement *models.Settlement, available int64) (int64, error) {
+	var invoiced int64
+	if settlement.Recipient == models.SettlementRecipientReimbursement {
+		invoice, err := s.store.Invoices().GetByProject(ctx, settlement.ProjectID)
+		if err != nil {
+			return 0, err
+		}
+		invoiced = invoice.Amount
+	}
+	return allocate(settlement.Recipient, available, settlement.RequestedAmount, invoiced), nil
+}
+
+// settlementRule は精算の作成時に適用した手数料のルールを返します（設定の変更は作成済みの精算に影響しない）
+func settlementRule(settlement *models.Settlement) FeeRule {
+	return FeeRule{
+		ProjectType:     settlement.ProjectType,
+		RateBasisPoints: settlement.FeeRateBasisPoints,
+		FixedPerSupport: settlement.FeeFixedPerSupport,
+	}
+}
+
+// 精算の失敗理由（主催者にも返すため、Stripeのエラーの詳細は保存せずログに出力します）
+const (
+	failureReasonTransfer = "transfer_failed"
+	failureReasonPayout   = "payout_failed"
+)
+
+// MarkPayoutPaid は銀行口座への入金の完了を反映します（payout.paid）
+func (s *PayoutService) MarkPayoutPaid(ctx context.Context, payoutID string) error {
+	settlement, err := s.settlementByPayout(ctx, payoutID)
+	if err != nil || settlement == nil {
+		return err
+	}
+	now := s.now()
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		if err := tx.Settlements().Update(ctx, settlement, map[string]interface{}{
+			"status":         models.SettlementStatusPaid,
+			"paid_at":        now,
+			"failure_reason": "",
+		}); err != nil {
+			return err
+		}
+		if settlement.InvoiceID == nil {
+			return nil
+		}
+		return tx.Invoices().Update(ctx, &models.Invoice{ID: *settlement.InvoiceID}, map[string]interface{}{
+			"status":  models.ReviewStatusPaid,
+			"paid_at": now,
+		})
+	})
+}
+
+// MarkPayoutFailed は銀行口座への入金の失敗を反映します（payout.failed）
+// 入金できなかった額はConnectアカウントの残高に戻るため、管理者が口座の確認後に再送します
+// 失敗理由にはStripeの失敗コード（account_closed など）を保存し、説明文はログにのみ出力します
+func (s *PayoutService) MarkPayoutFailed(ctx context.Context, payoutID, code, message string) error {
+	settlement, err := s.settlementByPayout(ctx, payoutID)
+	if err != nil || settlement == nil {
+		return err
+	}
+	logging.FromContext(ctx).Warn("精算の入金に失敗しました", "settlement_id", settlement.ID, "code", code, "message", message)
+	if code == "" {
+		code = failureReasonPayout
+	}
+	return s.store.Settlements().Update(ctx, settlement, map[string]interface{}{
+		"status":         models.SettlementStatusFailed,
+		"failure_reason": code,
+	})
+}
+
+// settlementByPayout は入金IDの精算を返します（精算以外の入金の場合は nil）
+func (s *PayoutService) settlementByPayout(ctx context.Context, payoutID string) (*models.Settlement, error) {
+	settlement, err := s.store.Settlements().GetByStripePayout(ctx, payoutID)
+	if errors.Is(err, repository.ErrNotFound) {
+		logging.FromContext(ctx).Warn("入金に対応する精算が見つかりません", "payout", payoutID)
+		return nil, nil
+	}
+	return settlement, err
+}
+
+// Retry は入金に失敗した精算を再送します（管理者による操作）
+func (s *PayoutService) Retry(ctx context.Context, actorID, id uint) (*models.Settlement, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "精算の再送は管理者のみ行えます"); err != nil {
+		return nil, err
+	}
+	settlement, err := s.store.Settlements().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errSettlementNotFound, utils.ErrInternalServer)
+	}
+	if settlement.Status != models.SettlementStatusFailed {
+		return nil, utils.ErrConflict.WithDetail("再送できるのは入金に失敗した精算のみです")
+	}
+	account, err := s.store.PayoutAccounts().GetByUser(ctx, settlement.UserID)
+	if err != nil || !account.Ready() {
+		return nil, utils.ErrConflict.WithDetail("主催者の送金先の登録が完了していません")
+	}
+
+	if err := s.pay(ctx, settlement, account); err != nil {
+		logging.FromContext(ctx).Error("精算の再送に失敗しました", "settlement_id", settlement.ID, "error", err)
+		return nil, utils.ErrInternalServer.WithDetail("精算の再送に失敗しました")
+	}
+	return s.store.Settlements().Get(ctx, id)
+}
+
+// ListForOwner は主催者の精算を新しい順に返します
+func (s *PayoutService) ListForOwner(ctx context.Context, userID uint) ([]models.Settlement, error) {
+	settlements, err := s.store.Settlements().List(ctx, repository.SettlementFilter{UserID: userID})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("精算の取得に失敗しました")
+	}
+	return settlements, nil
+}
+
+// List は管理者に精算を新しい順に返します（入金の状態の追跡用）
+func (s *PayoutService) List(ctx context.Context, actorID uint, status models.SettlementStatus) ([]models.Settlement, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "すべての精算は管理者のみ参照できます"); err != nil {
+		return nil, err
+	}
+	settlements, err := s.store.Settlements().List(ctx, repository.SettlementFilter{Status: status})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("精算の取得に失敗しました")
+	}
+	return settlements, nil
+}
+
+// Statement は精算の明細
+type Statement struct {
+	Settlement *models.Settlement
+	// Supports は決済済み（返金されたものを含む）の支援
+	Supports []models.Support
+}
+
+// Statement は主催者本人または管理者に精算の明細を返します
+func (s *PayoutService) Statement(ctx context.Context, actorID, id uint) (*Statement, error) {
+	settlement, err := s.store.Settlements().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errSettlementNotFound, utils.ErrInternalServer)
+	}
+	if settlement.UserID != actorID {
+		if err := requireAdmin(ctx, s.store, actorID, utils.ErrMsgUnauthorizedAccess); err != nil {
+			return nil, err
+		}
+	}
+	supports, err := s.store.Supports().ListPaid(ctx, settlement.ProjectID)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("精算の取得に失敗しました")
+	}
+	return &Statement{Settlement: settlement, Supports: supports}, nil
+}
+
+// Filename は明細のファイル名を返します
+func (st *Statement) Filename() string {
+	return fmt.Sprintf("oshiome-statement-%d.csv", st.Settlement.ProjectID)
+}
+
+// WriteCSV は明細をCSV（UTF-8、BOM付き）で書き出します
+// 先頭に精算の内訳、空行の後に支援ごとの明細を出力します
+func (st *Statement) WriteCSV(w io.Writer) error {
+	// 表計算ソフトで文字化けしないようBOMを付ける
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	settlement := st.Settlement
+	title, paidAt := "", ""
+	if settlement.Project != nil {
+		title = settlement.Project.Title
+	}
+	if settlement.PaidAt != nil {
+		paidAt = settlement.PaidAt.Format(time.RFC3339)
+	}
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"精算ID", itoa(int64(settlement.ID))},
+		{"プロジェクトID", itoa(int64(settlement.ProjectID))},
+		{"プロジェクト名", title},
+		{"企画の種別", string(settlement.ProjectType)},
+		{"受取人", string(settlement.Recipient)},
+		{"手数料のルール", settlementRule(settlement).Describe()},
+		{"支援総額", itoa(settlement.GrossAmount)},
+		{"返金額", itoa(settlement.RefundedAmount)},
+		{"手数料", itoa(settlement.FeeAmount)},
+		{"請求額", itoa(settlement.RequestedAmount)},
+		{"送金額", itoa(settlement.NetAmount)},
+		{"状態", string(settlement.Status)},
+		{"精算日時", settlement.CreatedAt.Format(time.RFC3339)},
+		{"入金日時", paidAt},
+		{},
+		{"支援ID", "支援日時", "支援額", "返金額", "状態"},
+	}
+	for _, support := range st.Supports {
+		rows = append(rows, []string{
+			itoa(int64(support.ID)),
+			support.CreatedAt.Format(time.RFC3339),
+			itoa(support.Amount),
+			itoa(min(support.RefundedAmount, support.Amount)),
+			string(support.Status),
+		})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
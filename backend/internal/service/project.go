@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// ProjectInput はプロジェクトの作成・更新の内容
+type ProjectInput struct {
+	Title        string
+	Description  string
+	TargetAmount int64
+	Deadline     time.Time
+	// Type は企画の種別（作成時に空の場合は other）
+	Type models.ProjectType
+	// VisionID は掲出するビジョン（nilの場合は変更しない）
+	VisionID *uint
+	// SettlementMode は精算方法（作成時に空の場合は organizer。下書きの間のみ変更できる）
+	SettlementMode models.SettlementMode
+	// 更新時のみ使用（作成時は常に下書き）
+	Status       models.ProjectStatus
+	ThumbnailURL string
+}
+
+// ProjectService はプロジェクトのユースケース
+type ProjectService struct {
+	store repository.Store
+	now   func() time.Time
+}
+
+// NewProjectService は新しいProjectServiceインスタンスを作成します
+func NewProjectService(store repository.Store) *ProjectService {
+	return &ProjectService{store: store, now: time.Now}
+}
+
+var (
+	errProjectNotFound = utils.ErrNotFound.WithDetail(utils.ErrMsgProjectNotFound)
+	errVisionNotFound  = utils.ErrInvalidInput.WithDetail("ビジョンが見つかりません")
+)
+
+// validProjectStatuses はプロジェクトの状態として有効な値
+var validProjectStatuses = map[models.ProjectStatus]bool{
+	models.ProjectStatusDraft:     true,
+	models.ProjectStatusActive:    true,
+	models.ProjectStatusComplete:  true,
+	models.ProjectStatusCancelled: true,
+}
+
+// List は状態で絞り込んだプロジェクト一覧を返します（未指定の場合は実施中のみ）
+func (s *ProjectService) List(ctx context.Context, status models.ProjectStatus) ([]models.Project, error) {
+	if status == "" {
+		status = models.ProjectStatusActive
+	}
+	projects, err := s.store.Projects().List(ctx, repository.ProjectFilter{Status: status})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail)
+	}
+	return projects, nil
+}
+
+// ListByOwner はユーザーが主催するプロジェクト一覧を返します
+func (s *ProjectService) ListByOwner(ctx context.Context, userID uint) ([]models.Project, error) {
+	projects, err := s.store.Projects().List(ctx, repository.ProjectFilter{OwnerID: userID})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail)
+	}
+	return projects, nil
+}
+
+// ListSupportedBy はユーザーが支援したプロジェクト一覧を返します
+func (s *ProjectService) ListSupportedBy(ctx context.Context, userID uint) ([]models.Project, error) {
+	projects, err := s.store.Projects().List(ctx, repository.ProjectFilter{SupporterID: userID})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectListFail)
+	}
+	return projects, nil
+}
+
+// Get はプロジェクトを返します
+func (s *ProjectService) Get(ctx context.Context, id uint) (*models.Project, error) {
+	project, err := s.store.Projects().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	return project, nil
+}
+
+// GetDetail は主催者と支援を含むプロジェクトの詳細を返します
+func (s *ProjectService) GetDetail(ctx context.Context, id uint) (*models.Project, error) {
+	project, err := s.store.Projects().GetWithSupports(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	return project, nil
+}
+
+// getOwned は主催者本人のプロジェクトを返します
+func (s *ProjectService) getOwned(ctx context.Context, id, userID uint) (*models.Project, error) {
+	project, err := s.GetDetail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.UserID != userID {
+		return nil, utils.ErrForbidden.WithDetail(utils.ErrMsgUnauthorizedAccess)
+	}
+	return project, nil
+}
+
+// Create はプロジェクトを下書きとして作成します
+func (s *ProjectService) Create(ctx context.Context, ownerID uint, input ProjectInput) (*models.Project, error) {
+	if err := s.checkSettlement(ctx, nil, input); err != nil {
+		return nil, err
+	}
+	project := &models.Project{
+		Title:          input.Title,
+		Description:    input.Description,
+		TargetAmount:   input.TargetAmount,
+		Deadline:       input.Deadline,
+		Type:           input.Type,
+		VisionID:       input.VisionID,
+		SettlementMode: input.SettlementMode,
+		UserID:         ownerID,
+		Status:         models.ProjectStatusDraft,
+		ThumbnailURL:   input.ThumbnailURL,
+	}
+	if err := s.store.Projects().Create(ctx, project); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectCreateFail)
+	}
+	return project, nil
+}
+
+// Update は主催者本人のプロジェクトを更新します
+func (s *ProjectService) Update(ctx context.Context, id, userID uint, input ProjectInput) (*models.Project, error) {
+	if input.Status != "" && !validProjectStatuses[input.Status] {
+		return nil, utils.ErrInvalidInput.WithDetail("無効なステータスです")
+	}
+
+	project, err := s.getOwned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkSettlement(ctx, project, input); err != nil {
+		return nil, err
+	}
+
+	// 変更は監査ログに主催者の操作として記録される
+	ctx = audit.WithActorID(ctx, userID)
+	updates := models.Project{
+		Title:          input.Title,
+		Description:    input.Description,
+		TargetAmount:   input.TargetAmount,
+		Deadline:       input.Deadline,
+		Type:           input.Type,
+		VisionID:       input.VisionID,
+		SettlementMode: input.SettlementMode,
+		Status:         input.Status,
+	}
+	if err := s.store.Projects().Update(ctx, project, updates); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectUpdateFail)
+	}
+	return project, nil
+}
+
+// checkSettlement はビジョンと精算方法を検証します（作成時は project が nil）
+// 支援金の支払先が変わるため、公開後は変更できません。運営会社への直接支払いには運営会社が登録されたビジョンが必要です
+func (s *ProjectService) checkSettlement(ctx context.Context, project *models.Project, input ProjectInput) error {
+	mode, visionID := input.SettlementMode, input.VisionID
+	if project != nil {
+		changed := (mode != "" && mode != project.SettlementMode) ||
+			(visionID != nil && (project.VisionID == nil || *visionID != *project.VisionID))
+		if changed && project.Status != models.ProjectStatusDraft {
+			return utils.ErrConflict.WithDetail("公開後はビジョンと精算方法を変更できません")
+		}
+		if mode == "" {
+			mode = project.SettlementMode
+		}
+		if visionID == nil {
+			visionID = project.VisionID
+		}
+	}
+
+	var vision *models.Vision
+	if visionID != nil {
+		var err error
+		if vision, err = s.store.Visions().Get(ctx, *visionID); err != nil {
+			return mapError(err, errVisionNotFound, utils.ErrInternalServer)
+		}
+	}
+	if mode == models.SettlementModeOperator && (vision == nil || vision.OperatorID == nil) {
+		return utils.ErrInvalidInput.WithDetail("運営会社への直接支払いには運営会社が登録されたビジョンを選択してください")
+	}
+	return nil
+}
+
+// Delete は主催者本人のプロジェクトを削除します
+func (s *ProjectService) Delete(ctx context.Context, id, userID uint) error {
+	project, err := s.getOwned(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Projects().Delete(audit.WithActorID(ctx, userID), project); err != nil {
+		return utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectDeleteFail)
+	}
+	return nil
+}
+
+// SetStatus はプロジェクトの状態を変更します（管理者による操作）
+func (s *ProjectService) SetStatus(ctx context.Context, id uint, status models.ProjectStatus) error {
+	if !validProjectStatuses[status] {
+		return utils.ErrInvalidInput.WithDetail("無効なステータスです")
+	}
+	if err := s.store.Projects().UpdateStatus(ctx, id, status); err != nil {
+		return mapError(err, errProjectNotFound, utils.ErrInternalServer.WithDetail(utils.ErrMsgProjectUpdateFail))
+	}
+	return nil
+}
+
+// CloseExpired は締め切りを過ぎた実施中のプロジェクトを完了にし、件数を返します
+func (s *ProjectService) CloseExpired(ctx context.Context) (int, error) {
+	projects, err := s.store.Projects().ListExpired(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, p := range projects {
+		if err := s.store.Projects().UpdateStatus(ctx, p.ID, models.ProjectStatusComplete); err != nil {
+			return closed, err
+		}
+		logging.FromContext(ctx).Info("締め切りを過ぎたプロジェクトを完了にしました", "project_id", p.ID)
+		closed++
+	}
+	return closed, nil
+}
+
+// CountByStatus はステータスごとのプロジェクト数を返します（メトリクス用）
+func (s *ProjectService) CountByStatus(ctx context.Context) (map[models.ProjectStatus]int64, error) {
+	return s.store.Projects().CountByStatus(ctx)
+}
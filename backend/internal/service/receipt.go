@@ -0,0 +1,405 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/pdf"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// ReceiptTaxRate は支援に適用する消費税率（％）
+const ReceiptTaxRate = 10
+
+// 宛名の最大文字数
+const maxReceiptAddresseeLength = 100
+
+// receiptLocation は領収書に印字する日付のタイムゾーン
+var receiptLocation = time.FixedZone("JST", 9*60*60)
+
+// ReceiptIssuer は領収書の発行者
+type ReceiptIssuer struct {
+	Name    string
+	Address string
+	// RegistrationNumber は適格請求書発行事業者の登録番号（空の場合は記載しない）
+	RegistrationNumber string
+}
+
+// ReissueReceiptInput は領収書の発行・再発行の内容
+type ReissueReceiptInput struct {
+	Addressee string
+	// Reason は無効にする領収書の再発行の理由（領収書が未発行の場合は不要）
+	Reason string
+}
+
+// ReceiptService は支援の領収書の発行・再発行のユースケース
+type ReceiptService struct {
+	store  repository.Store
+	issuer ReceiptIssuer
+	now    func() time.Time
+}
+
+// NewReceiptService は新しいReceiptServiceインスタンスを作成します
+func NewReceiptService(store repository.Store, issuer ReceiptIssuer) *ReceiptService {
+	return &ReceiptService{store: store, issuer: issuer, now: time.Now}
+}
+
+var (
+	errReceiptNotIssuable = utils.ErrConflict.WithDetail("決済が完了した支援のみ領収書を発行できます")
+	errReceiptFetchFail   = utils.ErrInternalServer.WithDetail("領収書の取得に失敗しました")
+	errReceiptIssueFail   = utils.ErrInternalServer.WithDetail("領収書の発行に失敗しました")
+)
+
+// Receipt は支援者本人・プロジェクトの主催者・管理者に支援の有効な領収書を返します
+// 未発行の場合は発行します（返金による無効化・再発行は返金の記録時に行います）
+func (s *ReceiptService) Receipt(ctx context.Context, actorID, supportID uint) (*models.Receipt, error) {
+	support, err := s.support(ctx, supportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, actorID, support); err != nil {
+		return nil, err
+	}
+	return s.current(ctx, support)
+}
+
+// History は支援の領収書を無効になったものも含めて発行順に返します
+func (s *ReceiptService) History(ctx context.Context, actorID, supportID uint) ([]models.Receipt, error) {
+	support, err := s.support(ctx, supportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, actorID, support); err != nil {
+		return nil, err
+	}
+	receipts, err := s.store.Receipts().ListBySupport(ctx, support.ID)
+	if err != nil {
+		return nil, errReceiptFetchFail
+	}
+	return receipts, nil
+}
+
+// Reissue は支援者本人の依頼で宛名を指定して領収書を発行します
+// 発行済みの場合は理由を記録して無効にし、新しい番号で再発行します
+func (s *ReceiptService) Reissue(ctx context.Context, actorID, supportID uint, input ReissueReceiptInput) (*models.Receipt, error) {
+	input.Addressee = strings.TrimSpace(input.Addressee)
+	input.Reason = strings.TrimSpace(input.Reason)
+	if utf8.RuneCountInString(input.Addressee) > maxReceiptAddresseeLength {
+		return nil, utils.ErrInvalidInput.WithDetail("宛名は100文字以内で入力してください")
+	}
+
+	support, err := s.support(ctx, supportID)
+	if err != nil {
+		return nil, err
+	}
+	if support.UserID != actorID {
+		return nil, utils.ErrForbidden.WithDetail(utils.ErrMsgUnauthorizedAccess)
+	}
+	if err := issuable(support); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.store.Receipts().Current(ctx, support.ID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		previous = nil
+	case err != nil:
+		return nil, errReceiptFetchFail
+	case input.Reason == "":
+		return nil, utils.ErrInvalidInput.WithDetail("再発行の理由を入力してください")
+	}
+	return s.issue(ctx, support, input.Addressee, previous, input.Reason)
+}
+
+// ProjectReceipts はプロジェクトの主催者または管理者に、決済が完了した支援の有効な領収書を返します
+// 未発行の支援には領収書を発行します
+func (s *ReceiptService) ProjectReceipts(ctx context.Context, actorID, projectID uint) ([]models.Receipt, error) {
+	project, err := s.store.Projects().Get(ctx, projectID)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	if project.UserID != actorID {
+		if err := requireAdmin(ctx, s.store, actorID, utils.ErrMsgUnauthorizedAccess); err != nil {
+			return nil, err
+		}
+	}
+	supports, err := s.store.Supports().ListPaid(ctx, project.ID)
+	if err != nil {
+		return nil, errReceiptFetchFail
+	}
+	receipts := make([]models.Receipt, 0, len(supports))
+	for i := range supports {
+		support := &supports[i]
+		if issuable(support) != nil {
+			continue
+		}
+		support.Project = *project
+		receipt, err := s.current(ctx, support)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, *receipt)
+	}
+	return receipts, nil
+}
+
+func (s *ReceiptService) support(ctx context.Context, id uint) (*models.Support, error) {
+	support, err := s.store.Supports().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errSupportNotFound, utils.ErrInternalServer)
+	}
+	return support, nil
+}
+
+// authorize は支援者本人・プロジェクトの主催者・管理者以外を拒否します
+func (s *ReceiptService) authorize(ctx context.Context, actorID uint, support *models.Support) error {
+	if support.UserID == actorID || support.Project.UserID == actorID {
+		return nil
+	}
+	return requireAdmin(ctx, s.store, actorID, utils.ErrMsgUnauthorizedAccess)
+}
+
+// issuable は領収書を発行できる支援（決済が完了し、全額は返金されていない）かを確認します
+func issuable(support *models.Support) error {
+	if support.Status != models.SupportStatusCompleted || receiptAmount(support) <= 0 {
+		return errReceiptNotIssuable
+	}
+	return nil
+}
+
+// receiptAmount は支援の領収額（返金された額を除く）を返します
+func receiptAmount(support *models.Support) int64 {
+	return support.Amount - min(support.RefundedAmount, support.Amount)
+}
+
+// current は支援の有効な領収書を返します（未発行の場合は発行します）
+// 発行済みの領収書は参照だけでは無効にも再発行もしません
+func (s *ReceiptService) current(ctx context.Context, support *models.Support) (*models.Receipt, error) {
+	receipt, err := s.store.Receipts().Current(ctx, support.ID)
+	switch {
+	case err == nil:
+		return receipt, nil
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, errReceiptFetchFail
+	}
+	if err := issuable(support); err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, support, "", nil, "")
+}
+
+// reviseForRefund は返金で領収額が変わった支援の有効な領収書を無効にし、返金後の金額で再発行します
+// 全額返金された場合は無効にするだけで、領収書が未発行の場合は何もしません
+// support の返金額は返金後の値、tx は返金を記録するトランザクションです
+func (s *ReceiptService) reviseForRefund(ctx context.Context, tx repository.Store, support *models.Support) error {
+	previous, err := tx.Receipts().Current(ctx, support.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	amount := receiptAmount(support)
+	switch {
+	case amount == previous.Amount:
+		return nil
+	case amount <= 0:
+		return tx.Receipts().Void(ctx, previous.ID, "全額返金", s.now())
+	}
+	receipt := s.newReceipt(ctx, tx, support, previous.Addressee)
+	receipt.ProjectTitle = previous.ProjectTitle
+	return replaceReceipt(ctx, tx, receipt, previous, "返金による金額の変更")
+}
+
+// issue は領収書を発行します（previous がある場合は無効にして再発行）
+func (s *ReceiptService) issue(ctx context.Context, support *models.Support, addressee string, previous *models.Receipt, reason string) (*models.Receipt, error) {
+	receipt := s.newReceipt(ctx, s.store, support, addressee)
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		return replaceReceipt(ctx, tx, receipt, previous, reason)
+	})
+	if err == nil {
+		return receipt, nil
+	}
+	// 同時に発行された場合は先に発行された領収書を返す
+	if errors.Is(err, repository.ErrDuplicate) || errors.Is(err, repository.ErrNotFound) {
+		if current, err := s.store.Receipts().Current(ctx, support.ID); err == nil {
+			return current, nil
+		}
+	}
+	return nil, errReceiptIssueFail
+}
+
+// newReceipt は支援の現在の領収額で発行する領収書を組み立てます
+func (s *ReceiptService) newReceipt(ctx context.Context, store repository.Store, support *models.Support, addressee string) *models.Receipt {
+	amount := receiptAmount(support)
+	return &models.Receipt{
+		SupportID:          support.ID,
+		ProjectID:          support.ProjectID,
+		UserID:             support.UserID,
+		Addressee:          addressee,
+		ProjectTitle:       support.Project.Title,
+		Amount:             amount,
+		TaxRate:            ReceiptTaxRate,
+		TaxAmount:          amount * ReceiptTaxRate / (100 + ReceiptTaxRate),
+		PaidAt:             paidAt(ctx, store, support),
+		IssuerName:         s.issuer.Name,
+		IssuerAddress:      s.issuer.Address,
+		RegistrationNumber: s.issuer.RegistrationNumber,
+		Status:             models.ReceiptStatusIssued,
+		CreatedAt:          s.now(),
+	}
+}
+
+// replaceReceipt は領収書を作成します（previous がある場合は reason を記録して無効にし、再発行として作成）
+func replaceReceipt(ctx context.Context, tx repository.Store, receipt, previous *models.Receipt, reason string) error {
+	if previous != nil {
+		if err := tx.Receipts().Void(ctx, previous.ID, reason, receipt.CreatedAt); err != nil {
+			return err
+		}
+		receipt.ReissueOfID = &previous.ID
+	}
+	return tx.Receipts().Create(ctx, receipt)
+}
+
+// paidAt は支援の決済が完了した日時（帳簿の仕訳の日時）を返します
+func paidAt(ctx context.Context, store repository.Store, support *models.Support) time.Time {
+	entry, err := store.Ledger().GetEntry(ctx, fmt.Sprintf("support:%d:completed", support.ID))
+	if err != nil {
+		return support.UpdatedAt
+	}
+	return entry.CreatedAt
+}
+
+// ReceiptFilename は領収書のPDFのファイル名を返します
+func ReceiptFilename(receipt *models.Receipt) string {
+	return fmt.Sprintf("receipt-%s.pdf", receipt.DisplayNumber())
+}
+
+// WriteReceiptPDF は領収書をA4のPDFで書き出します
+func WriteReceiptPDF(w io.Writer, receipt *models.Receipt) error {
+	doc := pdf.New()
+	doc.Title = "領収書 " + receipt.DisplayNumber()
+	doc.Author = receipt.IssuerName
+	doc.Created = receipt.CreatedAt
+
+	const left, right = 60.0, pdf.PageWidth - 60
+	page := doc.AddPage()
+	page.TextCenter(pdf.PageWidth/2, 90, 24, "領収書")
+	if receipt.Status == models.ReceiptStatusVoid {
+		page.Rect(pdf.PageWidth/2+50, 68, 50, 28, 1.5)
+		page.TextCenter(pdf.PageWidth/2+75, 89, 16, "無効")
+	}
+	page.TextRight(right, 130, 10, "No. "+receipt.DisplayNumber())
+	page.TextRight(right, 146, 10, "発行日 "+formatReceiptDate(receipt.CreatedAt))
+
+	// 宛名の指定がない場合は記入欄の下線だけを印字する
+	if receipt.Addressee != "" {
+		page.Text(left, 190, 16, truncateText(receipt.Addressee, 14, 300)+" 様")
+	}
+	page.Line(left, 197, left+330, 197, 0.8)
+
+	page.FillRect(left, 225, right-left, 50, 0.92)
+	page.Text(left+20, 257, 14, "金額")
+	page.TextRight(right-20, 259, 22, "￥"+formatAmount(receipt.Amount)+"-")
+	page.TextRight(right-20, 290, 9, "（税込）")
+
+	page.Text(left, 320, 11, "但し 「"+truncateText(receipt.ProjectTitle, 11, right-left-110)+"」への支援として")
+	page.Text(left, 340, 11, "上記正に領収いたしました")
+
+	rows := [][2]string{
+		{"取引年月日", formatReceiptDate(receipt.PaidAt)},
+		{fmt.Sprintf("%d%%対象（税込）", receipt.TaxRate), "￥" + formatAmount(receipt.Amount)},
+		{fmt.Sprintf("うち消費税（%d%%）", receipt.TaxRate), "￥" + formatAmount(receipt.TaxAmount)},
+	}
+	const tableTop, rowHeight, tableWidth = 380.0, 24.0, 280.0
+	page.Text(left, tableTop-8, 10, "内訳")
+	for i, row := range rows {
+		y := tableTop + float64(i)*rowHeight
+		page.Rect(left, y, tableWidth, rowHeight, 0.5)
+		page.Text(left+8, y+16, 10, row[0])
+		page.TextRight(left+tableWidth-8, y+16, 10, row[1])
+	}
+	page.Line(left+140, tableTop, left+140, tableTop+float64(len(rows))*rowHeight, 0.5)
+
+	issuerTop := 540.0
+	page.Text(330, issuerTop, 12, receipt.IssuerName)
+	if receipt.IssuerAddress != "" {
+		issuerTop += 18
+		page.Text(330, issuerTop, 9, truncateText(receipt.IssuerAddress, 9, right-330))
+	}
+	if receipt.RegistrationNumber != "" {
+		issuerTop += 16
+		page.Text(330, issuerTop, 9, "登録番号 "+receipt.RegistrationNumber)
+	}
+
+	if receipt.Status == models.ReceiptStatusVoid {
+		note := "この領収書は無効です"
+		if receipt.VoidReason != "" {
+			note += "（" + truncateText(receipt.VoidReason, 9, 300) + "）"
+		}
+		page.Text(left, 760, 9, note)
+	}
+	page.Text(left, 780, 8, "この領収書は電子的に発行したものです。")
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// WriteReceiptArchive は領収書のPDFをまとめたZIPを書き出します
+func WriteReceiptArchive(w io.Writer, receipts []models.Receipt) error {
+	zw := zip.NewWriter(w)
+	for i := range receipts {
+		receipt := &receipts[i]
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     ReceiptFilename(receipt),
+			Method:   zip.Deflate,
+			Modified: receipt.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := WriteReceiptPDF(f, receipt); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// formatReceiptDate は日付を「2006年1月2日」の形式（日本時間）にします
+func formatReceiptDate(t time.Time) string {
+	return t.In(receiptLocation).Format("2006年1月2日")
+}
+
+// formatAmount は金額を3桁区切りにします
+func formatAmount(amount int64) string {
+	s := strconv.FormatInt(amount, 10)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// truncateText は size ポイントで描いたときに width に収まるよう文字列を切り詰めます
+func truncateText(s string, size, width float64) string {
+	if pdf.TextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.TextWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
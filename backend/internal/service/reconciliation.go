@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// ReconciliationService はStripeの決済・残高取引と支援・帳簿の照合のユースケース
+//
+// Webhookの取りこぼしや誤った失敗による不一致のうち、安全に直せるものは自動で修正します
+//
+//	支払い済みの決済の支援が決済待ち・失敗・取り消し  支援を完了にする
+//	Stripeでの返金が支援に反映されていない            返金額を記録する
+//	決済手数料が帳簿に記録されていない                残高取引の手数料を記録する
+//
+// それ以外（金額の不一致・二重決済・支援に紐づかない決済など）は照合の結果に記録し、担当者が確認します
+type ReconciliationService struct {
+	store    repository.Store
+	payments PaymentGateway
+	supports *SupportService
+	now      func() time.Time
+}
+
+// NewReconciliationService は新しいReconciliationServiceインスタンスを作成します
+func NewReconciliationService(store repository.Store, payments PaymentGateway, supports *SupportService) *ReconciliationService {
+	return &ReconciliationService{store: store, payments: payments, supports: supports, now: time.Now}
+}
+
+// 管理者に返す照合の結果の件数
+const reconciliationReportLimit = 30
+
+// ReconcileRecent は直近の期間を照合します（定期実行用）
+// 期間は lookback だけ遡った時刻から、Webhookの到着を待つ delay だけ前までです
+// 複数のレプリカで同じ期間になるよう、期間の終わりを時間単位に切り捨てます
+func (s *ReconciliationService) ReconcileRecent(ctx context.Context, lookback, delay time.Duration) (*models.ReconciliationReport, error) {
+	to := s.now().Add(-delay).Truncate(time.Hour)
+	return s.Reconcile(ctx, to.Add(-lookback), to)
+}
+
+// Reconcile は期間内に作成されたStripeの決済・残高取引を支援と照合し、結果を保存します
+// 修正は冪等なため、期間が重なっても同じ不一致を二重に修正しません
+// 同じ期間の結果が保存済みの場合（他のレプリカが照合済み）も、今回の結果を返します
+func (s *ReconciliationService) Reconcile(ctx context.Context, from, to time.Time) (*models.ReconciliationReport, error) {
+	if !from.Before(to) {
+		return nil, utils.ErrInvalidInput.WithDetail("照合の期間が正しくありません")
+	}
+	r := &reconciler{
+		ReconciliationService: s,
+		report:                &models.ReconciliationReport{WindowFrom: from, WindowTo: to},
+		checked:               make(map[string]bool),
+		flagged:               make(map[string]bool),
+	}
+
+	payments, err := s.payments.ListPayments(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile: list payments: %w", err)
+	}
+	for _, payment := range payments {
+		if err := r.checkPayment(ctx, payment); err != nil {
+			return nil, err
+		}
+	}
+
+	txns, err := s.payments.ListBalanceTransactions(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile: list balance transactions: %w", err)
+	}
+	for _, txn := range txns {
+		// 期間より前に作成された決済の支払い・返金は、決済を取得して照合する
+		if !r.checked[txn.PaymentIntentID] {
+			payment, err := s.payments.GetPayment(ctx, txn.PaymentIntentID)
+			if err != nil {
+				return nil, fmt.Errorf("reconcile: get payment %s: %w", txn.PaymentIntentID, err)
+			}
+			if err := r.checkPayment(ctx, *payment); err != nil {
+				return nil, err
+			}
+		}
+		if txn.Type == BalanceTransactionCharge {
+			if err := r.checkFee(ctx, txn); err != nil {
+				return nil, err
+			}
+		}
+	}
+	r.report.Payments = len(r.checked)
+	r.report.BalanceTransactions = len(txns)
+
+	log := logging.FromContext(ctx)
+	if err := s.store.Reconciliations().Create(ctx, r.report); err != nil {
+		if !errors.Is(err, repository.ErrDuplicate) {
+			return nil, fmt.Errorf("reconcile: save report: %w", err)
+		}
+		log.Info("同じ期間の照合の結果は保存済みです", "from", from, "to", to)
+	}
+	if !r.report.Resolved() {
+		log.Warn("Stripeとの照合で確認が必要な不一致が見つかりました", "from", from, "to", to,
+			"discrepancies", len(r.report.Discrepancies))
+	}
+	log.Info("Stripeとの照合が完了しました", "from", from, "to", to, "payments", r.report.Payments,
+		"balance_transactions", r.report.BalanceTransactions, "repairs", len(r.report.Repairs))
+	return r.report, nil
+}
+
+// ListReports は管理者に照合の結果を新しい順に返します
+func (s *ReconciliationService) ListReports(ctx context.Context, actorID uint) ([]models.ReconciliationReport, error) {
+	if err := requireAdmin(ctx, s.store, actorID, "照合の結果は管理者のみ参照できます"); err != nil {
+		return nil, err
+	}
+	reports, err := s.store.Reconciliations().List(ctx, reconciliationReportLimit)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("照合の結果の取得に失敗しました")
+	}
+	return reports, nil
+}
+
+// reconciler は1回の照合の状態
+type reconciler struct {
+	*ReconciliationService
+	report *models.ReconciliationReport
+	// checked は照合済みの決済、flagged は不一致を記録済みの決済
+	checked map[string]bool
+	flagged map[string]bool
+}
+
+func (r *reconciler) repair(kind models.ReconciliationKind, paymentIntentID string, supportID uint, detail string) {
+	r.report.Repairs = append(r.report.Repairs, models.ReconciliationItem{
+		Kind: kind, PaymentIntentID: paymentIntentID, SupportID: supportID, Detail: detail,
+	})
+	metrics.ReconciliationItems.Inc(string(kind), metrics.ReconciliationRepaired)
+}
+
+func (r *reconciler) discrepancy(kind models.ReconciliationKind, paymentIntentID string, supportID uint, detail string) {
+	r.report.Discrepancies = append(r.report.Discrepancies, models.ReconciliationItem{
+		Kind: kind, PaymentIntentID: paymentIntentID, SupportID: supportID, Detail: detail,
+	})
+	r.flagged[paymentIntentID] = true
+	metrics.ReconciliationItems.Inc(string(kind), metrics.ReconciliationUnresolved)
+}
+
+// checkPayment は決済を決済セッションの支援と照合します
+func (r *reconciler) checkPayment(ctx context.Context, payment Payment) error {
+	r.checked[payment.ID] = true
+	if len(payment.SupportIDs) == 0 {
+		if payment.Status == PaymentStatusSucceeded {
+			r.discrepancy(models.ReconciliationKindUnknownPayment, payment.ID, 0,
+				fmt.Sprintf("支援に紐づかない決済です（%d円）", payment.Amount))
+		}
+		return nil
+	}
+
+	for _, id := range payment.SupportIDs {
+		support, err := r.store.Supports().Get(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			r.discrepancy(models.ReconciliationKindSupportNotFound, payment.ID, id, "決済セッションの支援が存在しません")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reconcile: support %d: %w", id, err)
+		}
+		if err := r.checkSupport(ctx, payment, support); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) checkSupport(ctx context.Context, payment Payment, support *models.Support) error {
+	paid := support.Status == models.SupportStatusCompleted || support.Status == models.SupportStatusRefunded
+
+	switch payment.Status {
+	case PaymentStatusSucceeded:
+		if support.Amount != payment.Amount {
+			r.discrepancy(models.ReconciliationKindAmountMismatch, payment.ID, support.ID,
+				fmt.Sprintf("支援額 %d円 に対して決済額は %d円 です", support.Amount, payment.Amount))
+			return nil
+		}
+		if paid && support.PaymentIntentID != payment.ID {
+			r.discrepancy(models.ReconciliationKindDuplicatePayment, payment.ID, support.ID,
+				fmt.Sprintf("支援は別の決済 %s で完了しています", support.PaymentIntentID))
+			return nil
+		}
+		if !paid {
+			if err := r.supports.CompletePayment(ctx, support.ID, payment.ID); err != nil {
+				return fmt.Errorf("reconcile: %w", err)
+			}
+			r.repair(models.ReconciliationKindSupportCompleted, payment.ID, support.ID,
+				fmt.Sprintf("%s だった支援を完了にしました", support.Status))
+		}
+
+		refunded := min(payment.Refunded, support.Amount)
+		switch {
+		case refunded > support.RefundedAmount:
+			if err := r.supports.RefundPaymentIntent(ctx, payment.ID, payment.Refunded); err != nil {
+				return fmt.Errorf("reconcile: refund %s: %w", payment.ID, err)
+			}
+			r.repair(models.ReconciliationKindRefundRecorded, payment.ID, support.ID,
+				fmt.Sprintf("返金額 %d円 を記録しました（記録済み %d円）", refunded, support.RefundedAmount))
+		case refunded < support.RefundedAmount:
+			r.discrepancy(models.ReconciliationKindRefundMismatch, payment.ID, support.ID,
+				fmt.Sprintf("記録済みの返金額 %d円 がStripeの返金額 %d円 を上回っています", support.RefundedAmount, refunded))
+		}
+
+	case PaymentStatusFailed, PaymentStatusCanceled:
+		// 決済待ちの支援は、顧客が決済をやり直す場合があるため変更しない（期限切れで取り消される）
+		if paid && support.PaymentIntentID == payment.ID {
+			r.discrepancy(models.ReconciliationKindNotCharged, payment.ID, support.ID,
+				fmt.Sprintf("支援は %s ですが、決済は %s です", support.Status, payment.Status))
+		}
+	}
+	return nil
+}
+
+// checkFee は支払いの残高取引の手数料を帳簿の決済手数料と照合します
+func (r *reconciler) checkFee(ctx context.Context, txn BalanceTransaction) error {
+	supports, err := r.store.Supports().ListPaidByPaymentIntent(ctx, txn.PaymentIntentID)
+	if err != nil {
+		return fmt.Errorf("reconcile: supports of %s: %w", txn.PaymentIntentID, err)
+	}
+	if len(supports) == 0 {
+		if !r.flagged[txn.PaymentIntentID] {
+			r.discrepancy(models.ReconciliationKindUnknownPayment, txn.PaymentIntentID, 0,
+				fmt.Sprintf("支援に紐づかない残高取引です（%s）", txn.ID))
+		}
+		return nil
+	}
+	support := supports[0]
+
+	reference := fmt.Sprintf("support:%d:stripe_fee", support.ID)
+	entry, err := r.store.Ledger().GetEntry(ctx, reference)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		fee := journal(models.JournalKindStripeFee, reference, support.ProjectID,
+			models.LedgerAccountStripeFees, models.LedgerAccountPlatformCash, txn.Fee)
+		if fee == nil {
+			return nil
+		}
+		fee.SupportID = &support.ID
+		if err := post(ctx, r.store, fee); err != nil {
+			return fmt.Errorf("reconcile: %w", err)
+		}
+		r.repair(models.ReconciliationKindFeeRecorded, txn.PaymentIntentID, support.ID,
+			fmt.Sprintf("決済手数料 %d円 を帳簿に記録しました", txn.Fee))
+	case err != nil:
+		return fmt.Errorf("reconcile: %s: %w", reference, err)
+	default:
+		var recorded int64
+		for _, line := range entry.Lines {
+			recorded += line.Debit
+		}
+		if recorded != txn.Fee {
+			r.discrepancy(models.ReconciliationKindFeeMismatch, txn.PaymentIntentID, support.ID,
+				fmt.Sprintf("帳簿の決済手数料 %d円 と残高取引 %s の手数料 %d円 が一致しません", recorded, txn.ID, txn.Fee))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package service はプロジェクト・支援・ユーザー・監査ログ・精算（主催者への送金と運営会社への直接支払い）・帳簿・Stripeとの照合・領収書のユースケースを提供します
+// HTTPハンドラー、Webhook、スケジューラー、管理CLIから同じ処理を呼び出せるよう、
+// gin やリクエストには依存せず、エラーは utils.APIError で返します
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// Services はアプリケーションで共有するサービスの集合
+type Services struct {
+	Projects *ProjectService
+	Supports *SupportService
+	Users    *UserService
+	Audit    *AuditService
+	Payouts  *PayoutService
+	Invoices *InvoiceService
+	Ledger   *LedgerService
+	// Reconciliation はStripeとの照合
+	Reconciliation *ReconciliationService
+	Receipts       *ReceiptService
+}
+
+// Deps はサービスが利用する外部の依存
+type Deps struct {
+	Payments PaymentGateway
+	Connect  ConnectGateway
+	// FeeRules は精算に適用する手数料のルール（空の場合は DefaultFeeRules）
+	FeeRules FeeRules
+	Notifier SupportNotifier
+	Hasher   *utils.PasswordHasher
+	Guard    *auth.LoginGuard
+	// AuditRetention は監査ログの保持期間（0の場合は削除しない）
+	AuditRetention time.Duration
+	// LoginAttemptRetention はログイン試行の記録の保持期間（0の場合は削除しない）
+	LoginAttemptRetention time.Duration
+	// ReceiptIssuer は領収書に記載する発行者
+	ReceiptIssuer ReceiptIssuer
+	// Now は現在時刻を返します（nilの場合は time.Now）
+	Now func() time.Time
+}
+
+// New はサービスを組み立てます
+func New(store repository.Store, deps Deps) *Services {
+	receipts := NewReceiptService(store, deps.ReceiptIssuer)
+	services := &Services{
+		Projects: NewProjectService(store),
+		Supports: NewSupportService(store, deps.Payments, deps.Notifier, receipts),
+		Users:    NewUserService(store, deps.Hasher, deps.Guard, deps.LoginAttemptRetention),
+		Audit:    NewAuditService(store, deps.AuditRetention),
+		Payouts:  NewPayoutService(store, deps.Connect, deps.FeeRules),
+		Invoices: NewInvoiceService(store),
+		Ledger:   NewLedgerService(store),
+		Receipts: receipts,
+	}
+	services.Reconciliation = NewReconciliationService(store, deps.Payments, services.Supports)
+	if deps.Now != nil {
+		services.Projects.now = deps.Now
+		services.Supports.now = deps.Now
+		services.Audit.now = deps.Now
+		services.Payouts.now = deps.Now
+		services.Invoices.now = deps.Now
+		services.Reconciliation.now = deps.Now
+		services.Receipts.now = deps.Now
+	}
+	return services
+}
+
+// mapError はリポジトリのエラーをAPIErrorに変換します
+// レコードが存在しない場合はnotFound、それ以外はinternalを返します
+func mapError(err error, notFound, internal *utils.APIError) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound
+	}
+	return internal
+}
+
+// requireAdmin は操作したユーザーが管理者でなければ detail を付けたエラーを返します
+func requireAdmin(ctx context.Context, store repository.Store, actorID uint, detail string) error {
+	actor, err := store.Users().Get(ctx, actorID)
+	if err != nil {
+		return mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	if actor.Role != models.UserRoleAdmin {
+		return utils.ErrForbidden.WithDetail(detail)
+	}
+	return nil
+}
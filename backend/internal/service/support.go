@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/metrics"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// SupportNotifier は支援の完了を接続中のクライアントへ通知します
+type SupportNotifier interface {
+	SupportCompleted(ctx context.Context, projectID, supportID uint) error
+}
+
+// CreateSupportInput は支援の作成内容
+type CreateSupportInput struct {
+	UserID    uint
+	ProjectID uint
+	Amount    int64
+	Message   string
+	// ReturnBaseURL は決済後に戻るフロントエンドのURL
+	ReturnBaseURL string
+}
+
+// CheckoutResult は支援の作成結果
+type CheckoutResult struct {
+	SupportID         uint
+	CheckoutSessionID string
+	CheckoutURL       string
+}
+
+// SupportService は支援と決済のユースケース
+type SupportService struct {
+	store    repository.Store
+	payments PaymentGateway
+	notifier SupportNotifier
+	// receipts は返金時に領収書を無効にして再発行します
+	receipts *ReceiptService
+	now      func() time.Time
+}
+
+// NewSupportService は新しいSupportServiceインスタンスを作成します
+func NewSupportService(store repository.Store, payments PaymentGateway, notifier SupportNotifier, receipts *ReceiptService) *SupportService {
+	return &SupportService{store: store, payments: payments, notifier: notifier, receipts: receipts, now: time.Now}
+}
+
+var errSupportNotFound = utils.ErrNotFound.WithDetail("支援情報が見つかりません")
+
+// completableStatuses は決済の完了で完了にできる支援の状態（完了済み・返金済みは含めない）
+var completableStatuses = []models.SupportStatus{
+	models.SupportStatusPending, models.SupportStatusFailed, models.SupportStatusCancelled,
+}
+
+// Create は決済待ちの支援を作成し、決済セッションを発行します
+func (s *SupportService) Create(ctx context.Context, input CreateSupportInput) (*CheckoutResult, error) {
+	project, err := s.store.Projects().Get(ctx, input.ProjectID)
+	if err != nil {
+		return nil, mapError(err, errProjectNotFound, utils.ErrInternalServer)
+	}
+	if project.Status != models.ProjectStatusActive {
+		return nil, utils.ErrInvalidInput.WithDetail("アクティブなプロジェクトのみ支援可能です")
+	}
+
+	support := &models.Support{
+		UserID:    input.UserID,
+		ProjectID: project.ID,
+		Amount:    input.Amount,
+		Message:   input.Message,
+		Status:    models.SupportStatusPending,
+	}
+	if err := s.store.Supports().Create(ctx, support); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("支援の作成に失敗しました")
+	}
+
+	session, err := s.payments.CreateCheckoutSession(ctx, CheckoutRequest{
+		ProjectID:    project.ID,
+		ProjectTitle: project.Title,
+		SupportID:    support.ID,
+		UserID:       input.UserID,
+		Amount:       input.Amount,
+		SuccessURL:   fmt.Sprintf("%s/payments/success?session_id={CHECKOUT_SESSION_ID}", input.ReturnBaseURL),
+		CancelURL:    fmt.Sprintf("%s/payments/cancel?project_id=%d", input.ReturnBaseURL, project.ID),
+	})
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("決済セッションの作成に失敗しました: " + err.Error())
+	}
+
+	if err := s.store.Supports().SetCheckoutSession(ctx, support.ID, session.ID); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("支援情報の更新に失敗しました")
+	}
+	metrics.SupportsCreated.Inc()
+
+	return &CheckoutResult{
+		SupportID:         support.ID,
+		CheckoutSessionID: session.ID,
+		CheckoutURL:       session.URL,
+	}, nil
+}
+
+// ListByProject はプロジェクトの支援一覧を返します
+func (s *SupportService) ListByProject(ctx context.Context, projectID uint) ([]models.Support, error) {
+	supports, err := s.store.Supports().ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("支援情報の取得に失敗しました")
+	}
+	return supports, nil
+}
+
+// GetForUser は支援者本人またはプロジェクトの主催者に支援を返します
+func (s *SupportService) GetForUser(ctx context.Context, id, userID uint) (*models.Support, error) {
+	support, err := s.store.Supports().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errSupportNotFound, utils.ErrInternalServer)
+	}
+	if userID != support.UserID && userID != support.Project.UserID {
+		return nil, utils.ErrForbidden.WithDetail("この情報にアクセスする権限がありません")
+	}
+	return support, nil
+}
+
+// GetByCheckoutSession は決済セッションIDに対応する支援を返します
+func (s *SupportService) GetByCheckoutSession(ctx context.Context, sessionID string) (*models.Support, error) {
+	if sessionID == "" {
+		return nil, utils.ErrInvalidInput.WithDetail("セッションIDが必要です")
+	}
+	support, err := s.store.Supports().GetByCheckoutSession(ctx, sessionID)
+	if err != nil {
+		return nil, mapError(err, utils.ErrNotFound.WithDetail("セッションIDに対応する支援情報が見つかりません"), utils.ErrInternalServer)
+	}
+	return support, nil
+}
+
+// CompletePayment は支援を完了にし、接続中のクライアントへ通知します
+// 支援の完了と同じトランザクションで、支援金の預り金とStripeの決済手数料を帳簿に記録します
+// Webhookの再送や通知の順序の入れ替わりに備え、完了済み・返金済みの支援に対しては何もしません
+func (s *SupportService) CompletePayment(ctx context.Context, supportID uint, paymentIntentID string) error {
+	support, err := s.store.Supports().Get(ctx, supportID)
+	if err != nil {
+		return fmt.Errorf("support %d: %w", supportID, err)
+	}
+	if support.Status == models.SupportStatusCompleted {
+		return nil
+	}
+
+	// 決済手数料を取得できない場合も支援は完了にする（帳簿の照合で検出する）
+	fee, err := s.payments.ProcessingFee(ctx, paymentIntentID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("決済手数料を取得できません", "support_id", support.ID, "payment_intent", paymentIntentID, "error", err)
+	}
+	completed := journal(models.JournalKindSupportCompleted, fmt.Sprintf("support:%d:completed", support.ID), support.ProjectID,
+		models.LedgerAccountPlatformCash, models.LedgerAccountProjectEscrow, support.Amount)
+	stripeFee := journal(models.JournalKindStripeFee, fmt.Sprintf("support:%d:stripe_fee", support.ID), support.ProjectID,
+		models.LedgerAccountStripeFees, models.LedgerAccountPlatformCash, fee)
+	for _, entry := range []*models.JournalEntry{completed, stripeFee} {
+		if entry != nil {
+			entry.SupportID = &support.ID
+		}
+	}
+
+	skipped := false
+	err = s.store.Transaction(ctx, func(tx repository.Store) error {
+		// 状態の確認と帳簿への記録を同じトランザクションで行い、並行する通知で二重に完了しないようにする
+		err := tx.Supports().UpdatePayment(ctx, support.ID, completableStatuses, models.SupportStatusCompleted, paymentIntentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			skipped = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return post(ctx, tx, completed, stripeFee)
+	})
+	if err != nil {
+		return fmt.Errorf("support %d: %w", supportID, err)
+	}
+	if skipped {
+		logging.FromContext(ctx).Info("完了にできない状態の支援のため更新しません", "support_id", support.ID)
+		return nil
+	}
+	metrics.SupportsCompleted.Inc()
+	metrics.CollectedYen.Add(float64(support.Amount))
+
+	if err := s.notifier.SupportCompleted(ctx, support.ProjectID, support.ID); err != nil {
+		logging.FromContext(ctx).Error("支援完了の通知に失敗しました", "support_id", support.ID, "error", err)
+	}
+
+	logging.FromContext(ctx).Info("支援を完了にしました", "support_id", support.ID, "amount", support.Amount)
+	return nil
+}
+
+// CompletePaymentIntent は決済に紐づく最初の有効な支援を完了にします
+func (s *SupportService) CompletePaymentIntent(ctx context.Context, paymentIntentID string) error {
+	ids, err := s.payments.SupportIDsByPaymentIntent(ctx, paymentIntentID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err := s.CompletePayment(ctx, id, paymentIntentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).Warn("支援が見つかりません", "support_id", id)
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+// FailPaymentIntent は決済に紐づく決済待ちの支援を失敗にします
+func (s *SupportService) FailPaymentIntent(ctx context.Context, paymentIntentID string) error {
+	ids, err := s.payments.SupportIDsByPaymentIntent(ctx, paymentIntentID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err := s.store.Supports().UpdatePayment(ctx, id, []models.SupportStatus{models.SupportStatusPending}, models.SupportStatusFailed, paymentIntentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			logging.FromContext(ctx).Info("決済待ちではない支援のため失敗にしません", "support_id", id)
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("支援の更新に失敗しました", "support_id", id, "error", err)
+			continue
+		}
+		metrics.SupportsFailed.Inc()
+		logging.FromContext(ctx).Info("支援を失敗にしました", "support_id", id)
+	}
+	return nil
+}
+
+// RefundPaymentIntent はStripeで返金された決済の返金額を支援に反映します（charge.refunded）
+// refunded は決済の返金額の累計で、全額返金された支援は返金済みになります
+// 前回の通知からの増分を同じトランザクションで帳簿に記録し、発行済みの領収書を返金後の金額で再発行します
+func (s *SupportService) RefundPaymentIntent(ctx context.Context, paymentIntentID string, refunded int64) error {
+	var n int64
+	err := s.store.Transaction(ctx, func(tx repository.Store) error {
+		supports, err := tx.Supports().LockPaidByPaymentIntent(ctx, paymentIntentID)
+		if err != nil {
+			return err
+		}
+		if n, err = tx.Supports().SetRefundedAmount(ctx, paymentIntentID, refunded); err != nil {
+			return err
+		}
+		for _, support := range supports {
+			total := min(refunded, support.Amount)
+			entry := journal(models.JournalKindRefund, fmt.Sprintf("support:%d:refund:%d", support.ID, total), support.ProjectID,
+				models.LedgerAccountProjectEscrow, models.LedgerAccountPlatformCash, total-min(support.RefundedAmount, support.Amount))
+			if entry != nil {
+				entry.SupportID = &support.ID
+			}
+			if err := post(ctx, tx, entry); err != nil {
+				return err
+			}
+			support.RefundedAmount = refunded
+			if err := s.receipts.reviseForRefund(ctx, tx, &support); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		logging.FromContext(ctx).Warn("返金された決済の支援が見つかりません", "payment_intent", paymentIntentID)
+		return nil
+	}
+	logging.FromContext(ctx).Info("支援の返金を記録しました", "payment_intent", paymentIntentID, "refunded", refunded)
+	return nil
+}
+
+// Dispute はStripeから通知されたチャージバック
+type Dispute struct {
+	ID              string
+	PaymentIntentID string
+	Amount          int64
+	// Fee は引き落とし・戻りに伴うチャージバック手数料（戻りの場合は返還された手数料を負の値で表します）
+	Fee int64
+}
+
+// WithdrawDispute はチャージバックで引き落とされた額と手数料を帳簿に記録します（charge.dispute.funds_withdrawn）
+func (s *SupportService) WithdrawDispute(ctx context.Context, dispute Dispute) error {
+	return s.recordDispute(ctx, dispute, models.JournalKindDisputeWithdrawn, 1)
+}
+
+// ReinstateDispute は勝訴して戻ったチャージバックの額を帳簿に記録します（charge.dispute.funds_reinstated）
+func (s *SupportService) ReinstateDispute(ctx context.Context, dispute Dispute) error {
+	return s.recordDispute(ctx, dispute, models.JournalKindDisputeReinstated, -1)
+}
+
+// recordDispute はチャージバックの仕訳を記録します（sign が負の場合は戻りとして逆仕訳）
+func (s *SupportService) recordDispute(ctx context.Context, dispute Dispute, kind models.JournalKind, sign int64) error {
+	return s.store.Transaction(ctx, func(tx repository.Store) error {
+		supports, err := tx.Supports().LockPaidByPaymentIntent(ctx, dispute.PaymentIntentID)
+		if err != nil {
+			return err
+		}
+		if len(supports) == 0 {
+			logging.FromContext(ctx).Warn("チャージバックの支援が見つかりません", "dispute", dispute.ID, "payment_intent", dispute.PaymentIntentID)
+			return nil
+		}
+		support := supports[0]
+
+		funds := journal(kind, fmt.Sprintf("dispute:%s:%s", dispute.ID, kind), support.ProjectID,
+			models.LedgerAccountProjectEscrow, models.LedgerAccountPlatformCash, sign*dispute.Amount)
+		fee := journal(models.JournalKindStripeFee, fmt.Sprintf("dispute:%s:%s:fee", dispute.ID, kind), support.ProjectID,
+			models.LedgerAccountStripeFees, models.LedgerAccountPlatformCash, dispute.Fee)
+		for _, entry := range []*models.JournalEntry{funds, fee} {
+			if entry != nil {
+				entry.SupportID = &support.ID
+			}
+		}
+		if err := post(ctx, tx, funds, fee); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("チャージバックを帳簿に記録しました", "dispute", dispute.ID, "kind", kind,
+			"support_id", support.ID, "amount", dispute.Amount, "fee", dispute.Fee)
+		return nil
+	})
+}
+
+// CancelStalePending は一定時間が経過しても決済されない支援を取り消し、件数を返します
+func (s *SupportService) CancelStalePending(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return s.store.Supports().CancelPendingBefore(ctx, s.now().Add(-olderThan))
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/audit"
+	"github.com/masvc/oshiome_go/backend/internal/auth"
+	"github.com/masvc/oshiome_go/backend/internal/i18n"
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+	"github.com/masvc/oshiome_go/backend/internal/models"
+	"github.com/masvc/oshiome_go/backend/internal/repository"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+)
+
+// RegisterInput はユーザー登録の内容
+type RegisterInput struct {
+	Name     string
+	Email    string
+	Password string
+	// Locale は登録時のリクエストのロケール（メールの言語に使用）
+	Locale string
+}
+
+// Credentials はログインの内容
+type Credentials struct {
+	Email     string
+	Password  string
+	IP        string
+	UserAgent string
+}
+
+// ProfileInput はプロフィールの更新内容（空の項目は更新しない）
+type ProfileInput struct {
+	Name            string
+	Bio             string
+	ProfileImageURL string
+	Locale          string
+}
+
+// UserService はユーザーのユースケース
+type UserService struct {
+	store  repository.Store
+	hasher *utils.PasswordHasher
+	guard  *auth.LoginGuard
+	// attemptRetention はログイン試行の記録の保持期間（0の場合は削除しない）
+	attemptRetention time.Duration
+
+	// ユーザーが存在しない場合もbcryptの比較を行い、応答時間からメールアドレスの登録有無を推測されないようにする
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+}
+
+// NewUserService は新しいUserServiceインスタンスを作成します
+func NewUserService(store repository.Store, hasher *utils.PasswordHasher, guard *auth.LoginGuard, attemptRetention time.Duration) *UserService {
+	return &UserService{store: store, hasher: hasher, guard: guard, attemptRetention: attemptRetention}
+}
+
+// PruneLoginAttempts は保持期間を過ぎたログイン試行の記録を削除し、件数を返します
+func (s *UserService) PruneLoginAttempts(ctx context.Context) (int64, error) {
+	return s.guard.PruneAttempts(ctx, s.attemptRetention)
+}
+
+var errUserNotFound = utils.ErrNotFound.WithDetail(utils.ErrMsgUserNotFound)
+
+// validUserRoles はユーザーのロールとして有効な値
+var validUserRoles = map[models.UserRole]bool{
+	models.UserRoleUser:     true,
+	models.UserRoleAdmin:    true,
+	models.UserRoleAgency:   true,
+	models.UserRoleOperator: true,
+}
+
+// Register はユーザーを登録します
+func (s *UserService) Register(ctx context.Context, input RegisterInput) (*models.User, error) {
+	hashedPassword, err := s.hasher.Hash(input.Password)
+	if err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("パスワードのハッシュ化に失敗しました")
+	}
+
+	user := &models.User{
+		Name:            input.Name,
+		Email:           input.Email,
+		Password:        hashedPassword,
+		Bio:             "よろしくお願いします！",
+		ProfileImageURL: fmt.Sprintf("https://api.dicebear.com/7.x/adventurer/svg?seed=%s", input.Email),
+		Locale:          input.Locale,
+	}
+	if err := s.store.Users().Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, utils.ErrDuplicateEmail
+		}
+		return nil, utils.ErrInternalServer.WithDetail(utils.ErrMsgUserCreateFail)
+	}
+	return user, nil
+}
+
+// Authenticate はメールアドレスとパスワードを検証します
+// 試行が制限されている場合は *auth.Throttled を返します
+// 二要素認証が必要なユーザーでは失敗回数をリセットしないため、成功の記録は呼び出し側で行います
+func (s *UserService) Authenticate(ctx context.Context, cred Credentials) (*models.User, error) {
+	if throttled := s.guard.CheckIP(ctx, cred.IP); throttled != nil {
+		return nil, throttled
+	}
+
+	user, err := s.store.Users().GetByEmail(ctx, cred.Email)
+	if err != nil {
+		s.compareDummyPassword(cred.Password)
+		s.guard.RecordFailure(ctx, nil, cred.Email, cred.IP, cred.UserAgent)
+		return nil, utils.ErrInvalidCredentials
+	}
+
+	if throttled := s.guard.CheckAccount(user); throttled != nil {
+		return nil, throttled
+	}
+
+	if !utils.CheckPasswordHash(cred.Password, user.Password) {
+		s.guard.RecordFailure(ctx, user, cred.Email, cred.IP, cred.UserAgent)
+		return nil, utils.ErrInvalidCredentials
+	}
+
+	// bcryptコストの設定が変わっていれば新しいコストで再ハッシュ化
+	if s.hasher.NeedsRehash(user.Password) {
+		if hashed, err := s.hasher.Hash(cred.Password); err == nil {
+			// ハッシュの再計算はパスワードの変更ではないため監査ログに記録しない
+			if err := s.store.Users().UpdatePassword(audit.SkipTracking(ctx), user.ID, hashed); err != nil {
+				logging.FromContext(ctx).Error("パスワードの再ハッシュ化に失敗しました", "user_id", user.ID, "error", err)
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func (s *UserService) compareDummyPassword(password string) {
+	s.dummyPasswordHashOnce.Do(func() {
+		s.dummyPasswordHash, _ = s.hasher.Hash("oshiome-dummy-password")
+	})
+	utils.CheckPasswordHash(password, s.dummyPasswordHash)
+}
+
+// Get はユーザーを返します
+func (s *UserService) Get(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.store.Users().Get(ctx, id)
+	if err != nil {
+		return nil, mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	return user, nil
+}
+
+// UpdateProfile は本人のプロフィールを更新します
+func (s *UserService) UpdateProfile(ctx context.Context, actorID, id uint, input ProfileInput) (*models.User, error) {
+	// 自分以外のユーザー情報は更新できない
+	if actorID != id {
+		return nil, utils.ErrForbidden.WithDetail("他のユーザーの情報は更新できません")
+	}
+
+	if input.Locale != "" && !i18n.IsSupported(input.Locale) {
+		return nil, utils.ErrInvalidInput.WithDetail("対応していない言語です")
+	}
+
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := models.User{
+		Name:            input.Name,
+		Bio:             input.Bio,
+		ProfileImageURL: input.ProfileImageURL,
+		Locale:          input.Locale,
+	}
+	if err := s.store.Users().Update(audit.WithActorID(ctx, actorID), user, updates); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("ユーザー情報の更新に失敗しました")
+	}
+	return user, nil
+}
+
+// SetRole はメールアドレスで指定したユーザーのロールを変更します（管理者による操作）
+func (s *UserService) SetRole(ctx context.Context, email string, role models.UserRole) (*models.User, error) {
+	if !validUserRoles[role] {
+		return nil, utils.ErrInvalidInput.WithDetail("無効なロールです")
+	}
+
+	user, err := s.store.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return nil, mapError(err, errUserNotFound, utils.ErrInternalServer)
+	}
+	if err := s.store.Users().UpdateRole(ctx, user.ID, role); err != nil {
+		return nil, utils.ErrInternalServer.WithDetail("ユーザー情報の更新に失敗しました")
+	}
+	user.Role = role
+	return user, nil
+}
@@ -0,0 +1,356 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/mail"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+)
+
+// Clock はテストから進められる時計
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock は指定した時刻で止まった時計を作成します
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now は現在時刻を返します
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance は時計を進めます
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set は時計を指定した時刻に合わせます
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// FakePayments は決済セッションと支払いを記録するだけの service.PaymentGateway
+type FakePayments struct {
+	mu       sync.Mutex
+	seq      int
+	sessions map[string]service.CheckoutRequest
+	// payments は決済ID（payment_intent）ごとの支払い
+	payments map[string]*fakePayment
+	// Err を設定するとセッションの作成が失敗します
+	Err error
+	// Now は支払い・返金の時刻を返します（nilの場合は time.Now）
+	Now func() time.Time
+}
+
+// fakePayment は決済セッションの支払いの状態と返金
+type fakePayment struct {
+	sessionID string
+	status    service.PaymentStatus
+	created   time.Time
+	paidAt    time.Time
+	refunds   []service.BalanceTransaction
+}
+
+// NewFakePayments は新しいFakePaymentsインスタンスを作成します
+func NewFakePayments() *FakePayments {
+	return &FakePayments{
+		sessions: make(map[string]service.CheckoutRequest),
+		payments: make(map[string]*fakePayment),
+	}
+}
+
+func (p *FakePayments) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *FakePayments) CreateCheckoutSession(ctx context.Context, req service.CheckoutRequest) (*service.CheckoutSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	p.seq++
+	id := fmt.Sprintf("cs_test_%d", p.seq)
+	p.sessions[id] = req
+	return &service.CheckoutSession{ID: id, URL: "https://checkout.example.com/" + id}, nil
+}
+
+func (p *FakePayments) SupportIDsByPaymentIntent(ctx context.Context, paymentIntentID string) ([]uint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payment, ok := p.payments[paymentIntentID]
+	if !ok {
+		return nil, nil
+	}
+	return []uint{p.sessions[payment.sessionID].SupportID}, nil
+}
+
+// ProcessingFeeRate はFakePaymentsが返す決済手数料の料率（ベーシスポイント。Stripeの国内カードと同じ3.6%）
+const ProcessingFeeRate = 360
+
+// ProcessingFee は支払い済みの決済セッションの額に ProcessingFeeRate を掛けた額（1円未満切り捨て）を返します
+func (p *FakePayments) ProcessingFee(ctx context.Context, paymentIntentID string) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payment, ok := p.payments[paymentIntentID]
+	if !ok {
+		return 0, fmt.Errorf("testutil: unknown payment intent %q", paymentIntentID)
+	}
+	return p.sessions[payment.sessionID].Amount * ProcessingFeeRate / 10000, nil
+}
+
+// ListPayments は期間内に作成された支払い・失敗した決済を返します
+func (p *FakePayments) ListPayments(ctx context.Context, from, to time.Time) ([]service.Payment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var payments []service.Payment
+	for id, payment := range p.payments {
+		if !payment.created.Before(from) && payment.created.Before(to) {
+			payments = append(payments, p.payment(id, payment))
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].ID < payments[j].ID })
+	return payments, nil
+}
+
+func (p *FakePayments) GetPayment(ctx context.Context, paymentIntentID string) (*service.Payment, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payment, ok := p.payments[paymentIntentID]
+	if !ok {
+		return nil, fmt.Errorf("testutil: unknown payment intent %q", paymentIntentID)
+	}
+	result := p.payment(paymentIntentID, payment)
+	return &result, nil
+}
+
+func (p *FakePayments) payment(id string, payment *fakePayment) service.Payment {
+	req := p.sessions[payment.sessionID]
+	result := service.Payment{
+		ID:         id,
+		SupportIDs: []uint{req.SupportID},
+		Status:     payment.status,
+		Amount:     req.Amount,
+		Created:    payment.created,
+	}
+	for _, refund := range payment.refunds {
+		result.Refunded -= refund.Amount
+	}
+	return result
+}
+
+// ListBalanceTransactions は期間内の支払い（ProcessingFee の手数料つき）と返金の残高取引を返します
+func (p *FakePayments) ListBalanceTransactions(ctx context.Context, from, to time.Time) ([]service.BalanceTransaction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var txns []service.BalanceTransaction
+	for id, payment := range p.payments {
+		if payment.status == service.PaymentStatusSucceeded {
+			amount := p.sessions[payment.sessionID].Amount
+			txns = append(txns, service.BalanceTransaction{
+				ID: "txn_" + payment.sessionID, Type: service.BalanceTransactionCharge, PaymentIntentID: id,
+				Amount: amount, Fee: amount * ProcessingFeeRate / 10000, Created: payment.paidAt,
+			})
+		}
+		txns = append(txns, payment.refunds...)
+	}
+
+	var inWindow []service.BalanceTransaction
+	for _, txn := range txns {
+		if !txn.Created.Before(from) && txn.Created.Before(to) {
+			inWindow = append(inWindow, txn)
+		}
+	}
+	sort.Slice(inWindow, func(i, j int) bool { return inWindow[i].ID < inWindow[j].ID })
+	return inWindow, nil
+}
+
+// Session は作成された決済セッションの内容を返します
+func (p *FakePayments) Session(id string) (service.CheckoutRequest, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	req, ok := p.sessions[id]
+	return req, ok
+}
+
+// Sessions は作成された決済セッションの数を返します
+func (p *FakePayments) Sessions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// Pay は決済セッションを支払い済みにし、決済IDを返します（Webhookは送信しません）
+func (p *FakePayments) Pay(sessionID string) (string, error) {
+	_, paymentIntentID, err := p.pay(sessionID, service.PaymentStatusSucceeded)
+	return paymentIntentID, err
+}
+
+// Fail は決済セッションの支払いを失敗にし、決済IDを返します（Webhookは送信しません）
+func (p *FakePayments) Fail(sessionID string) (string, error) {
+	_, paymentIntentID, err := p.pay(sessionID, service.PaymentStatusFailed)
+	return paymentIntentID, err
+}
+
+// Refund は支払い済みの決済を返金し、返金額の累計を返します（Webhookは送信しません）
+func (p *FakePayments) Refund(paymentIntentID string, amount int64) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	payment, ok := p.payments[paymentIntentID]
+	if !ok || payment.status != service.PaymentStatusSucceeded {
+		return 0, fmt.Errorf("testutil: payment intent %q is not paid", paymentIntentID)
+	}
+	payment.refunds = append(payment.refunds, service.BalanceTransaction{
+		ID:   fmt.Sprintf("txn_%s_refund_%d", payment.sessionID, len(payment.refunds)+1),
+		Type: service.BalanceTransactionRefund, PaymentIntentID: paymentIntentID, Amount: -amount, Created: p.now(),
+	})
+	return p.payment(paymentIntentID, payment).Refunded, nil
+}
+
+// pay は決済セッションの支払いの状態を記録し、決済IDを返します
+func (p *FakePayments) pay(sessionID string, status service.PaymentStatus) (service.CheckoutRequest, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	req, ok := p.sessions[sessionID]
+	if !ok {
+		return req, "", fmt.Errorf("testutil: unknown checkout session %q", sessionID)
+	}
+	paymentIntentID := "pi_" + sessionID
+	payment, ok := p.payments[paymentIntentID]
+	if !ok {
+		payment = &fakePayment{sessionID: sessionID, created: p.now()}
+		p.payments[paymentIntentID] = payment
+	}
+	payment.status = status
+	if status == service.PaymentStatusSucceeded {
+		payment.paidAt = p.now()
+	}
+	return req, paymentIntentID, nil
+}
+
+// Mailer は送信したメールを記録する mail.Mailer
+type Mailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *Mailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages は送信したメールを返します
+func (m *Mailer) Messages() []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mail.Message(nil), m.messages...)
+}
+
+// FakeConnect は送金先のアカウントと送金を記録するだけの service.ConnectGateway
+type FakeConnect struct {
+	mu        sync.Mutex
+	seq       int
+	accounts  map[string]*service.ConnectAccount
+	transfers []service.PayoutRequest
+	payouts   []service.PayoutRequest
+	// TransferErr / PayoutErr を設定すると送金・入金の作成が失敗します
+	TransferErr error
+	PayoutErr   error
+}
+
+// NewFakeConnect は新しいFakeConnectインスタンスを作成します
+func NewFakeConnect() *FakeConnect {
+	return &FakeConnect{accounts: make(map[string]*service.ConnectAccount)}
+}
+
+func (f *FakeConnect) CreateAccount(ctx context.Context, userID uint, email string) (*service.ConnectAccount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	a := &service.ConnectAccount{ID: fmt.Sprintf("acct_test_%d", f.seq)}
+	f.accounts[a.ID] = a
+	copied := *a
+	return &copied, nil
+}
+
+func (f *FakeConnect) GetAccount(ctx context.Context, accountID string) (*service.ConnectAccount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a, ok := f.accounts[accountID]
+	if !ok {
+		return nil, fmt.Errorf("testutil: unknown connect account %q", accountID)
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (f *FakeConnect) CreateOnboardingLink(ctx context.Context, accountID, refreshURL, returnURL string) (string, error) {
+	return "https://connect.example.com/setup/" + accountID, nil
+}
+
+func (f *FakeConnect) Transfer(ctx context.Context, req service.PayoutRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.TransferErr != nil {
+		return "", f.TransferErr
+	}
+	f.transfers = append(f.transfers, req)
+	return fmt.Sprintf("tr_test_%d", len(f.transfers)), nil
+}
+
+func (f *FakeConnect) Payout(ctx context.Context, req service.PayoutRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.PayoutErr != nil {
+		return "", f.PayoutErr
+	}
+	f.payouts = append(f.payouts, req)
+	return fmt.Sprintf("po_test_%d", len(f.payouts)), nil
+}
+
+// Complete はアカウントの本人確認・口座登録を完了した状態にします（Webhookは送信しません）
+func (f *FakeConnect) Complete(accountID string) *service.ConnectAccount {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a, ok := f.accounts[accountID]
+	if !ok {
+		return nil
+	}
+	a.DetailsSubmitted = true
+	a.PayoutsEnabled = true
+	copied := *a
+	return &copied
+}
+
+// Transfers は作成された送金を返します
+func (f *FakeConnect) Transfers() []service.PayoutRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]service.PayoutRequest(nil), f.transfers...)
+}
+
+// Payouts は作成された入金を返します
+func (f *FakeConnect) Payouts() []service.PayoutRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]service.PayoutRequest(nil), f.payouts...)
+}
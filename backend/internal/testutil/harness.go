@@ -0,0 +1,271 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+	"github.com/masvc/oshiome_go/backend/internal/server"
+	"github.com/masvc/oshiome_go/backend/internal/service"
+	"github.com/masvc/oshiome_go/backend/internal/utils"
+	"github.com/stripe/stripe-go/v72/webhook"
+	"gorm.io/gorm"
+)
+
+// WebhookSecret はテスト用のWebhook署名シークレット
+const WebhookSecret = "whsec_test"
+
+// ConnectWebhookSecret はテスト用のConnect Webhook署名シークレット
+const ConnectWebhookSecret = "whsec_test_connect"
+
+// Harness は組み立て済みのサーバーとテスト用の依存
+type Harness struct {
+	t        testing.TB
+	Config   *config.Config
+	DB       *gorm.DB
+	Server   *server.Server
+	Clock    *Clock
+	Payments *FakePayments
+	Connect  *FakeConnect
+	Mailer   *Mailer
+}
+
+// New はテスト専用のデータベースでサーバーを組み立てます
+// configure で設定を変更できます。PostgreSQLを利用できない場合はテストをスキップします
+func New(t testing.TB, configure ...func(cfg *config.Config)) *Harness {
+	t.Helper()
+
+	h := &Harness{
+		t:        t,
+		Config:   Config(),
+		DB:       NewDB(t),
+		Clock:    NewClock(time.Now()),
+		Payments: NewFakePayments(),
+		Connect:  NewFakeConnect(),
+		Mailer:   &Mailer{},
+	}
+	h.Payments.Now = h.Clock.Now
+	for _, fn := range configure {
+		fn(h.Config)
+	}
+	srv, err := server.New(server.Deps{
+		Config:   h.Config,
+		DB:       h.DB,
+		Mailer:   h.Mailer,
+		Payments: h.Payments,
+		Connect:  h.Connect,
+		Now:      h.Clock.Now,
+	})
+	if err != nil {
+		t.Fatalf("サーバーの組み立てに失敗しました: %v", err)
+	}
+	h.Server = srv
+	return h
+}
+
+// Config はテスト用の設定を返します
+func Config() *config.Config {
+	cfg := config.Default()
+	cfg.Env = config.EnvTest
+	cfg.Auth.JWTSecret = "test-jwt-secret-0123456789abcdef0123456789"
+	cfg.Auth.BcryptCost = 4
+	cfg.Stripe.SecretKey = "sk_test_dummy"
+	cfg.Stripe.WebhookSecret = WebhookSecret
+	cfg.Stripe.ConnectWebhookSecret = ConnectWebhookSecret
+	cfg.Receipt.RegistrationNumber = "T1234567890123"
+	// レート制限はテストの妨げにならない値にする（個別のテストで上書き可）
+	cfg.RateLimit.Policies = "global-ip:ip::100000/1m"
+	return cfg
+}
+
+// Response は記録されたHTTPレスポンス
+type Response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Decode はレスポンスの data フィールドを v にデコードします
+func (r *Response) Decode(t testing.TB, v interface{}) {
+	t.Helper()
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(r.Body, &envelope); err != nil {
+		t.Fatalf("レスポンスのデコードに失敗しました: %v\n%s", err, r.Body)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("data のデコードに失敗しました: %v\n%s", err, r.Body)
+	}
+}
+
+// APIError はエラーレスポンスをデコードして返します
+func (r *Response) APIError() *utils.APIError {
+	var apiErr utils.APIError
+	json.Unmarshal(r.Body, &apiErr)
+	return &apiErr
+}
+
+// ErrorCode はエラーレスポンスのコードを返します
+func (r *Response) ErrorCode() string {
+	return r.APIError().Code
+}
+
+// Expect はステータスコードを検証します
+func (r *Response) Expect(t testing.TB, code int) *Response {
+	t.Helper()
+	if r.Code != code {
+		t.Fatalf("ステータスコード: got %d, want %d\n%s", r.Code, code, r.Body)
+	}
+	return r
+}
+
+// Do はリクエストを送信します。bodyはJSONにエンコードし、tokenが空でなければBearerトークンを付けます
+func (h *Harness) Do(method, path string, body interface{}, token string) *Response {
+	h.t.Helper()
+	return h.Serve(h.NewRequest(method, path, body, token))
+}
+
+// NewRequest はDoと同じ内容のリクエストを作成します（ヘッダーを追加する場合に使用）
+func (h *Harness) NewRequest(method, path string, body interface{}, token string) *http.Request {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// Serve はリクエストをサーバーのハンドラーで処理します
+func (h *Harness) Serve(req *http.Request) *Response {
+	rec := httptest.NewRecorder()
+	h.Server.Handler().ServeHTTP(rec, req)
+	return &Response{Code: rec.Code, Header: rec.Header(), Body: rec.Body.Bytes()}
+}
+
+// User は登録済みのテストユーザー
+type User struct {
+	ID       uint
+	Email    string
+	Password string
+	Token    string
+}
+
+// Register はユーザーを登録し、発行されたトークンとともに返します
+func (h *Harness) Register(name string) *User {
+	h.t.Helper()
+
+	u := &User{Email: fmt.Sprintf("%s-%s@example.com", name, randomHex(4)), Password: "password123"}
+	res := h.Do(http.MethodPost, "/api/v1/register", map[string]string{
+		"name":     name,
+		"email":    u.Email,
+		"password": u.Password,
+	}, "").Expect(h.t, http.StatusCreated)
+
+	var data struct {
+		User struct {
+			ID uint `json:"id"`
+		} `json:"user"`
+		Token string `json:"token"`
+	}
+	res.Decode(h.t, &data)
+	u.ID = data.User.ID
+	u.Token = data.Token
+	return u
+}
+
+// Login はログインして発行されたトークンを返します
+func (h *Harness) Login(email, password string) string {
+	h.t.Helper()
+
+	res := h.Do(http.MethodPost, "/api/v1/login", map[string]string{
+		"email":    email,
+		"password": password,
+	}, "").Expect(h.t, http.StatusOK)
+
+	var data struct {
+		Token string `json:"token"`
+	}
+	res.Decode(h.t, &data)
+	return data.Token
+}
+
+// CompleteCheckout は決済セッションの支払いを完了し、署名付きの
+// checkout.session.completed イベントを /api/v1/webhook へ送信します
+func (h *Harness) CompleteCheckout(sessionID string) *Response {
+	h.t.Helper()
+
+	req, paymentIntentID, err := h.Payments.pay(sessionID, service.PaymentStatusSucceeded)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return h.SendWebhook("checkout.session.completed", map[string]interface{}{
+		"id":             sessionID,
+		"object":         "checkout.session",
+		"mode":           "payment",
+		"payment_status": "paid",
+		"payment_intent": paymentIntentID,
+		"metadata": map[string]string{
+			"support_id": strconv.FormatUint(uint64(req.SupportID), 10),
+			"project_id": strconv.FormatUint(uint64(req.ProjectID), 10),
+			"user_id":    strconv.FormatUint(uint64(req.UserID), 10),
+		},
+	})
+}
+
+// SendWebhook はStripe形式で署名したイベントを /api/v1/webhook へ送信します
+// 署名の有効期限はStripeのライブラリが実時間で検証するため、時計の操作には影響されません
+func (h *Harness) SendWebhook(eventType string, object interface{}) *Response {
+	h.t.Helper()
+	return h.sendEvent("/api/v1/webhook", WebhookSecret, map[string]interface{}{
+		"type": eventType,
+		"data": map[string]interface{}{"object": object},
+	})
+}
+
+// SendConnectWebhook はConnectアカウントのイベントを /api/v1/webhook/connect へ送信します
+func (h *Harness) SendConnectWebhook(eventType, accountID string, object interface{}) *Response {
+	h.t.Helper()
+	return h.sendEvent("/api/v1/webhook/connect", ConnectWebhookSecret, map[string]interface{}{
+		"type":    eventType,
+		"account": accountID,
+		"data":    map[string]interface{}{"object": object},
+	})
+}
+
+func (h *Harness) sendEvent(path, secret string, event map[string]interface{}) *Response {
+	h.t.Helper()
+
+	event["id"] = "evt_test_" + randomHex(8)
+	event["object"] = "event"
+	payload, err := json.Marshal(event)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+
+	now := time.Now()
+	signature := webhook.ComputeSignature(now, payload, secret)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", fmt.Sprintf("t=%d,v1=%x", now.Unix(), signature))
+	return h.Serve(req)
+}
@@ -0,0 +1,226 @@
+// Package testutil はHTTPの統合テストで使用するハーネスを提供します
+//
+// テストごとに使い捨てのPostgreSQLスキーマを作成してマイグレーションを適用し、
+// cmd/main.go と同じ組み立て（server.New）でルーターを作成します
+// 決済と時刻は偽物に差し替えるため、Stripeや実時間には依存しません
+//
+// 接続先は次の順に決まります
+//  1. TEST_DATABASE_URL（既存のPostgreSQL。スキーマはテストごとに作成・削除）
+//  2. PATH上の initdb / pg_ctl（一時ディレクトリにクラスタを作成）
+//
+// どちらも利用できない場合、データベースを使うテストはスキップされます
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/masvc/oshiome_go/backend/internal/db/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// baseDSN はテスト用PostgreSQLへの接続文字列（空の場合はデータベースを使うテストをスキップ）
+var (
+	baseDSN     string
+	skipReason  = "testutil.RunMain が TestMain から呼ばれていません"
+	stopCluster func()
+)
+
+// RunMain はテスト用のPostgreSQLを用意してテストを実行します
+// パッケージの TestMain から呼び出してください
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.RunMain(m)) }
+func RunMain(m *testing.M) int {
+	gin.SetMode(gin.TestMode)
+
+	if err := setupPostgres(); err != nil {
+		skipReason = err.Error()
+	}
+	defer func() {
+		if stopCluster != nil {
+			stopCluster()
+		}
+	}()
+	return m.Run()
+}
+
+// setupPostgres はTEST_DATABASE_URLまたはローカルのPostgreSQLバイナリから接続先を決めます
+func setupPostgres() error {
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		baseDSN = dsn
+		return nil
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		return errors.New("TEST_DATABASE_URL が未設定で、initdb も見つかりません")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		return errors.New("TEST_DATABASE_URL が未設定で、pg_ctl も見つかりません")
+	}
+	if os.Geteuid() == 0 {
+		return errors.New("root では initdb を実行できません。TEST_DATABASE_URL を設定してください")
+	}
+
+	dir, err := os.MkdirTemp("", "oshiome-pg-")
+	if err != nil {
+		return err
+	}
+	dataDir := filepath.Join(dir, "data")
+	if out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "--auth=trust", "--encoding=UTF8", "--no-sync").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("initdb に失敗しました: %v\n%s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, dir)
+	if out, err := exec.Command(pgCtl, "-D", dataDir, "-o", opts, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("PostgreSQLの起動に失敗しました: %v\n%s", err, out)
+	}
+
+	stopCluster = func() {
+		if out, err := exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "-w", "stop").CombinedOutput(); err != nil {
+			log.Printf("PostgreSQLの停止に失敗しました: %v\n%s", err, out)
+		}
+		os.RemoveAll(dir)
+	}
+	baseDSN = fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres sslmode=disable", port)
+	return nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// DSN はテスト用PostgreSQLの接続文字列を返します
+// LISTEN/NOTIFY のようにスキーマに依存しない接続（リアルタイム配信の購読など）に使用します
+func DSN(t testing.TB) string {
+	t.Helper()
+	if baseDSN == "" {
+		t.Skip("PostgreSQLを利用できないためスキップします: " + skipReason)
+	}
+	return baseDSN
+}
+
+// NewDB はテスト専用のスキーマを作成し、マイグレーションを適用した接続を返します
+// スキーマはテストの終了時に削除されます
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	database := NewSchema(t, "")
+	sqlDB, err := database.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("マイグレーションに失敗しました: %v", err)
+	}
+	return database
+}
+
+// NewSchema はテスト専用の空のスキーマを作成し、マイグレーションを適用せずに接続を返します
+// role を指定した場合はスキーマの所有者をそのロール（スーパーユーザーではないロール）にし、
+// 接続のロールも切り替えます（本番と同じくスーパーユーザーではない権限での動作の確認用）
+// スキーマはテストの終了時に削除されます
+func NewSchema(t testing.TB, role string) *gorm.DB {
+	t.Helper()
+	if baseDSN == "" {
+		t.Skip("PostgreSQLを利用できないためスキップします: " + skipReason)
+	}
+
+	admin, err := open(baseDSN)
+	if err != nil {
+		t.Fatalf("テスト用データベースへの接続に失敗しました: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	schema := "test_" + randomHex(8)
+	create := "CREATE SCHEMA " + schema
+	dsn := withParam(baseDSN, "search_path", schema)
+	if role != "" {
+		// ロールはクラスタ全体で共有するため、並行するテストで作成済みの場合は使い回す
+		if err := admin.Exec(`DO $$ BEGIN
+			CREATE ROLE ` + role + ` NOLOGIN NOSUPERUSER NOCREATEROLE NOCREATEDB;
+		EXCEPTION WHEN duplicate_object OR unique_violation THEN NULL;
+		END $$`).Error; err != nil {
+			t.Skipf("テスト用のロールを作成できないためスキップします: %v", err)
+		}
+		create += " AUTHORIZATION " + role
+		dsn = withParam(dsn, "role", role)
+	}
+	if err := admin.Exec(create).Error; err != nil {
+		t.Fatalf("スキーマの作成に失敗しました: %v", err)
+	}
+
+	database, err := open(dsn)
+	if err != nil {
+		t.Fatalf("テスト用データベースへの接続に失敗しました: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Logf("スキーマの削除に失敗しました: %v", err)
+		}
+	})
+	return database
+}
+
+func open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+}
+
+// withParam は接続文字列（URL形式またはkey=value形式）に接続時のパラメーターを追加します
+func withParam(dsn, key, value string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		return dsn + sep + key + "=" + value
+	}
+	return dsn + " " + key + "=" + value
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
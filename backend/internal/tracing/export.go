@@ -0,0 +1,402 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/logging"
+)
+
+const (
+	// 待ち行列に保持するスパンの上限（超えた分は破棄します）
+	maxQueueSize = 2048
+	// 1回のエクスポートで送るスパンの上限
+	maxBatchSize = 512
+	// エクスポートの間隔
+	exportInterval = 5 * time.Second
+)
+
+// Exporter は終了したスパンを送信します
+type Exporter interface {
+	Export(ctx context.Context, resource Resource, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Resource はスパンを出力したサービスの情報
+type Resource struct {
+	ServiceName string
+}
+
+// batchProcessor は終了したスパンを溜めて、一定の間隔または件数でエクスポートします
+// リクエストの処理をエクスポートの遅延や失敗で止めないため、送信は別のgoroutineで行います
+type batchProcessor struct {
+	resource Resource
+	exporter Exporter
+
+	mu      sync.Mutex
+	queue   []SpanData
+	dropped int
+
+	kick chan struct{}
+	// flushes は Flush の依頼（送信後に完了を通知するチャネル）
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+func newBatchProcessor(serviceName string, exporter Exporter) *batchProcessor {
+	p := &batchProcessor{
+		resource: Resource{ServiceName: serviceName},
+		exporter: exporter,
+		kick:     make(chan struct{}, 1),
+		flushes:  make(chan chan error),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) enqueue(span SpanData) {
+	p.mu.Lock()
+	if len(p.queue) >= maxQueueSize {
+		p.dropped++
+		p.mu.Unlock()
+		return
+	}
+	p.queue = append(p.queue, redactSpan(span))
+	full := len(p.queue) >= maxBatchSize
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.stopped)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.export(context.Background())
+		case <-p.kick:
+			p.export(context.Background())
+		case result := <-p.flushes:
+			result <- p.export(context.Background())
+		case <-p.done:
+			p.export(context.Background())
+			return
+		}
+	}
+}
+
+// export は待ち行列のスパンをすべて送信します
+func (p *batchProcessor) export(ctx context.Context) error {
+	p.mu.Lock()
+	spans := p.queue
+	p.queue = nil
+	dropped := p.dropped
+	p.dropped = 0
+	p.mu.Unlock()
+
+	if dropped > 0 {
+		slog.Warn("待ち行列があふれたためスパンを破棄しました", "dropped", dropped)
+	}
+
+	var firstErr error
+	for len(spans) > 0 {
+		n := min(len(spans), maxBatchSize)
+		if err := p.exporter.Export(ctx, p.resource, spans[:n]); err != nil {
+			slog.Warn("スパンのエクスポートに失敗しました", "error", err, "spans", n)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		spans = spans[n:]
+	}
+	return firstErr
+}
+
+func (p *batchProcessor) flush(ctx context.Context) error {
+	result := make(chan error, 1)
+	select {
+	case p.flushes <- result:
+	case <-p.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	p.once.Do(func() { close(p.done) })
+	select {
+	case <-p.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return p.exporter.Shutdown(ctx)
+}
+
+// redactSpan は名前・属性・エラーメッセージの秘密情報・メールアドレス・決済IDをログと同じ規則で伏せます
+func redactSpan(s SpanData) SpanData {
+	s.Name = logging.Redact(s.Name)
+	s.Attributes = redactAttributes(s.Attributes)
+	s.StatusMessage = logging.Redact(s.StatusMessage)
+	if len(s.Events) > 0 {
+		events := make([]Event, len(s.Events))
+		for i, ev := range s.Events {
+			ev.Attributes = redactAttributes(ev.Attributes)
+			events[i] = ev
+		}
+		s.Events = events
+	}
+	return s
+}
+
+func redactAttributes(attrs []Attribute) []Attribute {
+	out := make([]Attribute, len(attrs))
+	for i, a := range attrs {
+		if v, ok := a.Value.(string); ok {
+			a.Value = logging.Redact(v)
+		}
+		out[i] = a
+	}
+	return out
+}
+
+// StdoutExporter はスパンを1行ずつJSONで書き出します（ローカルでの確認用）
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter は w（nil の場合は標準出力）へ書き出すエクスポーターを作成します
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &StdoutExporter{w: w}
+}
+
+// stdoutSpan は標準出力に書き出すスパンの形式
+type stdoutSpan struct {
+	Service      string            `json:"service"`
+	Name         string            `json:"name"`
+	Kind         string            `json:"kind"`
+	TraceID      string            `json:"trace_id"`
+	SpanID       string            `json:"span_id"`
+	ParentSpanID string            `json:"parent_span_id,omitempty"`
+	Start        time.Time         `json:"start"`
+	DurationMS   float64           `json:"duration_ms"`
+	Attributes   map[string]any    `json:"attributes,omitempty"`
+	Events       []stdoutSpanEvent `json:"events,omitempty"`
+	Status       string            `json:"status"`
+	StatusDetail string            `json:"status_message,omitempty"`
+}
+
+type stdoutSpanEvent struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+var (
+	spanKindNames   = map[SpanKind]string{SpanKindInternal: "internal", SpanKindServer: "server", SpanKindClient: "client", SpanKindProducer: "producer", SpanKindConsumer: "consumer"}
+	statusCodeNames = map[StatusCode]string{StatusUnset: "unset", StatusOK: "ok", StatusError: "error"}
+)
+
+// Export はスパンを書き出します
+func (e *StdoutExporter) Export(_ context.Context, resource Resource, spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		out := stdoutSpan{
+			Service:      resource.ServiceName,
+			Name:         s.Name,
+			Kind:         spanKindNames[s.Kind],
+			TraceID:      s.SpanContext.TraceID.String(),
+			SpanID:       s.SpanContext.SpanID.String(),
+			Start:        s.Start,
+			DurationMS:   float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Attributes:   attributeMap(s.Attributes),
+			Status:       statusCodeNames[s.StatusCode],
+			StatusDetail: s.StatusMessage,
+		}
+		if s.Parent.IsValid() {
+			out.ParentSpanID = s.Parent.SpanID.String()
+		}
+		for _, ev := range s.Events {
+			out.Events = append(out.Events, stdoutSpanEvent{Name: ev.Name, Time: ev.Time, Attributes: attributeMap(ev.Attributes)})
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// Shutdown は何もしません
+func (e *StdoutExporter) Shutdown(context.Context) error { return nil }
+
+func attributeMap(attrs []Attribute) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value
+	}
+	return m
+}
+
+// OTLPExporter はOTLP/HTTP（JSON）でスパンをコレクターへ送信します
+type OTLPExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewOTLPExporter は endpoint（例: http://localhost:4318）の /v1/traces へ送信するエクスポーターを作成します
+// headers は key=value の形式（値はURLエンコード。OTEL_EXPORTER_OTLP_HEADERS と同じ）
+func NewOTLPExporter(endpoint string, headers []string) *OTLPExporter {
+	h := make(map[string]string, len(headers))
+	for _, kv := range headers {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(v); err == nil {
+			v = unescaped
+		}
+		h[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return &OTLPExporter{
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		headers: h,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export はスパンを送信します
+func (e *OTLPExporter) Export(ctx context.Context, resource Resource, spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(resource, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLPコレクターがエラーを返しました: %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown は何もしません（送信は Export で完了しています）
+func (e *OTLPExporter) Shutdown(context.Context) error { return nil }
+
+// otlpRequest はOTLPのExportTraceServiceRequest（JSON）を組み立てます
+// JSONではトレースID・スパンIDは16進数、64ビットの整数は文字列で表します
+func otlpRequest(resource Resource, spans []SpanData) map[string]any {
+	otlpSpans := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           s.SpanContext.TraceID.String(),
+			"spanId":            s.SpanContext.SpanID.String(),
+			"name":              s.Name,
+			"kind":              int(s.Kind),
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attributes),
+			"status":            map[string]any{"code": int(s.StatusCode), "message": s.StatusMessage},
+		}
+		if s.Parent.IsValid() {
+			span["parentSpanId"] = s.Parent.SpanID.String()
+		}
+		if len(s.Events) > 0 {
+			events := make([]map[string]any, 0, len(s.Events))
+			for _, ev := range s.Events {
+				events = append(events, map[string]any{
+					"name":         ev.Name,
+					"timeUnixNano": strconv.FormatInt(ev.Time.UnixNano(), 10),
+					"attributes":   otlpAttributes(ev.Attributes),
+				})
+			}
+			span["events"] = events
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes([]Attribute{String("service.name", resource.ServiceName)}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/masvc/oshiome_go/backend/internal/tracing"},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs []Attribute) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]any
+		switch v := a.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": a.Key, "value": value})
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GormPlugin はクエリごとにスパンを作成するGORMのプラグイン
+// 親はクエリのcontext（db.WithContext で渡したもの）のスパンです
+type GormPlugin struct {
+	Tracer *Tracer
+}
+
+const gormSpanKey = "tracing:span"
+
+// Name はプラグインの名前を返します
+func (GormPlugin) Name() string { return "tracing" }
+
+// Initialize は各処理の前後にコールバックを登録します
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+	for _, proc := range processors {
+		operation := proc.operation
+		if err := proc.before("tracing:before_"+operation, func(tx *gorm.DB) {
+			p.before(tx, operation)
+		}); err != nil {
+			return err
+		}
+		if err := proc.after("tracing:after_"+operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p GormPlugin) before(tx *gorm.DB, operation string) {
+	// 親のないクエリ（起動時の確認や定期タスクの一部）はトレースしない
+	if tx.Statement.Context == nil || !SpanContextFromContext(tx.Statement.Context).IsValid() {
+		return
+	}
+	name := "db." + operation
+	if table := tx.Statement.Table; table != "" {
+		name += " " + table
+	}
+	_, span := p.Tracer.Start(tx.Statement.Context, name,
+		WithSpanKind(SpanKindClient),
+		WithAttributes(String("db.system", "postgresql"), String("db.operation", operation)),
+	)
+	if span != nil {
+		tx.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p GormPlugin) after(tx *gorm.DB) {
+	v, ok := tx.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(*Span)
+	// SQLはプレースホルダーのまま（パラメーターの値は記録しない）
+	span.SetAttributes(
+		String("db.sql.table", tx.Statement.Table),
+		String("db.statement", tx.Statement.SQL.String()),
+		Int64("db.rows_affected", tx.RowsAffected),
+	)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(tx.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport は外部へのHTTPリクエストごとにクライアントのスパンを作成する http.RoundTripper
+// スパン名には peer（stripe など）とメソッド・パスを使用し、traceparentヘッダーを付けて送信します
+type Transport struct {
+	Tracer *Tracer
+	// Peer は呼び出し先の名前（スパン名と peer.service 属性に使用）
+	Peer string
+	// Base が nil の場合は http.DefaultTransport を使用します
+	Base http.RoundTripper
+}
+
+// RoundTrip はスパンを作成してリクエストを送信します
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := t.Tracer.Start(req.Context(), fmt.Sprintf("%s %s %s", t.Peer, req.Method, req.URL.Path),
+		WithSpanKind(SpanKindClient),
+		WithAttributes(
+			String("peer.service", t.Peer),
+			String("http.request.method", req.Method),
+			String("server.address", req.URL.Host),
+			String("url.path", req.URL.Path),
+		),
+	)
+	if span == nil {
+		return base.RoundTrip(req)
+	}
+	defer span.End()
+
+	// RoundTripper は元のリクエストを変更してはいけないため複製してヘッダーを付ける
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(StatusError, resp.Status)
+	}
+	// Stripeなどが返すリクエストID（問い合わせ時に使用）
+	if id := resp.Header.Get("Request-Id"); id != "" {
+		span.SetAttributes(String(t.Peer+".request_id", id))
+	}
+	return resp, nil
+}
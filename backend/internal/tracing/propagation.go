@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader はW3C Trace Contextのヘッダー
+const TraceparentHeader = "traceparent"
+
+// FormatTraceparent はスパンの識別情報をtraceparentの形式（version-traceid-spanid-flags）にします
+// 識別情報が無効な場合は空文字を返します
+func FormatTraceparent(sc SpanContext) string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent はtraceparentの値を解析します（形式が不正な場合は false）
+func ParseTraceparent(s string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// version 00 は4項目のみ（将来のバージョンは後ろに項目が増える可能性がある）
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+	sc.Remote = true
+	return sc, true
+}
+
+// decodeHex は小文字の16進数を dst の長さぶん読み込みます
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract はリクエストヘッダーのtraceparentを親としたcontextを返します（ない場合は ctx のまま）
+func Extract(ctx context.Context, header http.Header) context.Context {
+	if sc, ok := ParseTraceparent(header.Get(TraceparentHeader)); ok {
+		return ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// Inject はcontextのスパンをtraceparentヘッダーに設定します
+func Inject(ctx context.Context, header http.Header) {
+	if v := FormatTraceparent(SpanContextFromContext(ctx)); v != "" {
+		header.Set(TraceparentHeader, v)
+	}
+}
+
+// Traceparent はcontextのスパンのtraceparentを返します（ジョブなどに保存して後で親にする場合）
+func Traceparent(ctx context.Context) string {
+	return FormatTraceparent(SpanContextFromContext(ctx))
+}
+
+// ContextWithTraceparent は保存したtraceparentを親としたcontextを返します（空や不正な値の場合は ctx のまま）
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	if sc, ok := ParseTraceparent(traceparent); ok {
+		return ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// LogAttrs はログに付けるトレースID・スパンIDを返します（contextにスパンがない場合は nil）
+func LogAttrs(ctx context.Context) []any {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []any{"trace_id", sc.TraceID.String(), "span_id", sc.SpanID.String()}
+}
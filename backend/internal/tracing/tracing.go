@@ -0,0 +1,349 @@
+// Package tracing はOpenTelemetryのデータモデルに沿った分散トレーシングを提供します
+//
+// スパンはHTTPリクエスト（middleware.Tracing）、GORMのクエリ（GormPlugin）、外部API呼び出し（Transport）、
+// バックグラウンドジョブで作成し、contextで親子関係を引き継ぎます
+// 伝播にはW3C Trace Context（traceparentヘッダー）を使用し、OTLP/HTTP（JSON）または標準出力へ書き出します
+//
+// トレーシングを無効にした場合、Tracer は nil になり、Start は nil の Span を返します
+// Span のメソッドは nil でも呼び出せるため、呼び出し側で有効かどうかを確認する必要はありません
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/masvc/oshiome_go/backend/internal/config"
+)
+
+// TraceID はトレースの識別子（16バイト）
+type TraceID [16]byte
+
+// String は16進数の文字列を返します
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid はすべて0ではないかを返します
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// SpanID はスパンの識別子（8バイト）
+type SpanID [8]byte
+
+// String は16進数の文字列を返します
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid はすべて0ではないかを返します
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext はプロセスをまたいで伝播するスパンの識別情報
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled はトレースを記録するか（traceparentのフラグ）
+	Sampled bool
+	// Remote は他のプロセスから受け取ったものか
+	Remote bool
+}
+
+// IsValid はトレースIDとスパンIDがどちらも設定されているかを返します
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// SpanKind はスパンの種類
+type SpanKind int
+
+// OTLPの SpanKind と同じ値
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+	SpanKindProducer SpanKind = 4
+	SpanKindConsumer SpanKind = 5
+)
+
+// StatusCode はスパンの結果
+type StatusCode int
+
+// OTLPの StatusCode と同じ値
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute はスパンの属性（値は string / int64 / float64 / bool）
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String は文字列の属性を返します
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int は整数の属性を返します
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Int64 は整数の属性を返します
+func Int64(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+
+// Bool は真偽値の属性を返します
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Event はスパン中の出来事（エラーなど）
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanData は終了したスパンの内容（エクスポーターに渡します）
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanContext
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Events        []Event
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span は処理の区間
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext はスパンの識別情報を返します
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetName はスパンの名前を変更します（ルートが決まった後に名前を付ける場合など）
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttributes は属性を追加します
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// SetStatus は結果を設定します
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+}
+
+// RecordError はエラーをイベントとして記録し、結果をエラーにします（err が nil の場合は何もしません）
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Events = append(s.data.Events, Event{
+		Name: "exception",
+		Time: time.Now(),
+		Attributes: []Attribute{
+			String("exception.type", fmt.Sprintf("%T", err)),
+			String("exception.message", err.Error()),
+		},
+	})
+	s.data.StatusCode = StatusError
+	s.data.StatusMessage = err.Error()
+}
+
+// End はスパンを終了し、記録対象であればエクスポートの待ち行列に入れます
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.tracer.processor.enqueue(data)
+	}
+}
+
+// Tracer はスパンを作成し、終了したスパンをエクスポーターへ送ります
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+// New は設定に従ってTracerを作成します（TRACING_EXPORTER が未設定の場合は nil）
+func New(cfg config.Tracing) (*Tracer, error) {
+	var exporter Exporter
+	switch cfg.Exporter {
+	case "":
+		return nil, nil
+	case "otlp":
+		exporter = NewOTLPExporter(cfg.Endpoint, cfg.Headers)
+	case "stdout":
+		exporter = NewStdoutExporter(nil)
+	default:
+		return nil, fmt.Errorf("トレースのエクスポーターが不正です: %q", cfg.Exporter)
+	}
+	return NewTracer(cfg.ServiceName, cfg.SampleRatio, exporter), nil
+}
+
+// NewTracer はエクスポーターを指定してTracerを作成します
+// sampleRatio は親のないトレースを記録する割合（0〜1）。親がある場合は親の判定に従います
+func NewTracer(serviceName string, sampleRatio float64, exporter Exporter) *Tracer {
+	return &Tracer{
+		serviceName: serviceName,
+		sampleRatio: sampleRatio,
+		processor:   newBatchProcessor(serviceName, exporter),
+	}
+}
+
+// StartOption はスパンの作成時の設定
+type StartOption func(*SpanData)
+
+// WithSpanKind はスパンの種類を指定します（既定は SpanKindInternal）
+func WithSpanKind(kind SpanKind) StartOption {
+	return func(d *SpanData) { d.Kind = kind }
+}
+
+// WithAttributes は作成時の属性を指定します
+func WithAttributes(attrs ...Attribute) StartOption {
+	return func(d *SpanData) { d.Attributes = append(d.Attributes, attrs...) }
+}
+
+// Start はスパンを開始し、スパンを保持したcontextを返します
+// ctx にスパン（または ContextWithRemoteSpanContext で設定した親）がある場合はその子になります
+func (t *Tracer) Start(ctx context.Context, name string, opts ...StartOption) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.shouldSample(sc.TraceID)
+	}
+
+	span := &Span{tracer: t, data: SpanData{
+		Name:        name,
+		Kind:        SpanKindInternal,
+		SpanContext: sc,
+		Parent:      parent,
+		Start:       time.Now(),
+	}}
+	for _, opt := range opts {
+		opt(&span.data)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// shouldSample はトレースIDの下位8バイトで記録するかを決めます（同じトレースIDなら同じ判定）
+func (t *Tracer) shouldSample(id TraceID) bool {
+	switch {
+	case t.sampleRatio >= 1:
+		return true
+	case t.sampleRatio <= 0:
+		return false
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// Flush は待ち行列のスパンをすべてエクスポートします
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.processor.flush(ctx)
+}
+
+// Shutdown は待ち行列のスパンをエクスポートし、エクスポーターを終了します
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.processor.shutdown(ctx)
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// ContextWithSpan はスパンを保持したcontextを返します
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext はcontextのスパンを返します（ない場合は nil）
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext は他のプロセスから受け取った親を保持したcontextを返します
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext はcontextのスパン（ない場合は受け取った親）の識別情報を返します
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -1,50 +1,153 @@
 package utils
 
 import (
-	"os"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword パスワードをハッシュ化
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+// PasswordHasher 設定されたコストでパスワードをハッシュ化します
+type PasswordHasher struct {
+	cost int
+}
+
+// NewPasswordHasher PasswordHasherを作成します
+func NewPasswordHasher(cost int) *PasswordHasher {
+	return &PasswordHasher{cost: cost}
+}
+
+// Hash パスワードをハッシュ化
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	return string(bytes), err
 }
 
+// NeedsRehash ハッシュのコストが現在の設定と異なるかを判定
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost != h.cost
+}
+
 // CheckPasswordHash パスワードとハッシュを比較
 func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
 
-// GenerateToken JWTトークンを生成
-func GenerateToken(userID uint) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24時間有効
+// トークンの種類
+const (
+	// TokenTypeAccess APIにアクセスするための通常のトークン
+	TokenTypeAccess = "access"
+	// TokenTypePreAuth パスワード認証後、二要素認証が完了するまでの短命なトークン
+	TokenTypePreAuth = "pre_auth"
+)
+
+// トークンの有効期限
+const (
+	accessTokenTTL  = 24 * time.Hour
+	preAuthTokenTTL = 5 * time.Minute
+)
+
+// Claims JWTのクレーム
+type Claims struct {
+	UserID uint   `json:"user_id"`
+	Type   string `json:"typ,omitempty"`
+	// 二要素認証を経て発行されたトークンかどうか
+	MFA bool `json:"mfa,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ErrEmptySecret 署名鍵が空の場合のエラー
+var ErrEmptySecret = errors.New("jwt secret is empty")
+
+// TokenManager JWTトークンの発行と検証を行います
+type TokenManager struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewTokenManager TokenManagerを作成します
+// 署名鍵が空の場合は誰でもトークンを偽造できるため、エラーを返します
+func NewTokenManager(secret string) (*TokenManager, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+	return &TokenManager{secret: []byte(secret), now: time.Now}, nil
+}
+
+// SetClock 発行日時と有効期限の判定に使用する現在時刻の取得関数を差し替えます（テスト用）
+func (m *TokenManager) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// Generate JWTトークンを生成
+func (m *TokenManager) Generate(userID uint, mfa bool) (string, error) {
+	return m.sign(userID, TokenTypeAccess, mfa, accessTokenTTL)
+}
+
+// GeneratePreAuth 二要素認証待ちの短命なトークンを生成
+func (m *TokenManager) GeneratePreAuth(userID uint) (string, error) {
+	return m.sign(userID, TokenTypePreAuth, false, preAuthTokenTTL)
+}
+
+func (m *TokenManager) sign(userID uint, tokenType string, mfa bool, ttl time.Duration) (string, error) {
+	now := m.now()
+	claims := Claims{
+		UserID: userID,
+		Type:   tokenType,
+		MFA:    mfa,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return token.SignedString(m.secret)
 }
 
-// ValidateToken JWTトークンを検証
-func ValidateToken(tokenString string) (uint, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+// Parse JWTトークンを検証してクレームを返す
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(m.now))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	// typクレーム導入前に発行されたトークンは通常のトークンとして扱う
+	if claims.Type == "" {
+		claims.Type = TokenTypeAccess
+	}
+	return &claims, nil
+}
 
+// Validate JWTトークンを検証
+func (m *TokenManager) Validate(tokenString string) (uint, error) {
+	claims, err := m.Parse(tokenString)
 	if err != nil {
 		return 0, err
 	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := uint(claims["user_id"].(float64))
-		return userID, nil
+	if claims.Type != TokenTypeAccess {
+		return 0, jwt.ErrTokenInvalidClaims
 	}
+	return claims.UserID, nil
+}
 
-	return 0, jwt.ErrSignatureInvalid
+// ValidatePreAuth 二要素認証待ちのトークンを検証
+func (m *TokenManager) ValidatePreAuth(tokenString string) (uint, error) {
+	claims, err := m.Parse(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	if claims.Type != TokenTypePreAuth {
+		return 0, jwt.ErrTokenInvalidClaims
+	}
+	return claims.UserID, nil
 }
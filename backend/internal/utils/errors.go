@@ -1,11 +1,27 @@
 package utils
 
+import "net/http"
+
 // APIError はAPIのエラーを表す構造体
+// すべてのエラーレスポンスはこの形式で返します（middleware.ErrorHandler）
 type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Detail  string `json:"detail,omitempty"`
 	Status  string `json:"status"`
+	// Fields は入力値の検証エラー（項目ごと）
+	Fields []FieldError `json:"fields,omitempty"`
+	// HTTPStatus はレスポンスのステータスコード（未設定の場合は500）
+	HTTPStatus int `json:"-"`
+}
+
+// FieldError は項目ごとの検証エラー
+type FieldError struct {
+	// Field はリクエストの項目名（JSONのキー）
+	Field string `json:"field"`
+	// Rule は満たさなかった検証ルール（required, email, min など）
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 // Error はエラーインターフェースを実装
@@ -13,52 +29,121 @@ func (e *APIError) Error() string {
 	return e.Message
 }
 
+// StatusCode はレスポンスのHTTPステータスコードを返します
+func (e *APIError) StatusCode() int {
+	if e.HTTPStatus == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.HTTPStatus
+}
+
 // WithDetail はエラーの詳細を追加
 func (e *APIError) WithDetail(detail string) *APIError {
-	return &APIError{
-		Code:    e.Code,
-		Message: e.Message,
-		Detail:  detail,
-		Status:  "error",
-	}
+	err := *e
+	err.Detail = detail
+	err.Status = "error"
+	return &err
+}
+
+// WithFields は項目ごとの検証エラーを追加
+func (e *APIError) WithFields(fields []FieldError) *APIError {
+	err := *e
+	err.Fields = fields
+	err.Status = "error"
+	return &err
 }
 
 // 定義済みエラー
 var (
 	ErrInvalidInput = &APIError{
-		Code:    "INVALID_INPUT",
-		Message: "入力が無効です",
-		Status:  "error",
+		Code:       "INVALID_INPUT",
+		Message:    "入力が無効です",
+		Status:     "error",
+		HTTPStatus: http.StatusBadRequest,
 	}
 
 	ErrUnauthorized = &APIError{
-		Code:    "UNAUTHORIZED",
-		Message: "認証が必要です",
-		Status:  "error",
+		Code:       "UNAUTHORIZED",
+		Message:    "認証が必要です",
+		Status:     "error",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+
+	ErrForbidden = &APIError{
+		Code:       "FORBIDDEN",
+		Message:    "この操作を行う権限がありません",
+		Status:     "error",
+		HTTPStatus: http.StatusForbidden,
 	}
 
 	ErrNotFound = &APIError{
-		Code:    "NOT_FOUND",
-		Message: "リソースが見つかりません",
-		Status:  "error",
+		Code:       "NOT_FOUND",
+		Message:    "リソースが見つかりません",
+		Status:     "error",
+		HTTPStatus: http.StatusNotFound,
 	}
 
 	ErrInternalServer = &APIError{
-		Code:    "INTERNAL_SERVER_ERROR",
-		Message: "サーバーエラーが発生しました",
-		Status:  "error",
+		Code:       "INTERNAL_SERVER_ERROR",
+		Message:    "サーバーエラーが発生しました",
+		Status:     "error",
+		HTTPStatus: http.StatusInternalServerError,
 	}
 
 	ErrDuplicateEmail = &APIError{
-		Code:    "DUPLICATE_EMAIL",
-		Message: "このメールアドレスは既に使用されています",
-		Status:  "error",
+		Code:       "DUPLICATE_EMAIL",
+		Message:    "このメールアドレスは既に使用されています",
+		Status:     "error",
+		HTTPStatus: http.StatusConflict,
+	}
+
+	ErrConflict = &APIError{
+		Code:       "CONFLICT",
+		Message:    "現在の状態ではこの操作を行えません",
+		Status:     "error",
+		HTTPStatus: http.StatusConflict,
 	}
 
 	ErrInvalidCredentials = &APIError{
-		Code:    "INVALID_CREDENTIALS",
-		Message: "メールアドレスまたはパスワードが正しくありません",
-		Status:  "error",
+		Code:       "INVALID_CREDENTIALS",
+		Message:    "メールアドレスまたはパスワードが正しくありません",
+		Status:     "error",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+
+	ErrInvalidTwoFactorCode = &APIError{
+		Code:       "INVALID_2FA_CODE",
+		Message:    "認証コードが正しくありません",
+		Status:     "error",
+		HTTPStatus: http.StatusUnauthorized,
+	}
+
+	ErrAccountLocked = &APIError{
+		Code:       "ACCOUNT_LOCKED",
+		Message:    "ログイン失敗が続いたため、アカウントを一時的にロックしています",
+		Status:     "error",
+		HTTPStatus: http.StatusLocked,
+	}
+
+	ErrUnsupportedAPIVersion = &APIError{
+		Code:       "UNSUPPORTED_API_VERSION",
+		Message:    "指定されたAPIバージョンには対応していません",
+		Status:     "error",
+		HTTPStatus: http.StatusBadRequest,
+	}
+
+	ErrRateLimited = &APIError{
+		Code:       "RATE_LIMITED",
+		Message:    "リクエストが多すぎます。しばらく待ってから再度お試しください",
+		Status:     "error",
+		HTTPStatus: http.StatusTooManyRequests,
+	}
+
+	ErrTooManyAttempts = &APIError{
+		Code:       "TOO_MANY_ATTEMPTS",
+		Message:    "ログイン試行回数が多すぎます。しばらく待ってから再度お試しください",
+		Status:     "error",
+		HTTPStatus: http.StatusTooManyRequests,
 	}
 )
 
@@ -73,4 +158,5 @@ const (
 	ErrMsgUserCreateFail     = "ユーザーの作成に失敗しました"
 	ErrMsgUserNotFound       = "ユーザーが見つかりません"
 	ErrMsgInvalidCredentials = "メールアドレスまたはパスワードが正しくありません"
+	ErrMsgInvalidUnlockToken = "ロック解除リンクが無効か、有効期限が切れています"
 )
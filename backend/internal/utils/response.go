@@ -1,6 +1,7 @@
 package utils
 
-// Response はAPIレスポンスの基本構造
+// Response は成功時のAPIレスポンスの基本構造
+// エラー時は APIError を c.Error に渡し、middleware.ErrorHandler が返します
 type Response struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message,omitempty"`
@@ -31,11 +32,3 @@ func NewSuccessDataMessageResponse(data interface{}, message string) Response {
 		Data:    data,
 	}
 }
-
-// NewErrorResponse エラーレスポンスを生成
-func NewErrorResponse(message string) Response {
-	return Response{
-		Status:  "error",
-		Message: message,
-	}
-}
@@ -1,9 +1,12 @@
 package utils
 
 import (
-	"log"
-	"os"
+	"context"
+	"log/slog"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/checkout/session"
@@ -11,26 +14,47 @@ import (
 )
 
 // InitStripe はStripeの初期設定を行います
-func InitStripe() {
+func InitStripe(secretKey string) {
 	// APIキーの設定
-	stripe.Key = os.Getenv("STRIPE_SECRET_KEY")
+	stripe.Key = secretKey
 
-	// APIキーが設定されているかログ出力
-	if stripe.Key != "" {
-		log.Printf("Stripe initialized with key starting with: %s", stripe.Key[:8])
-	} else {
-		log.Printf("Warning: STRIPE_SECRET_KEY is not set")
+	// キーの値はログに出力しない（テストモードか本番モードかのみ）
+	if secretKey == "" {
+		slog.Warn("STRIPE_SECRET_KEY が設定されていません")
+		return
 	}
+	slog.Info("Stripeを初期化しました", "mode", StripeMode(secretKey))
+}
+
+// StripeMode はAPIキーの種類（live / test）を返します（StripeのAPIキーの形式でない場合は空文字）
+func StripeMode(secretKey string) string {
+	for _, prefix := range []string{"sk_", "rk_"} {
+		switch {
+		case strings.HasPrefix(secretKey, prefix+"live_"):
+			return "live"
+		case strings.HasPrefix(secretKey, prefix+"test_"):
+			return "test"
+		}
+	}
+	return ""
+}
+
+// SetStripeTransport はStripe APIの呼び出しに使用する http.RoundTripper を設定します（トレーシングなど）
+// APIを呼び出す前（InitStripe と同じタイミング）に設定してください
+func SetStripeTransport(transport http.RoundTripper) {
+	// stripe-goの既定と同じタイムアウト
+	stripe.SetHTTPClient(&http.Client{Timeout: 80 * time.Second, Transport: transport})
+	// 作成済みのバックエンドは古いクライアントを保持しているため作り直させる
+	stripe.SetBackend(stripe.APIBackend, nil)
 }
 
 // ValidateWebhookSignature はWebhookの署名を検証します
-func ValidateWebhookSignature(payload []byte, header string) (stripe.Event, error) {
-	webhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")
+func ValidateWebhookSignature(payload []byte, header, webhookSecret string) (stripe.Event, error) {
 	return webhook.ConstructEvent(payload, header, webhookSecret)
 }
 
 // CreateCheckoutSession はプロジェクト支援用のStripe Checkout Sessionを作成します
-func CreateCheckoutSession(projectID uint, projectTitle string, amount int64, supportID uint, userID uint, successURL, cancelURL string) (*stripe.CheckoutSession, error) {
+func CreateCheckoutSession(ctx context.Context, projectID uint, projectTitle string, amount int64, supportID uint, userID uint, successURL, cancelURL string) (*stripe.CheckoutSession, error) {
 	// プロジェクトタイトルが空の場合はデフォルト値を設定
 	productName := "プロジェクト支援"
 	if projectTitle != "" {
@@ -68,6 +92,7 @@ func CreateCheckoutSession(projectID uint, projectTitle string, amount int64, su
 
 	// メタデータを設定
 	params.Params.Metadata = metadata
+	params.Context = ctx
 
 	// Checkout Sessionの作成
 	s, err := session.New(params)
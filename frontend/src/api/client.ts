@@ -51,7 +51,7 @@ export const client = {
         removeStoredToken();
       }
       const error = await parseErrorResponse(response);
-      throw new APIErrorResponse(error.code, error.message, error.detail);
+      throw new APIErrorResponse(error.code, error.message, error.detail, error.fields);
     }
 
     return response.json();
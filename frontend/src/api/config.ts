@@ -13,27 +13,27 @@ export const API_CONFIG = {
 export const API_ENDPOINTS = {
   // 認証関連
   auth: {
-    login: '/api/login',
-    register: '/api/register',
-    me: '/api/auth/me',
-    passwordReset: '/api/auth/password-reset',
-    passwordResetConfirm: '/api/auth/password-reset/confirm',
+    login: '/api/v1/login',
+    register: '/api/v1/register',
+    me: '/api/v1/auth/me',
+    passwordReset: '/api/v1/auth/password-reset',
+    passwordResetConfirm: '/api/v1/auth/password-reset/confirm',
   },
   // プロジェクト関連
-  projects: '/api/projects',
-  project: (id: number) => `/api/projects/${id}`,
-  myProjects: '/api/projects/my',
-  supportedProjects: '/api/projects/supported',
+  projects: '/api/v1/projects',
+  project: (id: number) => `/api/v1/projects/${id}`,
+  myProjects: '/api/v1/projects/my',
+  supportedProjects: '/api/v1/projects/supported',
   // 支援関連
-  supports: '/api/supports',
-  support: (id: number) => `/api/supports/${id}`,
-  projectSupports: (projectId: number) => `/api/projects/${projectId}/supports`,
-  userSupports: (userId: number) => `/api/users/${userId}/supports`,
+  supports: '/api/v1/supports',
+  support: (id: number) => `/api/v1/supports/${id}`,
+  projectSupports: (projectId: number) => `/api/v1/projects/${projectId}/supports`,
+  userSupports: (userId: number) => `/api/v1/users/${userId}/supports`,
   // ユーザー関連
-  user: (id: number) => `/api/users/${id}`,
+  user: (id: number) => `/api/v1/users/${id}`,
   // Stripe関連
-  stripeCheckout: '/api/payments/checkout',
-  stripeVerify: '/api/payments/verify',
+  stripeCheckout: '/api/v1/payments/checkout',
+  stripeVerify: '/api/v1/payments/verify',
   stripeSuccess: '/payments/success',
   stripeCancel: '/payments/cancel',
 };
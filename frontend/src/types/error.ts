@@ -1,9 +1,17 @@
+// 項目ごとの検証エラー
+export interface FieldError {
+  field: string;
+  rule: string;
+  message: string;
+}
+
 // APIエラーの基本型
 export interface APIError {
   code: string;
   message: string;
   detail?: string;
   status: string;
+  fields?: FieldError[];
 }
 
 // エラーコードの定数
@@ -14,6 +22,12 @@ export const ErrorCodes = {
   INTERNAL_SERVER_ERROR: 'INTERNAL_SERVER_ERROR',
   DUPLICATE_EMAIL: 'DUPLICATE_EMAIL',
   INVALID_CREDENTIALS: 'INVALID_CREDENTIALS',
+  FORBIDDEN: 'FORBIDDEN',
+  CONFLICT: 'CONFLICT',
+  INVALID_2FA_CODE: 'INVALID_2FA_CODE',
+  ACCOUNT_LOCKED: 'ACCOUNT_LOCKED',
+  RATE_LIMITED: 'RATE_LIMITED',
+  TOO_MANY_ATTEMPTS: 'TOO_MANY_ATTEMPTS',
 } as const;
 
 // エラーメッセージの定数
@@ -42,7 +56,8 @@ export class APIErrorResponse extends Error {
   constructor(
     public code: string,
     message: string,
-    public detail?: string
+    public detail?: string,
+    public fields?: FieldError[]
   ) {
     super(message);
     this.name = 'APIErrorResponse';